@@ -2,7 +2,11 @@ package logger
 
 import (
 	"context"
+	"fmt"
+	"sort"
 	"time"
+
+	"go.uber.org/zap/zapcore"
 )
 
 // LogEntry 表示一条完整的日志记录
@@ -15,6 +19,50 @@ type LogEntry struct {
 	Module     string                 // 模块名称
 	IP         string                 // IP地址
 	Properties map[string]interface{} // 额外属性
+
+	// Order Properties中的key按添加顺序排列：子日志With添加的字段在前，Infow等调用时的键值对在后
+	// 可能不包含管道中追加的属性（如stacktrace、静态属性），只用于确定顺序，取值以Properties为准，不参与JSON序列化
+	Order []string `json:"-"`
+
+	ctx context.Context // 通过WithContext记录时的context，适配器处理日志的上下文由它派生，为空时使用日志的基础上下文
+}
+
+// Property 一个有序的属性
+type Property struct {
+	Key   string
+	Value interface{}
+}
+
+// OrderedProperties 按添加顺序返回属性：先按Order排列，Order中没有的属性按key排序追加在后面
+// 供logfmt等对字段顺序敏感的输出使用，查找单个属性仍使用Properties
+func (e LogEntry) OrderedProperties() []Property {
+	if len(e.Properties) == 0 {
+		return nil
+	}
+	properties := make([]Property, 0, len(e.Properties))
+	seen := make(map[string]bool, len(e.Order))
+	for _, k := range e.Order {
+		v, ok := e.Properties[k]
+		if !ok || seen[k] {
+			continue
+		}
+		seen[k] = true
+		properties = append(properties, Property{Key: k, Value: v})
+	}
+	if len(properties) == len(e.Properties) {
+		return properties
+	}
+	rest := make([]string, 0, len(e.Properties)-len(properties))
+	for k := range e.Properties {
+		if !seen[k] {
+			rest = append(rest, k)
+		}
+	}
+	sort.Strings(rest)
+	for _, k := range rest {
+		properties = append(properties, Property{Key: k, Value: e.Properties[k]})
+	}
+	return properties
 }
 
 // LogAdapter 日志适配器接口，第三方组件可以实现这个接口接收日志
@@ -41,11 +89,27 @@ type LogAdapterCreator func() LogAdapter
 var (
 	// adapterRegistry 存储已注册的适配器创建函数
 	adapterRegistry = make(map[string]LogAdapterCreator)
+	// adapterSchemas 存储注册时声明的适配器配置项
+	adapterSchemas = make(map[string]ConfigSchema)
 )
 
-// RegisterAdapter 注册一个日志适配器
-func RegisterAdapter(name string, creator LogAdapterCreator) {
+// RegisterAdapter 注册一个日志适配器，可选地声明其配置项，创建日志时会在Init之前校验配置
+func RegisterAdapter(name string, creator LogAdapterCreator, schema ...ConfigSchema) {
 	adapterRegistry[name] = creator
+	if len(schema) > 0 {
+		adapterSchemas[name] = ConfigSchema{}.Merge(schema...)
+	} else {
+		delete(adapterSchemas, name)
+	}
+}
+
+// ValidateAdapterConfig 使用注册时声明的配置项校验适配器配置，未声明时不做校验
+func ValidateAdapterConfig(name string, config map[string]interface{}) error {
+	schema, ok := adapterSchemas[name]
+	if !ok {
+		return nil
+	}
+	return schema.Validate(config)
 }
 
 // GetAdapter 获取已注册的日志适配器
@@ -56,3 +120,86 @@ func GetAdapter(name string) (LogAdapter, bool) {
 	}
 	return creator(), true
 }
+
+// AdapterLevelKey 所有适配器通用的配置项，设置适配器接收的最低日志级别，低于该级别的日志不交给适配器处理
+// 由日志包解析，不传给适配器的Init
+const AdapterLevelKey = "level"
+
+// instanceAdapter 为适配器实例指定ID，Name返回该ID；leveled为true时只处理不低于minLevel的日志
+type instanceAdapter struct {
+	LogAdapter
+	id       string
+	minLevel zapcore.Level
+	leveled  bool
+}
+
+// NewAdapterInstance 返回以id作为名称的适配器，用于添加同一适配器的多个实例，RemoveAdapter按该id移除
+func NewAdapterInstance(id string, adapter LogAdapter) LogAdapter {
+	if id == "" || id == adapter.Name() {
+		return adapter
+	}
+	return &instanceAdapter{LogAdapter: adapter, id: id}
+}
+
+// Name 返回实例ID
+func (a *instanceAdapter) Name() string {
+	return a.id
+}
+
+// Unwrap 返回被包装的适配器
+func (a *instanceAdapter) Unwrap() LogAdapter {
+	return a.LogAdapter
+}
+
+// splitAdapterLevel 从适配器配置中取出level配置项，返回不含该项的配置和解析后的级别，未设置时级别为nil
+func splitAdapterLevel(config map[string]interface{}) (map[string]interface{}, *zapcore.Level, error) {
+	value, exists := config[AdapterLevelKey]
+	if !exists {
+		return config, nil, nil
+	}
+	name, ok := value.(string)
+	if !ok {
+		return nil, nil, fmt.Errorf("adapter level expects string, got %T", value)
+	}
+	level, err := ParseLevel(name)
+	if err != nil {
+		return nil, nil, fmt.Errorf("invalid adapter level: %s", name)
+	}
+
+	rest := make(map[string]interface{}, len(config)-1)
+	for k, v := range config {
+		if k != AdapterLevelKey {
+			rest[k] = v
+		}
+	}
+	return rest, &level, nil
+}
+
+// withAdapterLevel 为适配器设置最低日志级别，保留已有的实例ID
+func withAdapterLevel(adapter LogAdapter, level zapcore.Level) LogAdapter {
+	instance, ok := adapter.(*instanceAdapter)
+	if !ok {
+		instance = &instanceAdapter{LogAdapter: adapter, id: adapter.Name()}
+	}
+	instance.minLevel = level
+	instance.leveled = true
+	return instance
+}
+
+// adapterAccepts 判断日志级别是否达到适配器配置的最低级别，未配置最低级别或级别无法解析时总是返回true
+func adapterAccepts(adapter LogAdapter, level string) bool {
+	instance, ok := adapter.(*instanceAdapter)
+	if !ok || !instance.leveled {
+		return true
+	}
+	lvl, err := zapcore.ParseLevel(level)
+	return err != nil || lvl >= instance.minLevel
+}
+
+// unwrapAdapter 返回实例包装下的原始适配器，用于检查适配器实现的可选接口
+func unwrapAdapter(adapter LogAdapter) LogAdapter {
+	if instance, ok := adapter.(*instanceAdapter); ok {
+		return instance.LogAdapter
+	}
+	return adapter
+}
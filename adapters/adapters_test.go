@@ -1,8 +1,22 @@
 package adapters
 
 import (
+	"bufio"
+	"bytes"
+	"compress/gzip"
 	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/binary"
+	"encoding/json"
 	"fmt"
+	"net"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"path/filepath"
+	"strings"
 	"sync"
 	"testing"
 	"time"
@@ -331,3 +345,1137 @@ func TestAdapterConcurrency(t *testing.T) {
 	adapter.bufferMu.Unlock()
 	assert.Equal(t, 0, bufferLen, "刷新后缓冲区应该为空")
 }
+
+// TestTransformAdapter 测试转换适配器
+func TestTransformAdapter(t *testing.T) {
+	plain := NewTestAdapter("plain")
+	base := NewTestAdapter("loki")
+	transformed := Transform(base, func(e logger.LogEntry) logger.LogEntry {
+		if e.Properties == nil {
+			e.Properties = make(map[string]interface{})
+		}
+		e.Properties["region"] = "cn-east-1"
+		e.Message = "[transformed] " + e.Message
+		return e
+	})
+
+	assert.Equal(t, "loki", transformed.Name())
+
+	ctx := context.Background()
+	entry := logger.LogEntry{
+		Level:   "info",
+		Time:    time.Now(),
+		Message: "test message",
+		Properties: map[string]interface{}{
+			"key": "value",
+		},
+	}
+
+	// 同一条日志分别交给两个适配器
+	assert.NoError(t, transformed.Process(ctx, entry))
+	assert.NoError(t, plain.Process(ctx, entry))
+
+	t.Run("Transformed", func(t *testing.T) {
+		assert.Len(t, base.received, 1)
+		got := base.received[0]
+		assert.Equal(t, "[transformed] test message", got.Message)
+		assert.Equal(t, "cn-east-1", got.Properties["region"])
+		assert.Equal(t, "value", got.Properties["key"])
+	})
+
+	t.Run("Isolation", func(t *testing.T) {
+		assert.Len(t, plain.received, 1)
+		got := plain.received[0]
+		assert.Equal(t, "test message", got.Message)
+		assert.NotContains(t, got.Properties, "region")
+		assert.NotContains(t, entry.Properties, "region")
+	})
+
+	t.Run("Nil Properties", func(t *testing.T) {
+		err := transformed.Process(ctx, logger.LogEntry{Level: "info", Message: "no props"})
+		assert.NoError(t, err)
+		assert.Equal(t, "cn-east-1", base.received[1].Properties["region"])
+	})
+
+	t.Run("Nested Isolation", func(t *testing.T) {
+		// 修改嵌套的属性和Order不影响原日志
+		nested := Transform(NewTestAdapter("nested"), func(e logger.LogEntry) logger.LogEntry {
+			e.Properties["user"].(map[string]interface{})["name"] = "redacted"
+			e.Properties["tags"].([]interface{})[0] = "redacted"
+			e.Properties["labels"].(map[string]string)["env"] = "redacted"
+			e.Properties["ids"].([]string)[0] = "redacted"
+			e.Order[0] = "redacted"
+			return e
+		})
+		entry := logger.LogEntry{
+			Level:   "info",
+			Message: "nested",
+			Properties: map[string]interface{}{
+				"user":   map[string]interface{}{"name": "alice"},
+				"tags":   []interface{}{"a"},
+				"labels": map[string]string{"env": "prod"},
+				"ids":    []string{"id-1"},
+			},
+			Order: []string{"user"},
+		}
+		assert.NoError(t, nested.Process(ctx, entry))
+		assert.Equal(t, "alice", entry.Properties["user"].(map[string]interface{})["name"])
+		assert.Equal(t, "a", entry.Properties["tags"].([]interface{})[0])
+		assert.Equal(t, "prod", entry.Properties["labels"].(map[string]string)["env"])
+		assert.Equal(t, "id-1", entry.Properties["ids"].([]string)[0])
+		assert.Equal(t, "user", entry.Order[0])
+	})
+}
+
+func TestAzureMonitorAdapter(t *testing.T) {
+	var (
+		mu       sync.Mutex
+		requests []*http.Request
+		bodies   [][]map[string]interface{}
+	)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		var records []map[string]interface{}
+		_ = json.NewDecoder(r.Body).Decode(&records)
+		mu.Lock()
+		requests = append(requests, r)
+		bodies = append(bodies, records)
+		mu.Unlock()
+		w.WriteHeader(http.StatusOK)
+	}))
+	defer server.Close()
+
+	key := base64.StdEncoding.EncodeToString([]byte("test-shared-key"))
+	adapter := &AzureMonitorAdapter{}
+
+	t.Run("Init", func(t *testing.T) {
+		assert.Error(t, adapter.Init(map[string]interface{}{}))
+		assert.Error(t, adapter.Init(map[string]interface{}{
+			"workspace_id": "ws-001",
+			"shared_key":   "not base64!",
+		}))
+
+		err := adapter.Init(map[string]interface{}{
+			"workspace_id":   "ws-001",
+			"shared_key":     key,
+			"log_type":       "TestLogs",
+			"endpoint":       server.URL + "/api/logs",
+			"batch_size":     float64(10),
+			"flush_interval": float64(60),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "ws-001", adapter.WorkspaceID)
+		assert.Equal(t, "TestLogs", adapter.LogType)
+		assert.Equal(t, 10, adapter.BatchSize)
+		assert.Equal(t, 60*time.Second, adapter.FlushInterval)
+
+		// 小于1秒的刷新间隔保留小数部分，非正数使用默认间隔
+		for interval, expected := range map[float64]time.Duration{0.5: 500 * time.Millisecond, 0: 10 * time.Second, -1: 10 * time.Second} {
+			fractional := &AzureMonitorAdapter{}
+			assert.NoError(t, fractional.Init(map[string]interface{}{
+				"workspace_id":   "ws-001",
+				"shared_key":     key,
+				"endpoint":       server.URL + "/api/logs",
+				"flush_interval": interval,
+			}))
+			assert.Equal(t, expected, fractional.FlushInterval)
+			assert.NoError(t, fractional.Close())
+		}
+	})
+
+	t.Run("Flush", func(t *testing.T) {
+		err := adapter.Process(context.Background(), logger.LogEntry{
+			Level:      "error",
+			Time:       time.Now(),
+			Message:    "test error",
+			NodeID:     "node-001",
+			Properties: map[string]interface{}{"key": "value"},
+		})
+		assert.NoError(t, err)
+		assert.NoError(t, adapter.Flush())
+
+		mu.Lock()
+		defer mu.Unlock()
+		assert.Len(t, requests, 1)
+		req := requests[0]
+		assert.Equal(t, "TestLogs", req.Header.Get("Log-Type"))
+		assert.Equal(t, "TimeGenerated", req.Header.Get("time-generated-field"))
+
+		// 校验签名
+		date := req.Header.Get("x-ms-date")
+		stringToSign := fmt.Sprintf("POST\n%d\napplication/json\nx-ms-date:%s\n/api/logs", req.ContentLength, date)
+		mac := hmac.New(sha256.New, []byte("test-shared-key"))
+		mac.Write([]byte(stringToSign))
+		expected := "SharedKey ws-001:" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+		assert.Equal(t, expected, req.Header.Get("Authorization"))
+
+		assert.Len(t, bodies[0], 1)
+		assert.Equal(t, "test error", bodies[0][0]["Message"])
+		assert.Equal(t, "node-001", bodies[0][0]["NodeID"])
+	})
+
+	assert.NoError(t, adapter.Close())
+}
+
+func TestGCPAdapter(t *testing.T) {
+	adapter := &GCPAdapter{}
+
+	t.Run("Init", func(t *testing.T) {
+		assert.Error(t, adapter.Init(map[string]interface{}{}))
+
+		err := adapter.Init(map[string]interface{}{
+			"project_id":     "my-project",
+			"log_name":       "test-logs",
+			"batch_size":     float64(2),
+			"flush_interval": float64(3600),
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, "my-project", adapter.ProjectID)
+		assert.Equal(t, "test-logs", adapter.LogName)
+		assert.Equal(t, 2, adapter.BatchSize)
+		assert.Equal(t, time.Hour, adapter.FlushInterval)
+	})
+
+	t.Run("Process", func(t *testing.T) {
+		entry := logger.LogEntry{
+			Level:      "warn",
+			Time:       time.Now(),
+			Message:    "test warning",
+			NodeID:     "node-001",
+			Module:     "test",
+			Properties: map[string]interface{}{"key": "value"},
+		}
+		assert.NoError(t, adapter.Process(context.Background(), entry))
+		assert.Equal(t, 1, adapter.Buffered())
+
+		e := toGCPEntry(entry)
+		assert.Equal(t, "WARNING", e.Severity)
+		assert.Equal(t, "node-001", e.Labels["node_id"])
+		assert.Equal(t, "test", e.Labels["module"])
+		assert.Equal(t, "value", e.Payload["key"])
+		assert.Equal(t, "test warning", e.Payload["message"])
+
+		// 达到批量大小时立即写出，缓冲区不会持续增长
+		assert.NoError(t, adapter.Process(context.Background(), entry))
+		assert.Equal(t, 0, adapter.Buffered())
+	})
+
+	t.Run("Close", func(t *testing.T) {
+		assert.NoError(t, adapter.Process(context.Background(), logger.LogEntry{Level: "info", Message: "last"}))
+		assert.NoError(t, adapter.Close())
+		assert.Equal(t, 0, adapter.Buffered())
+	})
+
+	t.Run("FlushInterval", func(t *testing.T) {
+		// 未达到批量大小时按刷新间隔定时写出
+		periodic := &GCPAdapter{}
+		assert.NoError(t, periodic.Init(map[string]interface{}{"project_id": "my-project", "flush_interval": 0.02}))
+		defer periodic.Close()
+		assert.NoError(t, periodic.Process(context.Background(), logger.LogEntry{Level: "info", Message: "periodic"}))
+		assert.Eventually(t, func() bool { return periodic.Buffered() == 0 }, time.Second, 5*time.Millisecond)
+	})
+}
+
+// TestLenientAdapters 测试适配器初始化失败时的宽松模式
+func TestLenientAdapters(t *testing.T) {
+	logger.RegisterAdapter("broken", func() logger.LogAdapter {
+		adapter := NewTestAdapter("broken")
+		adapter.initErr = assert.AnError
+		return adapter
+	})
+	healthy := NewMemoryAdapter(0)
+	logger.RegisterAdapter("healthy", func() logger.LogAdapter {
+		return healthy
+	})
+
+	t.Run("Strict", func(t *testing.T) {
+		l, err := logger.NewWithOptions(
+			logger.WithTerminalOutput(),
+			logger.WithAdapter("broken", nil),
+		)
+		assert.Error(t, err)
+		assert.Nil(t, l)
+	})
+
+	t.Run("Lenient", func(t *testing.T) {
+		l, err := logger.NewWithOptions(
+			logger.WithTerminalOutput(),
+			logger.WithLenientAdapters(),
+			logger.WithAdapter("broken", nil),
+			logger.WithAdapter("healthy", nil),
+		)
+		assert.NoError(t, err)
+		assert.NotNil(t, l)
+
+		l.Info("still logging")
+		assert.Eventually(t, func() bool { return len(healthy.Entries()) == 1 }, time.Second, 5*time.Millisecond)
+	})
+}
+
+// TestIdleFlushStrategy 测试空闲刷新策略
+func TestIdleFlushStrategy(t *testing.T) {
+	adapter := &KafkaAdapter{}
+	err := adapter.Init(map[string]interface{}{
+		"batch_size":     float64(100),
+		"flush_strategy": "idle",
+		"idle_timeout":   0.1,
+	})
+	assert.NoError(t, err)
+	defer adapter.Close()
+	assert.Equal(t, FlushIdle, adapter.strategy)
+
+	bufferLen := func() int {
+		adapter.bufferMu.Lock()
+		defer adapter.bufferMu.Unlock()
+		return len(adapter.buffer)
+	}
+
+	// 持续写入时不会刷新
+	for i := 0; i < 5; i++ {
+		assert.NoError(t, adapter.Process(context.Background(), logger.LogEntry{Level: "info", Message: "steady"}))
+		time.Sleep(30 * time.Millisecond)
+	}
+	assert.Equal(t, 5, bufferLen())
+
+	// 停止写入后超过idle_timeout会刷新
+	time.Sleep(250 * time.Millisecond)
+	assert.Equal(t, 0, bufferLen())
+}
+
+// TestConcurrentFlushWorkers 测试多个发送协程并发刷新
+func TestConcurrentFlushWorkers(t *testing.T) {
+	var (
+		mu      sync.Mutex
+		sent    = make(map[string]int)
+		batches int
+		active  int
+		peak    int
+	)
+
+	b := &batcher{}
+	b.initBatcher(map[string]interface{}{"flush_workers": float64(4)}, 10, time.Hour, func(ctx context.Context, entries []logger.LogEntry) error {
+		mu.Lock()
+		batches++
+		active++
+		if active > peak {
+			peak = active
+		}
+		for _, e := range entries {
+			sent[e.Message]++
+		}
+		mu.Unlock()
+
+		// 模拟阻塞的网络请求
+		time.Sleep(20 * time.Millisecond)
+
+		mu.Lock()
+		active--
+		mu.Unlock()
+		return nil
+	})
+
+	for i := 0; i < 100; i++ {
+		assert.NoError(t, b.add(context.Background(), logger.LogEntry{Message: fmt.Sprintf("msg-%d", i)}))
+	}
+	assert.NoError(t, b.closeBatcher())
+
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, 10, batches)
+	assert.Len(t, sent, 100)
+	for msg, count := range sent {
+		assert.Equal(t, 1, count, "每条日志只应发送一次: %s", msg)
+	}
+	assert.Greater(t, peak, 1, "应该有多个批次同时在途")
+}
+
+// TestContextCancellation 测试批量适配器遵循ctx取消
+func TestContextCancellation(t *testing.T) {
+	t.Run("Process", func(t *testing.T) {
+		adapter := &KafkaAdapter{}
+		assert.NoError(t, adapter.Init(map[string]interface{}{}))
+		defer adapter.Close()
+
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		err := adapter.Process(ctx, logger.LogEntry{Level: "info", Message: "cancelled"})
+		assert.ErrorIs(t, err, context.Canceled)
+		assert.Len(t, adapter.buffer, 0)
+	})
+
+	t.Run("InFlightRequest", func(t *testing.T) {
+		release := make(chan struct{})
+		server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+			select {
+			case <-release:
+			case <-r.Context().Done():
+			}
+		}))
+		defer server.Close()
+		defer close(release)
+
+		adapter := &AzureMonitorAdapter{}
+		assert.NoError(t, adapter.Init(map[string]interface{}{
+			"workspace_id": "ws-001",
+			"shared_key":   base64.StdEncoding.EncodeToString([]byte("key")),
+			"endpoint":     server.URL,
+			"batch_size":   float64(1),
+		}))
+
+		ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+		defer cancel()
+
+		start := time.Now()
+		err := adapter.Process(ctx, logger.LogEntry{Level: "info", Message: "slow"})
+		assert.ErrorContains(t, err, context.DeadlineExceeded.Error())
+		assert.Less(t, time.Since(start), time.Second)
+	})
+}
+
+// TestHTTPCompression 测试HTTP类适配器的gzip压缩
+func TestHTTPCompression(t *testing.T) {
+	received := make(chan []map[string]interface{}, 1)
+	server := httptest.NewServer(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		assert.Equal(t, "gzip", r.Header.Get("Content-Encoding"))
+		zr, err := gzip.NewReader(r.Body)
+		if !assert.NoError(t, err) {
+			w.WriteHeader(http.StatusBadRequest)
+			return
+		}
+		var records []map[string]interface{}
+		assert.NoError(t, json.NewDecoder(zr).Decode(&records))
+		received <- records
+	}))
+	defer server.Close()
+
+	adapter := &AzureMonitorAdapter{}
+	assert.NoError(t, adapter.Init(map[string]interface{}{
+		"workspace_id": "ws-001",
+		"shared_key":   base64.StdEncoding.EncodeToString([]byte("key")),
+		"endpoint":     server.URL,
+		"compress":     "gzip",
+	}))
+	defer adapter.Close()
+
+	assert.NoError(t, adapter.Process(context.Background(), logger.LogEntry{Level: "info", Message: "compressed"}))
+	assert.NoError(t, adapter.Flush())
+
+	select {
+	case records := <-received:
+		assert.Len(t, records, 1)
+		assert.Equal(t, "compressed", records[0]["Message"])
+	case <-time.After(time.Second):
+		t.Fatal("server did not receive request")
+	}
+}
+
+// fakeFluentServer 模拟Fluent Forward输入，返回收到的消息
+func fakeFluentServer(t *testing.T, sharedKey string) (string, <-chan []interface{}) {
+	ln, err := net.Listen("tcp", "127.0.0.1:0")
+	assert.NoError(t, err)
+	t.Cleanup(func() { ln.Close() })
+
+	messages := make(chan []interface{}, 10)
+	go func() {
+		conn, err := ln.Accept()
+		if err != nil {
+			return
+		}
+		defer conn.Close()
+		reader := &msgpackReader{r: bufio.NewReader(conn)}
+
+		if sharedKey != "" {
+			nonce := "server-nonce"
+			w := &msgpackWriter{}
+			w.writeArrayHeader(2)
+			w.writeString("HELO")
+			w.writeValue(map[string]interface{}{"nonce": nonce, "auth": "", "keepalive": true})
+			_, _ = conn.Write(w.bytes())
+
+			ping, err := reader.readValue()
+			if err != nil {
+				return
+			}
+			p := ping.([]interface{})
+			salt, hostname, digest := p[2].(string), p[1].(string), p[3].(string)
+			ok := digest == sha512Hex(salt, hostname, nonce, sharedKey)
+
+			w = &msgpackWriter{}
+			w.writeArrayHeader(5)
+			w.writeString("PONG")
+			w.writeBool(ok)
+			w.writeString("")
+			w.writeString("fluent-server")
+			w.writeString(sha512Hex(salt, "fluent-server", nonce, sharedKey))
+			_, _ = conn.Write(w.bytes())
+			if !ok {
+				return
+			}
+		}
+
+		for {
+			v, err := reader.readValue()
+			if err != nil {
+				return
+			}
+			messages <- v.([]interface{})
+		}
+	}()
+
+	return ln.Addr().String(), messages
+}
+
+func TestFluentAdapter(t *testing.T) {
+	for _, sharedKey := range []string{"", "secret"} {
+		t.Run("SharedKey="+sharedKey, func(t *testing.T) {
+			address, messages := fakeFluentServer(t, sharedKey)
+
+			adapter := &FluentAdapter{}
+			assert.NoError(t, adapter.Init(map[string]interface{}{
+				"address":       address,
+				"tag":           "app.test",
+				"shared_key":    sharedKey,
+				"self_hostname": "client",
+			}))
+
+			ts := time.Date(2024, 3, 15, 10, 24, 15, 500, time.UTC)
+			for i := 0; i < 2; i++ {
+				assert.NoError(t, adapter.Process(context.Background(), logger.LogEntry{
+					Level:      "info",
+					Time:       ts,
+					Message:    fmt.Sprintf("msg-%d", i),
+					NodeID:     "node-001",
+					Properties: map[string]interface{}{"attempt": i, "tags": []string{"a", "b"}},
+				}))
+			}
+			assert.NoError(t, adapter.Close())
+
+			select {
+			case msg := <-messages:
+				assert.Equal(t, "app.test", msg[0])
+				events := msg[1].([]interface{})
+				assert.Len(t, events, 2)
+
+				event := events[1].([]interface{})
+				eventTime := event[0].([]byte)
+				assert.Equal(t, uint32(ts.Unix()), binary.BigEndian.Uint32(eventTime[:4]))
+				assert.Equal(t, uint32(500), binary.BigEndian.Uint32(eventTime[4:]))
+
+				record := event[1].(map[string]interface{})
+				assert.Equal(t, "msg-1", record["message"])
+				assert.Equal(t, "info", record["level"])
+				assert.Equal(t, "node-001", record["node_id"])
+				assert.Equal(t, int64(1), record["attempt"])
+				assert.Equal(t, []interface{}{"a", "b"}, record["tags"])
+			case <-time.After(2 * time.Second):
+				t.Fatal("fluent server did not receive message")
+			}
+		})
+	}
+
+	t.Run("FractionalFlushInterval", func(t *testing.T) {
+		// 小于1秒的刷新间隔保留小数部分，定时刷新无需显式Flush；非正数使用默认间隔
+		address, messages := fakeFluentServer(t, "")
+		adapter := &FluentAdapter{}
+		assert.NoError(t, adapter.Init(map[string]interface{}{"address": address, "flush_interval": 0.05}))
+		defer adapter.Close()
+		assert.Equal(t, 50*time.Millisecond, adapter.FlushInterval)
+
+		assert.NoError(t, adapter.Process(context.Background(), logger.LogEntry{Level: "info", Time: time.Now(), Message: "periodic"}))
+		select {
+		case msg := <-messages:
+			assert.Len(t, msg[1].([]interface{}), 1)
+		case <-time.After(2 * time.Second):
+			t.Fatal("fluent server did not receive periodic flush")
+		}
+
+		defaulted := &FluentAdapter{}
+		assert.NoError(t, defaulted.Init(map[string]interface{}{"address": address, "flush_interval": 0}))
+		assert.Equal(t, 5*time.Second, defaulted.FlushInterval)
+		assert.NoError(t, defaulted.Close())
+	})
+}
+
+func TestValidateConfig(t *testing.T) {
+	adapter := &KafkaAdapter{}
+
+	// 整数和[]string同样被接受
+	assert.NoError(t, adapter.ValidateConfig(map[string]interface{}{
+		"brokers":       []string{"localhost:9092"},
+		"topic":         "logs",
+		"batch_size":    10,
+		"flush_timeout": 1.5,
+		"flush_workers": 2,
+	}))
+
+	err := adapter.ValidateConfig(map[string]interface{}{
+		"topik":      "logs",
+		"batch_size": "10",
+	})
+	assert.ErrorContains(t, err, `key "batch_size" expects number, got string`)
+	assert.ErrorContains(t, err, `unknown key "topik"`)
+
+	// 配置错误在创建日志时返回
+	l, err := logger.NewWithOptions(
+		logger.WithTerminalOutput(),
+		logger.WithAdapter("elasticsearch", map[string]interface{}{
+			"hosts":      []interface{}{"http://localhost:9200"},
+			"bulk_sise":  float64(10),
+			"index":      "logs",
+			"flush_mode": "idle",
+		}),
+	)
+	assert.ErrorContains(t, err, `unknown key "bulk_sise"`)
+	assert.Nil(t, l)
+
+	// 整数配置被正确解析
+	assert.NoError(t, adapter.Init(map[string]interface{}{"batch_size": 7}))
+	defer adapter.Close()
+	assert.Equal(t, 7, adapter.BatchSize)
+}
+
+func TestStacktraceTrim(t *testing.T) {
+	newLogger := func(opts ...logger.Option) (logger.Logger, *MemoryAdapter) {
+		adapter := NewMemoryAdapter(0)
+		logger.RegisterAdapter("stack", func() logger.LogAdapter {
+			return adapter
+		})
+		opts = append(opts, logger.WithTerminalOutput(), logger.WithAdapter("stack", nil))
+		l, err := logger.NewWithOptions(opts...)
+		assert.NoError(t, err)
+		return l, adapter
+	}
+
+	t.Run("Trimmed", func(t *testing.T) {
+		l, adapter := newLogger()
+		l.Error("boom")
+		l.Info("no stack for info")
+		assert.Eventually(t, func() bool { return len(adapter.Entries()) == 2 }, time.Second, 5*time.Millisecond)
+
+		if entries := adapter.Entries(); assert.Len(t, entries, 2) {
+			for _, entry := range entries {
+				if entry.Level == "info" {
+					assert.NotContains(t, entry.Properties, "stacktrace")
+					continue
+				}
+				stack, _ := entry.Properties["stacktrace"].(string)
+				// 第一帧是调用方代码
+				assert.True(t, strings.HasPrefix(stack, "github.com/qishenonly/logger/adapters.TestStacktraceTrim"), stack)
+				assert.NotContains(t, stack, "github.com/qishenonly/logger.(*ZapLogger)")
+			}
+		}
+	})
+
+	t.Run("Full", func(t *testing.T) {
+		l, adapter := newLogger(logger.WithFullStacktrace())
+		logger.AsKeyValueLogger(l).Errorw("boom", "stacktrace", "custom")
+		l.Error("boom")
+		assert.Eventually(t, func() bool { return len(adapter.Entries()) == 2 }, time.Second, 5*time.Millisecond)
+
+		var stacks []string
+		for _, entry := range adapter.Entries() {
+			stack, _ := entry.Properties["stacktrace"].(string)
+			stacks = append(stacks, stack)
+		}
+		assert.Contains(t, stacks, "custom")
+		assert.Len(t, stacks, 2)
+		for _, stack := range stacks {
+			if stack != "custom" {
+				assert.Contains(t, stack, "github.com/qishenonly/logger.(*ZapLogger).Error")
+			}
+		}
+	})
+}
+
+func TestRingBufferAdapter(t *testing.T) {
+	t.Run("AdapterSink", func(t *testing.T) {
+		sink := NewTestAdapter("ringsink")
+		logger.RegisterAdapter("ringsink", func() logger.LogAdapter {
+			return sink
+		})
+
+		adapter := &RingBufferAdapter{}
+		err := adapter.Init(map[string]interface{}{
+			"size":          float64(3),
+			"trigger_level": "error",
+			"sink_adapter":  "ringsink",
+			"sink_config":   map[string]interface{}{"key": "value"},
+		})
+		assert.NoError(t, err)
+		assert.Equal(t, map[string]interface{}{"key": "value"}, sink.initCfg)
+
+		ctx := context.Background()
+		for i := 1; i <= 4; i++ {
+			assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "debug", Message: fmt.Sprintf("debug %d", i)}))
+		}
+		assert.Empty(t, sink.received)
+		assert.Equal(t, 3, adapter.Buffered())
+
+		// 触发后按顺序输出最近的日志并清空缓冲区
+		assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "error", Message: "failed"}))
+		var messages []string
+		for _, entry := range sink.received {
+			messages = append(messages, entry.Message)
+		}
+		assert.Equal(t, []string{"debug 3", "debug 4", "failed"}, messages)
+		assert.Equal(t, 0, adapter.Buffered())
+		assert.NoError(t, adapter.Close())
+	})
+
+	t.Run("FileSink", func(t *testing.T) {
+		path := filepath.Join(t.TempDir(), "dump.log")
+		adapter := &RingBufferAdapter{}
+		assert.NoError(t, adapter.Init(map[string]interface{}{
+			"sink_file":     path,
+			"trigger_level": "warn",
+		}))
+
+		ctx := context.Background()
+		assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "info", Message: "context", Time: time.Now()}))
+		assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "warn", Message: "trigger", Time: time.Now()}))
+		assert.NoError(t, adapter.Close())
+
+		data, err := os.ReadFile(path)
+		assert.NoError(t, err)
+		lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+		if assert.Len(t, lines, 2) {
+			var record map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+			assert.Equal(t, "context", record["message"])
+			assert.Equal(t, "info", record["level"])
+		}
+	})
+
+	t.Run("MissingSink", func(t *testing.T) {
+		adapter := &RingBufferAdapter{}
+		assert.Error(t, adapter.Init(map[string]interface{}{"size": float64(10)}))
+	})
+}
+
+// fakeEventLog 记录写入事件的测试事件日志
+type fakeEventLog struct {
+	events []string
+	closed bool
+}
+
+func (f *fakeEventLog) Info(eid uint32, msg string) error {
+	f.events = append(f.events, fmt.Sprintf("info/%d/%s", eid, msg))
+	return nil
+}
+
+func (f *fakeEventLog) Warning(eid uint32, msg string) error {
+	f.events = append(f.events, fmt.Sprintf("warning/%d/%s", eid, msg))
+	return nil
+}
+
+func (f *fakeEventLog) Error(eid uint32, msg string) error {
+	f.events = append(f.events, fmt.Sprintf("error/%d/%s", eid, msg))
+	return nil
+}
+
+func (f *fakeEventLog) Close() error {
+	f.closed = true
+	return nil
+}
+
+func TestEventLogAdapter(t *testing.T) {
+	adapter := &EventLogAdapter{}
+	assert.EqualError(t, adapter.Init(map[string]interface{}{}), "eventlog source is required")
+
+	fake := &fakeEventLog{}
+	adapter = &EventLogAdapter{Source: "app", EventID: 7, writer: fake}
+	ctx := context.Background()
+	assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "error", Message: "disk full", Module: "poc", Properties: map[string]interface{}{"path": "/data"}}))
+	assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "warn", Message: "slow"}))
+	assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "debug", Message: "trace"}))
+	assert.Equal(t, []string{
+		"error/7/disk full\nmodule=poc\npath=/data",
+		"warning/7/slow",
+		"info/7/trace",
+	}, fake.events)
+
+	assert.NoError(t, adapter.Close())
+	assert.True(t, fake.closed)
+	assert.Error(t, adapter.Process(ctx, logger.LogEntry{Level: "info", Message: "after close"}))
+}
+
+func TestLoggerSink(t *testing.T) {
+	target, err := logger.NewWithOptions(logger.WithTerminalOutput(), logger.WithLevel("fatal"))
+	assert.NoError(t, err)
+	received := NewTestAdapter("received")
+	target.AddAdapter(received)
+
+	sink := LoggerSink(target)
+	ctx := context.Background()
+	assert.NoError(t, sink.Process(ctx, logger.LogEntry{Level: "warn", Message: "disk slow", Properties: map[string]interface{}{"disk": "sda"}}))
+	// panic级别以error转发，不会在分发协程中崩溃
+	assert.NoError(t, sink.Process(ctx, logger.LogEntry{Level: "panic", Message: "fatal state"}))
+	// 已经过当前适配器的日志不再转发
+	looped := logger.LogEntry{Level: "info", Message: "looped", Properties: map[string]interface{}{SinkPathField: []interface{}{"other", sink.(*LoggerSinkAdapter).id}}}
+	assert.NoError(t, sink.Process(ctx, looped))
+	assert.NoError(t, target.Close())
+
+	if assert.Len(t, received.received, 2) {
+		first, second := received.received[0], received.received[1]
+		if first.Message != "disk slow" {
+			first, second = second, first
+		}
+		assert.Equal(t, "warn", first.Level)
+		assert.Equal(t, "sda", first.Properties["disk"])
+		assert.Equal(t, []interface{}{sink.(*LoggerSinkAdapter).id}, first.Properties[SinkPathField])
+		assert.Equal(t, "error", second.Level)
+		assert.Equal(t, "fatal state", second.Message)
+	}
+}
+
+func TestBatcherStats(t *testing.T) {
+	fail := true
+	b := &batcher{}
+	b.initBatcher(map[string]interface{}{}, 2, time.Hour, func(ctx context.Context, entries []logger.LogEntry) error {
+		if fail {
+			return fmt.Errorf("connection refused")
+		}
+		return nil
+	})
+	defer b.closeBatcher()
+
+	ctx := context.Background()
+	assert.NoError(t, b.add(ctx, logger.LogEntry{Message: "a"}))
+	assert.Error(t, b.add(ctx, logger.LogEntry{Message: "b"}))
+	stats := b.Stats()
+	assert.Equal(t, uint64(2), stats.Failed)
+	assert.Equal(t, "connection refused", stats.LastError)
+	assert.False(t, stats.LastErrorTime.IsZero())
+	assert.True(t, stats.LastFlush.IsZero())
+
+	fail = false
+	assert.NoError(t, b.add(ctx, logger.LogEntry{Message: "c"}))
+	assert.NoError(t, b.flush())
+	stats = b.Stats()
+	assert.Equal(t, uint64(1), stats.Delivered)
+	assert.False(t, stats.LastFlush.IsZero())
+
+	// 批量适配器通过嵌入的batcher报告统计
+	var _ logger.StatsAdapter = &KafkaAdapter{}
+	var _ logger.StatsAdapter = &ElasticsearchAdapter{}
+}
+
+func TestClickHouseAdapter(t *testing.T) {
+	adapter := &ClickHouseAdapter{}
+	assert.EqualError(t, adapter.Init(map[string]interface{}{}), "clickhouse dsn is required")
+
+	assert.NoError(t, adapter.Init(map[string]interface{}{
+		"dsn":            "clickhouse://localhost:9000/default",
+		"table":          "app_logs",
+		"batch_size":     float64(2),
+		"flush_interval": float64(1),
+		"async_insert":   true,
+	}))
+	assert.Equal(t, "app_logs", adapter.Table)
+	assert.Equal(t, 2, adapter.BatchSize)
+	assert.Equal(t, time.Second, adapter.FlushInterval)
+	assert.True(t, adapter.AsyncInsert)
+
+	ctx := context.Background()
+	assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "info", Message: "a"}))
+	assert.Equal(t, 1, adapter.Buffered())
+	assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "info", Message: "b"}))
+	assert.Equal(t, 0, adapter.Buffered())
+	assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "warn", Message: "c"}))
+	assert.NoError(t, adapter.Close())
+	assert.Equal(t, uint64(3), adapter.Stats().Delivered)
+
+	row, err := toClickHouseRow(logger.LogEntry{Level: "error", Message: "m", NodeID: "n1", Module: "poc", IP: "10.0.0.1", Properties: map[string]interface{}{"user": "alice"}})
+	assert.NoError(t, err)
+	assert.Equal(t, clickHouseRow{Level: "error", Message: "m", NodeID: "n1", Module: "poc", IP: "10.0.0.1", Properties: `{"user":"alice"}`}, row)
+	row, _ = toClickHouseRow(logger.LogEntry{})
+	assert.Equal(t, "{}", row.Properties)
+}
+
+func TestOTLPAdapter(t *testing.T) {
+	adapter := &OTLPAdapter{}
+	assert.EqualError(t, adapter.Init(map[string]interface{}{}), "otlp endpoint is required")
+	assert.EqualError(t, adapter.Init(map[string]interface{}{"endpoint": "localhost:4317", "protocol": "udp"}), "unknown otlp protocol: udp")
+
+	assert.NoError(t, adapter.Init(map[string]interface{}{
+		"endpoint":   "localhost:4318",
+		"protocol":   "http",
+		"headers":    map[string]interface{}{"api-key": "secret"},
+		"insecure":   true,
+		"batch_size": float64(2),
+	}))
+	assert.Equal(t, OTLPProtocolHTTP, adapter.Protocol)
+	assert.Equal(t, map[string]string{"api-key": "secret"}, adapter.Headers)
+	assert.True(t, adapter.Insecure)
+	assert.Equal(t, time.Second, adapter.FlushInterval)
+
+	ctx := context.Background()
+	assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "info", Message: "a"}))
+	assert.Equal(t, 1, adapter.Buffered())
+	assert.NoError(t, adapter.Close())
+	assert.Equal(t, 0, adapter.Buffered())
+	assert.Equal(t, uint64(1), adapter.Stats().Delivered)
+
+	now := time.Now()
+	logs := toOTLPResourceLogs([]logger.LogEntry{
+		{Level: "warn", Time: now, Message: "m1", NodeID: "n1", Module: "poc", IP: "10.0.0.1", Caller: "a/b.go:1", Properties: map[string]interface{}{"user": "alice"}},
+		{Level: "error", Time: now, Message: "m2", Module: "finger"},
+		{Level: "panic", Time: now, Message: "m3", NodeID: "n1", Module: "poc", IP: "10.0.0.1"},
+	})
+	if assert.Len(t, logs, 2) {
+		assert.Equal(t, map[string]string{"service.instance.id": "n1", "service.name": "poc", "host.ip": "10.0.0.1"}, logs[0].Resource)
+		assert.Equal(t, []otlpLogRecord{
+			{TimeUnixNano: now.UnixNano(), SeverityNumber: 13, SeverityText: "WARN", Body: "m1", Attributes: map[string]interface{}{"user": "alice", "code.caller": "a/b.go:1"}},
+			{TimeUnixNano: now.UnixNano(), SeverityNumber: 21, SeverityText: "FATAL", Body: "m3"},
+		}, logs[0].LogRecords)
+		assert.Equal(t, map[string]string{"service.name": "finger"}, logs[1].Resource)
+		assert.Equal(t, 17, logs[1].LogRecords[0].SeverityNumber)
+	}
+}
+
+func TestBatchEncoder(t *testing.T) {
+	enc := newBatchEncoder()
+	enc.WriteString("meta\n")
+	first, err := enc.Encode(map[string]interface{}{"a": 1})
+	assert.NoError(t, err)
+	assert.Equal(t, `{"a":1}`, string(first))
+	_, err = enc.Encode(map[string]interface{}{"bad": make(chan int)})
+	assert.Error(t, err)
+	second, err := enc.Encode("b")
+	assert.NoError(t, err)
+	assert.Equal(t, `"b"`, string(second))
+	assert.Equal(t, "meta\n{\"a\":1}\n\"b\"\n", string(enc.Bytes()))
+	enc.release()
+
+	// 归还后取出的编码器是空的
+	enc = newBatchEncoder()
+	assert.Empty(t, enc.Bytes())
+	enc.release()
+}
+
+func TestTailHandler(t *testing.T) {
+	ring := &RingBufferAdapter{}
+	assert.NoError(t, ring.Init(map[string]interface{}{
+		"size":          float64(5),
+		"trigger_level": "fatal",
+		"sink_file":     filepath.Join(t.TempDir(), "dump.log"),
+	}))
+	defer ring.Close()
+
+	ctx := context.Background()
+	levels := []string{"debug", "info", "warn", "info", "error", "debug"}
+	for i, level := range levels {
+		assert.NoError(t, ring.Process(ctx, logger.LogEntry{Level: level, Message: fmt.Sprintf("request %d", i), Module: "api"}))
+	}
+	handler := TailHandler(ring)
+
+	get := func(target string) (int, []map[string]interface{}) {
+		rec := httptest.NewRecorder()
+		handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, target, nil))
+		var records []map[string]interface{}
+		if rec.Code == http.StatusOK {
+			assert.Equal(t, "application/json", rec.Header().Get("Content-Type"))
+			assert.NoError(t, json.Unmarshal(rec.Body.Bytes(), &records))
+		}
+		return rec.Code, records
+	}
+	messages := func(records []map[string]interface{}) []string {
+		var result []string
+		for _, record := range records {
+			result = append(result, record["message"].(string))
+		}
+		return result
+	}
+
+	// 缓冲区只保留最近5条，按时间顺序返回
+	code, records := get("/")
+	assert.Equal(t, http.StatusOK, code)
+	assert.Equal(t, []string{"request 1", "request 2", "request 3", "request 4", "request 5"}, messages(records))
+	assert.Equal(t, "api", records[0]["module"])
+
+	// 过滤后保留最近的n条
+	_, records = get("/?level=info&n=2")
+	assert.Equal(t, []string{"request 3", "request 4"}, messages(records))
+	_, records = get("/?q=request+2")
+	assert.Equal(t, []string{"request 2"}, messages(records))
+	_, records = get("/?q=missing")
+	assert.Empty(t, records)
+
+	// 读取不会清空缓冲区
+	assert.Equal(t, 5, ring.Buffered())
+
+	code, _ = get("/?n=zero")
+	assert.Equal(t, http.StatusBadRequest, code)
+	code, _ = get("/?level=loud")
+	assert.Equal(t, http.StatusBadRequest, code)
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodDelete, "/", nil))
+	assert.Equal(t, http.StatusMethodNotAllowed, rec.Code)
+}
+
+// upperCodec 测试用的自定义编码，只输出大写的消息
+type upperCodec struct{}
+
+func (upperCodec) EncodeEntry(entry logger.LogEntry) ([]byte, error) {
+	return []byte(strings.ToUpper(entry.Message)), nil
+}
+
+func TestCodec(t *testing.T) {
+	entry := logger.LogEntry{
+		Level:      "info",
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message:    "order created",
+		Module:     "orders",
+		Properties: map[string]interface{}{"order_id": 42},
+	}
+
+	// 默认JSON编码
+	codec, err := LookupCodec("")
+	assert.NoError(t, err)
+	data, err := codec.EncodeEntry(entry)
+	assert.NoError(t, err)
+	assert.Contains(t, string(data), `"Message":"order created"`)
+
+	// msgpack编码的字段与JSON编码相同
+	codec, err = LookupCodec(CodecMsgpack)
+	assert.NoError(t, err)
+	data, err = codec.EncodeEntry(entry)
+	assert.NoError(t, err)
+	decoded, err := (&msgpackReader{r: bufio.NewReader(bytes.NewReader(data))}).readValue()
+	assert.NoError(t, err)
+	record := decoded.(map[string]interface{})
+	assert.Equal(t, "order created", record["Message"])
+	assert.Equal(t, "orders", record["Module"])
+	assert.EqualValues(t, 42, record["Properties"].(map[string]interface{})["order_id"])
+
+	// 注册的编码可以通过codec配置项使用
+	RegisterCodec("upper", upperCodec{})
+	adapter := &KafkaAdapter{}
+	config := map[string]interface{}{"codec": "upper", "flush_timeout": float64(3600)}
+	assert.NoError(t, adapter.ValidateConfig(config))
+	assert.NoError(t, adapter.Init(config))
+	data, err = adapter.encodeEntry(entry)
+	assert.NoError(t, err)
+	assert.Equal(t, "ORDER CREATED", string(data))
+	assert.NoError(t, adapter.Close())
+
+	err = (&KafkaAdapter{}).Init(map[string]interface{}{"codec": "avro"})
+	assert.EqualError(t, err, "unknown codec: avro")
+}
+
+func TestLevelRouting(t *testing.T) {
+	entries := []logger.LogEntry{
+		{Level: "info", Message: "a"},
+		{Level: "error", Message: "b"},
+		{Level: "warn", Message: "c"},
+		{Level: "fatal", Message: "d"},
+		{Level: "info", Message: "e"},
+	}
+	messages := func(entries []logger.LogEntry) []string {
+		var result []string
+		for _, entry := range entries {
+			result = append(result, entry.Message)
+		}
+		return result
+	}
+
+	// 未映射的级别使用默认主题，同一主题内保持原有顺序
+	kafka := &KafkaAdapter{}
+	assert.NoError(t, kafka.Init(map[string]interface{}{
+		"topic":         "logs.all",
+		"level_topics":  map[string]interface{}{"error": "logs.errors", "FATAL": "logs.errors"},
+		"flush_timeout": float64(3600),
+	}))
+	defer kafka.Close()
+	topics, groups := kafka.router.group(entries)
+	assert.Equal(t, []string{"logs.all", "logs.errors"}, topics)
+	assert.Equal(t, []string{"a", "c", "e"}, messages(groups["logs.all"]))
+	assert.Equal(t, []string{"b", "d"}, messages(groups["logs.errors"]))
+
+	// 索引可以使用.Level模板，映射优先于模板
+	es := &ElasticsearchAdapter{}
+	assert.NoError(t, es.Init(map[string]interface{}{
+		"index":          "logs-{{.Level}}",
+		"level_indices":  map[string]interface{}{"fatal": "logs-error"},
+		"flush_interval": float64(3600),
+	}))
+	defer es.Close()
+	indices, groups := es.router.group(entries)
+	assert.Equal(t, []string{"logs-error", "logs-info", "logs-warn"}, indices)
+	assert.Equal(t, []string{"b", "d"}, messages(groups["logs-error"]))
+
+	// 未配置映射时使用单一的主题
+	single := &KafkaAdapter{}
+	assert.NoError(t, single.Init(map[string]interface{}{"flush_timeout": float64(3600)}))
+	defer single.Close()
+	topics, _ = single.router.group(entries)
+	assert.Equal(t, []string{"logs"}, topics)
+
+	err := (&KafkaAdapter{}).Init(map[string]interface{}{"level_topics": map[string]interface{}{"error": 1}})
+	assert.EqualError(t, err, "invalid route for level error: 1")
+	err = (&ElasticsearchAdapter{}).Init(map[string]interface{}{"index": "logs-{{.Level"})
+	assert.ErrorContains(t, err, "parse route template logs-{{.Level failed")
+}
+
+func TestMemoryAdapter(t *testing.T) {
+	adapter := NewMemoryAdapter(0)
+	ctx := context.Background()
+
+	// 并发保存，不限制条数
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 10; j++ {
+				_ = adapter.Process(ctx, logger.LogEntry{Level: "info", Message: "concurrent"})
+			}
+		}()
+	}
+	wg.Wait()
+	assert.Len(t, adapter.Entries(), 100)
+	adapter.Reset()
+	assert.Empty(t, adapter.Entries())
+
+	// 通过WithMemoryAdapter接入日志，只保留最近的max_entries条
+	l, err := logger.NewWithOptions(
+		logger.WithLevel("debug"),
+		logger.WithOutputType(logger.OutputAdapters),
+		logger.WithMemoryAdapter(map[string]interface{}{"max_entries": 3}),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	logger.AsKeyValueLogger(l).Errorw("payment failed", "order", "o-1")
+	assert.NoError(t, l.Flush())
+
+	found, ok := l.(*logger.ZapLogger).Adapter("memory")
+	if !assert.True(t, ok) {
+		return
+	}
+	memory, ok := found.(*MemoryAdapter)
+	if !assert.True(t, ok) {
+		return
+	}
+	entries := memory.Entries()
+	if assert.Len(t, entries, 3) {
+		assert.Equal(t, []string{"info", "warn", "payment failed"}, []string{entries[0].Message, entries[1].Message, entries[2].Message})
+	}
+	errs := memory.FilterByLevel("error")
+	if assert.Len(t, errs, 1) {
+		assert.Equal(t, "payment failed", errs[0].Message)
+		assert.Equal(t, "o-1", errs[0].Properties["order"])
+	}
+	assert.Len(t, memory.FilterByLevel("warning"), 1)
+	assert.Empty(t, memory.FilterByLevel("debug"))
+
+	// 关闭日志后保留已保存的日志，Reset后清空
+	assert.NoError(t, l.Close())
+	assert.Len(t, memory.Entries(), 3)
+	memory.Reset()
+	assert.Empty(t, memory.Entries())
+
+	assert.Error(t, memory.ValidateConfig(map[string]interface{}{"max_entries": "ten"}))
+}
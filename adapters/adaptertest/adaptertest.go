@@ -0,0 +1,183 @@
+// Package adaptertest 为适配器作者提供的测试辅助，检查适配器序列化的消息是下游能够接受的JSON
+package adaptertest
+
+import (
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"math"
+	"testing"
+	"time"
+	"unicode/utf8"
+
+	"github.com/qishenonly/logger"
+	"github.com/qishenonly/logger/adapters"
+)
+
+// JSON值的类型，用于Schema
+const (
+	TypeString  = "string"
+	TypeNumber  = "number"
+	TypeBoolean = "boolean"
+	TypeObject  = "object"
+	TypeArray   = "array"
+	TypeNull    = "null"
+	TypeAny     = "any" // 只要求字段存在
+)
+
+// Schema 序列化结果必须包含的顶层字段及其JSON类型，如{"message": TypeString, "time": TypeString}
+type Schema map[string]string
+
+// EncodeFunc 将函数适配为adapters.Codec，便于直接检查适配器自身的序列化方法
+type EncodeFunc func(entry logger.LogEntry) ([]byte, error)
+
+// EncodeEntry 实现adapters.Codec接口
+func (f EncodeFunc) EncodeEntry(entry logger.LogEntry) ([]byte, error) {
+	return f(entry)
+}
+
+// SampleEntries 返回一组容易序列化出错的日志：多字节字符、控制字符、引号、嵌套的属性、空属性和零值时间
+func SampleEntries() []logger.LogEntry {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 6, time.UTC)
+	return []logger.LogEntry{
+		{Level: "info", Time: now, Message: "plain message", Module: "api", Properties: map[string]interface{}{"user": "alice"}},
+		{Level: "warn", Time: now, Message: "引号\"与反斜杠\\、换行\n和制表\t", Caller: "main.go:1"},
+		{Level: "error", Time: now, Message: "control \x00\x1f chars", Properties: map[string]interface{}{
+			"nested":   map[string]interface{}{"ids": []interface{}{1, 2.5, "x"}, "ok": true},
+			"empty":    map[string]interface{}{},
+			"nil":      nil,
+			"duration": 1500 * time.Millisecond,
+		}},
+		{Level: "debug", Message: "zero time and nil properties"},
+	}
+}
+
+// ValidateJSON 用codec序列化每条日志，检查结果是单个合法的JSON对象：UTF-8编码正确、没有重复的key、没有多余的内容，
+// 并且包含schema中声明的顶层字段及类型；entries为空时使用SampleEntries，所有问题通过errors.Join合并返回
+func ValidateJSON(codec adapters.Codec, entries []logger.LogEntry, schema Schema) error {
+	if len(entries) == 0 {
+		entries = SampleEntries()
+	}
+	var errs []error
+	for i, entry := range entries {
+		data, err := codec.EncodeEntry(entry)
+		if err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: encode failed: %v", i, err))
+			continue
+		}
+		if err := validateObject(data, schema); err != nil {
+			errs = append(errs, fmt.Errorf("entry %d: %v", i, err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AssertValidJSON 在测试中调用ValidateJSON，失败时报告错误并返回false
+func AssertValidJSON(t testing.TB, codec adapters.Codec, entries []logger.LogEntry, schema Schema) bool {
+	t.Helper()
+	if err := ValidateJSON(codec, entries, schema); err != nil {
+		t.Errorf("adapter output is not valid JSON:\n%v", err)
+		return false
+	}
+	return true
+}
+
+// validateObject 检查data是单个JSON对象并符合schema
+func validateObject(data []byte, schema Schema) error {
+	if !utf8.Valid(data) {
+		return fmt.Errorf("invalid UTF-8")
+	}
+	dec := json.NewDecoder(bytes.NewReader(data))
+	dec.UseNumber()
+	value, err := readValue(dec, "$")
+	if err != nil {
+		return err
+	}
+	if _, err := dec.Token(); err != io.EOF {
+		return fmt.Errorf("unexpected data after JSON value")
+	}
+	object, ok := value.(map[string]interface{})
+	if !ok {
+		return fmt.Errorf("top-level value is %s, want object", typeOf(value))
+	}
+	for key, want := range schema {
+		v, ok := object[key]
+		if !ok {
+			return fmt.Errorf("missing field %s", key)
+		}
+		if got := typeOf(v); want != TypeAny && got != want {
+			return fmt.Errorf("field %s is %s, want %s", key, got, want)
+		}
+	}
+	return nil
+}
+
+// readValue 逐个读取JSON token并重建值，对象中重复的key视为错误，path用于错误信息
+func readValue(dec *json.Decoder, path string) (interface{}, error) {
+	tok, err := dec.Token()
+	if err != nil {
+		return nil, fmt.Errorf("malformed JSON at %s: %v", path, err)
+	}
+	switch tok {
+	case json.Delim('{'):
+		object := make(map[string]interface{})
+		for dec.More() {
+			keyTok, err := dec.Token()
+			if err != nil {
+				return nil, fmt.Errorf("malformed JSON at %s: %v", path, err)
+			}
+			key := keyTok.(string)
+			if _, exists := object[key]; exists {
+				return nil, fmt.Errorf("duplicate key %s.%s", path, key)
+			}
+			if object[key], err = readValue(dec, path+"."+key); err != nil {
+				return nil, err
+			}
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("malformed JSON at %s: %v", path, err)
+		}
+		return object, nil
+	case json.Delim('['):
+		var array []interface{}
+		for i := 0; dec.More(); i++ {
+			item, err := readValue(dec, fmt.Sprintf("%s[%d]", path, i))
+			if err != nil {
+				return nil, err
+			}
+			array = append(array, item)
+		}
+		if _, err := dec.Token(); err != nil {
+			return nil, fmt.Errorf("malformed JSON at %s: %v", path, err)
+		}
+		return array, nil
+	case json.Delim('}'), json.Delim(']'):
+		return nil, fmt.Errorf("malformed JSON at %s: unexpected %v", path, tok)
+	}
+	if n, ok := tok.(json.Number); ok {
+		if f, err := n.Float64(); err != nil || math.IsInf(f, 0) {
+			return nil, fmt.Errorf("number out of range at %s: %s", path, n)
+		}
+	}
+	return tok, nil
+}
+
+// typeOf 返回解码后的值对应的JSON类型
+func typeOf(v interface{}) string {
+	switch v.(type) {
+	case nil:
+		return TypeNull
+	case string:
+		return TypeString
+	case json.Number:
+		return TypeNumber
+	case bool:
+		return TypeBoolean
+	case map[string]interface{}:
+		return TypeObject
+	default:
+		return TypeArray
+	}
+}
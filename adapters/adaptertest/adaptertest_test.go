@@ -0,0 +1,45 @@
+package adaptertest
+
+import (
+	"fmt"
+	"testing"
+
+	"github.com/qishenonly/logger"
+	"github.com/qishenonly/logger/adapters"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestValidateJSON(t *testing.T) {
+	schema := Schema{"Level": TypeString, "Message": TypeString, "Time": TypeString, "Properties": TypeAny}
+
+	// 内置的JSON编码能正确处理各种样例
+	codec, err := adapters.LookupCodec(adapters.CodecJSON)
+	assert.NoError(t, err)
+	AssertValidJSON(t, codec, nil, schema)
+
+	// 手工拼接JSON的常见错误
+	cases := []struct {
+		name string
+		data string
+		err  string
+	}{
+		{"unescaped quote", `{"msg":"say "hi""}`, "malformed JSON"},
+		{"duplicate key", `{"msg":"a","nested":{"k":1,"k":2}}`, "duplicate key $.nested.k"},
+		{"trailing data", `{"msg":"a"}{"msg":"b"}`, "unexpected data after JSON value"},
+		{"not an object", `["msg"]`, "top-level value is array, want object"},
+		{"invalid utf-8", "{\"msg\":\"\xff\"}", "invalid UTF-8"},
+		{"missing field", `{"msg":"a"}`, "missing field level"},
+		{"wrong type", `{"msg":"a","level":1}`, "field level is number, want string"},
+	}
+	for _, tc := range cases {
+		t.Run(tc.name, func(t *testing.T) {
+			codec := EncodeFunc(func(logger.LogEntry) ([]byte, error) { return []byte(tc.data), nil })
+			err := ValidateJSON(codec, []logger.LogEntry{{Message: "a"}}, Schema{"msg": TypeString, "level": TypeString})
+			assert.ErrorContains(t, err, tc.err)
+		})
+	}
+
+	// 序列化失败同样报告，包含出错的日志序号
+	failing := EncodeFunc(func(logger.LogEntry) ([]byte, error) { return nil, fmt.Errorf("boom") })
+	assert.EqualError(t, ValidateJSON(failing, []logger.LogEntry{{}}, nil), "entry 0: encode failed: boom")
+}
@@ -0,0 +1,231 @@
+package adapters
+
+import (
+	"context"
+	"crypto/hmac"
+	"crypto/sha256"
+	"encoding/base64"
+	"encoding/json"
+	"fmt"
+	"net/http"
+	"strconv"
+	"time"
+
+	"github.com/qishenonly/logger"
+)
+
+const (
+	// azureMonitorMaxRequestSize Data Collector API单次请求的最大字节数
+	azureMonitorMaxRequestSize = 30 * 1024 * 1024
+	// azureMonitorAPIVersion Data Collector API版本
+	azureMonitorAPIVersion = "2016-04-01"
+)
+
+func init() {
+	// 注册适配器
+	logger.RegisterAdapter("azuremonitor", func() logger.LogAdapter {
+		return &AzureMonitorAdapter{}
+	}, azureMonitorConfigSchema)
+}
+
+// azureMonitorConfigSchema Azure Monitor适配器支持的配置项
+var azureMonitorConfigSchema = logger.ConfigSchema{
+	"workspace_id":   logger.ConfigString,
+	"shared_key":     logger.ConfigString,
+	"log_type":       logger.ConfigString,
+	"endpoint":       logger.ConfigString,
+	"batch_size":     logger.ConfigNumber,
+	"flush_interval": logger.ConfigNumber,
+}.Merge(batchConfigSchema, httpConfigSchema)
+
+// AzureMonitorAdapter 用于将日志通过HTTP Data Collector API输出到Azure Monitor(Log Analytics)
+type AzureMonitorAdapter struct {
+	WorkspaceID   string
+	SharedKey     string
+	LogType       string
+	Endpoint      string
+	BatchSize     int
+	FlushInterval time.Duration
+	batcher
+	httpSender
+	key []byte
+}
+
+// azureMonitorRecord 写入自定义表的一行记录
+type azureMonitorRecord struct {
+	TimeGenerated string                 `json:"TimeGenerated"`
+	Level         string                 `json:"Level"`
+	Message       string                 `json:"Message"`
+	Caller        string                 `json:"Caller,omitempty"`
+	NodeID        string                 `json:"NodeID,omitempty"`
+	Module        string                 `json:"Module,omitempty"`
+	IP            string                 `json:"IP,omitempty"`
+	Properties    map[string]interface{} `json:"Properties,omitempty"`
+}
+
+// Name 返回适配器名称
+func (a *AzureMonitorAdapter) Name() string {
+	return "azuremonitor"
+}
+
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *AzureMonitorAdapter) ValidateConfig(config map[string]interface{}) error {
+	return azureMonitorConfigSchema.Validate(config)
+}
+
+// Init 初始化适配器
+func (a *AzureMonitorAdapter) Init(config map[string]interface{}) error {
+	// 解析配置参数
+	workspaceID, ok := config["workspace_id"].(string)
+	if !ok || workspaceID == "" {
+		return fmt.Errorf("azure monitor workspace_id is required")
+	}
+	a.WorkspaceID = workspaceID
+
+	sharedKey, ok := config["shared_key"].(string)
+	if !ok || sharedKey == "" {
+		return fmt.Errorf("azure monitor shared_key is required")
+	}
+	key, err := base64.StdEncoding.DecodeString(sharedKey)
+	if err != nil {
+		return fmt.Errorf("decode azure monitor shared_key failed: %v", err)
+	}
+	a.SharedKey = sharedKey
+	a.key = key
+
+	if logType, ok := config["log_type"].(string); ok {
+		a.LogType = logType
+	} else {
+		a.LogType = "AppLogs"
+	}
+
+	if endpoint, ok := config["endpoint"].(string); ok {
+		a.Endpoint = endpoint
+	} else {
+		a.Endpoint = fmt.Sprintf("https://%s.ods.opinsights.azure.com/api/logs?api-version=%s", a.WorkspaceID, azureMonitorAPIVersion)
+	}
+
+	if batchSize, ok := logger.ToFloat64(config["batch_size"]); ok {
+		a.BatchSize = int(batchSize)
+	} else {
+		a.BatchSize = 500
+	}
+
+	if flushInterval, ok := logger.ToFloat64(config["flush_interval"]); ok && flushInterval > 0 {
+		a.FlushInterval = time.Duration(flushInterval * float64(time.Second))
+	} else {
+		a.FlushInterval = 10 * time.Second
+	}
+
+	a.initHTTPSender(config)
+
+	// 初始化缓冲区并启动定时刷新
+	a.initBatcher(config, a.BatchSize, a.FlushInterval, a.send)
+
+	return nil
+}
+
+// Process 处理日志条目
+func (a *AzureMonitorAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	return a.add(ctx, entry)
+}
+
+// Flush 刷新缓冲区
+func (a *AzureMonitorAdapter) Flush() error {
+	return a.flush()
+}
+
+// send 批量发送日志
+func (a *AzureMonitorAdapter) send(ctx context.Context, entries []logger.LogEntry) error {
+	// 按请求大小限制拆分批次
+	var firstErr error
+	batch := make([]json.RawMessage, 0, len(entries))
+	batchSize := 2 // JSON数组的方括号
+	for _, entry := range entries {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+
+		data, err := json.Marshal(toAzureMonitorRecord(entry))
+		if err != nil {
+			continue
+		}
+		// 单条记录本身超过限制，无法发送
+		if len(data)+2 > azureMonitorMaxRequestSize {
+			continue
+		}
+
+		if len(batch) > 0 && batchSize+len(data)+1 > azureMonitorMaxRequestSize {
+			if err := a.post(ctx, batch); err != nil && firstErr == nil {
+				firstErr = err
+			}
+			batch = batch[:0]
+			batchSize = 2
+		}
+
+		batch = append(batch, data)
+		batchSize += len(data) + 1
+	}
+
+	if len(batch) > 0 {
+		if err := a.post(ctx, batch); err != nil && firstErr == nil {
+			firstErr = err
+		}
+	}
+
+	return firstErr
+}
+
+// post 将一批记录发送到Data Collector API
+func (a *AzureMonitorAdapter) post(ctx context.Context, records []json.RawMessage) error {
+	body, err := json.Marshal(records)
+	if err != nil {
+		return fmt.Errorf("marshal azure monitor records failed: %v", err)
+	}
+
+	date := time.Now().UTC().Format(http.TimeFormat)
+	req, err := a.newRequest(ctx, http.MethodPost, a.Endpoint, body)
+	if err != nil {
+		return fmt.Errorf("create azure monitor request failed: %v", err)
+	}
+	req.Header.Set("Content-Type", "application/json")
+	req.Header.Set("Log-Type", a.LogType)
+	req.Header.Set("x-ms-date", date)
+	req.Header.Set("time-generated-field", "TimeGenerated")
+	// 签名使用实际发送的请求体长度
+	req.Header.Set("Authorization", a.signature(date, int(req.ContentLength)))
+
+	if err := a.do(req); err != nil {
+		return fmt.Errorf("send azure monitor request failed: %v", err)
+	}
+
+	return nil
+}
+
+// signature 生成SharedKey授权头
+func (a *AzureMonitorAdapter) signature(date string, contentLength int) string {
+	stringToSign := "POST\n" + strconv.Itoa(contentLength) + "\napplication/json\nx-ms-date:" + date + "\n/api/logs"
+	mac := hmac.New(sha256.New, a.key)
+	mac.Write([]byte(stringToSign))
+	return "SharedKey " + a.WorkspaceID + ":" + base64.StdEncoding.EncodeToString(mac.Sum(nil))
+}
+
+// toAzureMonitorRecord 将日志条目映射为自定义表的记录
+func toAzureMonitorRecord(entry logger.LogEntry) azureMonitorRecord {
+	return azureMonitorRecord{
+		TimeGenerated: entry.Time.UTC().Format(time.RFC3339Nano),
+		Level:         entry.Level,
+		Message:       entry.Message,
+		Caller:        entry.Caller,
+		NodeID:        entry.NodeID,
+		Module:        entry.Module,
+		IP:            entry.IP,
+		Properties:    entry.Properties,
+	}
+}
+
+// Close 关闭适配器
+func (a *AzureMonitorAdapter) Close() error {
+	// 刷新剩余日志并停止定时刷新
+	return a.closeBatcher()
+}
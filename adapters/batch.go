@@ -0,0 +1,343 @@
+package adapters
+
+import (
+	"bytes"
+	"context"
+	"encoding/json"
+	"sync"
+	"time"
+
+	"github.com/qishenonly/logger"
+)
+
+// FlushStrategy 批量适配器的定时刷新策略
+type FlushStrategy string
+
+const (
+	// FlushPeriodic 按固定间隔刷新
+	FlushPeriodic FlushStrategy = "periodic"
+	// FlushIdle 在最后一条日志之后空闲idle_timeout再刷新，每次Process都会重置计时
+	FlushIdle FlushStrategy = "idle"
+)
+
+// batcher 批量适配器公共的缓冲与刷新逻辑
+// 无论哪种策略，缓冲区达到批量大小时都会立即刷新
+//
+// flush_workers大于1时，刷新会把当前缓冲区复制成独立批次交给发送协程池，
+// 同一批次只会被发送一次，但多个批次可能同时在途，批次之间不再保证发送顺序。
+// 需要严格顺序的场景请保持flush_workers为1。
+type batcher struct {
+	buffer   []logger.LogEntry
+	bufferMu sync.Mutex
+
+	batchSize     int
+	strategy      FlushStrategy
+	flushInterval time.Duration
+	idleTimeout   time.Duration
+	send          func(ctx context.Context, entries []logger.LogEntry) error
+
+	idleTimer *time.Timer
+	done      chan struct{}
+
+	flushWorkers int
+	jobs         chan []logger.LogEntry
+	inflight     sync.WaitGroup
+	errMu        sync.Mutex
+	asyncErr     error
+
+	statsMu sync.Mutex
+	stats   logger.AdapterStats
+
+	codec Codec // 按条编码消息的适配器使用的编码，为空时使用JSON
+}
+
+// batchConfigSchema 批量适配器公共的配置项
+var batchConfigSchema = logger.ConfigSchema{
+	"flush_strategy": logger.ConfigString,
+	"idle_timeout":   logger.ConfigNumber,
+	"flush_workers":  logger.ConfigNumber,
+}
+
+// initBatcher 初始化批处理逻辑并启动定时刷新
+// config中的flush_strategy选择刷新策略，idle_timeout(秒)为空闲刷新的等待时间，默认与flushInterval相同，
+// flush_workers为并发发送的协程数，默认为1即在刷新时同步发送
+func (b *batcher) initBatcher(config map[string]interface{}, batchSize int, flushInterval time.Duration, send func(ctx context.Context, entries []logger.LogEntry) error) {
+	b.batchSize = batchSize
+	b.flushInterval = flushInterval
+	b.send = send
+
+	if strategy, ok := config["flush_strategy"].(string); ok && FlushStrategy(strategy) == FlushIdle {
+		b.strategy = FlushIdle
+	} else {
+		b.strategy = FlushPeriodic
+	}
+
+	if idleTimeout, ok := logger.ToFloat64(config["idle_timeout"]); ok {
+		b.idleTimeout = time.Duration(idleTimeout * float64(time.Second))
+	} else {
+		b.idleTimeout = flushInterval
+	}
+
+	if flushWorkers, ok := logger.ToFloat64(config["flush_workers"]); ok && flushWorkers > 1 {
+		b.flushWorkers = int(flushWorkers)
+	} else {
+		b.flushWorkers = 1
+	}
+
+	// 初始化日志缓冲区
+	b.buffer = make([]logger.LogEntry, 0, b.batchSize)
+	b.done = make(chan struct{})
+
+	// 启动发送协程池
+	if b.flushWorkers > 1 {
+		b.jobs = make(chan []logger.LogEntry, b.flushWorkers)
+		for i := 0; i < b.flushWorkers; i++ {
+			go b.sendWorker(b.jobs)
+		}
+	}
+
+	if b.strategy == FlushPeriodic {
+		go b.flushPeriodically(b.done)
+	}
+}
+
+// initCodec 按config中的codec选择消息编码，未设置时使用JSON，未注册的编码返回错误
+func (b *batcher) initCodec(config map[string]interface{}) error {
+	name, _ := config["codec"].(string)
+	codec, err := LookupCodec(name)
+	if err != nil {
+		return err
+	}
+	b.codec = codec
+	return nil
+}
+
+// encodeEntry 使用配置的编码序列化单条日志
+func (b *batcher) encodeEntry(entry logger.LogEntry) ([]byte, error) {
+	if b.codec == nil {
+		return jsonCodec{}.EncodeEntry(entry)
+	}
+	return b.codec.EncodeEntry(entry)
+}
+
+// add 添加日志到缓冲区，达到批量大小时立即刷新
+// ctx已取消时不再写入缓冲区，由add触发的同步发送同样受ctx控制
+func (b *batcher) add(ctx context.Context, entry logger.LogEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	b.bufferMu.Lock()
+	defer b.bufferMu.Unlock()
+
+	// 添加到缓冲区
+	b.buffer = append(b.buffer, entry)
+
+	// 如果达到批量大小，刷新缓冲区
+	if len(b.buffer) >= b.batchSize {
+		return b.flushBuffer(ctx)
+	}
+
+	// 空闲策略下重置计时
+	if b.strategy == FlushIdle {
+		if b.idleTimer == nil {
+			b.idleTimer = time.AfterFunc(b.idleTimeout, func() {
+				_ = b.flush()
+			})
+		} else {
+			b.idleTimer.Reset(b.idleTimeout)
+		}
+	}
+
+	return nil
+}
+
+// Buffered 返回缓冲区中尚未发送的日志数
+func (b *batcher) Buffered() int {
+	b.bufferMu.Lock()
+	defer b.bufferMu.Unlock()
+	return len(b.buffer)
+}
+
+// flush 刷新缓冲区，使用协程池时会等待所有在途批次发送完成
+func (b *batcher) flush() error {
+	b.bufferMu.Lock()
+	err := b.flushBuffer(context.Background())
+	pooled := b.jobs != nil
+	b.bufferMu.Unlock()
+
+	if pooled {
+		b.inflight.Wait()
+		err = b.takeAsyncErr()
+	}
+	return err
+}
+
+// flushBuffer 刷新缓冲区（无锁版本，调用前需要获取锁）
+// 交给协程池的批次在后台发送，不受调用方ctx控制
+func (b *batcher) flushBuffer(ctx context.Context) error {
+	if len(b.buffer) == 0 || b.send == nil {
+		b.buffer = b.buffer[:0]
+		return nil
+	}
+
+	// 使用协程池时复制出独立批次，避免缓冲区复用导致重复发送
+	if b.jobs != nil {
+		batch := make([]logger.LogEntry, len(b.buffer))
+		copy(batch, b.buffer)
+		b.buffer = b.buffer[:0]
+
+		b.inflight.Add(1)
+		b.jobs <- batch
+		return nil
+	}
+
+	err := b.deliver(ctx, b.buffer)
+
+	// 清空缓冲区
+	b.buffer = b.buffer[:0]
+
+	return err
+}
+
+// sendWorker 发送协程，逐个发送批次并记录错误
+func (b *batcher) sendWorker(jobs chan []logger.LogEntry) {
+	for batch := range jobs {
+		if err := b.deliver(context.Background(), batch); err != nil {
+			b.errMu.Lock()
+			if b.asyncErr == nil {
+				b.asyncErr = err
+			}
+			b.errMu.Unlock()
+		}
+		b.inflight.Done()
+	}
+}
+
+// deliver 发送一个批次并记录发送统计
+func (b *batcher) deliver(ctx context.Context, batch []logger.LogEntry) error {
+	err := b.send(ctx, batch)
+
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+	if err != nil {
+		b.stats.Failed += uint64(len(batch))
+		b.stats.LastError = err.Error()
+		b.stats.LastErrorTime = time.Now()
+	} else {
+		b.stats.Delivered += uint64(len(batch))
+		b.stats.LastFlush = time.Now()
+	}
+	return err
+}
+
+// Stats 返回适配器的发送统计
+func (b *batcher) Stats() logger.AdapterStats {
+	b.statsMu.Lock()
+	defer b.statsMu.Unlock()
+	return b.stats
+}
+
+// takeAsyncErr 取出并清空协程池记录的第一个错误
+func (b *batcher) takeAsyncErr() error {
+	b.errMu.Lock()
+	defer b.errMu.Unlock()
+
+	err := b.asyncErr
+	b.asyncErr = nil
+	return err
+}
+
+// flushPeriodically 定期刷新缓冲区
+func (b *batcher) flushPeriodically(done chan struct{}) {
+	ticker := time.NewTicker(b.flushInterval)
+	defer ticker.Stop()
+
+	for {
+		select {
+		case <-ticker.C:
+			_ = b.flush()
+		case <-done:
+			return
+		}
+	}
+}
+
+// closeBatcher 停止定时刷新，刷新剩余日志并关闭发送协程池
+func (b *batcher) closeBatcher() error {
+	b.bufferMu.Lock()
+	if b.idleTimer != nil {
+		b.idleTimer.Stop()
+		b.idleTimer = nil
+	}
+	if b.done != nil {
+		close(b.done)
+		b.done = nil
+	}
+	b.bufferMu.Unlock()
+
+	// 刷新剩余日志
+	err := b.flush()
+
+	b.bufferMu.Lock()
+	if b.jobs != nil {
+		close(b.jobs)
+		b.jobs = nil
+	}
+	b.bufferMu.Unlock()
+
+	return err
+}
+
+// maxPooledEncoderSize 归还到池中的编码缓冲区的最大容量，超大批次的缓冲区直接丢弃，避免长期占用内存
+const maxPooledEncoderSize = 4 << 20
+
+// batchEncoderPool 批量发送时复用的JSON编码缓冲区
+var batchEncoderPool = sync.Pool{
+	New: func() interface{} {
+		e := &batchEncoder{}
+		e.enc = json.NewEncoder(&e.buf)
+		return e
+	},
+}
+
+// batchEncoder 将一批日志编码为换行分隔的JSON，缓冲区和编码器在多次刷新之间复用
+// Encode返回的数据在release之前有效
+type batchEncoder struct {
+	buf bytes.Buffer
+	enc *json.Encoder
+}
+
+// newBatchEncoder 从池中取出一个空的编码器，使用完后需要调用release
+func newBatchEncoder() *batchEncoder {
+	return batchEncoderPool.Get().(*batchEncoder)
+}
+
+// WriteString 直接写入缓冲区，如Bulk请求的元数据行
+func (e *batchEncoder) WriteString(s string) {
+	e.buf.WriteString(s)
+}
+
+// Encode 将v编码为一行JSON追加到缓冲区，返回不含换行的该行数据
+func (e *batchEncoder) Encode(v interface{}) ([]byte, error) {
+	start := e.buf.Len()
+	if err := e.enc.Encode(v); err != nil {
+		e.buf.Truncate(start)
+		return nil, err
+	}
+	return e.buf.Bytes()[start : e.buf.Len()-1], nil
+}
+
+// Bytes 返回缓冲区中已编码的全部数据
+func (e *batchEncoder) Bytes() []byte {
+	return e.buf.Bytes()
+}
+
+// release 清空缓冲区并归还到池中
+func (e *batchEncoder) release() {
+	if e.buf.Cap() > maxPooledEncoderSize {
+		return
+	}
+	e.buf.Reset()
+	batchEncoderPool.Put(e)
+}
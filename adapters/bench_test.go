@@ -0,0 +1,63 @@
+package adapters
+
+import (
+	"bytes"
+	"encoding/json"
+	"testing"
+	"time"
+
+	"github.com/qishenonly/logger"
+)
+
+// 基准参考值（go test -run ^$ -bench BatchEncode -benchmem ./adapters/），每次编码100条日志：
+//
+//	BenchmarkBatchEncode/Marshal         117627 B/op   1009 allocs/op
+//	BenchmarkBatchEncode/PooledEncoder    35205 B/op    900 allocs/op
+//
+// 复用的编码器省去了每条日志的结果切片和请求体缓冲区的扩容，剩余分配来自反射编码Properties。
+
+// benchEntries 基准测试用的一批日志
+func benchEntries(n int) []logger.LogEntry {
+	entries := make([]logger.LogEntry, n)
+	for i := range entries {
+		entries[i] = logger.LogEntry{
+			Level:      "info",
+			Time:       time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC),
+			Message:    "request handled",
+			NodeID:     "node-1",
+			Module:     "api",
+			Properties: map[string]interface{}{"user": "alice", "status": 200},
+		}
+	}
+	return entries
+}
+
+func BenchmarkBatchEncode(b *testing.B) {
+	entries := benchEntries(100)
+	meta := `{ "index" : { "_index" : "logs" } }` + "\n"
+
+	b.Run("Marshal", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			var buf bytes.Buffer
+			for _, entry := range entries {
+				data, _ := json.Marshal(entry)
+				buf.WriteString(meta)
+				buf.Write(data)
+				buf.WriteByte('\n')
+			}
+		}
+	})
+
+	b.Run("PooledEncoder", func(b *testing.B) {
+		b.ReportAllocs()
+		for i := 0; i < b.N; i++ {
+			enc := newBatchEncoder()
+			for _, entry := range entries {
+				enc.WriteString(meta)
+				_, _ = enc.Encode(entry)
+			}
+			enc.release()
+		}
+	})
+}
@@ -0,0 +1,196 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/qishenonly/logger"
+)
+
+func init() {
+	// 注册适配器
+	logger.RegisterAdapter("clickhouse", func() logger.LogAdapter {
+		return &ClickHouseAdapter{}
+	}, clickHouseConfigSchema)
+}
+
+// clickHouseConfigSchema ClickHouse适配器支持的配置项
+var clickHouseConfigSchema = logger.ConfigSchema{
+	"dsn":            logger.ConfigString,
+	"table":          logger.ConfigString,
+	"batch_size":     logger.ConfigNumber,
+	"flush_interval": logger.ConfigNumber,
+	"async_insert":   logger.ConfigBool,
+}.Merge(batchConfigSchema)
+
+// ClickHouseAdapter 用于将日志批量写入ClickHouse表
+// 表结构: level String, time DateTime64(3), message String, node_id String, module String, ip String, properties String(JSON)
+type ClickHouseAdapter struct {
+	DSN           string
+	Table         string
+	BatchSize     int
+	FlushInterval time.Duration
+	AsyncInsert   bool // 使用服务端异步插入(async_insert=1)，插入在服务端缓冲后再落盘
+	batcher
+	conn interface{} // 这里用interface{}占位，实际应该是clickhouse-go的driver.Conn
+}
+
+// clickHouseRow 对应ClickHouse表中的一行
+type clickHouseRow struct {
+	Level      string    `json:"level"`
+	Time       time.Time `json:"time"`
+	Message    string    `json:"message"`
+	NodeID     string    `json:"node_id"`
+	Module     string    `json:"module"`
+	IP         string    `json:"ip"`
+	Properties string    `json:"properties"`
+}
+
+// Name 返回适配器名称
+func (a *ClickHouseAdapter) Name() string {
+	return "clickhouse"
+}
+
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *ClickHouseAdapter) ValidateConfig(config map[string]interface{}) error {
+	return clickHouseConfigSchema.Validate(config)
+}
+
+// Init 初始化适配器
+func (a *ClickHouseAdapter) Init(config map[string]interface{}) error {
+	// 解析配置参数
+	dsn, ok := config["dsn"].(string)
+	if !ok || dsn == "" {
+		return fmt.Errorf("clickhouse dsn is required")
+	}
+	a.DSN = dsn
+
+	if table, ok := config["table"].(string); ok && table != "" {
+		a.Table = table
+	} else {
+		a.Table = "logs"
+	}
+
+	if batchSize, ok := logger.ToFloat64(config["batch_size"]); ok && batchSize > 0 {
+		a.BatchSize = int(batchSize)
+	} else {
+		a.BatchSize = 1000
+	}
+
+	if flushInterval, ok := logger.ToFloat64(config["flush_interval"]); ok && flushInterval > 0 {
+		a.FlushInterval = time.Duration(flushInterval * float64(time.Second))
+	} else {
+		a.FlushInterval = 5 * time.Second
+	}
+
+	if asyncInsert, ok := config["async_insert"].(bool); ok {
+		a.AsyncInsert = asyncInsert
+	}
+
+	// 连接到ClickHouse
+	// 实际应该这样:
+	// options, err := clickhouse.ParseDSN(a.DSN)
+	// if err != nil {
+	//     return fmt.Errorf("parse clickhouse dsn failed: %v", err)
+	// }
+	// conn, err := clickhouse.Open(options)
+	// if err != nil {
+	//     return fmt.Errorf("failed to connect to clickhouse: %v", err)
+	// }
+	// if err := conn.Ping(context.Background()); err != nil {
+	//     return fmt.Errorf("failed to ping clickhouse: %v", err)
+	// }
+	// a.conn = conn
+
+	// 初始化缓冲区并启动定时刷新
+	a.initBatcher(config, a.BatchSize, a.FlushInterval, a.send)
+
+	return nil
+}
+
+// Process 处理日志条目
+func (a *ClickHouseAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	return a.add(ctx, entry)
+}
+
+// Flush 刷新缓冲区
+func (a *ClickHouseAdapter) Flush() error {
+	return a.flush()
+}
+
+// send 批量插入日志
+func (a *ClickHouseAdapter) send(ctx context.Context, entries []logger.LogEntry) error {
+	rows := make([]clickHouseRow, 0, len(entries))
+	for _, entry := range entries {
+		row, err := toClickHouseRow(entry)
+		if err != nil {
+			return err
+		}
+		rows = append(rows, row)
+	}
+
+	// 在实际应用中，这里使用批量插入
+	// if a.AsyncInsert {
+	//     ctx = clickhouse.Context(ctx, clickhouse.WithAsync(true))
+	// }
+	// batch, err := a.conn.(driver.Conn).PrepareBatch(ctx, "INSERT INTO "+a.Table)
+	// if err != nil {
+	//     return err
+	// }
+	// for _, row := range rows {
+	//     if err := batch.Append(row.Level, row.Time, row.Message, row.NodeID, row.Module, row.IP, row.Properties); err != nil {
+	//         return err
+	//     }
+	// }
+	// return batch.Send()
+
+	// 这里仅作演示，实际打印日志
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, row := range rows {
+		data, _ := json.Marshal(row)
+		fmt.Printf("[ClickHouse Adapter] Would insert into %s: %s\n", a.Table, string(data))
+	}
+
+	return nil
+}
+
+// Close 关闭适配器
+func (a *ClickHouseAdapter) Close() error {
+	// 刷新剩余日志并停止定时刷新
+	err := a.closeBatcher()
+
+	// 关闭连接
+	// if a.conn != nil {
+	//     if cerr := a.conn.(driver.Conn).Close(); cerr != nil && err == nil {
+	//         err = cerr
+	//     }
+	// }
+
+	return err
+}
+
+// toClickHouseRow 将日志条目转换为表中的一行，properties序列化为JSON字符串
+func toClickHouseRow(entry logger.LogEntry) (clickHouseRow, error) {
+	properties := "{}"
+	if len(entry.Properties) > 0 {
+		data, err := json.Marshal(entry.Properties)
+		if err != nil {
+			return clickHouseRow{}, fmt.Errorf("marshal clickhouse properties failed: %v", err)
+		}
+		properties = string(data)
+	}
+
+	return clickHouseRow{
+		Level:      entry.Level,
+		Time:       entry.Time,
+		Message:    entry.Message,
+		NodeID:     entry.NodeID,
+		Module:     entry.Module,
+		IP:         entry.IP,
+		Properties: properties,
+	}, nil
+}
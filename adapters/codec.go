@@ -0,0 +1,92 @@
+package adapters
+
+import (
+	"encoding/json"
+	"fmt"
+	"sync"
+
+	"github.com/qishenonly/logger"
+)
+
+// 内置的消息编码
+const (
+	CodecJSON    = "json"    // 每条日志编码为一个JSON对象，默认编码
+	CodecMsgpack = "msgpack" // 每条日志编码为一个MessagePack map，字段与JSON编码相同
+	CodecLogfmt  = "logfmt"  // 每条日志编码为一行logfmt，属性按添加顺序排列
+)
+
+// Codec 将单条日志序列化为发送的消息体，使传输与消息格式解耦
+// 实现需要能被多个发送协程并发调用
+type Codec interface {
+	EncodeEntry(entry logger.LogEntry) ([]byte, error)
+}
+
+var (
+	codecsMu sync.RWMutex
+	codecs   = map[string]Codec{
+		CodecJSON:    jsonCodec{},
+		CodecMsgpack: msgpackCodec{},
+		CodecLogfmt:  logfmtCodec{},
+	}
+)
+
+// RegisterCodec 注册消息编码，之后适配器可以通过codec配置项按名称使用，如Avro、Protobuf等与已有的schema registry对接
+// 同名的编码会被覆盖，包括内置的json和msgpack
+func RegisterCodec(name string, codec Codec) {
+	codecsMu.Lock()
+	defer codecsMu.Unlock()
+	codecs[name] = codec
+}
+
+// LookupCodec 按名称查找已注册的编码，名称为空时使用JSON，自定义适配器可以用它支持codec配置项
+func LookupCodec(name string) (Codec, error) {
+	if name == "" {
+		name = CodecJSON
+	}
+	codecsMu.RLock()
+	defer codecsMu.RUnlock()
+	codec, ok := codecs[name]
+	if !ok {
+		return nil, fmt.Errorf("unknown codec: %s", name)
+	}
+	return codec, nil
+}
+
+// codecConfigSchema 支持按条编码消息的适配器的配置项
+var codecConfigSchema = logger.ConfigSchema{
+	"codec": logger.ConfigString,
+}
+
+// jsonCodec 将日志编码为JSON对象
+type jsonCodec struct{}
+
+// EncodeEntry 实现Codec接口
+func (jsonCodec) EncodeEntry(entry logger.LogEntry) ([]byte, error) {
+	return json.Marshal(entry)
+}
+
+// msgpackCodec 将日志编码为MessagePack，先经过JSON转换，使字段名和取值与JSON编码一致
+type msgpackCodec struct{}
+
+// EncodeEntry 实现Codec接口
+func (msgpackCodec) EncodeEntry(entry logger.LogEntry) ([]byte, error) {
+	data, err := json.Marshal(entry)
+	if err != nil {
+		return nil, err
+	}
+	var generic map[string]interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return nil, err
+	}
+	w := &msgpackWriter{}
+	w.writeValue(generic)
+	return w.bytes(), nil
+}
+
+// logfmtCodec 将日志编码为一行logfmt，字段顺序见LogEntry.AppendLogfmt
+type logfmtCodec struct{}
+
+// EncodeEntry 实现Codec接口
+func (logfmtCodec) EncodeEntry(entry logger.LogEntry) ([]byte, error) {
+	return entry.AppendLogfmt(nil), nil
+}
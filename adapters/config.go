@@ -0,0 +1,18 @@
+package adapters
+
+// configStrings 解析字符串列表配置，接受[]string或[]interface{}，忽略非字符串元素
+func configStrings(value interface{}) ([]string, bool) {
+	switch list := value.(type) {
+	case []string:
+		return append([]string(nil), list...), true
+	case []interface{}:
+		result := make([]string, 0, len(list))
+		for _, item := range list {
+			if s, ok := item.(string); ok {
+				result = append(result, s)
+			}
+		}
+		return result, true
+	}
+	return nil, false
+}
@@ -2,9 +2,7 @@ package adapters
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/qishenonly/logger"
@@ -14,9 +12,20 @@ func init() {
 	// 注册适配器
 	logger.RegisterAdapter("elasticsearch", func() logger.LogAdapter {
 		return &ElasticsearchAdapter{}
-	})
+	}, elasticsearchConfigSchema)
 }
 
+// elasticsearchConfigSchema Elasticsearch适配器支持的配置项
+var elasticsearchConfigSchema = logger.ConfigSchema{
+	"hosts":          logger.ConfigStringList,
+	"index":          logger.ConfigString,
+	"level_indices":  logger.ConfigMap,
+	"username":       logger.ConfigString,
+	"password":       logger.ConfigString,
+	"bulk_size":      logger.ConfigNumber,
+	"flush_interval": logger.ConfigNumber,
+}.Merge(batchConfigSchema)
+
 // ElasticsearchAdapter 用于将日志输出到Elasticsearch
 type ElasticsearchAdapter struct {
 	Hosts         []string
@@ -25,9 +34,9 @@ type ElasticsearchAdapter struct {
 	Password      string
 	BulkSize      int
 	FlushInterval time.Duration
-	buffer        []logger.LogEntry
-	bufferMu      sync.Mutex
-	client        interface{} // 这里用interface{}占位，实际应该是ES客户端
+	batcher
+	router *levelRouter
+	client interface{} // 这里用interface{}占位，实际应该是ES客户端
 }
 
 // Name 返回适配器名称
@@ -35,16 +44,16 @@ func (a *ElasticsearchAdapter) Name() string {
 	return "elasticsearch"
 }
 
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *ElasticsearchAdapter) ValidateConfig(config map[string]interface{}) error {
+	return elasticsearchConfigSchema.Validate(config)
+}
+
 // Init 初始化适配器
 func (a *ElasticsearchAdapter) Init(config map[string]interface{}) error {
 	// 解析配置参数
-	if hosts, ok := config["hosts"].([]interface{}); ok {
-		a.Hosts = make([]string, 0, len(hosts))
-		for _, host := range hosts {
-			if h, ok := host.(string); ok {
-				a.Hosts = append(a.Hosts, h)
-			}
-		}
+	if hosts, ok := configStrings(config["hosts"]); ok {
+		a.Hosts = hosts
 	} else {
 		a.Hosts = []string{"http://localhost:9200"}
 	}
@@ -55,6 +64,13 @@ func (a *ElasticsearchAdapter) Init(config map[string]interface{}) error {
 		a.Index = "logs-" + time.Now().Format("2006.01.02")
 	}
 
+	// 按级别选择索引，未映射的级别使用index，index可以是"logs-{{.Level}}"这样的模板
+	router, err := newLevelRouter(a.Index, config["level_indices"])
+	if err != nil {
+		return err
+	}
+	a.router = router
+
 	if username, ok := config["username"].(string); ok {
 		a.Username = username
 	}
@@ -63,21 +79,18 @@ func (a *ElasticsearchAdapter) Init(config map[string]interface{}) error {
 		a.Password = password
 	}
 
-	if bulkSize, ok := config["bulk_size"].(float64); ok {
+	if bulkSize, ok := logger.ToFloat64(config["bulk_size"]); ok {
 		a.BulkSize = int(bulkSize)
 	} else {
 		a.BulkSize = 200
 	}
 
-	if flushInterval, ok := config["flush_interval"].(float64); ok {
+	if flushInterval, ok := logger.ToFloat64(config["flush_interval"]); ok {
 		a.FlushInterval = time.Duration(flushInterval) * time.Second
 	} else {
 		a.FlushInterval = 10 * time.Second
 	}
 
-	// 初始化日志缓冲区
-	a.buffer = make([]logger.LogEntry, 0, a.BulkSize)
-
 	// 连接到Elasticsearch
 	// 实际应该这样:
 	// cfg := elasticsearch.Config{
@@ -91,58 +104,52 @@ func (a *ElasticsearchAdapter) Init(config map[string]interface{}) error {
 	// }
 	// a.client = client
 
-	// 定期刷新缓冲区
-	go a.flushPeriodically()
+	// 初始化缓冲区并启动定时刷新
+	a.initBatcher(config, a.BulkSize, a.FlushInterval, a.send)
 
 	return nil
 }
 
 // Process 处理日志条目
 func (a *ElasticsearchAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
-	a.bufferMu.Lock()
-	defer a.bufferMu.Unlock()
-
-	// 添加到缓冲区
-	a.buffer = append(a.buffer, entry)
-
-	// 如果达到批量大小，刷新缓冲区
-	if len(a.buffer) >= a.BulkSize {
-		return a.flushBuffer()
-	}
-
-	return nil
+	return a.add(ctx, entry)
 }
 
 // Flush 刷新缓冲区
 func (a *ElasticsearchAdapter) Flush() error {
-	a.bufferMu.Lock()
-	defer a.bufferMu.Unlock()
+	return a.flush()
+}
 
-	return a.flushBuffer()
+// esDoc Bulk请求中的一个文档及其索引
+type esDoc struct {
+	index string
+	data  []byte
 }
 
-// flushBuffer 刷新缓冲区（无锁版本，调用前需要获取锁）
-func (a *ElasticsearchAdapter) flushBuffer() error {
-	if len(a.buffer) == 0 {
-		return nil
+// send 批量发送日志，按级别分组写入各自的索引
+func (a *ElasticsearchAdapter) send(ctx context.Context, entries []logger.LogEntry) error {
+	// Bulk请求体的元数据行和文档行写入复用的缓冲区，避免每次刷新为每条日志分配新的缓冲区
+	enc := newBatchEncoder()
+	defer enc.release()
+
+	indices, groups := a.router.group(entries)
+	docs := make([]esDoc, 0, len(entries))
+	for _, index := range indices {
+		meta := fmt.Sprintf(`{ "index" : { "_index" : "%s" } }%s`, index, "\n")
+		for _, entry := range groups[index] {
+			enc.WriteString(meta)
+			data, err := enc.Encode(entry)
+			if err != nil {
+				// 无法序列化的日志跳过，不影响同一批次的其他日志
+				continue
+			}
+			docs = append(docs, esDoc{index: index, data: data})
+		}
 	}
 
 	// 在实际应用中，这里应该批量发送到Elasticsearch
-	// var buf bytes.Buffer
-	// for _, entry := range a.buffer {
-	//     // 为每个文档添加索引元数据
-	//     meta := []byte(fmt.Sprintf(`{ "index" : { "_index" : "%s" } }%s`, a.Index, "\n"))
-	//     data, err := json.Marshal(entry)
-	//     if err != nil {
-	//         continue
-	//     }
-	//     data = append(data, '\n')
-	//
-	//     buf.Write(meta)
-	//     buf.Write(data)
-	// }
-	//
-	// res, err := a.client.(*elasticsearch.Client).Bulk(bytes.NewReader(buf.Bytes()))
+	// client := a.client.(*elasticsearch.Client)
+	// res, err := client.Bulk(bytes.NewReader(enc.Bytes()), client.Bulk.WithContext(ctx))
 	// if err != nil {
 	//     return err
 	// }
@@ -153,29 +160,18 @@ func (a *ElasticsearchAdapter) flushBuffer() error {
 	// }
 
 	// 这里仅作演示，实际打印日志
-	for _, entry := range a.buffer {
-		data, _ := json.Marshal(entry)
-		fmt.Printf("[Elasticsearch Adapter] Would index to %s: %s\n", a.Index, string(data))
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, doc := range docs {
+		fmt.Printf("[Elasticsearch Adapter] Would index to %s: %s\n", doc.index, doc.data)
 	}
-
-	// 清空缓冲区
-	a.buffer = a.buffer[:0]
 
 	return nil
 }
 
-// flushPeriodically 定期刷新缓冲区
-func (a *ElasticsearchAdapter) flushPeriodically() {
-	ticker := time.NewTicker(a.FlushInterval)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		_ = a.Flush()
-	}
-}
-
 // Close 关闭适配器
 func (a *ElasticsearchAdapter) Close() error {
-	// 刷新剩余日志
-	return a.Flush()
+	// 刷新剩余日志并停止定时刷新
+	return a.closeBatcher()
 }
@@ -0,0 +1,145 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"sort"
+	"strings"
+	"sync"
+
+	"github.com/qishenonly/logger"
+)
+
+func init() {
+	// 注册适配器
+	logger.RegisterAdapter("eventlog", func() logger.LogAdapter {
+		return &EventLogAdapter{}
+	}, eventLogConfigSchema)
+}
+
+// eventLogConfigSchema Windows事件日志适配器支持的配置项
+var eventLogConfigSchema = logger.ConfigSchema{
+	"source":   logger.ConfigString,
+	"event_id": logger.ConfigNumber,
+}
+
+// eventLogWriter 写入事件日志的接口，与eventlog.Log的方法一致
+type eventLogWriter interface {
+	Info(eid uint32, msg string) error
+	Warning(eid uint32, msg string) error
+	Error(eid uint32, msg string) error
+	Close() error
+}
+
+// EventLogAdapter 用于将日志写入Windows事件日志，非Windows平台Init返回错误
+type EventLogAdapter struct {
+	Source  string // 事件源名称
+	EventID uint32 // 写入事件使用的事件ID，默认1
+	writer  eventLogWriter
+	mu      sync.Mutex
+}
+
+// Name 返回适配器名称
+func (a *EventLogAdapter) Name() string {
+	return "eventlog"
+}
+
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *EventLogAdapter) ValidateConfig(config map[string]interface{}) error {
+	return eventLogConfigSchema.Validate(config)
+}
+
+// Init 初始化适配器
+func (a *EventLogAdapter) Init(config map[string]interface{}) error {
+	// 解析配置参数
+	source, ok := config["source"].(string)
+	if !ok || source == "" {
+		return fmt.Errorf("eventlog source is required")
+	}
+	a.Source = source
+
+	a.EventID = 1
+	if eventID, ok := logger.ToFloat64(config["event_id"]); ok && eventID > 0 {
+		a.EventID = uint32(eventID)
+	}
+
+	// 注册事件源并打开事件日志
+	writer, err := openEventLog(a.Source)
+	if err != nil {
+		return fmt.Errorf("open eventlog source %s failed: %v", a.Source, err)
+	}
+	a.writer = writer
+
+	return nil
+}
+
+// Process 处理日志条目，error及以上级别写为错误事件，warn写为警告事件，其余写为信息事件
+func (a *EventLogAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.writer == nil {
+		return fmt.Errorf("eventlog adapter not initialized")
+	}
+
+	msg := eventLogMessage(entry)
+	switch entry.Level {
+	case "error", "dpanic", "panic", "fatal":
+		return a.writer.Error(a.EventID, msg)
+	case "warn":
+		return a.writer.Warning(a.EventID, msg)
+	default:
+		return a.writer.Info(a.EventID, msg)
+	}
+}
+
+// Flush 事件日志逐条写入，无需刷新
+func (a *EventLogAdapter) Flush() error {
+	return nil
+}
+
+// Close 关闭适配器
+func (a *EventLogAdapter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+
+	if a.writer == nil {
+		return nil
+	}
+	err := a.writer.Close()
+	a.writer = nil
+	return err
+}
+
+// eventLogMessage 将日志条目格式化为事件消息：消息在第一行，其余字段按key=value逐行排列
+func eventLogMessage(entry logger.LogEntry) string {
+	var b strings.Builder
+	b.WriteString(entry.Message)
+
+	fields := make(map[string]interface{}, len(entry.Properties)+4)
+	for k, v := range entry.Properties {
+		fields[k] = v
+	}
+	if entry.Caller != "" {
+		fields["caller"] = entry.Caller
+	}
+	if entry.NodeID != "" {
+		fields["nodeId"] = entry.NodeID
+	}
+	if entry.Module != "" {
+		fields["module"] = entry.Module
+	}
+	if entry.IP != "" {
+		fields["ip"] = entry.IP
+	}
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		fmt.Fprintf(&b, "\n%s=%v", k, fields[k])
+	}
+	return b.String()
+}
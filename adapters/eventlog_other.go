@@ -0,0 +1,10 @@
+//go:build !windows
+
+package adapters
+
+import "fmt"
+
+// openEventLog 非Windows平台不支持事件日志
+func openEventLog(source string) (eventLogWriter, error) {
+	return nil, fmt.Errorf("eventlog is only supported on windows")
+}
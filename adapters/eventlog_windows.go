@@ -0,0 +1,12 @@
+//go:build windows
+
+package adapters
+
+import "golang.org/x/sys/windows/svc/eventlog"
+
+// openEventLog 注册事件源并打开事件日志
+// 注册需要管理员权限，事件源已存在或注册失败时直接尝试打开
+func openEventLog(source string) (eventLogWriter, error) {
+	_ = eventlog.InstallAsEventCreate(source, eventlog.Error|eventlog.Warning|eventlog.Info)
+	return eventlog.Open(source)
+}
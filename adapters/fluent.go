@@ -0,0 +1,319 @@
+package adapters
+
+import (
+	"bufio"
+	"context"
+	"crypto/rand"
+	"crypto/sha512"
+	"crypto/tls"
+	"encoding/hex"
+	"fmt"
+	"net"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qishenonly/logger"
+)
+
+func init() {
+	// 注册适配器
+	logger.RegisterAdapter("fluent", func() logger.LogAdapter {
+		return &FluentAdapter{}
+	}, fluentConfigSchema)
+}
+
+// fluentConfigSchema Fluent适配器支持的配置项
+var fluentConfigSchema = logger.ConfigSchema{
+	"address":                  logger.ConfigString,
+	"tag":                      logger.ConfigString,
+	"batch_size":               logger.ConfigNumber,
+	"flush_interval":           logger.ConfigNumber,
+	"tls":                      logger.ConfigBool,
+	"tls_insecure_skip_verify": logger.ConfigBool,
+	"shared_key":               logger.ConfigString,
+	"self_hostname":            logger.ConfigString,
+	"username":                 logger.ConfigString,
+	"password":                 logger.ConfigString,
+}.Merge(batchConfigSchema)
+
+// FluentAdapter 通过Forward协议将日志输出到Fluentd/Fluent Bit
+type FluentAdapter struct {
+	Address            string
+	Tag                string
+	BatchSize          int
+	FlushInterval      time.Duration
+	TLS                bool
+	InsecureSkipVerify bool
+	SharedKey          string
+	SelfHostname       string
+	Username           string
+	Password           string
+	batcher
+	conn   net.Conn
+	connMu sync.Mutex
+}
+
+// Name 返回适配器名称
+func (a *FluentAdapter) Name() string {
+	return "fluent"
+}
+
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *FluentAdapter) ValidateConfig(config map[string]interface{}) error {
+	return fluentConfigSchema.Validate(config)
+}
+
+// Init 初始化适配器
+func (a *FluentAdapter) Init(config map[string]interface{}) error {
+	// 解析配置参数
+	if address, ok := config["address"].(string); ok {
+		a.Address = address
+	} else {
+		a.Address = "127.0.0.1:24224"
+	}
+
+	if tag, ok := config["tag"].(string); ok {
+		a.Tag = tag
+	} else {
+		a.Tag = "app"
+	}
+
+	if batchSize, ok := logger.ToFloat64(config["batch_size"]); ok {
+		a.BatchSize = int(batchSize)
+	} else {
+		a.BatchSize = 100
+	}
+
+	if flushInterval, ok := logger.ToFloat64(config["flush_interval"]); ok && flushInterval > 0 {
+		a.FlushInterval = time.Duration(flushInterval * float64(time.Second))
+	} else {
+		a.FlushInterval = 5 * time.Second
+	}
+
+	if useTLS, ok := config["tls"].(bool); ok {
+		a.TLS = useTLS
+	}
+
+	if insecure, ok := config["tls_insecure_skip_verify"].(bool); ok {
+		a.InsecureSkipVerify = insecure
+	}
+
+	if sharedKey, ok := config["shared_key"].(string); ok {
+		a.SharedKey = sharedKey
+	}
+
+	if hostname, ok := config["self_hostname"].(string); ok {
+		a.SelfHostname = hostname
+	} else {
+		a.SelfHostname, _ = os.Hostname()
+	}
+
+	if username, ok := config["username"].(string); ok {
+		a.Username = username
+	}
+
+	if password, ok := config["password"].(string); ok {
+		a.Password = password
+	}
+
+	// 初始化缓冲区并启动定时刷新
+	a.initBatcher(config, a.BatchSize, a.FlushInterval, a.send)
+
+	return nil
+}
+
+// Process 处理日志条目
+func (a *FluentAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	return a.add(ctx, entry)
+}
+
+// Flush 刷新缓冲区
+func (a *FluentAdapter) Flush() error {
+	return a.flush()
+}
+
+// send 以Forward模式批量发送日志: [tag, [[time, record], ...]]
+func (a *FluentAdapter) send(ctx context.Context, entries []logger.LogEntry) error {
+	w := &msgpackWriter{}
+	w.writeArrayHeader(2)
+	w.writeString(a.Tag)
+	w.writeArrayHeader(len(entries))
+	for _, entry := range entries {
+		w.writeArrayHeader(2)
+		w.writeEventTime(entry.Time)
+		w.writeValue(fluentRecord(entry))
+	}
+
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+
+	conn, err := a.connect(ctx)
+	if err != nil {
+		return err
+	}
+
+	if deadline, ok := ctx.Deadline(); ok {
+		_ = conn.SetWriteDeadline(deadline)
+	} else {
+		_ = conn.SetWriteDeadline(time.Time{})
+	}
+
+	if _, err := conn.Write(w.bytes()); err != nil {
+		// 连接失效，下次发送时重新连接
+		_ = conn.Close()
+		a.conn = nil
+		return fmt.Errorf("write to fluent failed: %v", err)
+	}
+
+	return nil
+}
+
+// connect 返回可用连接，需要时建立连接并完成共享密钥握手（调用前需要获取connMu）
+func (a *FluentAdapter) connect(ctx context.Context) (net.Conn, error) {
+	if a.conn != nil {
+		return a.conn, nil
+	}
+
+	dialer := &net.Dialer{Timeout: 10 * time.Second}
+	var (
+		conn net.Conn
+		err  error
+	)
+	if a.TLS {
+		tlsDialer := &tls.Dialer{
+			NetDialer: dialer,
+			Config:    &tls.Config{InsecureSkipVerify: a.InsecureSkipVerify},
+		}
+		conn, err = tlsDialer.DialContext(ctx, "tcp", a.Address)
+	} else {
+		conn, err = dialer.DialContext(ctx, "tcp", a.Address)
+	}
+	if err != nil {
+		return nil, fmt.Errorf("connect to fluent failed: %v", err)
+	}
+
+	if a.SharedKey != "" {
+		if err := a.handshake(conn); err != nil {
+			_ = conn.Close()
+			return nil, err
+		}
+	}
+
+	a.conn = conn
+	return conn, nil
+}
+
+// handshake 完成Forward协议的共享密钥握手: HELO -> PING -> PONG
+func (a *FluentAdapter) handshake(conn net.Conn) error {
+	_ = conn.SetDeadline(time.Now().Add(10 * time.Second))
+	defer conn.SetDeadline(time.Time{})
+
+	reader := &msgpackReader{r: bufio.NewReader(conn)}
+
+	// 读取HELO: ["HELO", {"nonce": ..., "auth": ..., "keepalive": ...}]
+	helo, err := reader.readValue()
+	if err != nil {
+		return fmt.Errorf("read fluent HELO failed: %v", err)
+	}
+	heloArr, ok := helo.([]interface{})
+	if !ok || len(heloArr) < 2 || heloArr[0] != "HELO" {
+		return fmt.Errorf("unexpected fluent HELO message: %v", helo)
+	}
+	options, _ := heloArr[1].(map[string]interface{})
+	nonce, _ := options["nonce"].(string)
+	authSalt, _ := options["auth"].(string)
+
+	// 发送PING
+	saltBytes := make([]byte, 16)
+	if _, err := rand.Read(saltBytes); err != nil {
+		return fmt.Errorf("generate fluent shared key salt failed: %v", err)
+	}
+	salt := hex.EncodeToString(saltBytes)
+
+	var username, passwordDigest string
+	if authSalt != "" {
+		username = a.Username
+		passwordDigest = sha512Hex(authSalt, a.Username, a.Password)
+	}
+
+	w := &msgpackWriter{}
+	w.writeArrayHeader(6)
+	w.writeString("PING")
+	w.writeString(a.SelfHostname)
+	w.writeString(salt)
+	w.writeString(sha512Hex(salt, a.SelfHostname, nonce, a.SharedKey))
+	w.writeString(username)
+	w.writeString(passwordDigest)
+	if _, err := conn.Write(w.bytes()); err != nil {
+		return fmt.Errorf("write fluent PING failed: %v", err)
+	}
+
+	// 读取PONG: ["PONG", auth_result, reason, server_hostname, digest]
+	pong, err := reader.readValue()
+	if err != nil {
+		return fmt.Errorf("read fluent PONG failed: %v", err)
+	}
+	pongArr, ok := pong.([]interface{})
+	if !ok || len(pongArr) < 5 || pongArr[0] != "PONG" {
+		return fmt.Errorf("unexpected fluent PONG message: %v", pong)
+	}
+	if authorized, _ := pongArr[1].(bool); !authorized {
+		return fmt.Errorf("fluent authentication failed: %v", pongArr[2])
+	}
+	serverHostname, _ := pongArr[3].(string)
+	if digest, _ := pongArr[4].(string); digest != sha512Hex(salt, serverHostname, nonce, a.SharedKey) {
+		return fmt.Errorf("fluent server shared key mismatch")
+	}
+
+	return nil
+}
+
+// Close 关闭适配器
+func (a *FluentAdapter) Close() error {
+	// 刷新剩余日志并停止定时刷新
+	err := a.closeBatcher()
+
+	a.connMu.Lock()
+	defer a.connMu.Unlock()
+	if a.conn != nil {
+		if cerr := a.conn.Close(); cerr != nil && err == nil {
+			err = cerr
+		}
+		a.conn = nil
+	}
+
+	return err
+}
+
+// fluentRecord 将日志条目转换为Fluent记录，Properties合并到顶层
+func fluentRecord(entry logger.LogEntry) map[string]interface{} {
+	record := make(map[string]interface{}, len(entry.Properties)+6)
+	for k, v := range entry.Properties {
+		record[k] = v
+	}
+	record["level"] = entry.Level
+	record["message"] = entry.Message
+	if entry.Caller != "" {
+		record["caller"] = entry.Caller
+	}
+	if entry.NodeID != "" {
+		record["node_id"] = entry.NodeID
+	}
+	if entry.Module != "" {
+		record["module"] = entry.Module
+	}
+	if entry.IP != "" {
+		record["ip"] = entry.IP
+	}
+	return record
+}
+
+// sha512Hex 计算各部分拼接后的SHA-512十六进制摘要
+func sha512Hex(parts ...string) string {
+	h := sha512.New()
+	for _, p := range parts {
+		h.Write([]byte(p))
+	}
+	return hex.EncodeToString(h.Sum(nil))
+}
@@ -0,0 +1,209 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/qishenonly/logger"
+)
+
+func init() {
+	// 注册适配器
+	logger.RegisterAdapter("gcp", func() logger.LogAdapter {
+		return &GCPAdapter{}
+	}, gcpConfigSchema)
+}
+
+// gcpConfigSchema GCP适配器支持的配置项
+var gcpConfigSchema = logger.ConfigSchema{
+	"project_id":       logger.ConfigString,
+	"log_name":         logger.ConfigString,
+	"credentials_file": logger.ConfigString,
+	"batch_size":       logger.ConfigNumber,
+	"flush_interval":   logger.ConfigNumber,
+}.Merge(batchConfigSchema)
+
+// GCPAdapter 用于将日志通过Cloud Logging API输出到GCP
+// 日志先进入缓冲区，达到BatchSize或每隔FlushInterval批量写出，长时间运行时缓冲区不会无限增长
+type GCPAdapter struct {
+	ProjectID       string
+	LogName         string
+	CredentialsFile string // 为空时使用ADC(Application Default Credentials)
+	BatchSize       int
+	FlushInterval   time.Duration
+	batcher
+	client interface{} // 这里用interface{}占位，实际应该是*logging.Client
+	logger interface{} // 这里用interface{}占位，实际应该是*logging.Logger
+}
+
+// gcpEntry 对应Cloud Logging中的一条日志
+type gcpEntry struct {
+	Timestamp time.Time              `json:"timestamp"`
+	Severity  string                 `json:"severity"`
+	Labels    map[string]string      `json:"labels,omitempty"`
+	Payload   map[string]interface{} `json:"jsonPayload"`
+}
+
+// Name 返回适配器名称
+func (a *GCPAdapter) Name() string {
+	return "gcp"
+}
+
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *GCPAdapter) ValidateConfig(config map[string]interface{}) error {
+	return gcpConfigSchema.Validate(config)
+}
+
+// Init 初始化适配器
+func (a *GCPAdapter) Init(config map[string]interface{}) error {
+	// 解析配置参数
+	projectID, ok := config["project_id"].(string)
+	if !ok || projectID == "" {
+		return fmt.Errorf("gcp project_id is required")
+	}
+	a.ProjectID = projectID
+
+	if logName, ok := config["log_name"].(string); ok {
+		a.LogName = logName
+	} else {
+		a.LogName = "app"
+	}
+
+	if credentials, ok := config["credentials_file"].(string); ok {
+		a.CredentialsFile = credentials
+	}
+
+	if batchSize, ok := logger.ToFloat64(config["batch_size"]); ok && batchSize > 0 {
+		a.BatchSize = int(batchSize)
+	} else {
+		a.BatchSize = 1000
+	}
+
+	if flushInterval, ok := logger.ToFloat64(config["flush_interval"]); ok && flushInterval > 0 {
+		a.FlushInterval = time.Duration(flushInterval * float64(time.Second))
+	} else {
+		a.FlushInterval = time.Second
+	}
+
+	// 连接到Cloud Logging
+	// 实际应该这样:
+	// var opts []option.ClientOption
+	// if a.CredentialsFile != "" {
+	//     opts = append(opts, option.WithCredentialsFile(a.CredentialsFile))
+	// }
+	// client, err := logging.NewClient(context.Background(), a.ProjectID, opts...)
+	// if err != nil {
+	//     return fmt.Errorf("failed to create gcp logging client: %v", err)
+	// }
+	// a.client = client
+	// a.logger = client.Logger(a.LogName)
+
+	// 初始化缓冲区并启动定时刷新
+	a.initBatcher(config, a.BatchSize, a.FlushInterval, a.send)
+
+	return nil
+}
+
+// Process 处理日志条目
+func (a *GCPAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	return a.add(ctx, entry)
+}
+
+// Flush 刷新缓冲区
+func (a *GCPAdapter) Flush() error {
+	return a.flush()
+}
+
+// send 批量写入日志
+func (a *GCPAdapter) send(ctx context.Context, entries []logger.LogEntry) error {
+	// 在实际应用中，这里交给客户端的Logger批量写入并等待发送完成
+	// l := a.logger.(*logging.Logger)
+	// for _, entry := range entries {
+	//     e := toGCPEntry(entry)
+	//     l.Log(logging.Entry{
+	//         Timestamp: e.Timestamp,
+	//         Severity:  logging.ParseSeverity(e.Severity),
+	//         Labels:    e.Labels,
+	//         Payload:   e.Payload,
+	//     })
+	// }
+	// return l.Flush()
+
+	// 这里仅作演示，实际打印日志
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, entry := range entries {
+		data, _ := json.Marshal(toGCPEntry(entry))
+		fmt.Printf("[GCP Adapter] Would write to projects/%s/logs/%s: %s\n", a.ProjectID, a.LogName, string(data))
+	}
+
+	return nil
+}
+
+// Close 关闭适配器
+func (a *GCPAdapter) Close() error {
+	// 刷新剩余日志并停止定时刷新
+	err := a.closeBatcher()
+
+	// 关闭客户端
+	// if a.client != nil {
+	//     if cerr := a.client.(*logging.Client).Close(); cerr != nil && err == nil {
+	//         err = cerr
+	//     }
+	// }
+
+	return err
+}
+
+// toGCPEntry 将日志条目转换为Cloud Logging格式
+func toGCPEntry(entry logger.LogEntry) gcpEntry {
+	labels := make(map[string]string)
+	if entry.NodeID != "" {
+		labels["node_id"] = entry.NodeID
+	}
+	if entry.Module != "" {
+		labels["module"] = entry.Module
+	}
+	if entry.IP != "" {
+		labels["ip"] = entry.IP
+	}
+
+	payload := make(map[string]interface{}, len(entry.Properties)+2)
+	for k, v := range entry.Properties {
+		payload[k] = v
+	}
+	payload["message"] = entry.Message
+	if entry.Caller != "" {
+		payload["caller"] = entry.Caller
+	}
+
+	return gcpEntry{
+		Timestamp: entry.Time,
+		Severity:  gcpSeverity(entry.Level),
+		Labels:    labels,
+		Payload:   payload,
+	}
+}
+
+// gcpSeverity 将日志级别映射为Cloud Logging的severity
+func gcpSeverity(level string) string {
+	switch level {
+	case "debug":
+		return "DEBUG"
+	case "info":
+		return "INFO"
+	case "warn":
+		return "WARNING"
+	case "error":
+		return "ERROR"
+	case "panic":
+		return "CRITICAL"
+	case "fatal":
+		return "ALERT"
+	default:
+		return "DEFAULT"
+	}
+}
@@ -0,0 +1,84 @@
+package adapters
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"fmt"
+	"io"
+	"net/http"
+	"time"
+
+	"github.com/qishenonly/logger"
+)
+
+// httpSender HTTP类适配器共享的请求发送逻辑
+type httpSender struct {
+	client   *http.Client
+	compress bool
+}
+
+// httpConfigSchema HTTP适配器公共的配置项，compress可以是布尔值或字符串
+var httpConfigSchema = logger.ConfigSchema{
+	"compress": logger.ConfigAny,
+	"timeout":  logger.ConfigNumber,
+}
+
+// initHTTPSender 初始化HTTP客户端
+// config中的compress为true或"gzip"时请求体使用gzip压缩，timeout(秒)为请求超时时间，默认30秒
+func (s *httpSender) initHTTPSender(config map[string]interface{}) {
+	switch compress := config["compress"].(type) {
+	case bool:
+		s.compress = compress
+	case string:
+		s.compress = compress == "gzip"
+	}
+
+	timeout := 30 * time.Second
+	if t, ok := logger.ToFloat64(config["timeout"]); ok && t > 0 {
+		timeout = time.Duration(t * float64(time.Second))
+	}
+	s.client = &http.Client{Timeout: timeout}
+}
+
+// newRequest 创建请求，开启压缩时请求体为gzip压缩后的数据并设置Content-Encoding
+func (s *httpSender) newRequest(ctx context.Context, method string, url string, body []byte) (*http.Request, error) {
+	if s.compress {
+		var buf bytes.Buffer
+		zw := gzip.NewWriter(&buf)
+		if _, err := zw.Write(body); err != nil {
+			return nil, fmt.Errorf("gzip request body failed: %v", err)
+		}
+		if err := zw.Close(); err != nil {
+			return nil, fmt.Errorf("gzip request body failed: %v", err)
+		}
+		body = buf.Bytes()
+	}
+
+	req, err := http.NewRequestWithContext(ctx, method, url, bytes.NewReader(body))
+	if err != nil {
+		return nil, err
+	}
+	if s.compress {
+		req.Header.Set("Content-Encoding", "gzip")
+	}
+	return req, nil
+}
+
+// do 发送请求，非2xx响应返回错误
+func (s *httpSender) do(req *http.Request) error {
+	resp, err := s.client.Do(req)
+	if err != nil {
+		return err
+	}
+	defer resp.Body.Close()
+
+	if resp.StatusCode < 200 || resp.StatusCode >= 300 {
+		msg, _ := io.ReadAll(io.LimitReader(resp.Body, 1024))
+		return fmt.Errorf("unexpected status %s: %s", resp.Status, string(msg))
+	}
+
+	// 读完响应体以便复用连接
+	_, _ = io.Copy(io.Discard, resp.Body)
+	return nil
+}
@@ -2,9 +2,7 @@ package adapters
 
 import (
 	"context"
-	"encoding/json"
 	"fmt"
-	"sync"
 	"time"
 
 	"github.com/qishenonly/logger"
@@ -14,18 +12,27 @@ func init() {
 	// 注册适配器
 	logger.RegisterAdapter("kafka", func() logger.LogAdapter {
 		return &KafkaAdapter{}
-	})
+	}, kafkaConfigSchema)
 }
 
+// kafkaConfigSchema Kafka适配器支持的配置项
+var kafkaConfigSchema = logger.ConfigSchema{
+	"brokers":       logger.ConfigStringList,
+	"topic":         logger.ConfigString,
+	"level_topics":  logger.ConfigMap,
+	"batch_size":    logger.ConfigNumber,
+	"flush_timeout": logger.ConfigNumber,
+}.Merge(batchConfigSchema, codecConfigSchema)
+
 // KafkaAdapter 用于将日志输出到Kafka
 type KafkaAdapter struct {
 	Brokers      []string
 	Topic        string
 	BatchSize    int
 	FlushTimeout time.Duration
-	buffer       []logger.LogEntry
-	bufferMu     sync.Mutex
-	producer     interface{} // 这里用interface{}占位，实际应该是Kafka生产者
+	batcher
+	router   *levelRouter
+	producer interface{} // 这里用interface{}占位，实际应该是Kafka生产者
 }
 
 // Name 返回适配器名称
@@ -33,16 +40,16 @@ func (a *KafkaAdapter) Name() string {
 	return "kafka"
 }
 
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *KafkaAdapter) ValidateConfig(config map[string]interface{}) error {
+	return kafkaConfigSchema.Validate(config)
+}
+
 // Init 初始化适配器
 func (a *KafkaAdapter) Init(config map[string]interface{}) error {
 	// 解析配置参数
-	if brokers, ok := config["brokers"].([]interface{}); ok {
-		a.Brokers = make([]string, 0, len(brokers))
-		for _, broker := range brokers {
-			if b, ok := broker.(string); ok {
-				a.Brokers = append(a.Brokers, b)
-			}
-		}
+	if brokers, ok := configStrings(config["brokers"]); ok {
+		a.Brokers = brokers
 	} else {
 		a.Brokers = []string{"localhost:9092"}
 	}
@@ -53,21 +60,25 @@ func (a *KafkaAdapter) Init(config map[string]interface{}) error {
 		a.Topic = "logs"
 	}
 
-	if batchSize, ok := config["batch_size"].(float64); ok {
+	// 按级别选择主题，未映射的级别使用topic，topic可以是"logs.{{.Level}}"这样的模板
+	router, err := newLevelRouter(a.Topic, config["level_topics"])
+	if err != nil {
+		return err
+	}
+	a.router = router
+
+	if batchSize, ok := logger.ToFloat64(config["batch_size"]); ok {
 		a.BatchSize = int(batchSize)
 	} else {
 		a.BatchSize = 100
 	}
 
-	if flushTimeout, ok := config["flush_timeout"].(float64); ok {
+	if flushTimeout, ok := logger.ToFloat64(config["flush_timeout"]); ok {
 		a.FlushTimeout = time.Duration(flushTimeout) * time.Second
 	} else {
 		a.FlushTimeout = 5 * time.Second
 	}
 
-	// 初始化日志缓冲区
-	a.buffer = make([]logger.LogEntry, 0, a.BatchSize)
-
 	// 连接到Kafka
 	// 实际应该这样:
 	// config := sarama.NewConfig()
@@ -78,85 +89,73 @@ func (a *KafkaAdapter) Init(config map[string]interface{}) error {
 	// }
 	// a.producer = producer
 
-	// 定期刷新缓冲区
-	go a.flushPeriodically()
+	// 消息编码，默认JSON，可以通过codec配置为msgpack或RegisterCodec注册的编码
+	if err := a.initCodec(config); err != nil {
+		return err
+	}
+
+	// 初始化缓冲区并启动定时刷新
+	a.initBatcher(config, a.BatchSize, a.FlushTimeout, a.send)
 
 	return nil
 }
 
 // Process 处理日志条目
 func (a *KafkaAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
-	a.bufferMu.Lock()
-	defer a.bufferMu.Unlock()
-
-	// 添加到缓冲区
-	a.buffer = append(a.buffer, entry)
-
-	// 如果达到批量大小，刷新缓冲区
-	if len(a.buffer) >= a.BatchSize {
-		return a.flushBuffer()
-	}
-
-	return nil
+	return a.add(ctx, entry)
 }
 
 // Flush 刷新缓冲区
 func (a *KafkaAdapter) Flush() error {
-	a.bufferMu.Lock()
-	defer a.bufferMu.Unlock()
-
-	return a.flushBuffer()
+	return a.flush()
 }
 
-// flushBuffer 刷新缓冲区（无锁版本，调用前需要获取锁）
-func (a *KafkaAdapter) flushBuffer() error {
-	if len(a.buffer) == 0 {
-		return nil
-	}
+// send 批量发送日志，按级别分组发送到各自的主题
+func (a *KafkaAdapter) send(ctx context.Context, entries []logger.LogEntry) error {
+	topics, groups := a.router.group(entries)
 
 	// 在实际应用中，这里应该批量发送到Kafka
-	// var messages []*sarama.ProducerMessage
-	// for _, entry := range a.buffer {
-	//     data, _ := json.Marshal(entry)
-	//     messages = append(messages, &sarama.ProducerMessage{
-	//         Topic: a.Topic,
-	//         Value: sarama.ByteEncoder(data),
-	//     })
-	// }
-	//
-	// for _, msg := range messages {
-	//     _, _, err := a.producer.(*sarama.SyncProducer).SendMessage(msg)
-	//     if err != nil {
-	//         return err
+	// for _, topic := range topics {
+	//     for _, entry := range groups[topic] {
+	//         if err := ctx.Err(); err != nil {
+	//             return err
+	//         }
+	//         data, err := a.encodeEntry(entry)
+	//         if err != nil {
+	//             return fmt.Errorf("marshal kafka message failed: %v", err)
+	//         }
+	//         _, _, err = a.producer.(*sarama.SyncProducer).SendMessage(&sarama.ProducerMessage{
+	//             Topic: topic,
+	//             Value: sarama.ByteEncoder(data),
+	//         })
+	//         if err != nil {
+	//             return err
+	//         }
 	//     }
 	// }
 
 	// 这里仅作演示，实际打印日志
-	for _, entry := range a.buffer {
-		data, _ := json.Marshal(entry)
-		fmt.Printf("[Kafka Adapter] Would send to topic %s: %s\n", a.Topic, string(data))
+	for _, topic := range topics {
+		for _, entry := range groups[topic] {
+			if err := ctx.Err(); err != nil {
+				return err
+			}
+			data, err := a.encodeEntry(entry)
+			if err != nil {
+				// 无法序列化的日志跳过，不影响同一批次的其他日志
+				continue
+			}
+			fmt.Printf("[Kafka Adapter] Would send to topic %s: %d bytes\n", topic, len(data))
+		}
 	}
 
-	// 清空缓冲区
-	a.buffer = a.buffer[:0]
-
 	return nil
 }
 
-// flushPeriodically 定期刷新缓冲区
-func (a *KafkaAdapter) flushPeriodically() {
-	ticker := time.NewTicker(a.FlushTimeout)
-	defer ticker.Stop()
-
-	for range ticker.C {
-		_ = a.Flush()
-	}
-}
-
 // Close 关闭适配器
 func (a *KafkaAdapter) Close() error {
-	// 刷新剩余日志
-	err := a.Flush()
+	// 刷新剩余日志并停止定时刷新
+	err := a.closeBatcher()
 
 	// 关闭Kafka生产者
 	// if a.producer != nil {
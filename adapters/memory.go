@@ -0,0 +1,104 @@
+package adapters
+
+import (
+	"context"
+	"sync"
+
+	"github.com/qishenonly/logger"
+)
+
+func init() {
+	// 注册适配器
+	logger.RegisterAdapter("memory", func() logger.LogAdapter {
+		return &MemoryAdapter{}
+	}, memoryConfigSchema)
+}
+
+// memoryConfigSchema 内存适配器支持的配置项
+var memoryConfigSchema = logger.ConfigSchema{
+	"max_entries": logger.ConfigNumber,
+}
+
+// MemoryAdapter 在内存中保存收到的日志，用于在测试中断言代码输出了哪些日志
+// 可以并发调用；设置MaxEntries后只保留最近的MaxEntries条，Flush和Close不清空已保存的日志，需要时调用Reset
+type MemoryAdapter struct {
+	MaxEntries int
+	mu         sync.Mutex
+	entries    []logger.LogEntry
+}
+
+// NewMemoryAdapter 创建内存适配器，maxEntries小于等于0时不限制条数，可以直接通过AddAdapter添加
+func NewMemoryAdapter(maxEntries int) *MemoryAdapter {
+	return &MemoryAdapter{MaxEntries: maxEntries}
+}
+
+// Name 返回适配器名称
+func (a *MemoryAdapter) Name() string {
+	return "memory"
+}
+
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *MemoryAdapter) ValidateConfig(config map[string]interface{}) error {
+	return memoryConfigSchema.Validate(config)
+}
+
+// Init 初始化适配器
+func (a *MemoryAdapter) Init(config map[string]interface{}) error {
+	// 解析配置参数
+	if maxEntries, ok := logger.ToFloat64(config["max_entries"]); ok && maxEntries > 0 {
+		a.MaxEntries = int(maxEntries)
+	}
+	return nil
+}
+
+// Process 保存日志，超过MaxEntries时丢弃最早的日志
+func (a *MemoryAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = append(a.entries, entry)
+	if a.MaxEntries > 0 && len(a.entries) > a.MaxEntries {
+		a.entries = append(a.entries[:0], a.entries[len(a.entries)-a.MaxEntries:]...)
+	}
+	return nil
+}
+
+// Entries 按收到的顺序返回已保存日志的副本
+func (a *MemoryAdapter) Entries() []logger.LogEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return append([]logger.LogEntry(nil), a.entries...)
+}
+
+// FilterByLevel 返回指定级别的日志，级别接受ParseLevel支持的别名，如warning
+func (a *MemoryAdapter) FilterByLevel(level string) []logger.LogEntry {
+	if lvl, err := logger.ParseLevel(level); err == nil {
+		level = lvl.String()
+	}
+
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var entries []logger.LogEntry
+	for _, entry := range a.entries {
+		if entry.Level == level {
+			entries = append(entries, entry)
+		}
+	}
+	return entries
+}
+
+// Reset 清空已保存的日志
+func (a *MemoryAdapter) Reset() {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.entries = nil
+}
+
+// Flush 日志保存在内存中，不需要刷新
+func (a *MemoryAdapter) Flush() error {
+	return nil
+}
+
+// Close 保留已保存的日志，关闭日志后仍然可以断言
+func (a *MemoryAdapter) Close() error {
+	return nil
+}
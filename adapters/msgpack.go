@@ -0,0 +1,395 @@
+package adapters
+
+import (
+	"bufio"
+	"encoding/binary"
+	"encoding/json"
+	"fmt"
+	"io"
+	"math"
+	"reflect"
+	"time"
+)
+
+// msgpackWriter 最小化的MessagePack编码器，支持日志记录中常见的类型
+type msgpackWriter struct {
+	buf []byte
+}
+
+// bytes 返回已编码的数据
+func (w *msgpackWriter) bytes() []byte {
+	return w.buf
+}
+
+// writeNil 编码nil
+func (w *msgpackWriter) writeNil() {
+	w.buf = append(w.buf, 0xc0)
+}
+
+// writeBool 编码布尔值
+func (w *msgpackWriter) writeBool(v bool) {
+	if v {
+		w.buf = append(w.buf, 0xc3)
+	} else {
+		w.buf = append(w.buf, 0xc2)
+	}
+}
+
+// writeInt 编码有符号整数
+func (w *msgpackWriter) writeInt(v int64) {
+	switch {
+	case v >= 0:
+		w.writeUint(uint64(v))
+	case v >= -32:
+		w.buf = append(w.buf, byte(v))
+	case v >= math.MinInt8:
+		w.buf = append(w.buf, 0xd0, byte(v))
+	case v >= math.MinInt16:
+		w.buf = append(w.buf, 0xd1)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(v))
+	case v >= math.MinInt32:
+		w.buf = append(w.buf, 0xd2)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(v))
+	default:
+		w.buf = append(w.buf, 0xd3)
+		w.buf = binary.BigEndian.AppendUint64(w.buf, uint64(v))
+	}
+}
+
+// writeUint 编码无符号整数
+func (w *msgpackWriter) writeUint(v uint64) {
+	switch {
+	case v <= 0x7f:
+		w.buf = append(w.buf, byte(v))
+	case v <= math.MaxUint8:
+		w.buf = append(w.buf, 0xcc, byte(v))
+	case v <= math.MaxUint16:
+		w.buf = append(w.buf, 0xcd)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(v))
+	case v <= math.MaxUint32:
+		w.buf = append(w.buf, 0xce)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(v))
+	default:
+		w.buf = append(w.buf, 0xcf)
+		w.buf = binary.BigEndian.AppendUint64(w.buf, v)
+	}
+}
+
+// writeFloat 编码浮点数
+func (w *msgpackWriter) writeFloat(v float64) {
+	w.buf = append(w.buf, 0xcb)
+	w.buf = binary.BigEndian.AppendUint64(w.buf, math.Float64bits(v))
+}
+
+// writeString 编码字符串
+func (w *msgpackWriter) writeString(v string) {
+	n := len(v)
+	switch {
+	case n <= 31:
+		w.buf = append(w.buf, 0xa0|byte(n))
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xd9, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xda)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdb)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, v...)
+}
+
+// writeBinary 编码二进制数据
+func (w *msgpackWriter) writeBinary(v []byte) {
+	n := len(v)
+	switch {
+	case n <= math.MaxUint8:
+		w.buf = append(w.buf, 0xc4, byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xc5)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xc6)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+	w.buf = append(w.buf, v...)
+}
+
+// writeArrayHeader 编码数组头
+func (w *msgpackWriter) writeArrayHeader(n int) {
+	switch {
+	case n <= 15:
+		w.buf = append(w.buf, 0x90|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xdc)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdd)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+}
+
+// writeMapHeader 编码map头
+func (w *msgpackWriter) writeMapHeader(n int) {
+	switch {
+	case n <= 15:
+		w.buf = append(w.buf, 0x80|byte(n))
+	case n <= math.MaxUint16:
+		w.buf = append(w.buf, 0xde)
+		w.buf = binary.BigEndian.AppendUint16(w.buf, uint16(n))
+	default:
+		w.buf = append(w.buf, 0xdf)
+		w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(n))
+	}
+}
+
+// writeEventTime 编码Fluentd的EventTime扩展类型(ext type 0)
+func (w *msgpackWriter) writeEventTime(t time.Time) {
+	w.buf = append(w.buf, 0xd7, 0x00)
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(t.Unix()))
+	w.buf = binary.BigEndian.AppendUint32(w.buf, uint32(t.Nanosecond()))
+}
+
+// writeValue 编码任意值，不支持的类型先经过JSON转换
+func (w *msgpackWriter) writeValue(v interface{}) {
+	switch val := v.(type) {
+	case nil:
+		w.writeNil()
+	case bool:
+		w.writeBool(val)
+	case int:
+		w.writeInt(int64(val))
+	case int8:
+		w.writeInt(int64(val))
+	case int16:
+		w.writeInt(int64(val))
+	case int32:
+		w.writeInt(int64(val))
+	case int64:
+		w.writeInt(val)
+	case uint:
+		w.writeUint(uint64(val))
+	case uint8:
+		w.writeUint(uint64(val))
+	case uint16:
+		w.writeUint(uint64(val))
+	case uint32:
+		w.writeUint(uint64(val))
+	case uint64:
+		w.writeUint(val)
+	case float32:
+		w.writeFloat(float64(val))
+	case float64:
+		w.writeFloat(val)
+	case string:
+		w.writeString(val)
+	case []byte:
+		w.writeBinary(val)
+	case time.Time:
+		w.writeString(val.Format(time.RFC3339Nano))
+	case time.Duration:
+		w.writeString(val.String())
+	case error:
+		w.writeString(val.Error())
+	case fmt.Stringer:
+		w.writeString(val.String())
+	case []interface{}:
+		w.writeArrayHeader(len(val))
+		for _, item := range val {
+			w.writeValue(item)
+		}
+	case map[string]interface{}:
+		w.writeMapHeader(len(val))
+		for k, item := range val {
+			w.writeString(k)
+			w.writeValue(item)
+		}
+	default:
+		// 其他类型(结构体、切片、map等)通过JSON转换为基础类型
+		rv := reflect.ValueOf(v)
+		if rv.Kind() == reflect.Ptr && rv.IsNil() {
+			w.writeNil()
+			return
+		}
+		data, err := json.Marshal(v)
+		if err != nil {
+			w.writeString(fmt.Sprint(v))
+			return
+		}
+		var generic interface{}
+		if err := json.Unmarshal(data, &generic); err != nil {
+			w.writeString(string(data))
+			return
+		}
+		w.writeValue(generic)
+	}
+}
+
+// msgpackReader 最小化的MessagePack解码器，用于解析握手消息
+type msgpackReader struct {
+	r *bufio.Reader
+}
+
+// readValue 解码一个值，str和bin都解码为string，ext解码为[]byte
+func (r *msgpackReader) readValue() (interface{}, error) {
+	b, err := r.r.ReadByte()
+	if err != nil {
+		return nil, err
+	}
+
+	switch {
+	case b <= 0x7f:
+		return int64(b), nil
+	case b >= 0xe0:
+		return int64(int8(b)), nil
+	case b&0xf0 == 0x80:
+		return r.readMap(int(b & 0x0f))
+	case b&0xf0 == 0x90:
+		return r.readArray(int(b & 0x0f))
+	case b&0xe0 == 0xa0:
+		return r.readString(int(b & 0x1f))
+	}
+
+	switch b {
+	case 0xc0:
+		return nil, nil
+	case 0xc2:
+		return false, nil
+	case 0xc3:
+		return true, nil
+	case 0xc4, 0xd9:
+		n, err := r.readUint(1)
+		if err != nil {
+			return nil, err
+		}
+		return r.readString(int(n))
+	case 0xc5, 0xda:
+		n, err := r.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+		return r.readString(int(n))
+	case 0xc6, 0xdb:
+		n, err := r.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return r.readString(int(n))
+	case 0xca:
+		n, err := r.readUint(4)
+		return float64(math.Float32frombits(uint32(n))), err
+	case 0xcb:
+		n, err := r.readUint(8)
+		return math.Float64frombits(n), err
+	case 0xcc:
+		n, err := r.readUint(1)
+		return int64(n), err
+	case 0xcd:
+		n, err := r.readUint(2)
+		return int64(n), err
+	case 0xce:
+		n, err := r.readUint(4)
+		return int64(n), err
+	case 0xcf:
+		n, err := r.readUint(8)
+		return int64(n), err
+	case 0xd0:
+		n, err := r.readUint(1)
+		return int64(int8(n)), err
+	case 0xd1:
+		n, err := r.readUint(2)
+		return int64(int16(n)), err
+	case 0xd2:
+		n, err := r.readUint(4)
+		return int64(int32(n)), err
+	case 0xd3:
+		n, err := r.readUint(8)
+		return int64(n), err
+	case 0xd4, 0xd5, 0xd6, 0xd7, 0xd8:
+		// fixext: 1字节类型 + 1/2/4/8/16字节数据
+		size := 1 << (b - 0xd4)
+		data := make([]byte, size+1)
+		if _, err := io.ReadFull(r.r, data); err != nil {
+			return nil, err
+		}
+		return data[1:], nil
+	case 0xdc:
+		n, err := r.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+		return r.readArray(int(n))
+	case 0xdd:
+		n, err := r.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return r.readArray(int(n))
+	case 0xde:
+		n, err := r.readUint(2)
+		if err != nil {
+			return nil, err
+		}
+		return r.readMap(int(n))
+	case 0xdf:
+		n, err := r.readUint(4)
+		if err != nil {
+			return nil, err
+		}
+		return r.readMap(int(n))
+	}
+
+	return nil, fmt.Errorf("unsupported msgpack type 0x%x", b)
+}
+
+// readUint 读取n字节大端无符号整数
+func (r *msgpackReader) readUint(n int) (uint64, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return 0, err
+	}
+	var v uint64
+	for _, b := range data {
+		v = v<<8 | uint64(b)
+	}
+	return v, nil
+}
+
+// readString 读取n字节字符串
+func (r *msgpackReader) readString(n int) (string, error) {
+	data := make([]byte, n)
+	if _, err := io.ReadFull(r.r, data); err != nil {
+		return "", err
+	}
+	return string(data), nil
+}
+
+// readArray 读取n个元素的数组
+func (r *msgpackReader) readArray(n int) ([]interface{}, error) {
+	arr := make([]interface{}, 0, n)
+	for i := 0; i < n; i++ {
+		v, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		arr = append(arr, v)
+	}
+	return arr, nil
+}
+
+// readMap 读取n个键值对的map，键统一转换为字符串
+func (r *msgpackReader) readMap(n int) (map[string]interface{}, error) {
+	m := make(map[string]interface{}, n)
+	for i := 0; i < n; i++ {
+		k, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		v, err := r.readValue()
+		if err != nil {
+			return nil, err
+		}
+		m[fmt.Sprint(k)] = v
+	}
+	return m, nil
+}
@@ -0,0 +1,260 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"time"
+
+	"github.com/qishenonly/logger"
+)
+
+func init() {
+	// 注册适配器
+	logger.RegisterAdapter("otlp", func() logger.LogAdapter {
+		return &OTLPAdapter{}
+	}, otlpConfigSchema)
+}
+
+// OTLP导出协议
+const (
+	OTLPProtocolGRPC = "grpc"
+	OTLPProtocolHTTP = "http"
+)
+
+// otlpConfigSchema OTLP适配器支持的配置项
+var otlpConfigSchema = logger.ConfigSchema{
+	"endpoint":       logger.ConfigString,
+	"protocol":       logger.ConfigString,
+	"headers":        logger.ConfigMap,
+	"insecure":       logger.ConfigBool,
+	"batch_size":     logger.ConfigNumber,
+	"flush_interval": logger.ConfigNumber,
+}.Merge(batchConfigSchema)
+
+// OTLPAdapter 用于将日志以OpenTelemetry LogRecord的形式导出到OTLP collector
+// 默认批量大小和间隔与OpenTelemetry批处理器一致：512条或1秒
+type OTLPAdapter struct {
+	Endpoint      string
+	Protocol      string            // grpc(默认)或http
+	Headers       map[string]string // 每次导出请求附加的头，如认证信息
+	Insecure      bool              // 不使用TLS连接collector
+	BatchSize     int
+	FlushInterval time.Duration
+	batcher
+	exporter interface{} // 这里用interface{}占位，实际应该是otlploggrpc或otlploghttp的Exporter
+}
+
+// otlpResourceLogs 同一资源下的一组日志，对应OTLP的ResourceLogs
+type otlpResourceLogs struct {
+	Resource   map[string]string `json:"resource"`
+	LogRecords []otlpLogRecord   `json:"logRecords"`
+}
+
+// otlpLogRecord 对应OTLP的LogRecord
+type otlpLogRecord struct {
+	TimeUnixNano   int64                  `json:"timeUnixNano"`
+	SeverityNumber int                    `json:"severityNumber"`
+	SeverityText   string                 `json:"severityText"`
+	Body           string                 `json:"body"`
+	Attributes     map[string]interface{} `json:"attributes,omitempty"`
+}
+
+// Name 返回适配器名称
+func (a *OTLPAdapter) Name() string {
+	return "otlp"
+}
+
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *OTLPAdapter) ValidateConfig(config map[string]interface{}) error {
+	return otlpConfigSchema.Validate(config)
+}
+
+// Init 初始化适配器
+func (a *OTLPAdapter) Init(config map[string]interface{}) error {
+	// 解析配置参数
+	endpoint, ok := config["endpoint"].(string)
+	if !ok || endpoint == "" {
+		return fmt.Errorf("otlp endpoint is required")
+	}
+	a.Endpoint = endpoint
+
+	a.Protocol = OTLPProtocolGRPC
+	if protocol, ok := config["protocol"].(string); ok && protocol != "" {
+		if protocol != OTLPProtocolGRPC && protocol != OTLPProtocolHTTP {
+			return fmt.Errorf("unknown otlp protocol: %s", protocol)
+		}
+		a.Protocol = protocol
+	}
+
+	a.Headers = make(map[string]string)
+	if headers, ok := config["headers"].(map[string]interface{}); ok {
+		for k, v := range headers {
+			a.Headers[k] = fmt.Sprint(v)
+		}
+	}
+
+	if insecure, ok := config["insecure"].(bool); ok {
+		a.Insecure = insecure
+	}
+
+	if batchSize, ok := logger.ToFloat64(config["batch_size"]); ok && batchSize > 0 {
+		a.BatchSize = int(batchSize)
+	} else {
+		a.BatchSize = 512
+	}
+
+	if flushInterval, ok := logger.ToFloat64(config["flush_interval"]); ok && flushInterval > 0 {
+		a.FlushInterval = time.Duration(flushInterval * float64(time.Second))
+	} else {
+		a.FlushInterval = time.Second
+	}
+
+	// 创建OTLP导出器
+	// 实际应该这样:
+	// switch a.Protocol {
+	// case OTLPProtocolHTTP:
+	//     opts := []otlploghttp.Option{otlploghttp.WithEndpoint(a.Endpoint), otlploghttp.WithHeaders(a.Headers)}
+	//     if a.Insecure {
+	//         opts = append(opts, otlploghttp.WithInsecure())
+	//     }
+	//     exporter, err = otlploghttp.New(context.Background(), opts...)
+	// default:
+	//     opts := []otlploggrpc.Option{otlploggrpc.WithEndpoint(a.Endpoint), otlploggrpc.WithHeaders(a.Headers)}
+	//     if a.Insecure {
+	//         opts = append(opts, otlploggrpc.WithInsecure())
+	//     }
+	//     exporter, err = otlploggrpc.New(context.Background(), opts...)
+	// }
+	// if err != nil {
+	//     return fmt.Errorf("failed to create otlp exporter: %v", err)
+	// }
+	// a.exporter = exporter
+
+	// 初始化缓冲区并启动定时刷新
+	a.initBatcher(config, a.BatchSize, a.FlushInterval, a.send)
+
+	return nil
+}
+
+// Process 处理日志条目
+func (a *OTLPAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	return a.add(ctx, entry)
+}
+
+// Flush 刷新缓冲区
+func (a *OTLPAdapter) Flush() error {
+	return a.flush()
+}
+
+// send 按资源分组后导出一批日志
+func (a *OTLPAdapter) send(ctx context.Context, entries []logger.LogEntry) error {
+	resourceLogs := toOTLPResourceLogs(entries)
+
+	// 在实际应用中，这里调用导出器
+	// records := make([]sdklog.Record, 0, len(entries))
+	// ...
+	// return a.exporter.(sdklog.Exporter).Export(ctx, records)
+
+	// 这里仅作演示，实际打印日志
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	for _, logs := range resourceLogs {
+		data, _ := json.Marshal(logs)
+		fmt.Printf("[OTLP Adapter] Would export to %s via %s: %s\n", a.Endpoint, a.Protocol, string(data))
+	}
+
+	return nil
+}
+
+// Close 关闭适配器
+func (a *OTLPAdapter) Close() error {
+	// 导出剩余日志并停止定时刷新
+	err := a.closeBatcher()
+
+	// 关闭导出器
+	// if a.exporter != nil {
+	//     if cerr := a.exporter.(sdklog.Exporter).Shutdown(context.Background()); cerr != nil && err == nil {
+	//         err = cerr
+	//     }
+	// }
+
+	return err
+}
+
+// toOTLPResourceLogs 将日志条目按节点、模块和IP分组为ResourceLogs，保持组内顺序
+func toOTLPResourceLogs(entries []logger.LogEntry) []otlpResourceLogs {
+	var result []otlpResourceLogs
+	index := make(map[[3]string]int)
+	for _, entry := range entries {
+		key := [3]string{entry.NodeID, entry.Module, entry.IP}
+		i, ok := index[key]
+		if !ok {
+			i = len(result)
+			index[key] = i
+			result = append(result, otlpResourceLogs{Resource: otlpResource(entry)})
+		}
+		result[i].LogRecords = append(result[i].LogRecords, toOTLPLogRecord(entry))
+	}
+	return result
+}
+
+// otlpResource 将节点ID、模块和IP映射为OpenTelemetry资源属性，空值不输出
+func otlpResource(entry logger.LogEntry) map[string]string {
+	resource := make(map[string]string, 3)
+	if entry.NodeID != "" {
+		resource["service.instance.id"] = entry.NodeID
+	}
+	if entry.Module != "" {
+		resource["service.name"] = entry.Module
+	}
+	if entry.IP != "" {
+		resource["host.ip"] = entry.IP
+	}
+	return resource
+}
+
+// toOTLPLogRecord 将日志条目转换为LogRecord，属性来自Properties和调用位置
+func toOTLPLogRecord(entry logger.LogEntry) otlpLogRecord {
+	number, text := otlpSeverity(entry.Level)
+	record := otlpLogRecord{
+		TimeUnixNano:   entry.Time.UnixNano(),
+		SeverityNumber: number,
+		SeverityText:   text,
+		Body:           entry.Message,
+	}
+
+	if len(entry.Properties) > 0 || entry.Caller != "" {
+		record.Attributes = make(map[string]interface{}, len(entry.Properties)+1)
+		for k, v := range entry.Properties {
+			record.Attributes[k] = v
+		}
+		if entry.Caller != "" {
+			record.Attributes["code.caller"] = entry.Caller
+		}
+	}
+	return record
+}
+
+// otlpSeverity 将日志级别映射为OpenTelemetry的SeverityNumber和SeverityText
+func otlpSeverity(level string) (int, string) {
+	switch level {
+	case "debug":
+		return 5, "DEBUG"
+	case "info", logger.AuditLevel:
+		return 9, "INFO"
+	case "warn":
+		return 13, "WARN"
+	case "error":
+		return 17, "ERROR"
+	case "dpanic":
+		return 19, "ERROR3"
+	case "panic":
+		return 21, "FATAL"
+	case "fatal":
+		return 24, "FATAL4"
+	default:
+		return 0, "UNSPECIFIED"
+	}
+}
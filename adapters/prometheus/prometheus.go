@@ -0,0 +1,120 @@
+// Package prometheus 提供按级别、模块和节点统计日志条数的Prometheus适配器
+// 计数器使用Prometheus客户端库注册，可以与应用已有的注册表一起暴露；单独成包，不使用时不引入客户端库
+//
+//	import _ "github.com/qishenonly/logger/adapters/prometheus"
+//
+//	logger.InitWithOptions(logger.WithPrometheusAdapter(map[string]interface{}{"namespace": "app"}))
+package prometheus
+
+import (
+	"context"
+	"errors"
+	"fmt"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/qishenonly/logger"
+)
+
+func init() {
+	// 注册适配器
+	logger.RegisterAdapter("prometheus", func() logger.LogAdapter {
+		return &Adapter{}
+	}, configSchema)
+}
+
+// configSchema Prometheus适配器支持的配置项
+var configSchema = logger.ConfigSchema{
+	"namespace": logger.ConfigString,
+	"subsystem": logger.ConfigString,
+	"registry":  logger.ConfigAny,
+}
+
+// Adapter 按级别、模块和节点统计日志条数，用于绘制日志量和错误率
+// 计数器名为 <namespace>_<subsystem>_log_entries_total，标签为level、module、node_id
+type Adapter struct {
+	Namespace string
+	Subsystem string
+	counter   *prom.CounterVec
+}
+
+// Name 返回适配器名称
+func (a *Adapter) Name() string {
+	return "prometheus"
+}
+
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *Adapter) ValidateConfig(config map[string]interface{}) error {
+	if err := configSchema.Validate(config); err != nil {
+		return err
+	}
+	if registry, exists := config["registry"]; exists {
+		if _, ok := registry.(prom.Registerer); !ok {
+			return fmt.Errorf("prometheus registry must be prometheus.Registerer, got %T", registry)
+		}
+	}
+	return nil
+}
+
+// Init 初始化适配器，计数器注册到registry配置项指定的Registerer，未配置时使用prometheus.DefaultRegisterer
+// 同名计数器已经注册时复用已有的计数器，多个日志实例或重新创建的日志累加到同一个计数器
+func (a *Adapter) Init(config map[string]interface{}) error {
+	// 解析配置参数
+	if namespace, ok := config["namespace"].(string); ok {
+		a.Namespace = namespace
+	} else {
+		a.Namespace = "logger"
+	}
+
+	if subsystem, ok := config["subsystem"].(string); ok {
+		a.Subsystem = subsystem
+	}
+
+	registerer := prom.DefaultRegisterer
+	if registry, ok := config["registry"].(prom.Registerer); ok && registry != nil {
+		registerer = registry
+	}
+
+	counter := prom.NewCounterVec(prom.CounterOpts{
+		Namespace: a.Namespace,
+		Subsystem: a.Subsystem,
+		Name:      "log_entries_total",
+		Help:      "Log entries by level, module and node.",
+	}, []string{"level", "module", "node_id"})
+	if err := registerer.Register(counter); err != nil {
+		var registered prom.AlreadyRegisteredError
+		if !errors.As(err, &registered) {
+			return fmt.Errorf("register prometheus counter failed: %v", err)
+		}
+		existing, ok := registered.ExistingCollector.(*prom.CounterVec)
+		if !ok {
+			return fmt.Errorf("register prometheus counter failed: %v", err)
+		}
+		counter = existing
+	}
+	a.counter = counter
+	return nil
+}
+
+// Process 按日志的级别、模块和节点累加计数，可以并发调用
+func (a *Adapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	if a.counter == nil {
+		return fmt.Errorf("prometheus adapter not initialized")
+	}
+	a.counter.WithLabelValues(entry.Level, entry.Module, entry.NodeID).Inc()
+	return nil
+}
+
+// Counter 返回适配器累加的计数器
+func (a *Adapter) Counter() *prom.CounterVec {
+	return a.counter
+}
+
+// Flush 计数器实时更新，不需要刷新
+func (a *Adapter) Flush() error {
+	return nil
+}
+
+// Close 计数器在进程内持续累加，关闭时不从注册表中注销
+func (a *Adapter) Close() error {
+	return nil
+}
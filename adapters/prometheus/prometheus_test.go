@@ -0,0 +1,75 @@
+package prometheus
+
+import (
+	"context"
+	"strings"
+	"sync"
+	"testing"
+
+	prom "github.com/prometheus/client_golang/prometheus"
+	"github.com/prometheus/client_golang/prometheus/testutil"
+	"github.com/qishenonly/logger"
+	"github.com/stretchr/testify/assert"
+)
+
+func TestAdapter(t *testing.T) {
+	registry := prom.NewRegistry()
+	adapter := &Adapter{}
+	if !assert.NoError(t, adapter.Init(map[string]interface{}{"namespace": "app", "subsystem": "api", "registry": registry})) {
+		return
+	}
+
+	// 并发处理时计数准确
+	ctx := context.Background()
+	var wg sync.WaitGroup
+	for i := 0; i < 10; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 100; j++ {
+				_ = adapter.Process(ctx, logger.LogEntry{Level: "info", Module: "orders", NodeID: "node-1"})
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, adapter.Process(ctx, logger.LogEntry{Level: "error", Module: "orders", NodeID: "node-1"}))
+	assert.Equal(t, float64(1000), testutil.ToFloat64(adapter.Counter().WithLabelValues("info", "orders", "node-1")))
+
+	expected := `# HELP app_api_log_entries_total Log entries by level, module and node.
+# TYPE app_api_log_entries_total counter
+app_api_log_entries_total{level="error",module="orders",node_id="node-1"} 1
+app_api_log_entries_total{level="info",module="orders",node_id="node-1"} 1000
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "app_api_log_entries_total"))
+
+	// 同名计数器已注册时复用，计数累加
+	again := &Adapter{}
+	assert.NoError(t, again.Init(map[string]interface{}{"namespace": "app", "subsystem": "api", "registry": registry}))
+	assert.Same(t, adapter.Counter(), again.Counter())
+	assert.NoError(t, adapter.Close())
+
+	// 注册表必须是prometheus.Registerer，未初始化时Process返回错误
+	assert.Error(t, (&Adapter{}).ValidateConfig(map[string]interface{}{"registry": "default"}))
+	assert.Error(t, (&Adapter{}).Process(ctx, logger.LogEntry{}))
+}
+
+func TestWithPrometheusAdapter(t *testing.T) {
+	// 通过WithPrometheusAdapter接入日志，默认指标名为logger_log_entries_total
+	registry := prom.NewRegistry()
+	l, err := logger.NewWithOptions(
+		logger.WithOutputType(logger.OutputAdapters),
+		logger.WithModule("billing"),
+		logger.WithPrometheusAdapter(map[string]interface{}{"registry": registry}),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Warn("slow")
+	assert.NoError(t, l.Close())
+
+	expected := `# HELP logger_log_entries_total Log entries by level, module and node.
+# TYPE logger_log_entries_total counter
+logger_log_entries_total{level="warn",module="billing",node_id=""} 1
+`
+	assert.NoError(t, testutil.GatherAndCompare(registry, strings.NewReader(expected), "logger_log_entries_total"))
+}
@@ -0,0 +1,231 @@
+package adapters
+
+import (
+	"context"
+	"encoding/json"
+	"fmt"
+	"os"
+	"sync"
+	"time"
+
+	"github.com/qishenonly/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+func init() {
+	// 注册适配器
+	logger.RegisterAdapter("ringbuffer", func() logger.LogAdapter {
+		return &RingBufferAdapter{}
+	}, ringBufferConfigSchema)
+}
+
+// ringBufferConfigSchema 环形缓冲适配器支持的配置项
+var ringBufferConfigSchema = logger.ConfigSchema{
+	"size":          logger.ConfigNumber,
+	"trigger_level": logger.ConfigString,
+	"sink_file":     logger.ConfigString,
+	"sink_adapter":  logger.ConfigString,
+	"sink_config":   logger.ConfigMap,
+}
+
+// RingBufferAdapter 在内存中保留最近的日志，遇到触发级别的日志时将缓冲的日志整体输出到sink
+// 适合配合debug级别使用：平时不落盘，出错时输出错误前后的完整上下文
+// sink可以是文件(sink_file，每行一条JSON)或另一个已注册的适配器(sink_adapter及其sink_config)
+type RingBufferAdapter struct {
+	Size         int
+	TriggerLevel zapcore.Level
+	SinkFile     string
+	ring         []logger.LogEntry
+	next         int
+	count        int
+	mu           sync.Mutex
+	sink         logger.LogAdapter
+	file         *os.File
+	fileMu       sync.Mutex
+}
+
+// Name 返回适配器名称
+func (a *RingBufferAdapter) Name() string {
+	return "ringbuffer"
+}
+
+// ValidateConfig 校验配置，报告未知或类型错误的配置项
+func (a *RingBufferAdapter) ValidateConfig(config map[string]interface{}) error {
+	return ringBufferConfigSchema.Validate(config)
+}
+
+// Init 初始化适配器
+func (a *RingBufferAdapter) Init(config map[string]interface{}) error {
+	// 解析配置参数
+	if size, ok := logger.ToFloat64(config["size"]); ok && size > 0 {
+		a.Size = int(size)
+	} else {
+		a.Size = 1000
+	}
+
+	a.TriggerLevel = zapcore.ErrorLevel
+	if triggerLevel, ok := config["trigger_level"].(string); ok {
+		level, err := zapcore.ParseLevel(triggerLevel)
+		if err != nil {
+			return fmt.Errorf("ringbuffer trigger_level invalid: %v", err)
+		}
+		a.TriggerLevel = level
+	}
+
+	// 初始化输出目标
+	if sinkFile, ok := config["sink_file"].(string); ok && sinkFile != "" {
+		file, err := os.OpenFile(sinkFile, os.O_CREATE|os.O_APPEND|os.O_WRONLY, 0644)
+		if err != nil {
+			return fmt.Errorf("open ringbuffer sink file failed: %v", err)
+		}
+		a.SinkFile = sinkFile
+		a.file = file
+	} else if sinkAdapter, ok := config["sink_adapter"].(string); ok && sinkAdapter != "" {
+		sink, exists := logger.GetAdapter(sinkAdapter)
+		if !exists {
+			return fmt.Errorf("ringbuffer sink adapter %s not registered", sinkAdapter)
+		}
+		sinkConfig, _ := config["sink_config"].(map[string]interface{})
+		if err := logger.ValidateAdapterConfig(sinkAdapter, sinkConfig); err != nil {
+			return fmt.Errorf("init ringbuffer sink adapter %s failed: %v", sinkAdapter, err)
+		}
+		if err := sink.Init(sinkConfig); err != nil {
+			return fmt.Errorf("init ringbuffer sink adapter %s failed: %v", sinkAdapter, err)
+		}
+		a.sink = sink
+	} else {
+		return fmt.Errorf("ringbuffer requires sink_file or sink_adapter")
+	}
+
+	// 初始化环形缓冲区
+	a.ring = make([]logger.LogEntry, a.Size)
+	a.next = 0
+	a.count = 0
+
+	return nil
+}
+
+// Process 将日志写入环形缓冲区，达到触发级别时输出并清空缓冲区
+func (a *RingBufferAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	a.mu.Lock()
+	if len(a.ring) == 0 {
+		a.mu.Unlock()
+		return fmt.Errorf("ringbuffer adapter not initialized")
+	}
+
+	a.ring[a.next] = entry
+	a.next = (a.next + 1) % len(a.ring)
+	if a.count < len(a.ring) {
+		a.count++
+	}
+
+	level, err := zapcore.ParseLevel(entry.Level)
+	if err != nil || level < a.TriggerLevel {
+		a.mu.Unlock()
+		return nil
+	}
+
+	// 按时间顺序取出缓冲的日志并清空
+	entries := make([]logger.LogEntry, 0, a.count)
+	start := (a.next - a.count + len(a.ring)) % len(a.ring)
+	for i := 0; i < a.count; i++ {
+		idx := (start + i) % len(a.ring)
+		entries = append(entries, a.ring[idx])
+		a.ring[idx] = logger.LogEntry{}
+	}
+	a.count = 0
+	sink := a.sink
+	a.mu.Unlock()
+
+	return a.dump(ctx, sink, entries)
+}
+
+// Buffered 返回环形缓冲区中的日志数
+func (a *RingBufferAdapter) Buffered() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return a.count
+}
+
+// Snapshot 按时间顺序返回环形缓冲区中日志的副本，不清空缓冲区，只在复制期间持有锁
+func (a *RingBufferAdapter) Snapshot() []logger.LogEntry {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	if len(a.ring) == 0 {
+		return nil
+	}
+
+	entries := make([]logger.LogEntry, 0, a.count)
+	start := (a.next - a.count + len(a.ring)) % len(a.ring)
+	for i := 0; i < a.count; i++ {
+		entries = append(entries, a.ring[(start+i)%len(a.ring)])
+	}
+	return entries
+}
+
+// dump 将日志输出到sink
+func (a *RingBufferAdapter) dump(ctx context.Context, sink logger.LogAdapter, entries []logger.LogEntry) error {
+	if sink != nil {
+		for _, entry := range entries {
+			if err := sink.Process(ctx, entry); err != nil {
+				return fmt.Errorf("dump ringbuffer to %s failed: %v", sink.Name(), err)
+			}
+		}
+		return sink.Flush()
+	}
+
+	a.fileMu.Lock()
+	defer a.fileMu.Unlock()
+	if a.file == nil {
+		return nil
+	}
+
+	encoder := json.NewEncoder(a.file)
+	for _, entry := range entries {
+		record := fluentRecord(entry)
+		record["time"] = entry.Time.Format(time.RFC3339Nano)
+		if err := encoder.Encode(record); err != nil {
+			return fmt.Errorf("dump ringbuffer to file failed: %v", err)
+		}
+	}
+	return nil
+}
+
+// Flush 刷新输出目标，缓冲区中未触发的日志不会输出
+func (a *RingBufferAdapter) Flush() error {
+	a.mu.Lock()
+	sink := a.sink
+	a.mu.Unlock()
+
+	if sink != nil {
+		return sink.Flush()
+	}
+
+	a.fileMu.Lock()
+	defer a.fileMu.Unlock()
+	if a.file != nil {
+		return a.file.Sync()
+	}
+	return nil
+}
+
+// Close 关闭适配器及其输出目标
+func (a *RingBufferAdapter) Close() error {
+	a.mu.Lock()
+	sink := a.sink
+	a.sink = nil
+	a.mu.Unlock()
+
+	if sink != nil {
+		return sink.Close()
+	}
+
+	a.fileMu.Lock()
+	defer a.fileMu.Unlock()
+	if a.file != nil {
+		err := a.file.Close()
+		a.file = nil
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,80 @@
+package adapters
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+	"text/template"
+
+	"github.com/qishenonly/logger"
+)
+
+// levelRouter 按日志级别选择Kafka主题或ES索引
+// 级别在routes中时使用映射的目标，否则使用默认目标；默认目标可以是使用.Level的模板，如"logs.{{.Level}}"
+type levelRouter struct {
+	routes   map[string]string
+	fallback string
+	tmpl     *template.Template // 默认目标包含模板时解析，为空表示固定目标
+}
+
+// newLevelRouter 创建级别路由，routes为配置中级别到目标的映射，可以为空
+func newLevelRouter(fallback string, routes interface{}) (*levelRouter, error) {
+	r := &levelRouter{fallback: fallback}
+	if routes != nil {
+		m, ok := routes.(map[string]interface{})
+		if !ok {
+			return nil, fmt.Errorf("level routes must be a map, got %T", routes)
+		}
+		r.routes = make(map[string]string, len(m))
+		for level, target := range m {
+			s, ok := target.(string)
+			if !ok || s == "" {
+				return nil, fmt.Errorf("invalid route for level %s: %v", level, target)
+			}
+			r.routes[strings.ToLower(level)] = s
+		}
+	}
+	if strings.Contains(fallback, "{{") {
+		tmpl, err := template.New("route").Option("missingkey=error").Parse(fallback)
+		if err != nil {
+			return nil, fmt.Errorf("parse route template %s failed: %v", fallback, err)
+		}
+		r.tmpl = tmpl
+	}
+	return r, nil
+}
+
+// target 返回级别对应的目标，模板执行失败时使用未渲染的默认目标
+func (r *levelRouter) target(level string) string {
+	if target, ok := r.routes[strings.ToLower(level)]; ok {
+		return target
+	}
+	if r.tmpl == nil {
+		return r.fallback
+	}
+	var b strings.Builder
+	if err := r.tmpl.Execute(&b, struct{ Level string }{Level: level}); err != nil {
+		return r.fallback
+	}
+	return b.String()
+}
+
+// group 按目标对一批日志分组，同一目标内保持原有顺序，返回的目标按名称排序
+func (r *levelRouter) group(entries []logger.LogEntry) ([]string, map[string][]logger.LogEntry) {
+	targets := make(map[string]string) // 级别到目标的缓存，每个级别只渲染一次模板
+	groups := make(map[string][]logger.LogEntry)
+	for _, entry := range entries {
+		target, ok := targets[entry.Level]
+		if !ok {
+			target = r.target(entry.Level)
+			targets[entry.Level] = target
+		}
+		groups[target] = append(groups[target], entry)
+	}
+	names := make([]string, 0, len(groups))
+	for name := range groups {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	return names, groups
+}
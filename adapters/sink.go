@@ -0,0 +1,85 @@
+package adapters
+
+import (
+	"context"
+	"fmt"
+	"sync/atomic"
+
+	"github.com/qishenonly/logger"
+)
+
+// SinkPathField 记录日志已经经过的LoggerSink，用于防止日志在多个Logger之间循环转发
+const SinkPathField = "sink_path"
+
+// sinkSeq 用于生成LoggerSink实例的唯一ID
+var sinkSeq atomic.Uint64
+
+// LoggerSinkAdapter 将日志条目转发给另一个Logger，由它按自己的输出和适配器处理
+type LoggerSinkAdapter struct {
+	id     string
+	target logger.Logger
+}
+
+// LoggerSink 创建转发到target的适配器，每条日志调用target对应级别的方法
+// panic及以上级别以error级别转发，避免在分发协程中崩溃或退出进程；
+// 转发的日志携带sink_path字段，已经过当前适配器的日志不会再次转发，因此互相转发的配置不会形成循环
+func LoggerSink(target logger.Logger) logger.LogAdapter {
+	return &LoggerSinkAdapter{
+		id:     fmt.Sprintf("logger_sink#%d", sinkSeq.Add(1)),
+		target: target,
+	}
+}
+
+// Name 返回适配器名称
+func (a *LoggerSinkAdapter) Name() string {
+	return "logger_sink"
+}
+
+// Init 目标Logger已经完成配置，无需初始化
+func (a *LoggerSinkAdapter) Init(config map[string]interface{}) error {
+	return nil
+}
+
+// Process 将日志条目转发给目标Logger
+func (a *LoggerSinkAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	path, _ := configStrings(entry.Properties[SinkPathField])
+	for _, id := range path {
+		if id == a.id {
+			// 日志已经过当前适配器，丢弃以终止循环
+			return nil
+		}
+	}
+
+	// 按添加顺序转发属性，目标Logger的输出与原日志的字段顺序一致
+	properties := entry.OrderedProperties()
+	keysAndValues := make([]any, 0, 2*len(properties)+2)
+	for _, p := range properties {
+		if p.Key != SinkPathField {
+			keysAndValues = append(keysAndValues, p.Key, p.Value)
+		}
+	}
+	keysAndValues = append(keysAndValues, SinkPathField, append(path, a.id))
+
+	target := logger.AsKeyValueLogger(a.target)
+	switch entry.Level {
+	case "debug":
+		target.Debugw(entry.Message, keysAndValues...)
+	case "warn":
+		target.Warnw(entry.Message, keysAndValues...)
+	case "error", "dpanic", "panic", "fatal":
+		target.Errorw(entry.Message, keysAndValues...)
+	default:
+		target.Infow(entry.Message, keysAndValues...)
+	}
+	return nil
+}
+
+// Flush 目标Logger自行管理刷新
+func (a *LoggerSinkAdapter) Flush() error {
+	return nil
+}
+
+// Close 目标Logger由调用方负责关闭
+func (a *LoggerSinkAdapter) Close() error {
+	return nil
+}
@@ -0,0 +1,73 @@
+package adapters
+
+import (
+	"encoding/json"
+	"net/http"
+	"strconv"
+	"strings"
+	"time"
+
+	"github.com/qishenonly/logger"
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultTailLines TailHandler未指定n时返回的日志条数
+const defaultTailLines = 100
+
+// TailHandler 返回以JSON数组提供环形缓冲区中最近日志的只读HTTP处理器，用于在无法登录的节点上查看最近的日志
+// 查询参数：n为最多返回的条数(默认100)，level为最低级别，q为消息中需要包含的子串
+// 处理器只复制缓冲区后在锁外过滤，不会阻塞日志写入；缓冲区在达到触发级别输出后会被清空
+func TailHandler(ring *RingBufferAdapter) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if r.Method != http.MethodGet && r.Method != http.MethodHead {
+			w.Header().Set("Allow", "GET, HEAD")
+			http.Error(w, "method not allowed", http.StatusMethodNotAllowed)
+			return
+		}
+
+		query := r.URL.Query()
+		n := defaultTailLines
+		if value := query.Get("n"); value != "" {
+			parsed, err := strconv.Atoi(value)
+			if err != nil || parsed <= 0 {
+				http.Error(w, "invalid n: "+value, http.StatusBadRequest)
+				return
+			}
+			n = parsed
+		}
+
+		minLevel := zapcore.DebugLevel
+		if value := query.Get("level"); value != "" {
+			level, err := logger.ParseLevel(value)
+			if err != nil {
+				http.Error(w, err.Error(), http.StatusBadRequest)
+				return
+			}
+			minLevel = level
+		}
+		substring := query.Get("q")
+
+		// 从最新的日志向前过滤，保留最近的n条后恢复时间顺序
+		entries := ring.Snapshot()
+		records := make([]map[string]interface{}, 0, min(n, len(entries)))
+		for i := len(entries) - 1; i >= 0 && len(records) < n; i-- {
+			entry := entries[i]
+			if level, err := logger.ParseLevel(entry.Level); err == nil && level < minLevel {
+				continue
+			}
+			if substring != "" && !strings.Contains(entry.Message, substring) {
+				continue
+			}
+			record := fluentRecord(entry)
+			record["time"] = entry.Time.Format(time.RFC3339Nano)
+			records = append(records, record)
+		}
+		for i, j := 0, len(records)-1; i < j; i, j = i+1, j-1 {
+			records[i], records[j] = records[j], records[i]
+		}
+
+		w.Header().Set("Content-Type", "application/json")
+		w.Header().Set("Cache-Control", "no-store")
+		_ = json.NewEncoder(w).Encode(records)
+	})
+}
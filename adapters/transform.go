@@ -0,0 +1,93 @@
+package adapters
+
+import (
+	"context"
+
+	"github.com/qishenonly/logger"
+)
+
+// TransformFunc 日志条目转换函数
+type TransformFunc func(logger.LogEntry) logger.LogEntry
+
+// TransformAdapter 在日志条目进入被包装的适配器之前对其进行转换
+type TransformAdapter struct {
+	base logger.LogAdapter
+	fn   TransformFunc
+}
+
+// Transform 包装一个适配器，每条日志在交给base之前都会经过fn处理
+// fn拿到的是条目的副本，Order和Properties中嵌套的map、切片也会被复制，修改不会影响其他适配器看到的同一条日志；
+// 指针、结构体等其他类型的属性值仍与原日志共享，需要修改时应替换为新值
+func Transform(base logger.LogAdapter, fn func(logger.LogEntry) logger.LogEntry) logger.LogAdapter {
+	return &TransformAdapter{
+		base: base,
+		fn:   fn,
+	}
+}
+
+// Name 返回被包装适配器的名称
+func (a *TransformAdapter) Name() string {
+	return a.base.Name()
+}
+
+// Init 初始化被包装的适配器
+func (a *TransformAdapter) Init(config map[string]interface{}) error {
+	return a.base.Init(config)
+}
+
+// Process 复制并转换日志条目后交给被包装的适配器
+func (a *TransformAdapter) Process(ctx context.Context, entry logger.LogEntry) error {
+	if a.fn != nil {
+		entry = a.fn(copyEntry(entry))
+	}
+	return a.base.Process(ctx, entry)
+}
+
+// Flush 刷新被包装的适配器
+func (a *TransformAdapter) Flush() error {
+	return a.base.Flush()
+}
+
+// Close 关闭被包装的适配器
+func (a *TransformAdapter) Close() error {
+	return a.base.Close()
+}
+
+// copyEntry 复制日志条目，Order和Properties(包括嵌套的map和切片)会被复制
+func copyEntry(entry logger.LogEntry) logger.LogEntry {
+	if entry.Properties != nil {
+		entry.Properties = copyValue(entry.Properties).(map[string]interface{})
+	}
+	if entry.Order != nil {
+		entry.Order = append([]string(nil), entry.Order...)
+	}
+	return entry
+}
+
+// copyValue 递归复制属性值中的map和切片，其他类型原样返回
+func copyValue(v interface{}) interface{} {
+	switch v := v.(type) {
+	case map[string]interface{}:
+		m := make(map[string]interface{}, len(v))
+		for k, item := range v {
+			m[k] = copyValue(item)
+		}
+		return m
+	case []interface{}:
+		s := make([]interface{}, len(v))
+		for i, item := range v {
+			s[i] = copyValue(item)
+		}
+		return s
+	case map[string]string:
+		m := make(map[string]string, len(v))
+		for k, item := range v {
+			m[k] = item
+		}
+		return m
+	case []string:
+		return append([]string(nil), v...)
+	default:
+		return v
+	}
+}
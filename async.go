@@ -0,0 +1,141 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// 异步输出队列满时的处理策略
+const (
+	AsyncPolicyDrop  = "drop"  // 丢弃新日志并计入Stats().AsyncDropped，调用方不会被阻塞
+	AsyncPolicyBlock = "block" // 阻塞调用方直到队列有空位，不丢失日志
+)
+
+// asyncBufferPool 异步输出复制日志内容使用的缓冲区池
+var asyncBufferPool = buffer.NewPool()
+
+// asyncItem 异步输出队列中的一项，data为待写出的内容，synced不为空时表示Sync请求
+type asyncItem struct {
+	data   *buffer.Buffer
+	synced chan error
+}
+
+// asyncWriter 由单独协程写出的输出，日志调用方只需复制已编码的内容放入队列
+// Sync等待此前入队的内容全部写出后再刷新底层输出，停止时写出队列中剩余的内容
+type asyncWriter struct {
+	ws      zapcore.WriteSyncer
+	block   bool
+	queue   chan asyncItem
+	quit    chan struct{} // 停止时关闭，唤醒阻塞在队列上的调用方
+	done    chan struct{} // 写出协程退出时关闭
+	onError func(error)
+
+	mu       sync.RWMutex // 写锁保证停止后不再向队列发送
+	stopped  bool
+	stopOnce sync.Once
+	dropped  atomic.Uint64
+}
+
+// newAsyncWriter 创建容量为size条的异步输出并启动写出协程，onError接收底层输出的写入错误
+func newAsyncWriter(ws zapcore.WriteSyncer, size int, policy string, onError func(error)) (*asyncWriter, error) {
+	switch policy {
+	case "", AsyncPolicyDrop, AsyncPolicyBlock:
+	default:
+		return nil, fmt.Errorf("unknown async policy: %s", policy)
+	}
+	w := &asyncWriter{
+		ws:      ws,
+		block:   policy == AsyncPolicyBlock,
+		queue:   make(chan asyncItem, size),
+		quit:    make(chan struct{}),
+		done:    make(chan struct{}),
+		onError: onError,
+	}
+	go w.run()
+	return w, nil
+}
+
+// run 依次写出队列中的内容，队列关闭后退出
+func (w *asyncWriter) run() {
+	defer close(w.done)
+	for item := range w.queue {
+		if item.synced != nil {
+			item.synced <- w.ws.Sync()
+			continue
+		}
+		if _, err := w.ws.Write(item.data.Bytes()); err != nil && w.onError != nil {
+			w.onError(err)
+		}
+		item.data.Free()
+	}
+}
+
+// Write 实现zapcore.WriteSyncer接口，复制内容后入队立即返回，停止后直接写入底层输出
+func (w *asyncWriter) Write(p []byte) (int, error) {
+	w.mu.RLock()
+	defer w.mu.RUnlock()
+	if w.stopped {
+		return w.ws.Write(p)
+	}
+
+	data := asyncBufferPool.Get()
+	_, _ = data.Write(p)
+	item := asyncItem{data: data}
+	if w.block {
+		select {
+		case w.queue <- item:
+		case <-w.quit:
+			data.Free()
+			return w.ws.Write(p)
+		}
+		return len(p), nil
+	}
+
+	select {
+	case w.queue <- item:
+	default:
+		data.Free()
+		w.dropped.Add(1)
+	}
+	return len(p), nil
+}
+
+// Sync 实现zapcore.WriteSyncer接口，等待此前入队的内容写出后刷新底层输出
+func (w *asyncWriter) Sync() error {
+	w.mu.RLock()
+	if w.stopped {
+		w.mu.RUnlock()
+		return w.ws.Sync()
+	}
+	synced := make(chan error, 1)
+	select {
+	case w.queue <- asyncItem{synced: synced}:
+	case <-w.quit:
+		w.mu.RUnlock()
+		<-w.done
+		return w.ws.Sync()
+	}
+	w.mu.RUnlock()
+	return <-synced
+}
+
+// Stop 写出队列中剩余的内容并停止写出协程，之后的写入直接交给底层输出，可以多次调用
+func (w *asyncWriter) Stop() {
+	w.stopOnce.Do(func() {
+		close(w.quit)
+		w.mu.Lock()
+		w.stopped = true
+		close(w.queue)
+		w.mu.Unlock()
+	})
+	<-w.done
+}
+
+// Dropped 返回队列满时被丢弃的日志数
+func (w *asyncWriter) Dropped() uint64 {
+	return w.dropped.Load()
+}
@@ -0,0 +1,85 @@
+package logger
+
+import (
+	"sort"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+// LogAt 以指定的时间写入一条日志，用于重放历史事件或补录外部导入的日志
+// 时间同时用于输出和适配器的LogEntry.Time，文件仍按当前时间选择和旋转；级别无法识别时日志被丢弃并写入内部错误输出
+func (l *ZapLogger) LogAt(t time.Time, level string, msg string, fields map[string]interface{}) {
+	l.logAt(t, level, msg, fields)
+}
+
+// DebugAt 以指定的时间写入一条debug日志
+func (l *ZapLogger) DebugAt(t time.Time, msg string, fields map[string]interface{}) {
+	l.logAt(t, "debug", msg, fields)
+}
+
+// InfoAt 以指定的时间写入一条info日志
+func (l *ZapLogger) InfoAt(t time.Time, msg string, fields map[string]interface{}) {
+	l.logAt(t, "info", msg, fields)
+}
+
+// WarnAt 以指定的时间写入一条warn日志
+func (l *ZapLogger) WarnAt(t time.Time, msg string, fields map[string]interface{}) {
+	l.logAt(t, "warn", msg, fields)
+}
+
+// ErrorAt 以指定的时间写入一条error日志
+func (l *ZapLogger) ErrorAt(t time.Time, msg string, fields map[string]interface{}) {
+	l.logAt(t, "error", msg, fields)
+}
+
+// logAt 写入指定时间的日志，供LogAt及其便捷方法调用，调用层级固定以保证调用位置正确
+func (l *ZapLogger) logAt(t time.Time, level string, msg string, fields map[string]interface{}) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		l.internalError("log at failed: %v", err)
+		return
+	}
+	if l.suppressed(lvl) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(lvl) {
+		return
+	}
+
+	meta := l.entryMeta()
+	if dispatch {
+		properties := make(map[string]interface{}, len(fields))
+		for k, v := range fields {
+			properties[k] = l.propertyValue(v)
+		}
+		l.sendToAdaptersAt(t, lvl.String(), msg, metaProperties(meta, properties), nil)
+	}
+
+	// 跳过logAt和公开方法两层，调用位置指向用户代码
+	ce := l.logger.WithOptions(zap.AddCallerSkip(1)).Check(lvl, msg)
+	if ce == nil {
+		return
+	}
+	ce.Time = t
+
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	zapFields := make([]zap.Field, 0, len(meta)/2+len(keys))
+	for i := 0; i+1 < len(meta); i += 2 {
+		zapFields = append(zapFields, zap.Any(meta[i].(string), meta[i+1]))
+	}
+	for _, k := range keys {
+		zapFields = append(zapFields, zap.Any(k, fields[k]))
+	}
+	ce.Write(zapFields...)
+}
+
+// LogAt 使用默认日志实例以指定的时间写入一条日志
+func LogAt(t time.Time, level string, msg string, fields map[string]interface{}) {
+	Default().LogAt(t, level, msg, fields)
+}
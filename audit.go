@@ -0,0 +1,78 @@
+package logger
+
+import (
+	"context"
+	"errors"
+	"fmt"
+)
+
+// AuditLevel 审计日志条目的级别
+const AuditLevel = "audit"
+
+// Audit 将一条审计日志同步交给审计适配器处理并刷新，返回前日志已经持久化
+// 审计日志与普通适配器分发完全隔离：不经过分发队列、不采样、不丢弃，多个调用按顺序串行处理
+// 未配置审计适配器或处理失败时返回错误，由调用方决定如何处理
+func (l *ZapLogger) Audit(msg string, fields map[string]interface{}) error {
+	if l.audit == nil {
+		return fmt.Errorf("audit adapter not configured")
+	}
+
+	properties := make(map[string]interface{}, len(l.adapterProperties)+len(l.fields)+len(fields))
+	for k, v := range l.adapterProperties {
+		properties[k] = v
+	}
+	for k, v := range l.fields {
+		properties[k] = v
+	}
+	for k, v := range fields {
+		properties[k] = l.propertyValue(v)
+	}
+	if l.omitEmpty {
+		properties = omitEmptyProperties(properties)
+	}
+	properties = l.fieldLimit.limitProperties(properties)
+
+	l.auditMu.Lock()
+	defer l.auditMu.Unlock()
+
+	entry := LogEntry{
+		Level:      AuditLevel,
+		Time:       clockNow(l.clock),
+		Message:    msg,
+		NodeID:     l.nodeID,
+		Module:     l.module,
+		IP:         l.ip,
+		Properties: properties,
+		Order:      l.fieldOrder,
+	}
+	process := func() error { return l.audit.Process(context.Background(), entry) }
+	if err := l.safeCall(l.audit, "process", process); err != nil {
+		return fmt.Errorf("audit adapter %s process failed: %v", l.audit.Name(), err)
+	}
+	if err := l.safeCall(l.audit, "flush", l.audit.Flush); err != nil {
+		return fmt.Errorf("audit adapter %s flush failed: %v", l.audit.Name(), err)
+	}
+	return nil
+}
+
+// closeAudit 关闭审计适配器，等待正在进行的审计日志完成，返回刷新和关闭的错误
+func (g *adapterGroup) closeAudit() error {
+	if g.audit == nil {
+		return nil
+	}
+
+	g.auditMu.Lock()
+	defer g.auditMu.Unlock()
+	var errs []error
+	if err := g.safeCall(g.audit, "flush", g.audit.Flush); err != nil {
+		err = fmt.Errorf("flush audit adapter %s failed: %w", g.audit.Name(), err)
+		g.internalError("%v", err)
+		errs = append(errs, err)
+	}
+	if err := g.safeCall(g.audit, "close", g.audit.Close); err != nil {
+		err = fmt.Errorf("close audit adapter %s failed: %w", g.audit.Name(), err)
+		g.internalError("%v", err)
+		errs = append(errs, err)
+	}
+	return errors.Join(errs...)
+}
@@ -0,0 +1,190 @@
+package logger
+
+import (
+	"context"
+	"io"
+	"sort"
+	"sync"
+	"testing"
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// 基准参考值（go test -run ^$ -bench . -benchmem）：
+//
+//	BenchmarkInfo/NoAdapters             0 allocs/op
+//	BenchmarkInfo/Disabled               0 allocs/op
+//	BenchmarkInfo/TwoBufferingAdapters   6 allocs/op   ~630 B/op
+//	BenchmarkInfow/NoAdapters            1 allocs/op
+//	BenchmarkInfow/Disabled              0 allocs/op
+//
+// CI应关注以上allocs/op，特别是没有适配器时的Info路径应保持0分配。
+//
+// 文件输出单次调用的p99延迟（BenchmarkFileOutputLatency，p99-ns）：
+//
+//	Sync         ~12000 ns
+//	AsyncBlock    ~9100 ns
+//	AsyncDrop     ~6900 ns
+//
+// 适配器饱和时的分发（BenchmarkDispatchSaturation，队列64条，单个慢适配器）：
+//
+//	Drop               ~600 ns/op      ~1.0 dropped/op
+//	BlockWithTimeout   ~550000 ns/op   ~0.5 dropped/op（1ms超时）
+
+// bufferingAdapter 基准测试用的缓冲适配器
+type bufferingAdapter struct {
+	name   string
+	mu     sync.Mutex
+	buffer []LogEntry
+}
+
+func (a *bufferingAdapter) Name() string                             { return a.name }
+func (a *bufferingAdapter) Init(config map[string]interface{}) error { return nil }
+func (a *bufferingAdapter) Flush() error                             { return nil }
+func (a *bufferingAdapter) Close() error                             { return nil }
+
+func (a *bufferingAdapter) Process(ctx context.Context, entry LogEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.buffer = append(a.buffer, entry)
+	if len(a.buffer) >= 1000 {
+		a.buffer = a.buffer[:0]
+	}
+	return nil
+}
+
+// newBenchLogger 创建输出到io.Discard的日志实例
+func newBenchLogger(level zapcore.Level, adapters ...LogAdapter) *ZapLogger {
+	encoderConfig := zap.NewProductionEncoderConfig()
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(encoderConfig), zapcore.AddSync(io.Discard), level)
+	logger := zap.New(core)
+	return &ZapLogger{
+		logger:       logger,
+		sugar:        logger.Sugar(),
+		adapterGroup: &adapterGroup{adapters: adapters},
+		module:       "bench",
+	}
+}
+
+func BenchmarkInfo(b *testing.B) {
+	b.Run("NoAdapters", func(b *testing.B) {
+		l := newBenchLogger(zap.InfoLevel)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Info("benchmark message")
+		}
+	})
+
+	b.Run("Disabled", func(b *testing.B) {
+		l := newBenchLogger(zap.WarnLevel)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Info("benchmark message")
+		}
+	})
+
+	b.Run("TwoBufferingAdapters", func(b *testing.B) {
+		l := newBenchLogger(zap.InfoLevel,
+			&bufferingAdapter{name: "first"},
+			&bufferingAdapter{name: "second"},
+		)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Info("benchmark message")
+		}
+	})
+}
+
+func BenchmarkInfow(b *testing.B) {
+	b.Run("NoAdapters", func(b *testing.B) {
+		l := newBenchLogger(zap.InfoLevel)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Infow("benchmark message", "user", "alice", "attempt", 3)
+		}
+	})
+
+	b.Run("Disabled", func(b *testing.B) {
+		l := newBenchLogger(zap.WarnLevel)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Infow("benchmark message", "user", "alice", "attempt", 3)
+		}
+	})
+}
+
+// BenchmarkFileOutputLatency 比较文件输出同步写入和WithAsyncCore异步写入时单次日志调用的p99延迟
+func BenchmarkFileOutputLatency(b *testing.B) {
+	run := func(b *testing.B, opts ...Option) {
+		opts = append([]Option{WithFileOutput(), WithPath(b.TempDir())}, opts...)
+		logger, err := NewWithOptions(opts...)
+		if err != nil {
+			b.Fatal(err)
+		}
+		defer logger.Close()
+
+		latencies := make([]time.Duration, b.N)
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			start := time.Now()
+			logger.(*ZapLogger).Infow("benchmark message", "user", "alice", "attempt", i)
+			latencies[i] = time.Since(start)
+		}
+		b.StopTimer()
+
+		sort.Slice(latencies, func(i, j int) bool { return latencies[i] < latencies[j] })
+		b.ReportMetric(float64(latencies[len(latencies)*99/100].Nanoseconds()), "p99-ns")
+	}
+
+	b.Run("Sync", func(b *testing.B) { run(b) })
+	b.Run("AsyncBlock", func(b *testing.B) { run(b, WithAsyncCore(4096), WithAsyncCorePolicy(AsyncPolicyBlock)) })
+	b.Run("AsyncDrop", func(b *testing.B) { run(b, WithAsyncCore(4096)) })
+}
+
+// slowAdapter 每条日志耗时固定时间的适配器，用于模拟饱和的后端
+type slowAdapter struct {
+	delay time.Duration
+}
+
+func (a *slowAdapter) Name() string                             { return "slow" }
+func (a *slowAdapter) Init(config map[string]interface{}) error { return nil }
+func (a *slowAdapter) Flush() error                             { return nil }
+func (a *slowAdapter) Close() error                             { return nil }
+
+func (a *slowAdapter) Process(ctx context.Context, entry LogEntry) error {
+	time.Sleep(a.delay)
+	return nil
+}
+
+func BenchmarkDispatchSaturation(b *testing.B) {
+	run := func(b *testing.B, policy BackpressurePolicy) {
+		l := newBenchLogger(zap.InfoLevel, &slowAdapter{delay: 20 * time.Microsecond})
+		l.dispatchBuffer = 64
+		l.dispatchWorkers = 1
+		l.backpressure = policy
+
+		b.ReportAllocs()
+		b.ResetTimer()
+		for i := 0; i < b.N; i++ {
+			l.Info("benchmark message")
+		}
+		b.StopTimer()
+
+		stats := l.Stats()
+		_ = l.Close()
+		b.ReportMetric(float64(stats.Dropped)/float64(b.N), "dropped/op")
+		b.ReportMetric(float64(stats.QueueFull)/float64(b.N), "full/op")
+		b.ReportMetric(float64(stats.QueueHighMark), "high-mark")
+	}
+
+	b.Run("Drop", func(b *testing.B) { run(b, DropWhenFull()) })
+	b.Run("BlockWithTimeout", func(b *testing.B) { run(b, BlockWithTimeout(time.Millisecond)) })
+}
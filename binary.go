@@ -0,0 +1,149 @@
+package logger
+
+import (
+	"encoding/base64"
+	"encoding/hex"
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// BinaryBase64 二进制数据使用base64编码
+	BinaryBase64 = "base64"
+	// BinaryHex 二进制数据使用十六进制编码
+	BinaryHex = "hex"
+)
+
+// defaultMaxBinarySize 二进制数据默认最多编码的字节数
+const defaultMaxBinarySize = 4096
+
+// binaryEncoder 将[]byte编码为可读字符串，超出大小限制的部分被截断
+// 为空时使用base64和默认大小限制
+type binaryEncoder struct {
+	hex     bool
+	maxSize int
+}
+
+// newBinaryEncoder 按配置创建二进制编码器，format为空时使用base64，maxSize小于等于0时使用默认值
+func newBinaryEncoder(format string, maxSize int) (*binaryEncoder, error) {
+	enc := &binaryEncoder{maxSize: maxSize}
+	switch format {
+	case "", BinaryBase64:
+	case BinaryHex:
+		enc.hex = true
+	default:
+		return nil, fmt.Errorf("unknown binary encoding: %s", format)
+	}
+	return enc, nil
+}
+
+// encode 编码二进制数据，截断时在末尾注明原始大小
+func (e *binaryEncoder) encode(data []byte) string {
+	maxSize := defaultMaxBinarySize
+	useHex := false
+	if e != nil {
+		if e.maxSize > 0 {
+			maxSize = e.maxSize
+		}
+		useHex = e.hex
+	}
+
+	size := len(data)
+	truncated := size > maxSize
+	if truncated {
+		data = data[:maxSize]
+	}
+
+	var s string
+	if useHex {
+		s = hex.EncodeToString(data)
+	} else {
+		s = base64.StdEncoding.EncodeToString(data)
+	}
+	if truncated {
+		s += fmt.Sprintf("...(truncated, %d bytes)", size)
+	}
+	return s
+}
+
+// args 将消息参数中的[]byte替换为编码后的字符串，没有[]byte时原样返回
+func (e *binaryEncoder) args(args []any) []any {
+	for i, arg := range args {
+		if _, ok := arg.([]byte); !ok {
+			continue
+		}
+		replaced := append([]any(nil), args...)
+		for j := i; j < len(replaced); j++ {
+			if data, ok := replaced[j].([]byte); ok {
+				replaced[j] = e.encode(data)
+			}
+		}
+		return replaced
+	}
+	return args
+}
+
+// properties 将属性中的[]byte替换为编码后的字符串，没有[]byte时原样返回
+func (e *binaryEncoder) properties(properties map[string]interface{}) map[string]interface{} {
+	var replaced map[string]interface{}
+	for k, v := range properties {
+		data, ok := v.([]byte)
+		if !ok {
+			continue
+		}
+		if replaced == nil {
+			replaced = make(map[string]interface{}, len(properties))
+			for key, value := range properties {
+				replaced[key] = value
+			}
+		}
+		replaced[k] = e.encode(data)
+	}
+	if replaced == nil {
+		return properties
+	}
+	return replaced
+}
+
+// binaryCore 将二进制字段编码为字符串后再写入，使输出核心中的二进制数据与适配器一致
+type binaryCore struct {
+	zapcore.Core
+	enc *binaryEncoder
+}
+
+// With 实现zapcore.Core接口
+func (c *binaryCore) With(fields []zapcore.Field) zapcore.Core {
+	return &binaryCore{Core: c.Core.With(c.fields(fields)), enc: c.enc}
+}
+
+// Check 实现zapcore.Core接口
+func (c *binaryCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *binaryCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, c.fields(fields))
+}
+
+// fields 将二进制字段替换为编码后的字符串字段，没有二进制字段时原样返回
+func (c *binaryCore) fields(fields []zapcore.Field) []zapcore.Field {
+	for i, field := range fields {
+		if field.Type != zapcore.BinaryType {
+			continue
+		}
+		replaced := append([]zapcore.Field(nil), fields...)
+		for j := i; j < len(replaced); j++ {
+			if replaced[j].Type == zapcore.BinaryType {
+				replaced[j] = zap.String(replaced[j].Key, c.enc.encode(replaced[j].Interface.([]byte)))
+			}
+		}
+		return replaced
+	}
+	return fields
+}
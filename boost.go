@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"sync"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// coreLevel 输出核心的级别及其配置值，BoostLevel临时降低级别后恢复为配置值
+type coreLevel struct {
+	level zap.AtomicLevel
+	base  zapcore.Level
+}
+
+// newCoreLevel 创建输出核心使用的级别并记录到levels
+func newCoreLevel(levels *[]*coreLevel, level zapcore.Level) zap.AtomicLevel {
+	cl := &coreLevel{level: zap.NewAtomicLevelAt(level), base: level}
+	*levels = append(*levels, cl)
+	return cl.level
+}
+
+// BoostLevel 临时将输出核心的级别和最低级别闸门降低到level，返回恢复函数
+// 嵌套或并发的提升互不影响：生效的级别总是所有未结束提升中最低的，全部结束后恢复配置的级别
+// 级别无法识别时不做调整，返回的恢复函数可以重复调用
+func (l *ZapLogger) BoostLevel(level string) func() {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return func() {}
+	}
+
+	l.levelMu.Lock()
+	l.boosts = append(l.boosts, lvl)
+	l.applyLevels()
+	l.levelMu.Unlock()
+
+	var once sync.Once
+	return func() {
+		once.Do(func() {
+			l.levelMu.Lock()
+			defer l.levelMu.Unlock()
+			for i, boost := range l.boosts {
+				if boost == lvl {
+					l.boosts = append(l.boosts[:i], l.boosts[i+1:]...)
+					break
+				}
+			}
+			l.applyLevels()
+		})
+	}
+}
+
+// SetLevel 将所有输出核心的级别改为level，之后的日志立即按新级别过滤，子日志共享同一级别
+// 分别配置的控制台和文件级别统一改为level；正在生效的BoostLevel结束后恢复为新的级别；级别无法识别时返回错误且不做修改
+func (l *ZapLogger) SetLevel(level string) error {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return err
+	}
+
+	l.levelMu.Lock()
+	defer l.levelMu.Unlock()
+	for _, cl := range l.coreLevels {
+		cl.base = lvl
+	}
+	l.applyLevels()
+	return nil
+}
+
+// applyLevels 根据配置的级别和正在生效的提升更新输出核心级别和闸门（调用前需要获取levelMu）
+func (g *adapterGroup) applyLevels() {
+	boosted := len(g.boosts) > 0
+	lowest := zapcore.FatalLevel
+	for _, boost := range g.boosts {
+		if boost < lowest {
+			lowest = boost
+		}
+	}
+
+	for _, cl := range g.coreLevels {
+		level := cl.base
+		if boosted && lowest < level {
+			level = lowest
+		}
+		cl.level.SetLevel(level)
+	}
+
+	floor := g.baseFloor
+	if boosted && int32(lowest-zapcore.DebugLevel) < floor {
+		floor = int32(lowest - zapcore.DebugLevel)
+	}
+	g.floor.Store(floor)
+}
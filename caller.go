@@ -0,0 +1,53 @@
+package logger
+
+import (
+	"fmt"
+	"path/filepath"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// CallerShort 调用位置只保留最后一级目录和文件名，如pkg/handler.go:42
+	CallerShort = "short"
+	// CallerFull 调用位置使用完整的文件路径
+	CallerFull = "full"
+	// CallerPackage 调用位置使用包导入路径加文件名，如github.com/org/repo/pkg/handler.go:42
+	CallerPackage = "package"
+)
+
+// newCallerEncoder 按格式创建调用位置编码器，格式为空时使用short
+func newCallerEncoder(format string) (zapcore.CallerEncoder, error) {
+	switch format {
+	case "", CallerShort:
+		return zapcore.ShortCallerEncoder, nil
+	case CallerFull:
+		return zapcore.FullCallerEncoder, nil
+	case CallerPackage:
+		return packageCallerEncoder, nil
+	default:
+		return nil, fmt.Errorf("unknown caller format: %s", format)
+	}
+}
+
+// packageCallerEncoder 以包导入路径加文件名编码调用位置，无法取得函数名时退回short格式
+func packageCallerEncoder(caller zapcore.EntryCaller, enc zapcore.PrimitiveArrayEncoder) {
+	pkg := functionPackage(caller.Function)
+	if !caller.Defined || pkg == "" {
+		zapcore.ShortCallerEncoder(caller, enc)
+		return
+	}
+	enc.AppendString(pkg + "/" + filepath.Base(caller.File) + ":" + strconv.Itoa(caller.Line))
+}
+
+// functionPackage 从完整函数名中取出包导入路径，如github.com/org/repo/pkg.(*T).Method返回github.com/org/repo/pkg
+func functionPackage(function string) string {
+	slash := strings.LastIndex(function, "/")
+	dot := strings.Index(function[slash+1:], ".")
+	if dot < 0 {
+		return ""
+	}
+	return function[:slash+1+dot]
+}
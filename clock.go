@@ -0,0 +1,49 @@
+package logger
+
+import "time"
+
+// Clock 日志使用的时钟，测试中可以注入固定时间以获得确定的时间戳
+type Clock interface {
+	// Now 返回当前时间
+	Now() time.Time
+}
+
+// systemClock 使用系统时间的时钟
+type systemClock struct{}
+
+// Now 返回系统当前时间
+func (systemClock) Now() time.Time {
+	return time.Now()
+}
+
+// SystemClock 默认时钟，返回系统当前时间
+var SystemClock Clock = systemClock{}
+
+// utcClock 返回UTC时间的系统时钟
+type utcClock struct{}
+
+// Now 返回UTC表示的系统当前时间
+func (utcClock) Now() time.Time {
+	return time.Now().UTC()
+}
+
+// UTCClock 返回UTC时间的系统时钟，日志时间戳和文件按天切换都使用UTC
+var UTCClock Clock = utcClock{}
+
+// zapClock 将Clock适配为zapcore.Clock
+type zapClock struct {
+	Clock
+}
+
+// NewTicker 实现zapcore.Clock接口，使用系统计时器
+func (zapClock) NewTicker(d time.Duration) *time.Ticker {
+	return time.NewTicker(d)
+}
+
+// clockNow 返回时钟的当前时间，时钟为空时使用系统时间
+func clockNow(clock Clock) time.Time {
+	if clock == nil {
+		return time.Now()
+	}
+	return clock.Now()
+}
@@ -0,0 +1,187 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"strconv"
+	"strings"
+	"time"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// ConsoleFormatText 默认的控制台格式，字段以JSON追加在行尾
+	ConsoleFormatText = "console"
+	// ConsoleFormatHybrid 可读的时间、级别、消息，字段以key=value追加在同一行
+	ConsoleFormatHybrid = "hybrid"
+	// ConsoleFormatJSON 每行一个JSON对象(NDJSON)，与文件输出的字段相同，用于容器环境由节点代理采集标准输出
+	ConsoleFormatJSON = "json"
+)
+
+const (
+	// ConsoleSyncAlways 标准输出不是终端时每条日志写入后Sync
+	ConsoleSyncAlways = "always"
+	// ConsoleSyncError 标准输出不是终端时error及以上级别写入后Sync
+	ConsoleSyncError = "error"
+)
+
+// levelColors 支持的控制台颜色名称及其ANSI前景色代码
+var levelColors = map[string]string{
+	"black":   "30",
+	"red":     "31",
+	"green":   "32",
+	"yellow":  "33",
+	"blue":    "34",
+	"magenta": "35",
+	"cyan":    "36",
+	"white":   "37",
+	"gray":    "90",
+}
+
+// newLevelDecorationEncoder 创建控制台使用的级别编码器
+// decorations为级别到前缀符号的映射，colors为级别到颜色名称的映射，均为空时返回nil
+func newLevelDecorationEncoder(decorations map[string]string, colors map[string]string) zapcore.LevelEncoder {
+	if len(decorations) == 0 && len(colors) == 0 {
+		return nil
+	}
+
+	return func(level zapcore.Level, enc zapcore.PrimitiveArrayEncoder) {
+		name := level.String()
+		text := level.CapitalString()
+		if prefix, ok := decorations[name]; ok && prefix != "" {
+			text = prefix + " " + text
+		}
+		if code, ok := levelColors[colors[name]]; ok {
+			text = "\x1b[" + code + "m" + text + "\x1b[0m"
+		}
+		enc.AppendString(text)
+	}
+}
+
+// newConsoleEncoder 按格式创建控制台编码器，格式为空时使用默认的console格式
+// inline为true时字段以key=value拼接到消息中，不再作为单独的结构化字段输出
+func newConsoleEncoder(format string, config zapcore.EncoderConfig, inline bool) (zapcore.Encoder, error) {
+	switch format {
+	case "", ConsoleFormatText:
+		if inline {
+			return newHybridEncoder(config, true), nil
+		}
+		return zapcore.NewConsoleEncoder(config), nil
+	case ConsoleFormatHybrid:
+		return newHybridEncoder(config, inline), nil
+	default:
+		return nil, fmt.Errorf("unknown console format: %s", format)
+	}
+}
+
+// hybridEncoder 用控制台编码器输出时间、级别、调用位置和消息，字段按key排序以key=value追加在同一行
+// inline为true时key=value拼接在消息之后，成为消息列的一部分，供只读取消息列的旧工具解析
+type hybridEncoder struct {
+	*zapcore.MapObjectEncoder // With添加的字段
+	console                   zapcore.Encoder
+	lineEnding                string
+	inline                    bool
+}
+
+// newHybridEncoder 创建hybrid格式的控制台编码器
+func newHybridEncoder(config zapcore.EncoderConfig, inline bool) *hybridEncoder {
+	lineEnding := config.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	return &hybridEncoder{
+		MapObjectEncoder: zapcore.NewMapObjectEncoder(),
+		console:          zapcore.NewConsoleEncoder(config),
+		lineEnding:       lineEnding,
+		inline:           inline,
+	}
+}
+
+// Clone 实现zapcore.Encoder接口
+func (e *hybridEncoder) Clone() zapcore.Encoder {
+	clone := zapcore.NewMapObjectEncoder()
+	for k, v := range e.Fields {
+		clone.Fields[k] = v
+	}
+	return &hybridEncoder{MapObjectEncoder: clone, console: e.console, lineEnding: e.lineEnding, inline: e.inline}
+}
+
+// EncodeEntry 实现zapcore.Encoder接口
+func (e *hybridEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	all := zapcore.NewMapObjectEncoder()
+	for k, v := range e.Fields {
+		all.Fields[k] = v
+	}
+	for _, field := range fields {
+		field.AddTo(all)
+	}
+
+	keys := make([]string, 0, len(all.Fields))
+	for k := range all.Fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var pairs strings.Builder
+	for _, k := range keys {
+		pairs.WriteByte(' ')
+		pairs.WriteString(k)
+		pairs.WriteByte('=')
+		pairs.WriteString(hybridValue(all.Fields[k]))
+	}
+
+	if e.inline {
+		ent.Message += pairs.String()
+	}
+	line, err := e.console.EncodeEntry(ent, nil)
+	if err != nil {
+		return nil, err
+	}
+	if e.inline {
+		return line, nil
+	}
+
+	line.TrimNewline()
+	line.AppendString(pairs.String())
+	line.AppendString(e.lineEnding)
+	return line, nil
+}
+
+// hybridValue 格式化字段值：含空白或特殊字符的字符串加引号，对象和数组输出紧凑的JSON
+func hybridValue(value interface{}) string {
+	switch v := value.(type) {
+	case string:
+		if v == "" || strings.ContainsAny(v, " \t\r\n=\"") {
+			return strconv.Quote(v)
+		}
+		return v
+	case time.Time:
+		return v.Format(iso8601Layout)
+	case time.Duration:
+		return v.String()
+	case bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, uintptr, float32, float64, complex64, complex128:
+		return fmt.Sprint(v)
+	case error:
+		return strconv.Quote(v.Error())
+	default:
+		data, err := json.Marshal(v)
+		if err != nil {
+			return strconv.Quote(fmt.Sprint(v))
+		}
+		return string(data)
+	}
+}
+
+// splitLevel 按stderr级别拆分控制台核心的级别，stderr为true时只接受达到split的级别，否则只接受低于split的级别
+type splitLevel struct {
+	zapcore.LevelEnabler
+	split  zapcore.Level
+	stderr bool
+}
+
+func (l splitLevel) Enabled(lvl zapcore.Level) bool {
+	return l.LevelEnabler.Enabled(lvl) && (lvl >= l.split) == l.stderr
+}
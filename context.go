@@ -0,0 +1,166 @@
+package logger
+
+import (
+	"context"
+	"sort"
+)
+
+// contextKey 日志包在context中使用的键类型，避免与其他包冲突
+type contextKey int
+
+const (
+	loggerContextKey contextKey = iota
+	traceIDContextKey
+	spanIDContextKey
+	requestIDContextKey
+	fieldsContextKey
+)
+
+// 从context中提取的字段名
+const (
+	TraceIDField   = "trace_id"
+	SpanIDField    = "span_id"
+	RequestIDField = "request_id"
+)
+
+// ContextKey 供WithContextFields按名称查找的context key类型，避免直接使用字符串作为key
+type ContextKey string
+
+// contextFieldValue 按字段名从context中查找值，依次查找ContextKey(name)、内置的trace ID等键和字符串name，
+// 没有找到或值为空字符串时返回false
+func contextFieldValue(ctx context.Context, name string) (interface{}, bool) {
+	value := ctx.Value(ContextKey(name))
+	if value == nil {
+		switch name {
+		case TraceIDField:
+			value = ctx.Value(traceIDContextKey)
+		case SpanIDField:
+			value = ctx.Value(spanIDContextKey)
+		case RequestIDField:
+			value = ctx.Value(requestIDContextKey)
+		}
+	}
+	if value == nil {
+		value = ctx.Value(name)
+	}
+	if value == nil || value == "" {
+		return nil, false
+	}
+	return value, true
+}
+
+// WithContext 返回携带ctx的子日志，附加WithContextFields配置的字段中ctx存在的值，没有值的字段不附加
+// 适配器处理子日志的日志时，处理上下文携带ctx中的值，但不随ctx取消，请求结束后异步发送的日志仍会送达；ctx为nil时返回自身
+// 不传递取消是有意的：日志在队列中异步分发，请求的ctx往往在送达前就已取消，传递取消会丢失这些日志；
+// 处理上下文仍随WithBaseContext设置的父上下文取消，并受每条日志的处理超时限制
+func (l *ZapLogger) WithContext(ctx context.Context) Logger {
+	if ctx == nil {
+		return l
+	}
+	var fields map[string]interface{}
+	for _, name := range l.contextFields {
+		if value, ok := contextFieldValue(ctx, name); ok {
+			if fields == nil {
+				fields = make(map[string]interface{}, len(l.contextFields))
+			}
+			fields[name] = value
+		}
+	}
+
+	child := *l.with(fields)
+	child.ctx = ctx
+	return &child
+}
+
+// ContextWithLogger 将日志实例存入context，之后通过FromContext取出
+func ContextWithLogger(ctx context.Context, l Logger) context.Context {
+	return context.WithValue(ctx, loggerContextKey, l)
+}
+
+// WithTraceID 将trace ID存入context
+func WithTraceID(ctx context.Context, traceID string) context.Context {
+	return context.WithValue(ctx, traceIDContextKey, traceID)
+}
+
+// WithSpanID 将span ID存入context
+func WithSpanID(ctx context.Context, spanID string) context.Context {
+	return context.WithValue(ctx, spanIDContextKey, spanID)
+}
+
+// WithRequestID 将请求ID存入context
+func WithRequestID(ctx context.Context, requestID string) context.Context {
+	return context.WithValue(ctx, requestIDContextKey, requestID)
+}
+
+// AddField 返回累积了一个字段的context，FromContext和InfoCtx等函数会附加所有累积的字段
+// 每次调用复制已有字段，不修改父context中的字段，同名字段以后添加的为准
+func AddField(ctx context.Context, key string, value interface{}) context.Context {
+	parent, _ := ctx.Value(fieldsContextKey).(map[string]interface{})
+	fields := make(map[string]interface{}, len(parent)+1)
+	for k, v := range parent {
+		fields[k] = v
+	}
+	fields[key] = value
+	return context.WithValue(ctx, fieldsContextKey, fields)
+}
+
+// FromContext 返回context中的日志实例，没有时使用默认日志实例
+// context中的trace ID、span ID和请求ID会作为字段附加到返回的日志上，未设置的字段不会附加，
+// 之后按key的顺序附加AddField累积的字段
+func FromContext(ctx context.Context) Logger {
+	var l Logger
+	if ctx != nil {
+		l, _ = ctx.Value(loggerContextKey).(Logger)
+	}
+	if l == nil {
+		l = Default()
+	}
+	if ctx == nil {
+		return l
+	}
+
+	for _, f := range []struct {
+		key  contextKey
+		name string
+	}{
+		{traceIDContextKey, TraceIDField},
+		{spanIDContextKey, SpanIDField},
+		{requestIDContextKey, RequestIDField},
+	} {
+		if v, ok := ctx.Value(f.key).(string); ok && v != "" {
+			l = l.WithField(f.name, v)
+		}
+	}
+
+	fields, _ := ctx.Value(fieldsContextKey).(map[string]interface{})
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		l = l.WithField(k, fields[k])
+	}
+	return l
+}
+
+// 以下是带context的全局日志函数，使用FromContext返回的日志实例
+func PanicCtx(ctx context.Context, args ...any) {
+	FromContext(ctx).Panic(args...)
+}
+
+func ErrorCtx(ctx context.Context, args ...any) {
+	FromContext(ctx).Error(args...)
+}
+
+func WarnCtx(ctx context.Context, args ...any) {
+	FromContext(ctx).Warn(args...)
+}
+
+func InfoCtx(ctx context.Context, args ...any) {
+	FromContext(ctx).Info(args...)
+}
+
+func DebugCtx(ctx context.Context, args ...any) {
+	FromContext(ctx).Debug(args...)
+}
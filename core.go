@@ -0,0 +1,79 @@
+package logger
+
+import (
+	"time"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// adapterCore 包装输出核心，使直接通过*zap.Logger写入的日志同样分发到适配器
+// 只用于Zap()返回的原生logger，封装的日志方法自己调用sendToAdapters，避免重复分发
+type adapterCore struct {
+	zapcore.Core
+	logger *ZapLogger
+	fields []zapcore.Field
+}
+
+// With 实现zapcore.Core接口，记录附加字段以便转换为适配器属性
+func (c *adapterCore) With(fields []zapcore.Field) zapcore.Core {
+	merged := make([]zapcore.Field, 0, len(c.fields)+len(fields))
+	merged = append(merged, c.fields...)
+	merged = append(merged, fields...)
+	return &adapterCore{
+		Core:   c.Core.With(fields),
+		logger: c.logger,
+		fields: merged,
+	}
+}
+
+// Enabled 实现zapcore.Core接口，存在适配器时所有级别都需要进入Check
+func (c *adapterCore) Enabled(level zapcore.Level) bool {
+	return c.Core.Enabled(level) || c.logger.hasAdapters()
+}
+
+// Check 实现zapcore.Core接口，输出核心按自身级别判断，存在适配器时总是额外登记分发
+func (c *adapterCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ce = c.Core.Check(ent, ce)
+	if c.logger.hasAdapters() {
+		ce = ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口，仅负责分发到适配器，输出由Check登记的内部核心完成
+func (c *adapterCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	var (
+		properties map[string]interface{}
+		order      []string
+	)
+	if len(c.fields)+len(fields) > 0 {
+		enc := zapcore.NewMapObjectEncoder()
+		order = make([]string, 0, len(c.fields)+len(fields))
+		for _, f := range c.fields {
+			f.AddTo(enc)
+			order = append(order, f.Key)
+		}
+		for _, f := range fields {
+			f.AddTo(enc)
+			order = append(order, f.Key)
+		}
+		properties = make(map[string]interface{}, len(enc.Fields))
+		for k, v := range enc.Fields {
+			properties[k] = c.logger.propertyValue(v)
+		}
+	}
+
+	c.logger.sendToAdaptersAt(time.Time{}, ent.Level.String(), ent.Message, properties, order)
+	return nil
+}
+
+// Zap 返回底层的*zap.Logger，通过它写入的日志同样会分发到适配器
+func (l *ZapLogger) Zap() *zap.Logger {
+	return l.logger.WithOptions(
+		zap.AddCallerSkip(-1),
+		zap.WrapCore(func(core zapcore.Core) zapcore.Core {
+			return &adapterCore{Core: core, logger: l}
+		}),
+	)
+}
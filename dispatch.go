@@ -0,0 +1,300 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+)
+
+const (
+	// defaultDispatchBuffer 默认分发队列容量
+	defaultDispatchBuffer = 1024
+	// defaultDispatchWorkers 默认分发工作协程数，与适配器数量无关
+	defaultDispatchWorkers = 4
+	// adapterProcessTimeout 适配器处理单条日志的超时时间
+	adapterProcessTimeout = 5 * time.Second
+	// defaultDispatchRetryDelay 启用分发重试但未设置间隔时的重试间隔
+	defaultDispatchRetryDelay = 100 * time.Millisecond
+	// defaultMaxAdapterGoroutines 默认同时处理适配器日志的协程数上限
+	defaultMaxAdapterGoroutines = 256
+)
+
+// AdapterLimitPolicy 同时处理适配器日志的协程数达到上限时的处理方式
+type AdapterLimitPolicy string
+
+const (
+	// AdapterLimitBlock 等待其他协程处理完成后再处理，为默认方式
+	AdapterLimitBlock AdapterLimitPolicy = "block"
+	// AdapterLimitDrop 放弃把这条日志交给该适配器并计入Dropped，调用方不等待
+	AdapterLimitDrop AdapterLimitPolicy = "drop"
+)
+
+// newAdapterSlots 创建限制同时处理适配器日志的协程数的信号量，n小于等于0时使用defaultMaxAdapterGoroutines
+func newAdapterSlots(n int) chan struct{} {
+	if n <= 0 {
+		n = defaultMaxAdapterGoroutines
+	}
+	return make(chan struct{}, n)
+}
+
+// acquireAdapterSlot 占用一个处理适配器日志的名额，达到上限时计入AdapterLimitHits，
+// 按AdapterLimitDrop放弃时计入Dropped并返回false，否则等待其他协程释放名额；未创建信号量时不限制
+func (g *adapterGroup) acquireAdapterSlot() bool {
+	if g.adapterSlots == nil {
+		return true
+	}
+	select {
+	case g.adapterSlots <- struct{}{}:
+		return true
+	default:
+	}
+
+	g.stats.adapterLimitHits.Add(1)
+	if g.adapterLimit == AdapterLimitDrop {
+		g.stats.dropped.Add(1)
+		return false
+	}
+	g.adapterSlots <- struct{}{}
+	return true
+}
+
+// releaseAdapterSlot 释放acquireAdapterSlot占用的名额
+func (g *adapterGroup) releaseAdapterSlot() {
+	if g.adapterSlots != nil {
+		<-g.adapterSlots
+	}
+}
+
+// BackpressurePolicy 适配器分发队列满时的处理策略，零值表示立即丢弃新日志
+type BackpressurePolicy struct {
+	timeout time.Duration // 阻塞等待队列空位的最长时间，为0时不等待
+}
+
+// DropWhenFull 队列满时立即丢弃新日志并计入Dropped，日志调用方不会被阻塞，为默认策略
+func DropWhenFull() BackpressurePolicy {
+	return BackpressurePolicy{}
+}
+
+// BlockWithTimeout 队列满时阻塞调用方最多d等待空位，超时后丢弃并计入Dropped
+// 每个调用方各自等待，互不阻塞，d小于等于0时等同于DropWhenFull
+func BlockWithTimeout(d time.Duration) BackpressurePolicy {
+	return BackpressurePolicy{timeout: d}
+}
+
+// dispatcher 有界的适配器分发队列及其工作协程
+// 队列满时新日志被丢弃并计入Dropped，日志调用方不会被慢适配器阻塞；
+// 多个工作协程并行处理时不保证日志到达适配器的顺序
+type dispatcher struct {
+	entries chan LogEntry
+	retries chan dispatchRetry // 到期的重试，与新日志由工作协程交替处理，未启用重试时为空
+	work    sync.WaitGroup     // 已入队但尚未处理完成的日志和重试
+	wg      sync.WaitGroup
+	abort   atomic.Bool // Close的排空超时后丢弃剩余的日志和重试
+}
+
+// dispatchRetry 适配器处理失败后等待重试的日志
+type dispatchRetry struct {
+	adapter LogAdapter
+	entry   LogEntry
+	attempt int // 已经重试的次数
+}
+
+// enqueue 将日志放入分发队列，队列满或已经Close时丢弃
+// 按背压策略等待空位时不持有dispatchMu，已计入work的日志保证队列在其入队或放弃前不会被关闭
+func (g *adapterGroup) enqueue(entry LogEntry) {
+	g.dispatchMu.Lock()
+	if g.dispatchClosed {
+		g.dispatchMu.Unlock()
+		g.stats.dropped.Add(1)
+		return
+	}
+
+	// 首次分发时才启动工作协程
+	if g.dispatcher == nil {
+		g.dispatcher = g.newDispatcher()
+		g.activeDispatcher.Store(g.dispatcher)
+	}
+	d := g.dispatcher
+	g.stats.pending.Add(1)
+	d.work.Add(1)
+	g.dispatchMu.Unlock()
+
+	select {
+	case d.entries <- entry:
+		g.stats.observeQueue(len(d.entries))
+		return
+	default:
+	}
+
+	// 队列已满，按策略等待空位或直接丢弃
+	g.stats.queueFull.Add(1)
+	if timeout := g.backpressure.timeout; timeout > 0 {
+		timer := time.NewTimer(timeout)
+		defer timer.Stop()
+		select {
+		case d.entries <- entry:
+			g.stats.observeQueue(len(d.entries))
+			return
+		case <-timer.C:
+		}
+	}
+	g.stats.pending.Add(-1)
+	g.stats.dropped.Add(1)
+	d.work.Done()
+}
+
+// queueLength 返回分发队列当前的长度和容量，队列尚未创建时长度为0、容量为配置值
+// 不获取dispatchMu，分发的调用方阻塞等待队列空位时也能读取
+func (g *adapterGroup) queueLength() (length, capacity int) {
+	if d := g.activeDispatcher.Load(); d != nil {
+		return len(d.entries), cap(d.entries)
+	}
+	if g.dispatchBuffer > 0 {
+		return 0, g.dispatchBuffer
+	}
+	return 0, defaultDispatchBuffer
+}
+
+// newDispatcher 创建分发队列并启动固定数量的工作协程，未配置工作协程数时使用defaultDispatchWorkers
+func (g *adapterGroup) newDispatcher() *dispatcher {
+	buffer := g.dispatchBuffer
+	if buffer <= 0 {
+		buffer = defaultDispatchBuffer
+	}
+	workers := g.dispatchWorkers
+	if workers <= 0 {
+		workers = defaultDispatchWorkers
+	}
+
+	d := &dispatcher{entries: make(chan LogEntry, buffer)}
+	if g.dispatchRetries > 0 {
+		d.retries = make(chan dispatchRetry, buffer)
+	}
+	for i := 0; i < workers; i++ {
+		d.wg.Add(1)
+		go g.dispatchWorker(d)
+	}
+	return d
+}
+
+// stopDispatcher 停止分发队列，等待队列中剩余的日志及其重试处理完成
+// 之后的日志进入新的队列；正在等待空位的调用方已计入work，全部入队或放弃后才关闭队列
+func (g *adapterGroup) stopDispatcher() {
+	g.dispatchMu.Lock()
+	d := g.dispatcher
+	g.dispatcher = nil
+	g.activeDispatcher.Store(nil)
+	g.dispatchMu.Unlock()
+
+	if d != nil {
+		// 剩余的日志和重试都处理完后不会再有新的日志和重试
+		d.work.Wait()
+		close(d.entries)
+		if d.retries != nil {
+			close(d.retries)
+		}
+		d.wg.Wait()
+	}
+}
+
+// snapshotAdapters 返回当前的适配器列表，可以在不持有adapterMu时遍历，慢适配器不会阻塞添加、移除和替换
+// 列表只会在末尾追加或整体替换，已返回的切片不会被修改
+func (g *adapterGroup) snapshotAdapters() []LogAdapter {
+	g.adapterMu.RLock()
+	defer g.adapterMu.RUnlock()
+	return g.adapters
+}
+
+// dispatchWorker 依次将队列中的日志交给所有适配器处理，然后调用级别回调
+// 启用重试时同时处理到期的重试，两个队列都就绪时随机选择，持续失败的重试不会让新日志饿死
+func (g *adapterGroup) dispatchWorker(d *dispatcher) {
+	defer d.wg.Done()
+
+	entries, retries := d.entries, d.retries
+	for entries != nil || retries != nil {
+		select {
+		case entry, ok := <-entries:
+			if !ok {
+				entries = nil
+				continue
+			}
+			if d.abort.Load() {
+				g.stats.dropped.Add(1)
+				g.stats.pending.Add(-1)
+				d.work.Done()
+				continue
+			}
+			for _, adapter := range g.snapshotAdapters() {
+				if err := g.processAdapter(adapter, entry); err != nil {
+					g.scheduleRetry(d, dispatchRetry{adapter: adapter, entry: entry})
+				}
+			}
+			g.runLevelHooks(entry)
+			g.stats.pending.Add(-1)
+			d.work.Done()
+		case r, ok := <-retries:
+			if !ok {
+				retries = nil
+				continue
+			}
+			g.retry(d, r)
+		}
+	}
+}
+
+// scheduleRetry 在重试次数内延迟后把失败的日志放入重试队列，次数用完或重试队列已满时丢弃并计入RetryDropped
+// 未启用重试时直接返回，与之前一样只计入AdapterErrors
+func (g *adapterGroup) scheduleRetry(d *dispatcher, r dispatchRetry) {
+	if d.retries == nil {
+		return
+	}
+	if d.abort.Load() {
+		g.stats.retryDropped.Add(1)
+		return
+	}
+	if r.attempt >= g.dispatchRetries {
+		g.stats.retryDropped.Add(1)
+		return
+	}
+	r.attempt++
+	delay := g.dispatchRetryDelay
+	if delay <= 0 {
+		delay = defaultDispatchRetryDelay
+	}
+	g.stats.pending.Add(1)
+	d.work.Add(1)
+	time.AfterFunc(delay, func() {
+		select {
+		case d.retries <- r:
+		default:
+			g.stats.retryDropped.Add(1)
+			g.stats.pending.Add(-1)
+			d.work.Done()
+		}
+	})
+}
+
+// retry 重新把日志交给处理失败的适配器，适配器已被移除时放弃，再次失败时继续安排重试
+func (g *adapterGroup) retry(d *dispatcher, r dispatchRetry) {
+	defer d.work.Done()
+	defer g.stats.pending.Add(-1)
+
+	if d.abort.Load() {
+		g.stats.retryDropped.Add(1)
+		return
+	}
+	registered := false
+	for _, adapter := range g.snapshotAdapters() {
+		if adapter == r.adapter {
+			registered = true
+			break
+		}
+	}
+	if !registered {
+		return
+	}
+	g.stats.retried.Add(1)
+	if err := g.processAdapter(r.adapter, r.entry); err != nil {
+		g.scheduleRetry(d, r)
+	}
+}
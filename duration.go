@@ -0,0 +1,51 @@
+package logger
+
+import (
+	"fmt"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// DurationMillis time.Duration编码为毫秒数，如250ms编码为250
+	DurationMillis = "ms"
+	// DurationNanos time.Duration编码为纳秒整数，如250ms编码为250000000
+	DurationNanos = "ns"
+	// DurationString time.Duration编码为字符串，如250ms编码为"250ms"
+	DurationString = "string"
+	// DurationSeconds time.Duration编码为秒数，如250ms编码为0.25
+	DurationSeconds = "seconds"
+)
+
+// newDurationEncoder 按格式创建时长编码器和对应的适配器属性转换函数，两者的取值保持一致，格式为空时使用秒数
+func newDurationEncoder(format string) (zapcore.DurationEncoder, func(time.Duration) interface{}, error) {
+	switch format {
+	case DurationMillis:
+		return zapcore.MillisDurationEncoder, func(d time.Duration) interface{} {
+			return float64(d) / float64(time.Millisecond)
+		}, nil
+	case DurationNanos:
+		return zapcore.NanosDurationEncoder, func(d time.Duration) interface{} {
+			return int64(d)
+		}, nil
+	case DurationString:
+		return zapcore.StringDurationEncoder, func(d time.Duration) interface{} {
+			return d.String()
+		}, nil
+	case "", DurationSeconds:
+		return zapcore.SecondsDurationEncoder, func(d time.Duration) interface{} {
+			return d.Seconds()
+		}, nil
+	default:
+		return nil, nil, fmt.Errorf("unknown duration format: %s", format)
+	}
+}
+
+// propertyValue 转换适配器属性值，time.Duration按日志配置的时长格式转换，其余类型与包级propertyValue一致
+func (g *adapterGroup) propertyValue(value interface{}) interface{} {
+	if d, ok := value.(time.Duration); ok && g.durationProperty != nil {
+		return g.durationProperty(d)
+	}
+	return propertyValue(value)
+}
@@ -1,31 +1,88 @@
 package logger
 
+import (
+	"context"
+	"fmt"
+	"time"
+)
+
 // emptyLogger 是一个空的日志实现，不做任何操作
 // 用于在极端情况下避免空指针异常
 type emptyLogger struct{}
 
+func (l *emptyLogger) Fatal(args ...any) {}
+
+func (l *emptyLogger) Fatalf(format string, args ...any) {}
+
+func (l *emptyLogger) Fatalw(msg string, keysAndValues ...any) {}
+
 func (l *emptyLogger) Panic(args ...any) {}
 
 func (l *emptyLogger) Panicf(format string, args ...any) {}
 
+func (l *emptyLogger) Panicw(msg string, keysAndValues ...any) {}
+
 func (l *emptyLogger) Error(args ...any) {}
 
 func (l *emptyLogger) Errorf(format string, args ...any) {}
 
+func (l *emptyLogger) Errorw(msg string, keysAndValues ...any) {}
+
 func (l *emptyLogger) Warn(args ...any) {}
 
 func (l *emptyLogger) Warnf(format string, args ...any) {}
 
+func (l *emptyLogger) Warnw(msg string, keysAndValues ...any) {}
+
 func (l *emptyLogger) Info(args ...any) {}
 
 func (l *emptyLogger) Infof(format string, args ...any) {}
 
+func (l *emptyLogger) Infow(msg string, keysAndValues ...any) {}
+
 func (l *emptyLogger) Debug(args ...any) {}
 
 func (l *emptyLogger) Debugf(format string, args ...any) {}
 
+func (l *emptyLogger) Debugw(msg string, keysAndValues ...any) {}
+
+func (l *emptyLogger) WithField(key string, value interface{}) Logger { return l }
+
+func (l *emptyLogger) WithFields(fields map[string]interface{}) Logger { return l }
+
+func (l *emptyLogger) With(keysAndValues ...any) Logger { return l }
+
+func (l *emptyLogger) WithContext(ctx context.Context) Logger { return l }
+
+func (l *emptyLogger) WithError(err error) Logger { return l }
+
+func (l *emptyLogger) NoAdapters() Logger { return l }
+
+func (l *emptyLogger) InfoOnce(key string, msg string) {}
+
+func (l *emptyLogger) WarnOnce(key string, msg string) {}
+
+func (l *emptyLogger) WithThrottle(key string, interval time.Duration) Logger { return l }
+
+func (l *emptyLogger) SetLevel(level string) error {
+	_, err := ParseLevel(level)
+	return err
+}
+
+func (l *emptyLogger) BoostLevel(level string) func() { return func() {} }
+
+func (l *emptyLogger) LogAt(t time.Time, level string, msg string, fields map[string]interface{}) {}
+
+func (l *emptyLogger) Audit(msg string, fields map[string]interface{}) error {
+	return fmt.Errorf("audit adapter not configured")
+}
+
+func (l *emptyLogger) Flush() error { return nil }
+
 func (l *emptyLogger) Close() error { return nil }
 
+func (l *emptyLogger) CloseWithContext(ctx context.Context) error { return nil }
+
 func (l *emptyLogger) AddAdapter(adapter LogAdapter) {}
 
 func (l *emptyLogger) RemoveAdapter(name string) {}
@@ -0,0 +1,67 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap/zapcore"
+)
+
+const (
+	// FileFormatJSON 默认的文件格式，每行一个JSON对象
+	FileFormatJSON = "json"
+	// FileFormatText 文件使用与控制台相同的纯文本格式：时间、级别、调用位置、消息以制表符分隔，字段以JSON追加在行尾
+	FileFormatText = "console"
+)
+
+// EncoderKeys 覆盖输出中标准字段的key，如按采集规范使用@timestamp、severity，为空的字段保持默认key
+type EncoderKeys struct {
+	Time    string // 时间，默认time
+	Level   string // 级别，默认level
+	Message string // 消息，默认msg
+	Caller  string // 调用位置，默认caller
+}
+
+// apply 将非空的key写入编码器配置，标准字段的key重复或与nodeId等顶层字段同名时返回错误
+func (k EncoderKeys) apply(encoderConfig *zapcore.EncoderConfig) error {
+	if k.Time != "" {
+		encoderConfig.TimeKey = k.Time
+	}
+	if k.Level != "" {
+		encoderConfig.LevelKey = k.Level
+	}
+	if k.Message != "" {
+		encoderConfig.MessageKey = k.Message
+	}
+	if k.Caller != "" {
+		encoderConfig.CallerKey = k.Caller
+	}
+
+	seen := make(map[string]bool)
+	for _, key := range []string{
+		encoderConfig.TimeKey, encoderConfig.LevelKey, encoderConfig.NameKey, encoderConfig.CallerKey,
+		encoderConfig.MessageKey, encoderConfig.StacktraceKey,
+	} {
+		if seen[key] || rootFieldKeys[key] {
+			return fmt.Errorf("encoder key %s collides with standard key", key)
+		}
+		seen[key] = true
+	}
+	return nil
+}
+
+// newFileEncoder 按文件格式创建文件输出的编码器，格式为空时使用JSON
+// 纯文本格式同样携带顶层固定字段，SortedFields只作用于JSON格式
+func newFileEncoder(format string, encoderConfig zapcore.EncoderConfig, rootFields map[string]interface{}, sorted bool) (zapcore.Encoder, error) {
+	switch format {
+	case "", FileFormatJSON:
+		return newJSONEncoder(encoderConfig, rootFields, sorted)
+	case FileFormatText:
+		enc := zapcore.NewConsoleEncoder(encoderConfig)
+		if err := addRootFields(enc, encoderConfig, rootFields); err != nil {
+			return nil, err
+		}
+		return enc, nil
+	default:
+		return nil, fmt.Errorf("unknown file format: %s", format)
+	}
+}
@@ -0,0 +1,50 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newJSONEncoder 创建文件和旁路输出使用的JSON编码器
+// rootFields为每行JSON顶层固定附加的字段，开启sorted时字段按固定顺序输出
+func newJSONEncoder(encoderConfig zapcore.EncoderConfig, rootFields map[string]interface{}, sorted bool) (zapcore.Encoder, error) {
+	enc := zapcore.NewJSONEncoder(encoderConfig)
+	if err := addRootFields(enc, encoderConfig, rootFields); err != nil {
+		return nil, err
+	}
+	if sorted {
+		enc = newSortedJSONEncoder(enc, encoderConfig)
+	}
+	return enc, nil
+}
+
+// addRootFields 按key的顺序将顶层固定字段写入编码器，之后编码的每条日志都会携带这些字段
+// 字段不能与时间、级别、消息等标准字段同名，值按JSON编码
+func addRootFields(enc zapcore.Encoder, encoderConfig zapcore.EncoderConfig, rootFields map[string]interface{}) error {
+	standard := map[string]bool{}
+	for _, key := range []string{
+		encoderConfig.TimeKey, encoderConfig.LevelKey, encoderConfig.NameKey, encoderConfig.CallerKey,
+		encoderConfig.FunctionKey, encoderConfig.MessageKey, encoderConfig.StacktraceKey,
+	} {
+		if key != "" && key != zapcore.OmitKey {
+			standard[key] = true
+		}
+	}
+
+	keys := make([]string, 0, len(rootFields))
+	for k := range rootFields {
+		if standard[k] {
+			return fmt.Errorf("extra root field %s collides with standard key", k)
+		}
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		if err := enc.AddReflected(k, rootFields[k]); err != nil {
+			return fmt.Errorf("encode extra root field %s failed: %v", k, err)
+		}
+	}
+	return nil
+}
@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// errorGroup 判断值是否为错误组：[]error或实现Unwrap() []error的错误（如errors.Join的结果）
+func errorGroup(value interface{}) ([]error, bool) {
+	switch v := value.(type) {
+	case []error:
+		return v, true
+	case interface{ Unwrap() []error }:
+		if _, ok := v.(error); ok {
+			return v.Unwrap(), true
+		}
+	}
+	return nil, false
+}
+
+// errorArray 将错误组编码为数组，每个元素为{"error": 错误信息}，原因链多于一层时附加error_chain；
+// nil元素编码为null，保持元素下标与原切片一致
+type errorArray []error
+
+// MarshalLogArray 实现zapcore.ArrayMarshaler接口
+func (errs errorArray) MarshalLogArray(enc zapcore.ArrayEncoder) error {
+	for _, err := range errs {
+		if err == nil {
+			if e := enc.AppendReflected(nil); e != nil {
+				return e
+			}
+			continue
+		}
+		if e := enc.AppendObject(errorObject{err}); e != nil {
+			return e
+		}
+	}
+	return nil
+}
+
+// errorObject 错误组中的单个错误
+type errorObject struct {
+	err error
+}
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler接口
+func (o errorObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("error", o.err.Error())
+	if chain := errorChain(o.err); len(chain) > 1 {
+		return enc.AddArray("error_chain", zapcore.ArrayMarshalerFunc(func(arr zapcore.ArrayEncoder) error {
+			for _, s := range chain {
+				arr.AppendString(s)
+			}
+			return nil
+		}))
+	}
+	return nil
+}
+
+// anyField 创建zap字段，错误组编码为结构化的数组，其余与zap.Any一致
+func anyField(key string, value interface{}) zap.Field {
+	if errs, ok := errorGroup(value); ok {
+		return zap.Array(key, errorArray(errs))
+	}
+	return zap.Any(key, value)
+}
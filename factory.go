@@ -1,30 +1,164 @@
 package logger
 
 import (
+	"context"
+	"errors"
 	"fmt"
+	"io"
 	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
 )
 
 var (
 	defaultLogger Logger
-	loggerMu      sync.Mutex
+	loggerMu      sync.RWMutex
 )
 
 // AdapterConfig 定义适配器配置
 type AdapterConfig struct {
-	Name   string                 // 适配器名称
-	Config map[string]interface{} // 适配器配置
+	Name     string                 // 适配器名称
+	ID       string                 // 适配器实例ID，同一适配器配置多个实例时用于区分，为空时使用Name
+	Priority int                    // 处理优先级，数值大的适配器先处理同一条日志，相同优先级保持配置顺序
+	Config   map[string]interface{} // 适配器配置
 }
 
 // Config 定义日志配置
 type Config struct {
-	Level      string          // 日志级别: debug, info, warn, error, panic
+	Level      string          // 日志级别: debug, info, warn, error, panic, fatal
 	Path       string          // 日志文件路径，为空则只输出到控制台
 	NodeID     string          // 节点ID，用于分布式系统标识当前节点
 	Module     string          // 模块名称，如poc、finger等
 	IP         string          // IP地址
-	OutputType OutputType      // 输出类型：file、terminal、both
+	Env        string          // 运行环境，如prod、staging
+	Version    string          // 服务版本
+	OutputType OutputType      // 输出类型：file、terminal、both、adapters
 	Adapters   []AdapterConfig // 日志适配器配置
+
+	ConsoleLevel     string            // 控制台输出级别，为空时使用Level
+	FileLevel        string            // 文件输出级别，为空时使用Level
+	LenientAdapters  bool              // 适配器初始化失败时跳过该适配器而不是返回错误
+	LevelDecorations map[string]string // 控制台级别前缀符号，如 info: "ℹ"
+	LevelColors      map[string]string // 控制台级别颜色，如 error: "red"
+	LineEnding       string            // 文件输出的行尾，为空使用"\n"，NoLineEnding表示不追加行尾
+	ConsoleFormat    string            // 控制台输出格式：console(默认)、hybrid(可读的行加key=value字段)、json(NDJSON)
+	InlineFields     bool              // 控制台输出将字段以key=value拼接到消息中，文件的JSON输出不受影响
+	CallerFormat     string            // 调用位置格式：short(默认，目录/文件:行)、full(完整路径)、package(包路径/文件:行)
+	BinaryEncoding   string            // []byte参数和字段的编码：base64(默认)、hex
+	DurationFormat   string            // time.Duration字段的编码：seconds(默认，秒数)、ms(毫秒数)、ns(纳秒整数)、string(如"250ms")
+	Verbosity        int               // 详细级别阈值，V(n)视图的info日志在n不超过该值时输出，默认0
+	MaxBinarySize    int               // []byte最多编码的字节数，超出部分截断，默认4096
+	Rotation         RotationOptions   // 文件旋转选项，默认只按天旋转
+
+	StackTrimPrefixes []string      // 适配器堆栈顶部额外裁剪的函数前缀，日志包和zap的帧总是被裁剪
+	FullStacktrace    bool          // 适配器堆栈保留完整帧，不做裁剪
+	AdapterCallerSkip int           // 适配器调用位置在裁剪日志包和zap的帧之后额外跳过的帧数，用于封装日志的函数
+	StackDedupWindow  time.Duration // 窗口内重复的适配器堆栈只输出stack_ref和次数，为0时不去重
+	SamplingKey       string        // 分发到适配器时按该属性的取值分组采样
+	SamplingRate      int           // 每个取值每SamplingRate条保留一条，小于等于1时不采样
+	Clock             Clock         // 日志时间戳使用的时钟，为空时使用系统时间
+	DispatchBuffer    int           // 适配器分发队列容量，默认1024，队列满时丢弃新日志并计入Dropped
+	DispatchWorkers   int           // 适配器分发工作协程数，默认4个
+
+	BackpressurePolicy BackpressurePolicy // 适配器分发队列满时的处理策略，默认DropWhenFull
+
+	MaxAdapterGoroutines int                // 同时处理适配器日志的协程数上限，包括分发工作协程和同步处理panic日志的调用方，默认256
+	AdapterLimitPolicy   AdapterLimitPolicy // 达到协程数上限时的处理方式，默认AdapterLimitBlock
+
+	DispatchRetries    int           // 适配器处理日志失败后重新入队的最大次数，用尽后丢弃并计入RetryDropped，为0时不重试
+	DispatchRetryDelay time.Duration // 每次重试前的等待时间，默认100ms
+	DrainTimeout       time.Duration // Close等待分发队列处理完成的最长时间，超时后丢弃剩余日志并继续关闭适配器，为0时不限制
+
+	ModuleOutputs map[string]OutputType // 按模块名覆盖输出类型，集中为多个模块配置输出策略，未列出的模块使用OutputType
+
+	BaseContext context.Context // 适配器处理日志的上下文的父上下文，取消时中止正在进行的发送，默认context.Background()
+
+	ContextFields []string // WithContext从context中提取并附加到日志的字段名，如trace_id、span_id，值为空时不附加
+
+	ExtraRootFields map[string]interface{} // 文件和旁路JSON输出每行顶层固定附加的字段（如logstash的@version），json格式的控制台同样携带，不发送给适配器
+
+	LevelSampling map[string]LevelSampling // 控制台和文件输出按级别采样，未配置的级别不采样，error及以上级别不允许采样
+
+	StacktraceLevel string // 达到该级别的控制台和文件输出附加stacktrace字段，为空时不附加，适配器的堆栈不受影响
+
+	LevelRemap map[string]string // 级别重映射规则，key为module=、level=、msg=条件，value为改写后的级别，见WithLevelRemap
+
+	FieldNamespace string // With和调用时传入的字段放入的嵌套对象名（如ECS的labels），nodeId、module、ip等标准字段保留在顶层，为空时字段输出在顶层
+
+	SamplingHook func(level string, dropped int) // 每秒按级别汇总被采样丢弃的日志数，Close时报告剩余计数，回调中不应通过本日志输出
+
+	GoroutineID         bool      // 每条日志附加当前协程ID(goroutine字段)，需要解析调用栈，有额外开销
+	Sequence            bool      // 每条日志附加递增序号(seq字段)
+	CallerFunction      bool      // 每条日志附加调用方的完整函数名(func字段)
+	SortedFields        bool      // 文件和旁路的JSON输出按固定顺序排列字段：time、level、msg在前，其余按key排序
+	OmitEmpty           bool      // 输出和适配器属性省略值为空的字段：nil、空字符串、空切片/map、零值时间，数字0和false保留
+	InternalErrorWriter io.Writer // 日志包自身错误（适配器初始化、刷新失败，文件写入和旋转失败）的输出，默认标准错误输出
+
+	MaxFields          int // 每条日志最多保留的字段数，超出的字段被丢弃并附加fields_truncated，为0表示不限制
+	MaxFieldValueBytes int // 单个字段值的最大字节数，超出时截断并附加fields_truncated，为0表示不限制
+
+	ConsoleBufferSize    int           // 控制台输出缓冲区字节数，大于0时启用缓冲，error及以上级别立即刷新
+	ConsoleFlushInterval time.Duration // 控制台缓冲区定时刷新间隔，默认100ms
+	ConsoleSync          string        // 标准输出不是终端时写入后立即Sync：always(每条日志)、error(error及以上级别)，为空时不Sync
+	FatalExitCode        int           // Fatal输出日志并刷新适配器后的进程退出码，为0时使用1
+	PanicBehavior        string        // Panic系列方法的行为：crash(默认，输出后panic)、log_only(输出并刷新适配器后返回)
+	AuditAdapter         LogAdapter    // 审计适配器，Audit写入的日志同步交给它处理，不经过普通适配器的分发
+	AdapterPanicLimit    int           // 适配器panic达到该次数后停用，为0时不停用，panic总是被捕获
+	RequestIDGenerator   func() string // HTTP中间件生成请求ID的函数，为空时使用UUIDv4
+	RequestIDHeader      string        // HTTP中间件读取和回写请求ID的头，默认X-Request-ID
+	LevelHooks           []LevelHook   // 日志达到指定级别时在分发协程中调用的回调
+	Tee                  io.Writer     // 旁路输出，以JSON额外写一份每条日志，用于排查问题，可在运行时通过SetTee修改
+
+	ConsoleColor       bool   // 控制台级别使用颜色，设置了LevelDecorations或LevelColors时以其为准
+	ConsoleTimeFormat  string // 控制台时间格式，为time包的布局字符串，为空使用TimeFormat
+	ConsoleStderrLevel string // 达到该级别的控制台日志输出到标准错误输出，为空时全部输出到标准输出
+	FileTimeFormat     string // 文件时间格式，为time包的布局字符串，为空使用TimeFormat
+	FileFormat         string // 文件输出格式：json(默认)、console(与控制台相同的纯文本)
+	TimeFormat         string // 控制台和文件的时间格式，为time包的布局字符串，为空使用ISO8601，ConsoleTimeFormat和FileTimeFormat优先
+
+	EncoderKeys EncoderKeys // 覆盖时间、级别、消息、调用位置的key，默认time、level、msg、caller
+
+	FileFallbackThreshold int            // 文件输出连续失败该次数后回退到控制台，为0时使用3，小于0时不回退
+	FileWriter            io.WriteCloser // 文件输出的写入器，如*lumberjack.Logger，设置后替代按天旋转的DailyRotateWriter，Path可以为空，输出类型必须包含文件
+
+	AsyncCoreBuffer int    // 大于0时控制台和文件输出由单独协程写出，队列容量为该条数
+	AsyncCorePolicy string // 异步输出队列满时的策略：drop(默认，丢弃并计入AsyncDropped)、block(阻塞调用方)
+
+	AdapterProperties map[string]interface{} // 合并到每条适配器日志的静态属性，如数据中心、集群名，不输出到控制台和文件
+
+	AccessLog          bool // HTTP中间件在请求结束时输出访问日志
+	SuccessSampleKeep  int  // 成功(2xx/3xx)请求每SuccessSampleEvery条输出SuccessSampleKeep条访问日志
+	SuccessSampleEvery int  // 小于等于1时成功的请求全部输出，4xx和5xx总是全部输出
+
+	AccessLogFields []string // 访问日志输出的字段（AccessLogMethod等），为空时输出全部字段
+
+	consoleOutput    zapcore.WriteSyncer // 控制台输出目标，为空时使用标准输出
+	consoleErrOutput zapcore.WriteSyncer // 控制台stderr输出目标，为空时使用标准错误输出
+}
+
+// ConsoleOptions 汇总控制台输出的配置，零值与默认行为一致
+type ConsoleOptions struct {
+	Level            string            // 控制台输出级别，为空时使用全局级别
+	Format           string            // 输出格式：console(默认)、hybrid
+	Color            bool              // 级别使用颜色
+	LevelDecorations map[string]string // 级别前缀符号，如 info: "ℹ"
+	LevelColors      map[string]string // 级别颜色，如 error: "red"
+	TimeFormat       string            // 时间格式，为time包的布局字符串，为空使用ISO8601
+	StderrLevel      string            // 达到该级别的日志输出到标准错误输出，为空时全部输出到标准输出
+	InlineFields     bool              // 字段以key=value拼接到消息中
+	BufferSize       int               // 缓冲区字节数，大于0时启用缓冲
+	FlushInterval    time.Duration     // 缓冲区定时刷新间隔，默认100ms
+	Sync             string            // 标准输出不是终端时写入后立即Sync：always、error，为空时不Sync
+}
+
+// FileOptions 汇总文件输出的配置，零值与默认行为一致
+type FileOptions struct {
+	Level      string          // 文件输出级别，为空时使用全局级别
+	Format     string          // 输出格式：json(默认)、console
+	TimeFormat string          // 时间格式，为time包的布局字符串，为空使用ISO8601
+	LineEnding string          // 行尾，为空使用"\n"，NoLineEnding表示不追加行尾
+	Rotation   RotationOptions // 文件旋转选项
 }
 
 // Init 初始化默认日志
@@ -34,10 +168,10 @@ func Init(config Config) error {
 
 	// 如果没有指定输出类型，设置默认值
 	if !IsValidOutputType(config.OutputType) {
-		config.OutputType = GetDefaultOutputType()
+		config.OutputType = configOutputType(config)
 	}
 
-	logger, err := NewZapLogger(config.Level, config.Path, config.NodeID, config.Module, config.IP, config.OutputType, config.Adapters)
+	logger, err := newZapLogger(config)
 	if err != nil {
 		return fmt.Errorf("init logger failed: %v", err)
 	}
@@ -53,20 +187,26 @@ func InitWithOptions(opts ...Option) error {
 }
 
 // Default 获取默认日志实例
+// 读取时持有读锁，与Init、Close替换默认日志并发时不会读到中间状态
 func Default() Logger {
+	loggerMu.RLock()
+	logger := defaultLogger
+	loggerMu.RUnlock()
+	if logger != nil {
+		return logger
+	}
+
+	// 如果默认日志未初始化或已关闭，创建一个只输出到控制台的默认日志
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
 	if defaultLogger == nil {
-		// 如果默认日志未初始化，创建一个只输出到控制台的默认日志
-		loggerMu.Lock()
-		if defaultLogger == nil {
-			logger, err := NewZapLogger("info", "", "", "default", "", OutputTerminal, nil) // 默认日志只输出到终端
-			if err != nil {
-				// 在极端情况下，如果创建日志失败，使用一个空实现避免空指针
-				defaultLogger = &emptyLogger{}
-			} else {
-				defaultLogger = logger
-			}
+		logger, err := NewZapLogger("info", "", "", "default", "", OutputTerminal, nil) // 默认日志只输出到终端
+		if err != nil {
+			// 在极端情况下，如果创建日志失败，使用一个空实现避免空指针
+			defaultLogger = &emptyLogger{}
+		} else {
+			defaultLogger = logger
 		}
-		loggerMu.Unlock()
 	}
 	return defaultLogger
 }
@@ -75,10 +215,33 @@ func Default() Logger {
 func New(config Config) (Logger, error) {
 	// 如果没有指定输出类型，设置默认值
 	if !IsValidOutputType(config.OutputType) {
-		config.OutputType = GetDefaultOutputType()
+		config.OutputType = configOutputType(config)
+	}
+
+	logger, err := newZapLogger(config)
+	if err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// Validate 按配置完整创建一次日志（创建输出、校验并初始化适配器）后立即关闭，返回创建过程中的错误
+// 用于部署前或启动脚本中提前发现错误的配置，不会保留打开的文件和后台协程
+func Validate(config Config) error {
+	if !IsValidOutputType(config.OutputType) {
+		config.OutputType = configOutputType(config)
+	}
+
+	// 校验时不写入也不关闭调用方提供的文件写入器
+	if config.FileWriter != nil {
+		config.FileWriter = nopWriteCloser{io.Discard}
 	}
 
-	return NewZapLogger(config.Level, config.Path, config.NodeID, config.Module, config.IP, config.OutputType, config.Adapters)
+	logger, err := newZapLogger(config)
+	if err != nil {
+		return err
+	}
+	return logger.Close()
 }
 
 // NewWithOptions 使用选项模式创建一个新的日志实例
@@ -87,7 +250,91 @@ func NewWithOptions(opts ...Option) (Logger, error) {
 	return New(config)
 }
 
+// ReplaceGlobals 将zap的全局日志替换为默认日志实例，返回恢复原全局日志的函数
+// 默认日志不是ZapLogger时不做任何操作
+func ReplaceGlobals() func() {
+	if l, ok := Default().(*ZapLogger); ok {
+		return l.ReplaceGlobals()
+	}
+	return func() {}
+}
+
+// SetFloorLevel 设置默认日志实例的最低级别闸门，传入空字符串恢复正常
+func SetFloorLevel(level string) error {
+	if l, ok := Default().(*ZapLogger); ok {
+		return l.SetFloorLevel(level)
+	}
+	return nil
+}
+
+// BoostLevel 临时降低默认日志实例的级别，返回恢复函数
+//
+//	done := logger.BoostLevel("debug")
+//	defer done()
+func BoostLevel(level string) func() {
+	return Default().BoostLevel(level)
+}
+
+// CloseTimeout 关闭默认日志实例，最多等待d，超时时返回未能按时关闭的适配器
+// 与Close一样先同步输出核心并清除默认日志，之后再调用Default()会重新创建只输出到终端的默认日志
+func CloseTimeout(d time.Duration) error {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	if defaultLogger == nil {
+		return nil
+	}
+	l := defaultLogger
+	defaultLogger = nil
+
+	ctx, cancel := context.WithTimeout(context.Background(), d)
+	defer cancel()
+	return errors.Join(syncLogger(l), l.CloseWithContext(ctx))
+}
+
+// Sync 刷新默认日志实例输出核心中缓冲的日志，忽略标准输出为终端或管道时不支持Sync返回的错误
+func Sync() error {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	return syncLogger(defaultLogger)
+}
+
+// Close 刷新并关闭默认日志实例：先同步输出核心，再处理完分发队列、依次刷新并关闭适配器、关闭日志文件
+// 关闭后再调用Default()会重新创建只输出到终端的默认日志，也可以再次调用Init，适合在main中使用
+//
+//	defer logger.Close()
+func Close() error {
+	loggerMu.Lock()
+	defer loggerMu.Unlock()
+	if defaultLogger == nil {
+		return nil
+	}
+	l := defaultLogger
+	defaultLogger = nil
+	return errors.Join(syncLogger(l), l.Close())
+}
+
+// syncLogger 同步日志实例的输出核心，不支持Sync的日志实例直接返回nil
+func syncLogger(l Logger) error {
+	s, ok := l.(interface{ Sync() error })
+	if !ok {
+		return nil
+	}
+	return dropUnsupportedSync(s.Sync())
+}
+
 // 以下是全局日志函数，使用默认日志实例
+func Fatal(args ...any) {
+	Default().Fatal(args...)
+}
+
+func Fatalf(format string, args ...any) {
+	Default().Fatalf(format, args...)
+}
+
+func Fatalw(msg string, keysAndValues ...any) {
+	AsKeyValueLogger(Default()).Fatalw(msg, keysAndValues...)
+}
+
 func Panic(args ...any) {
 	Default().Panic(args...)
 }
@@ -96,6 +343,10 @@ func Panicf(format string, args ...any) {
 	Default().Panicf(format, args...)
 }
 
+func Panicw(msg string, keysAndValues ...any) {
+	AsKeyValueLogger(Default()).Panicw(msg, keysAndValues...)
+}
+
 func Error(args ...any) {
 	Default().Error(args...)
 }
@@ -104,6 +355,10 @@ func Errorf(format string, args ...any) {
 	Default().Errorf(format, args...)
 }
 
+func Errorw(msg string, keysAndValues ...any) {
+	AsKeyValueLogger(Default()).Errorw(msg, keysAndValues...)
+}
+
 func Warn(args ...any) {
 	Default().Warn(args...)
 }
@@ -112,6 +367,10 @@ func Warnf(format string, args ...any) {
 	Default().Warnf(format, args...)
 }
 
+func Warnw(msg string, keysAndValues ...any) {
+	AsKeyValueLogger(Default()).Warnw(msg, keysAndValues...)
+}
+
 func Info(args ...any) {
 	Default().Info(args...)
 }
@@ -120,6 +379,10 @@ func Infof(format string, args ...any) {
 	Default().Infof(format, args...)
 }
 
+func Infow(msg string, keysAndValues ...any) {
+	AsKeyValueLogger(Default()).Infow(msg, keysAndValues...)
+}
+
 func Debug(args ...any) {
 	Default().Debug(args...)
 }
@@ -127,3 +390,38 @@ func Debug(args ...any) {
 func Debugf(format string, args ...any) {
 	Default().Debugf(format, args...)
 }
+
+func Debugw(msg string, keysAndValues ...any) {
+	AsKeyValueLogger(Default()).Debugw(msg, keysAndValues...)
+}
+
+func InfoOnce(key string, msg string) {
+	Default().InfoOnce(key, msg)
+}
+
+func WarnOnce(key string, msg string) {
+	Default().WarnOnce(key, msg)
+}
+
+// SetLevel 修改默认日志实例的级别，之后的日志立即按新级别输出
+func SetLevel(level string) error {
+	return Default().SetLevel(level)
+}
+
+// WithThrottle 返回默认日志实例按key节流的子日志
+func WithThrottle(key string, interval time.Duration) Logger {
+	return Default().WithThrottle(key, interval)
+}
+
+// Audit 使用默认日志实例同步写入一条审计日志
+func Audit(msg string, fields map[string]interface{}) error {
+	return Default().Audit(msg, fields)
+}
+
+// CurrentLogFile 返回默认日志实例当前正在写入的日志文件路径，没有文件输出时返回空字符串
+func CurrentLogFile() string {
+	if l, ok := Default().(*ZapLogger); ok {
+		return l.CurrentLogFile()
+	}
+	return ""
+}
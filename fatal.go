@@ -0,0 +1,62 @@
+package logger
+
+import (
+	"fmt"
+	"os"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultFatalExitCode Fatal默认的进程退出码
+const defaultFatalExitCode = 1
+
+// Panic系列方法的行为
+const (
+	PanicCrash   = "crash"    // 输出日志后panic，默认行为
+	PanicLogOnly = "log_only" // 以panic级别输出日志并刷新适配器后返回，由调用方决定如何处理
+)
+
+// fatalHook Fatal写入输出后执行：处理完分发队列中剩余的日志、刷新适配器，再以配置的退出码退出进程
+type fatalHook struct {
+	group *adapterGroup
+	code  int
+}
+
+// OnWrite 实现zapcore.CheckWriteHook接口
+func (h *fatalHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	h.group.stopDispatcher()
+	h.group.adapterMu.RLock()
+	_ = h.group.flushAdapters()
+	h.group.adapterMu.RUnlock()
+
+	code := h.code
+	if code == 0 {
+		code = defaultFatalExitCode
+	}
+	os.Exit(code)
+}
+
+// newPanicHook 按配置的panic行为创建Panic输出后执行的回调，PanicCrash时返回nil使用zap默认的panic
+func newPanicHook(behavior string, group *adapterGroup) (zapcore.CheckWriteHook, error) {
+	switch behavior {
+	case "", PanicCrash:
+		return nil, nil
+	case PanicLogOnly:
+		return &panicLogOnlyHook{group: group}, nil
+	default:
+		return nil, fmt.Errorf("unknown panic behavior: %s", behavior)
+	}
+}
+
+// panicLogOnlyHook Panic写入输出后处理完分发队列中剩余的日志、刷新适配器，然后返回而不panic
+type panicLogOnlyHook struct {
+	group *adapterGroup
+}
+
+// OnWrite 实现zapcore.CheckWriteHook接口
+func (h *panicLogOnlyHook) OnWrite(*zapcore.CheckedEntry, []zapcore.Field) {
+	h.group.stopDispatcher()
+	h.group.adapterMu.RLock()
+	_ = h.group.flushAdapters()
+	h.group.adapterMu.RUnlock()
+}
@@ -0,0 +1,188 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"sort"
+	"unicode/utf8"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// FieldsTruncatedField 字段因WithFieldLimit被丢弃或截断时附加的标记字段
+const FieldsTruncatedField = "fields_truncated"
+
+// fieldLimit 每条日志的字段数和单个字段值大小的上限，为空表示不限制
+type fieldLimit struct {
+	maxFields     int // 每条日志最多保留的字段数，为0表示不限制
+	maxValueBytes int // 单个字段值编码后的最大字节数，为0表示不限制
+}
+
+// newFieldLimit 创建字段限制，两个上限都为0时返回nil，负数返回错误
+func newFieldLimit(maxFields, maxValueBytes int) (*fieldLimit, error) {
+	if maxFields < 0 || maxValueBytes < 0 {
+		return nil, fmt.Errorf("invalid field limit: %d fields, %d bytes", maxFields, maxValueBytes)
+	}
+	if maxFields == 0 && maxValueBytes == 0 {
+		return nil, nil
+	}
+	return &fieldLimit{maxFields: maxFields, maxValueBytes: maxValueBytes}, nil
+}
+
+// truncateString 将s截断到最多n个字节，不拆分UTF-8字符，末尾注明原始大小
+func truncateString(s string, n int) string {
+	cut := n
+	for cut > 0 && !utf8.RuneStart(s[cut]) {
+		cut--
+	}
+	return fmt.Sprintf("%s...(truncated, %d bytes)", s[:cut], len(s))
+}
+
+// limitValue 截断超出大小上限的字符串，其他结构化的值按JSON编码后检查大小，超出时替换为截断的JSON字符串
+// 数字和布尔值大小固定，不做检查；返回值是否被截断
+func (f *fieldLimit) limitValue(v interface{}) (interface{}, bool) {
+	if f.maxValueBytes == 0 {
+		return v, false
+	}
+	switch x := v.(type) {
+	case nil, bool, int, int8, int16, int32, int64, uint, uint8, uint16, uint32, uint64, float32, float64:
+		return v, false
+	case string:
+		if len(x) > f.maxValueBytes {
+			return truncateString(x, f.maxValueBytes), true
+		}
+		return v, false
+	}
+	data, err := json.Marshal(v)
+	if err != nil || len(data) <= f.maxValueBytes {
+		return v, false
+	}
+	return truncateString(string(data), f.maxValueBytes), true
+}
+
+// limitField 截断超出大小上限的字段值，只检查字符串、错误和需要反射编码的字段
+func (f *fieldLimit) limitField(field zapcore.Field) (zapcore.Field, bool) {
+	if f.maxValueBytes == 0 {
+		return field, false
+	}
+	switch field.Type {
+	case zapcore.StringType:
+		if len(field.String) > f.maxValueBytes {
+			return zap.String(field.Key, truncateString(field.String, f.maxValueBytes)), true
+		}
+	case zapcore.ErrorType:
+		if err, ok := field.Interface.(error); ok && err != nil && len(err.Error()) > f.maxValueBytes {
+			return zap.String(field.Key, truncateString(err.Error(), f.maxValueBytes)), true
+		}
+	case zapcore.ReflectType:
+		if v, truncated := f.limitValue(field.Interface); truncated {
+			return zap.String(field.Key, v.(string)), true
+		}
+	}
+	return field, false
+}
+
+// limitFields 最多保留remaining个字段并截断过大的值，remaining小于0表示不限制字段数
+func (f *fieldLimit) limitFields(fields []zapcore.Field, remaining int) ([]zapcore.Field, bool) {
+	truncated := false
+	if remaining >= 0 && len(fields) > remaining {
+		fields = fields[:remaining]
+		truncated = true
+	}
+	if f.maxValueBytes == 0 {
+		return fields, truncated
+	}
+	limited := fields
+	copied := false
+	for i, field := range fields {
+		replaced, ok := f.limitField(field)
+		if !ok {
+			continue
+		}
+		// 调用方的字段切片可能被复用，替换前先复制
+		if !copied {
+			limited = append([]zapcore.Field(nil), fields...)
+			copied = true
+		}
+		limited[i] = replaced
+		truncated = true
+	}
+	return limited, truncated
+}
+
+// limitProperties 限制适配器属性的数量和大小，超出数量时按key排序保留前maxFields个，被限制时附加标记属性
+func (f *fieldLimit) limitProperties(properties map[string]interface{}) map[string]interface{} {
+	if f == nil {
+		return properties
+	}
+	keys := make([]string, 0, len(properties))
+	for k := range properties {
+		keys = append(keys, k)
+	}
+	truncated := false
+	if f.maxFields > 0 && len(keys) > f.maxFields {
+		sort.Strings(keys)
+		keys = keys[:f.maxFields]
+		truncated = true
+	}
+
+	limited := make(map[string]interface{}, len(keys)+1)
+	for _, k := range keys {
+		v, ok := f.limitValue(properties[k])
+		limited[k] = v
+		truncated = truncated || ok
+	}
+	if !truncated {
+		return properties
+	}
+	limited[FieldsTruncatedField] = true
+	return limited
+}
+
+// fieldLimitCore 写入前限制字段数和字段值大小，With添加的字段计入每条日志的字段数
+type fieldLimitCore struct {
+	zapcore.Core
+	limit     *fieldLimit
+	count     int  // With已添加的字段数
+	truncated bool // With添加的字段已被限制
+}
+
+// remaining 返回还能添加的字段数，小于0表示不限制
+func (c *fieldLimitCore) remaining() int {
+	if c.limit.maxFields == 0 {
+		return -1
+	}
+	if n := c.limit.maxFields - c.count; n > 0 {
+		return n
+	}
+	return 0
+}
+
+// With 实现zapcore.Core接口
+func (c *fieldLimitCore) With(fields []zapcore.Field) zapcore.Core {
+	limited, truncated := c.limit.limitFields(fields, c.remaining())
+	return &fieldLimitCore{
+		Core:      c.Core.With(limited),
+		limit:     c.limit,
+		count:     c.count + len(limited),
+		truncated: c.truncated || truncated,
+	}
+}
+
+// Check 实现zapcore.Core接口
+func (c *fieldLimitCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *fieldLimitCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	limited, truncated := c.limit.limitFields(fields, c.remaining())
+	if truncated || c.truncated {
+		limited = append(limited[:len(limited):len(limited)], zap.Bool(FieldsTruncatedField, true))
+	}
+	return c.Core.Write(ent, limited)
+}
@@ -0,0 +1,57 @@
+package logger
+
+import "go.uber.org/zap/zapcore"
+
+// LevelHook 在日志达到指定级别时调用的回调
+type LevelHook struct {
+	Level string         // 触发回调的最低级别
+	Fn    func(LogEntry) // 回调函数，在分发协程中调用，panic会被捕获
+}
+
+// levelHook 解析后的级别回调
+type levelHook struct {
+	level zapcore.Level
+	fn    func(LogEntry)
+}
+
+// newLevelHooks 解析级别回调配置
+func newLevelHooks(hooks []LevelHook) ([]levelHook, error) {
+	result := make([]levelHook, 0, len(hooks))
+	for _, hook := range hooks {
+		if hook.Fn == nil {
+			continue
+		}
+		level, err := ParseLevel(hook.Level)
+		if err != nil {
+			return nil, err
+		}
+		result = append(result, levelHook{level: level, fn: hook.Fn})
+	}
+	return result, nil
+}
+
+// runLevelHooks 依次调用级别不高于日志级别的回调
+func (g *adapterGroup) runLevelHooks(entry LogEntry) {
+	if len(g.levelHooks) == 0 {
+		return
+	}
+	level, err := zapcore.ParseLevel(entry.Level)
+	if err != nil {
+		return
+	}
+	for _, hook := range g.levelHooks {
+		if level >= hook.level {
+			g.runLevelHook(hook, entry)
+		}
+	}
+}
+
+// runLevelHook 调用单个回调，捕获其中的panic并写入内部错误输出
+func (g *adapterGroup) runLevelHook(hook levelHook, entry LogEntry) {
+	defer func() {
+		if r := recover(); r != nil {
+			g.internalError("level hook for %s panicked: %v", hook.level, r)
+		}
+	}()
+	hook.fn(entry)
+}
@@ -0,0 +1,35 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"os"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// newErrorOutput 创建日志包自身错误的输出目标，为空时使用标准错误输出
+func newErrorOutput(w io.Writer) zapcore.WriteSyncer {
+	if w == nil {
+		w = os.Stderr
+	}
+	return zapcore.Lock(zapcore.AddSync(w))
+}
+
+// writeInternalError 写出一条日志包自身的运行错误，与应用日志分开
+func writeInternalError(w io.Writer, format string, args ...interface{}) {
+	if w == nil {
+		w = os.Stderr
+	}
+	fmt.Fprintf(w, "%s logger error: %s\n", time.Now().Format(iso8601Layout), fmt.Sprintf(format, args...))
+}
+
+// internalError 写出日志包自身的运行错误，如适配器刷新失败
+func (g *adapterGroup) internalError(format string, args ...interface{}) {
+	var w io.Writer
+	if g.errorOutput != nil {
+		w = g.errorOutput
+	}
+	writeInternalError(w, format, args...)
+}
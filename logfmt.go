@@ -0,0 +1,145 @@
+package logger
+
+import (
+	"encoding/json"
+	"fmt"
+	"strconv"
+	"strings"
+	"time"
+	"unicode"
+)
+
+// AppendLogfmt 将日志按logfmt格式追加到buf，不含换行
+// 依次输出time、level、msg，非空时输出caller、node_id、module、ip，然后按OrderedProperties的顺序输出属性；
+// 包含空格、引号、等号或控制字符的值加引号转义，map、切片等结构化的值按JSON编码
+func (e LogEntry) AppendLogfmt(buf []byte) []byte {
+	add := func(key string, value interface{}) {
+		if len(buf) > 0 {
+			buf = append(buf, ' ')
+		}
+		buf = append(buf, logfmtKey(key)...)
+		buf = append(buf, '=')
+		buf = appendLogfmtValue(buf, value)
+	}
+	if !e.Time.IsZero() {
+		add("time", e.Time.Format(time.RFC3339Nano))
+	}
+	add("level", e.Level)
+	add("msg", e.Message)
+	for _, field := range [][2]string{{"caller", e.Caller}, {"node_id", e.NodeID}, {"module", e.Module}, {"ip", e.IP}} {
+		if field[1] != "" {
+			add(field[0], field[1])
+		}
+	}
+	for _, p := range e.OrderedProperties() {
+		add(p.Key, p.Value)
+	}
+	return buf
+}
+
+// Logfmt 返回日志的logfmt格式
+func (e LogEntry) Logfmt() string {
+	return string(e.AppendLogfmt(nil))
+}
+
+// logfmtKey key中的空格、等号、引号和控制字符替换为下划线，空key替换为下划线
+func logfmtKey(key string) string {
+	if key == "" {
+		return "_"
+	}
+	if !strings.ContainsFunc(key, logfmtNeedsQuote) {
+		return key
+	}
+	return strings.Map(func(r rune) rune {
+		if logfmtNeedsQuote(r) {
+			return '_'
+		}
+		return r
+	}, key)
+}
+
+// logfmtNeedsQuote 判断字符是否需要给值加引号
+func logfmtNeedsQuote(r rune) bool {
+	return r == ' ' || r == '=' || r == '"' || unicode.IsSpace(r) || unicode.IsControl(r)
+}
+
+// appendLogfmtValue 追加一个值，nil输出为空值
+func appendLogfmtValue(buf []byte, value interface{}) []byte {
+	var s string
+	switch v := value.(type) {
+	case nil:
+		return buf
+	case string:
+		s = v
+	case bool:
+		return strconv.AppendBool(buf, v)
+	case int:
+		return strconv.AppendInt(buf, int64(v), 10)
+	case int64:
+		return strconv.AppendInt(buf, v, 10)
+	case uint64:
+		return strconv.AppendUint(buf, v, 10)
+	case float64:
+		return strconv.AppendFloat(buf, v, 'g', -1, 64)
+	case time.Time:
+		s = v.Format(time.RFC3339Nano)
+	case error:
+		s = v.Error()
+	case fmt.Stringer:
+		s = v.String()
+	default:
+		if data, err := json.Marshal(v); err == nil {
+			s = string(data)
+		} else {
+			s = fmt.Sprint(v)
+		}
+	}
+	if s == "" || strings.ContainsFunc(s, logfmtNeedsQuote) {
+		return strconv.AppendQuote(buf, s)
+	}
+	return append(buf, s...)
+}
+
+// ParseLogfmt 按顺序解析一行logfmt，值均为字符串，加引号的值会去掉转义；没有等号的key值为空字符串
+func ParseLogfmt(line string) ([]Property, error) {
+	var properties []Property
+	for {
+		line = strings.TrimLeft(line, " \t")
+		if line == "" {
+			return properties, nil
+		}
+		end := strings.IndexAny(line, "= \t")
+		if end < 0 {
+			end = len(line)
+		}
+		key := line[:end]
+		if key == "" {
+			return nil, fmt.Errorf("parse logfmt failed: empty key at %q", line)
+		}
+		line = line[end:]
+		if !strings.HasPrefix(line, "=") {
+			properties = append(properties, Property{Key: key, Value: ""})
+			continue
+		}
+		line = line[1:]
+
+		var value string
+		if strings.HasPrefix(line, `"`) {
+			quoted, err := strconv.QuotedPrefix(line)
+			if err != nil {
+				return nil, fmt.Errorf("parse logfmt failed: invalid quoted value for %s", key)
+			}
+			if value, err = strconv.Unquote(quoted); err != nil {
+				return nil, fmt.Errorf("parse logfmt failed: invalid quoted value for %s", key)
+			}
+			line = line[len(quoted):]
+		} else {
+			end := strings.IndexAny(line, " \t")
+			if end < 0 {
+				end = len(line)
+			}
+			value, line = line[:end], line[end:]
+		}
+		properties = append(properties, Property{Key: key, Value: value})
+	}
+}
@@ -1,6 +1,13 @@
 package logger
 
+import (
+	"context"
+	"time"
+)
+
 type Logger interface {
+	Fatal(args ...any)
+	Fatalf(fmt string, args ...any)
 	Panic(args ...any)
 	Panicf(fmt string, args ...any)
 	Error(args ...any)
@@ -12,12 +19,106 @@ type Logger interface {
 	Debug(args ...any)
 	Debugf(fmt string, args ...any)
 
+	// WithField 返回携带一个字段的子日志
+	WithField(key string, value interface{}) Logger
+
+	// WithFields 返回携带多个字段的子日志，子日志与父日志共享输出和适配器
+	WithFields(fields map[string]interface{}) Logger
+
+	// With 返回以键值对携带字段的子日志，如With("requestId", id, "userId", uid)，缺少值的最后一个key被忽略
+	With(keysAndValues ...any) Logger
+
+	// WithContext 返回携带ctx的子日志：附加WithContextFields配置的、ctx中存在的字段，适配器处理其日志的上下文携带ctx中的值，
+	// 但不随ctx取消，异步分发的日志在请求结束后仍会送达
+	WithContext(ctx context.Context) Logger
+
+	// WithError 返回在error字段中携带错误信息的子日志，合并的错误另在errors字段中逐个记录，err为nil时返回自身
+	WithError(err error) Logger
+
+	// NoAdapters 返回只写入输出核心、不分发到适配器的日志视图
+	NoAdapters() Logger
+
+	// InfoOnce 同一个key在进程生命周期内只输出第一次
+	InfoOnce(key string, msg string)
+
+	// WarnOnce 同一个key在进程生命周期内只输出第一次
+	WarnOnce(key string, msg string)
+
+	// WithThrottle 返回按key节流的子日志，同一key在interval内最多输出一次
+	WithThrottle(key string, interval time.Duration) Logger
+
+	// SetLevel 修改运行中的日志级别，控制台、文件等所有输出立即生效，级别无法识别时返回错误
+	SetLevel(level string) error
+
+	// BoostLevel 临时降低日志级别，返回恢复函数，用于在一段代码中输出更详细的日志
+	BoostLevel(level string) func()
+
+	// LogAt 以指定的时间写入一条日志，用于重放历史事件或补录导入的日志
+	LogAt(t time.Time, level string, msg string, fields map[string]interface{})
+
+	// Audit 同步写入一条审计日志，返回前已由审计适配器处理并刷新
+	Audit(msg string, fields map[string]interface{}) error
+
+	// Flush 等待已分发的日志处理完成并刷新所有适配器，返回各适配器刷新失败的合并错误
+	Flush() error
+
 	// Close 关闭日志记录器
 	Close() error
 
+	// CloseWithContext 关闭日志记录器，最多等待到ctx结束，超时时返回未能按时关闭的适配器
+	CloseWithContext(ctx context.Context) error
+
 	// AddAdapter 添加一个适配器
 	AddAdapter(adapter LogAdapter)
 
 	// RemoveAdapter 移除一个适配器
 	RemoveAdapter(name string)
 }
+
+// KeyValueLogger 以键值对附加字段的日志方法，如Infow("login", "user", "alice")
+// 不属于Logger接口，已有的Logger实现无需增加这些方法；本包返回的日志都实现了它
+type KeyValueLogger interface {
+	Fatalw(msg string, keysAndValues ...any)
+	Panicw(msg string, keysAndValues ...any)
+	Errorw(msg string, keysAndValues ...any)
+	Warnw(msg string, keysAndValues ...any)
+	Infow(msg string, keysAndValues ...any)
+	Debugw(msg string, keysAndValues ...any)
+}
+
+// AsKeyValueLogger 返回l的键值对日志方法，l未实现KeyValueLogger时通过With附加字段后输出
+func AsKeyValueLogger(l Logger) KeyValueLogger {
+	if kv, ok := l.(KeyValueLogger); ok {
+		return kv
+	}
+	return withKeyValues{l}
+}
+
+// withKeyValues 通过With为未实现KeyValueLogger的Logger提供键值对日志方法
+type withKeyValues struct {
+	Logger
+}
+
+func (l withKeyValues) Fatalw(msg string, keysAndValues ...any) {
+	l.With(keysAndValues...).Fatal(msg)
+}
+
+func (l withKeyValues) Panicw(msg string, keysAndValues ...any) {
+	l.With(keysAndValues...).Panic(msg)
+}
+
+func (l withKeyValues) Errorw(msg string, keysAndValues ...any) {
+	l.With(keysAndValues...).Error(msg)
+}
+
+func (l withKeyValues) Warnw(msg string, keysAndValues ...any) {
+	l.With(keysAndValues...).Warn(msg)
+}
+
+func (l withKeyValues) Infow(msg string, keysAndValues ...any) {
+	l.With(keysAndValues...).Info(msg)
+}
+
+func (l withKeyValues) Debugw(msg string, keysAndValues ...any) {
+	l.With(keysAndValues...).Debug(msg)
+}
@@ -0,0 +1,4446 @@
+package logger
+
+import (
+	"bytes"
+	"compress/gzip"
+	"context"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"log/slog"
+	"net/http"
+	"net/http/httptest"
+	"os"
+	"os/exec"
+	"path/filepath"
+	"regexp"
+	"runtime"
+	"strings"
+	"sync"
+	"sync/atomic"
+	"syscall"
+	"testing"
+	"time"
+
+	"github.com/stretchr/testify/assert"
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+	"go.uber.org/zap/zaptest/observer"
+)
+
+// recordingAdapter 记录收到日志的测试适配器
+type recordingAdapter struct {
+	name     string
+	mu       sync.Mutex
+	received []LogEntry
+}
+
+func (a *recordingAdapter) Name() string                             { return a.name }
+func (a *recordingAdapter) Init(config map[string]interface{}) error { return nil }
+func (a *recordingAdapter) Flush() error                             { return nil }
+func (a *recordingAdapter) Close() error                             { return nil }
+
+func (a *recordingAdapter) Process(ctx context.Context, entry LogEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.received = append(a.received, entry)
+	return nil
+}
+
+// entries 等待并返回至少n条日志
+func (a *recordingAdapter) entries(t *testing.T, n int) []LogEntry {
+	t.Helper()
+	deadline := time.Now().Add(time.Second)
+	for {
+		a.mu.Lock()
+		got := append([]LogEntry(nil), a.received...)
+		a.mu.Unlock()
+		if len(got) >= n || time.Now().After(deadline) {
+			assert.GreaterOrEqual(t, len(got), n)
+			return got
+		}
+		time.Sleep(5 * time.Millisecond)
+	}
+}
+
+// newObservedLogger 创建输出可观测的日志实例
+func newObservedLogger(level zapcore.Level, adapters ...LogAdapter) (*ZapLogger, *observer.ObservedLogs) {
+	core, logs := observer.New(level)
+	logger := zap.New(core)
+	return &ZapLogger{
+		logger:       logger,
+		sugar:        logger.Sugar(),
+		adapterGroup: &adapterGroup{adapters: adapters},
+	}, logs
+}
+
+// encodeConsoleLevel 使用给定级别编码器编码一条日志并返回输出
+func encodeConsoleLevel(t *testing.T, levelEncoder zapcore.LevelEncoder, level zapcore.Level) string {
+	cfg := zapcore.EncoderConfig{
+		LevelKey:    "level",
+		MessageKey:  "msg",
+		EncodeLevel: levelEncoder,
+	}
+	buf, err := zapcore.NewConsoleEncoder(cfg).EncodeEntry(zapcore.Entry{Level: level, Time: time.Now(), Message: "hello"}, nil)
+	assert.NoError(t, err)
+	defer buf.Free()
+	return buf.String()
+}
+
+func TestLevelDecorations(t *testing.T) {
+	t.Run("Disabled", func(t *testing.T) {
+		assert.Nil(t, newLevelDecorationEncoder(nil, nil))
+	})
+
+	t.Run("Icons", func(t *testing.T) {
+		enc := newLevelDecorationEncoder(map[string]string{"info": "ℹ", "error": "✖"}, nil)
+		assert.Equal(t, "ℹ INFO\thello\n", encodeConsoleLevel(t, enc, zap.InfoLevel))
+		assert.Equal(t, "✖ ERROR\thello\n", encodeConsoleLevel(t, enc, zap.ErrorLevel))
+		assert.Equal(t, "WARN\thello\n", encodeConsoleLevel(t, enc, zap.WarnLevel))
+	})
+
+	t.Run("Colors", func(t *testing.T) {
+		enc := newLevelDecorationEncoder(map[string]string{"error": "✖"}, map[string]string{"error": "red", "info": "unknown"})
+		assert.Equal(t, "\x1b[31m✖ ERROR\x1b[0m\thello\n", encodeConsoleLevel(t, enc, zap.ErrorLevel))
+		assert.Equal(t, "INFO\thello\n", encodeConsoleLevel(t, enc, zap.InfoLevel))
+	})
+}
+
+// plainLogger 只实现Logger接口的日志包装，用于校验未实现KeyValueLogger时的回退
+type plainLogger struct {
+	Logger
+}
+
+func TestAsKeyValueLogger(t *testing.T) {
+	l, logs := newObservedLogger(zap.InfoLevel)
+
+	// 本包的日志直接实现键值对方法
+	_, ok := AsKeyValueLogger(l).(*ZapLogger)
+	assert.True(t, ok)
+
+	// 外部的Logger实现通过With附加字段
+	AsKeyValueLogger(plainLogger{l}).Warnw("slow", "user", "alice", "attempt", 2)
+	all := logs.TakeAll()
+	if assert.Len(t, all, 1) {
+		assert.Equal(t, zap.WarnLevel, all[0].Level)
+		assert.Equal(t, "slow", all[0].Message)
+		assert.Equal(t, "alice", all[0].ContextMap()["user"])
+		assert.EqualValues(t, 2, all[0].ContextMap()["attempt"])
+	}
+}
+
+func TestWithField(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, logs := newObservedLogger(zap.InfoLevel, adapter)
+
+	child := l.WithField("user", "alice")
+	child.Info("login")
+	AsKeyValueLogger(child.WithField("attempt", 2)).Infow("retry", "user", "bob")
+
+	// 父日志不受影响
+	l.Info("parent")
+
+	all := logs.All()
+	assert.Len(t, all, 3)
+	assert.Equal(t, "alice", all[0].ContextMap()["user"])
+	assert.Equal(t, "bob", all[1].ContextMap()["user"])
+	assert.EqualValues(t, 2, all[1].ContextMap()["attempt"])
+	assert.NotContains(t, all[2].ContextMap(), "user")
+
+	entries := adapter.entries(t, 3)
+	byMessage := make(map[string]LogEntry)
+	for _, e := range entries {
+		byMessage[e.Message] = e
+	}
+	assert.Equal(t, "alice", byMessage["login"].Properties["user"])
+	assert.Equal(t, "bob", byMessage["retry"].Properties["user"])
+	assert.Equal(t, 2, byMessage["retry"].Properties["attempt"])
+	assert.Nil(t, byMessage["parent"].Properties)
+}
+
+func TestWithError(t *testing.T) {
+	l, logs := newObservedLogger(zap.InfoLevel)
+
+	t.Run("Nil", func(t *testing.T) {
+		assert.Same(t, l, l.WithError(nil))
+	})
+
+	t.Run("Chain", func(t *testing.T) {
+		root := errors.New("connection refused")
+		err := fmt.Errorf("query users: %w", root)
+		l.WithError(err).Error("request failed")
+
+		ctx := logs.TakeAll()[0].ContextMap()
+		assert.Equal(t, "query users: connection refused", ctx["error"])
+		assert.Equal(t, []interface{}{"query users: connection refused", "connection refused"}, ctx["error_chain"])
+	})
+
+	t.Run("Single", func(t *testing.T) {
+		l.WithError(errors.New("boom")).Error("failed")
+
+		ctx := logs.TakeAll()[0].ContextMap()
+		assert.Equal(t, "boom", ctx["error"])
+		assert.NotContains(t, ctx, "error_chain")
+	})
+}
+
+func TestDurationAndTimeFields(t *testing.T) {
+	var buf bytes.Buffer
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(newEncoderConfig()), zapcore.AddSync(&buf), zap.InfoLevel)
+	adapter := &recordingAdapter{name: "recording"}
+	l := &ZapLogger{
+		logger:       zap.New(core),
+		adapterGroup: &adapterGroup{adapters: []LogAdapter{adapter}},
+	}
+	l.sugar = l.logger.Sugar()
+
+	at := time.Date(2024, 3, 15, 10, 24, 15, 123000000, time.UTC)
+	AsKeyValueLogger(l.WithField("started_at", at)).Infow("request done", "latency", 150*time.Millisecond)
+
+	// time.Duration默认编码为秒数，输出和适配器属性一致
+	var out map[string]interface{}
+	assert.NoError(t, json.Unmarshal(buf.Bytes(), &out))
+	assert.Equal(t, 0.15, out["latency"])
+	assert.Equal(t, "2024-03-15T10:24:15.123Z", out["started_at"])
+
+	props := adapter.entries(t, 1)[0].Properties
+	assert.Equal(t, 0.15, props["latency"])
+	assert.Equal(t, "2024-03-15T10:24:15.123Z", props["started_at"])
+}
+
+// captureTB 记录Log调用的testing.TB
+type captureTB struct {
+	testing.TB
+	mu    sync.Mutex
+	lines []string
+}
+
+func (c *captureTB) Log(args ...any) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.lines = append(c.lines, fmt.Sprint(args...))
+}
+
+func TestNewTestLogger(t *testing.T) {
+	tb := &captureTB{TB: t}
+	adapter := &recordingAdapter{name: "recording"}
+	RegisterAdapter("recording-test-logger", func() LogAdapter { return adapter })
+
+	l := NewTestLogger(tb, WithAdapter("recording-test-logger", nil))
+	l.WithField("user", "alice").Debug("hello from test")
+
+	tb.mu.Lock()
+	assert.Len(t, tb.lines, 1)
+	assert.Contains(t, tb.lines[0], "hello from test")
+	assert.Contains(t, tb.lines[0], `"user": "alice"`)
+	assert.NotContains(t, tb.lines[0], "\n")
+	tb.mu.Unlock()
+
+	entries := adapter.entries(t, 1)
+	assert.Equal(t, "alice", entries[0].Properties["user"])
+}
+
+// failingAdapter 处理日志总是失败的测试适配器
+type failingAdapter struct {
+	recordingAdapter
+}
+
+func (a *failingAdapter) Process(ctx context.Context, entry LogEntry) error {
+	_ = a.recordingAdapter.Process(ctx, entry)
+	return errors.New("process failed")
+}
+
+func (a *failingAdapter) Buffered() int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	return len(a.received)
+}
+
+func TestStats(t *testing.T) {
+	failing := &failingAdapter{recordingAdapter{name: "failing"}}
+	RegisterAdapter("failing-stats", func() LogAdapter { return failing })
+
+	config := NewConfig(
+		WithLevel("info"),
+		WithTerminalOutput(),
+		WithAdapter("failing-stats", nil),
+	)
+	config.consoleOutput = zapcore.AddSync(io.Discard)
+	l, err := newZapLogger(config)
+	assert.NoError(t, err)
+
+	l.Info("one")
+	l.WithField("k", "v").Info("two")
+	l.Error("three")
+	l.Debug("filtered")
+
+	failing.entries(t, 4)
+	assert.Eventually(t, func() bool { return l.Stats().Pending == 0 }, time.Second, 5*time.Millisecond)
+
+	stats := l.Stats()
+	assert.Equal(t, uint64(2), stats.Logged["info"])
+	assert.Equal(t, uint64(1), stats.Logged["error"])
+	assert.NotContains(t, stats.Logged, "debug")
+	assert.Equal(t, uint64(4), stats.AdapterErrors)
+	assert.Equal(t, uint64(0), stats.Dropped)
+	assert.Equal(t, 4, stats.Buffered["failing"])
+}
+
+func TestReplaceGlobals(t *testing.T) {
+	l, logs := newObservedLogger(zap.InfoLevel)
+
+	restore := l.ReplaceGlobals()
+	zap.L().Info("from zap.L")
+	zap.S().Infow("from zap.S", "key", "value")
+	restore()
+	zap.L().Info("after restore")
+
+	all := logs.All()
+	assert.Len(t, all, 2)
+	assert.Equal(t, "from zap.L", all[0].Message)
+	assert.Equal(t, "value", all[1].ContextMap()["key"])
+}
+
+func TestZapCoreHook(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, logs := newObservedLogger(zap.InfoLevel, adapter)
+
+	raw := l.Zap()
+	raw.With(zap.String("component", "db")).Info("raw zap", zap.Duration("latency", 20*time.Millisecond))
+	raw.Debug("raw debug")
+	l.Info("sugar")
+
+	// 输出核心仍然按级别过滤
+	assert.Len(t, logs.All(), 2)
+
+	adapter.entries(t, 3)
+	time.Sleep(20 * time.Millisecond)
+	entries := adapter.entries(t, 3)
+	assert.Len(t, entries, 3, "每条日志只应分发一次")
+
+	byMessage := make(map[string]LogEntry)
+	for _, e := range entries {
+		byMessage[e.Message] = e
+	}
+	assert.Equal(t, "db", byMessage["raw zap"].Properties["component"])
+	assert.Equal(t, 0.02, byMessage["raw zap"].Properties["latency"])
+	assert.Equal(t, "debug", byMessage["raw debug"].Level)
+	assert.Equal(t, "info", byMessage["sugar"].Level)
+}
+
+// readLogFile 读取按天生成的日志文件内容
+func readLogFile(t *testing.T, dir string) string {
+	t.Helper()
+	now := time.Now()
+	data, err := os.ReadFile(filepath.Join(dir, now.Format("2006-01"), now.Format("01-02.log")))
+	assert.NoError(t, err)
+	return string(data)
+}
+
+func TestLineEnding(t *testing.T) {
+	tests := []struct {
+		name       string
+		option     Option
+		separator  string
+		terminated bool
+	}{
+		{"Default", WithLineEnding(""), "\n", true},
+		{"CRLF", WithLineEnding("\r\n"), "\r\n", true},
+		{"None", WithNoLineEnding(), "", false},
+	}
+
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			dir := t.TempDir()
+			l, err := New(NewConfig(WithPath(dir), WithFileOutput(), tt.option))
+			assert.NoError(t, err)
+			l.Info("first")
+			l.Info("second")
+
+			content := readLogFile(t, dir)
+			if tt.separator != "" {
+				assert.Equal(t, 2, strings.Count(content, tt.separator))
+				assert.Contains(t, content, "}"+tt.separator+"{")
+			} else {
+				assert.NotContains(t, content, "\n")
+				assert.Contains(t, content, "}{")
+			}
+			assert.Equal(t, tt.terminated, strings.HasSuffix(content, "\n"))
+		})
+	}
+}
+
+func TestSetFloorLevel(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, logs := newObservedLogger(zap.DebugLevel, adapter)
+	child := l.WithField("k", "v")
+
+	assert.Error(t, l.SetFloorLevel("verbose"))
+	assert.NoError(t, l.SetFloorLevel("warn"))
+
+	l.Debug("debug")
+	l.Infof("info %d", 1)
+	AsKeyValueLogger(child).Infow("child info")
+	l.Warn("warn")
+	child.Error("error")
+
+	messages := make([]string, 0)
+	for _, e := range logs.All() {
+		messages = append(messages, e.Message)
+	}
+	assert.Equal(t, []string{"warn", "error"}, messages)
+
+	// 恢复正常
+	assert.NoError(t, l.SetFloorLevel(""))
+	l.Debug("debug again")
+	assert.Len(t, logs.All(), 3)
+
+	assert.Len(t, adapter.entries(t, 3), 3)
+}
+
+func TestOnceAndThrottle(t *testing.T) {
+	l, logs := newObservedLogger(zap.InfoLevel)
+
+	t.Run("Once", func(t *testing.T) {
+		for i := 0; i < 3; i++ {
+			l.InfoOnce("test-once-deprecated", "option X is deprecated")
+			l.WarnOnce("test-once-config", "config file not found")
+		}
+		all := logs.TakeAll()
+		assert.Len(t, all, 2)
+		assert.Equal(t, zap.InfoLevel, all[0].Level)
+		assert.Equal(t, zap.WarnLevel, all[1].Level)
+	})
+
+	t.Run("Throttle", func(t *testing.T) {
+		throttled := l.WithThrottle("test-throttle-retry", 50*time.Millisecond)
+		for i := 0; i < 5; i++ {
+			throttled.Warnf("retrying %d", i)
+		}
+		assert.Len(t, logs.TakeAll(), 1)
+
+		time.Sleep(60 * time.Millisecond)
+		throttled.Warn("retrying again")
+		l.Warn("not throttled")
+		assert.Len(t, logs.TakeAll(), 2)
+	})
+}
+
+func TestConsoleAndFileLevels(t *testing.T) {
+	dir := t.TempDir()
+	var console bytes.Buffer
+	config := NewConfig(
+		WithLevel("warn"),
+		WithConsoleLevel("info"),
+		WithFileLevel("debug"),
+		WithPath(dir),
+		WithBothOutput(),
+	)
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err := New(config)
+	assert.NoError(t, err)
+
+	l.Debug("debug message")
+	l.Info("info message")
+
+	assert.NotContains(t, console.String(), "debug message")
+	assert.Contains(t, console.String(), "info message")
+
+	content := readLogFile(t, dir)
+	assert.Contains(t, content, "debug message")
+	assert.Contains(t, content, "info message")
+}
+
+func TestNoAdapters(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, logs := newObservedLogger(zap.InfoLevel, adapter)
+
+	AsKeyValueLogger(l.NoAdapters()).Infow("sensitive", "token", "secret")
+	l.NoAdapters().WithField("k", "v").Info("also skipped")
+	l.Info("normal")
+
+	assert.Len(t, logs.All(), 3)
+	time.Sleep(20 * time.Millisecond)
+	entries := adapter.entries(t, 1)
+	assert.Len(t, entries, 1)
+	assert.Equal(t, "normal", entries[0].Message)
+}
+
+func TestRotation(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewRotateWriter(dir, RotationOptions{MaxSizeMB: 1, MaxBackups: 1, Compress: true})
+	assert.NoError(t, err)
+
+	chunk := bytes.Repeat([]byte("x"), 600*1024)
+	for i := 0; i < 3; i++ {
+		_, err := w.Write(chunk)
+		assert.NoError(t, err)
+		time.Sleep(5 * time.Millisecond)
+	}
+	assert.NoError(t, w.Close())
+
+	monthDir := filepath.Join(dir, time.Now().Format("2006-01"))
+	entries, err := os.ReadDir(monthDir)
+	assert.NoError(t, err)
+
+	var current, backups []string
+	for _, e := range entries {
+		if e.Name() == time.Now().Format("01-02.log") {
+			current = append(current, e.Name())
+		} else {
+			backups = append(backups, e.Name())
+		}
+	}
+	assert.Len(t, current, 1)
+	if assert.Len(t, backups, 1) {
+		assert.True(t, strings.HasSuffix(backups[0], ".log.gz"))
+		_, ok := parseBackupTime(backups[0], false)
+		assert.True(t, ok)
+
+		// 保留的是最新的备份，内容为第二次写入
+		f, err := os.Open(filepath.Join(monthDir, backups[0]))
+		assert.NoError(t, err)
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		assert.NoError(t, err)
+		data, err := io.ReadAll(gz)
+		assert.NoError(t, err)
+		assert.Equal(t, chunk, data)
+	}
+
+	info, err := os.Stat(filepath.Join(monthDir, current[0]))
+	assert.NoError(t, err)
+	assert.Equal(t, int64(len(chunk)), info.Size())
+}
+
+func TestContextLogging(t *testing.T) {
+	adapter := &recordingAdapter{}
+	l, logs := newObservedLogger(zapcore.DebugLevel, adapter)
+
+	ctx := ContextWithLogger(context.Background(), l)
+	ctx = WithTraceID(ctx, "trace-1")
+	ctx = WithRequestID(ctx, "req-1")
+
+	InfoCtx(ctx, "handled")
+
+	entries := logs.All()
+	if assert.Len(t, entries, 1) {
+		fields := entries[0].ContextMap()
+		assert.Equal(t, "trace-1", fields[TraceIDField])
+		assert.Equal(t, "req-1", fields[RequestIDField])
+		assert.NotContains(t, fields, SpanIDField)
+	}
+
+	received := adapter.entries(t, 1)
+	assert.Equal(t, "handled", received[0].Message)
+	assert.Equal(t, "trace-1", received[0].Properties[TraceIDField])
+	assert.NotContains(t, received[0].Properties, SpanIDField)
+
+	// context中没有相关信息时直接使用存入的日志实例
+	assert.Same(t, l, FromContext(ContextWithLogger(context.Background(), l)))
+	assert.Equal(t, Default(), FromContext(context.Background()))
+}
+
+func TestRotatorRecreatesMissingDirectory(t *testing.T) {
+	defer func(interval time.Duration) { fileCheckInterval = interval }(fileCheckInterval)
+	fileCheckInterval = 0
+
+	dir := t.TempDir()
+	w, err := NewDailyRotateWriter(dir)
+	assert.NoError(t, err)
+	defer w.Close()
+
+	_, err = w.Write([]byte("before\n"))
+	assert.NoError(t, err)
+
+	// 模拟运维脚本清理日志目录
+	assert.NoError(t, os.RemoveAll(dir))
+
+	_, err = w.Write([]byte("after\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Sync())
+
+	data, err := os.ReadFile(filepath.Join(dir, time.Now().Format("2006-01"), time.Now().Format("01-02.log")))
+	assert.NoError(t, err)
+	assert.Equal(t, "after\n", string(data))
+}
+
+func TestRotationResumesAfterRestart(t *testing.T) {
+	dir := t.TempDir()
+	options := RotationOptions{MaxSizeMB: 1, MaxBackups: 2, Compress: true}
+	monthDir := filepath.Join(dir, time.Now().Format("2006-01"))
+	current := filepath.Join(monthDir, time.Now().Format("01-02.log"))
+
+	// 模拟上次运行留下的当天文件和三个未压缩的备份
+	assert.NoError(t, os.MkdirAll(monthDir, 0755))
+	existing := bytes.Repeat([]byte("a"), 700*1024)
+	assert.NoError(t, os.WriteFile(current, existing, 0644))
+	for i := 1; i <= 3; i++ {
+		ts := time.Now().UTC().Add(-time.Duration(i) * time.Hour).Format(backupTimeFormat)
+		name := time.Now().Format("01-02") + "-" + ts + ".log"
+		assert.NoError(t, os.WriteFile(filepath.Join(monthDir, name), []byte("old"), 0644))
+	}
+
+	w, err := NewRotateWriter(dir, options)
+	assert.NoError(t, err)
+
+	// 继续追加，未超过限制时不旋转
+	_, err = w.Write(bytes.Repeat([]byte("b"), 200*1024))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Sync())
+	info, err := os.Stat(current)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(900*1024), info.Size())
+
+	// 累计超过限制时旋转当天文件
+	time.Sleep(5 * time.Millisecond)
+	_, err = w.Write(bytes.Repeat([]byte("c"), 200*1024))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	info, err = os.Stat(current)
+	assert.NoError(t, err)
+	assert.Equal(t, int64(200*1024), info.Size())
+
+	backups, err := (&DailyRotateWriter{logPath: dir}).backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 2) {
+		for _, b := range backups {
+			assert.True(t, strings.HasSuffix(b.path, ".log.gz"), b.path)
+		}
+		// 最新的备份是刚旋转的文件
+		assert.True(t, time.Since(backups[0].timestamp) < time.Minute)
+	}
+}
+
+func TestUnknownAdapterWarning(t *testing.T) {
+	var console bytes.Buffer
+	config := NewConfig(
+		WithTerminalOutput(),
+		WithAdapter("kafak", nil),
+	)
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err := New(config)
+	assert.NoError(t, err)
+	assert.NotNil(t, l)
+
+	assert.Contains(t, console.String(), "unknown adapter, skipped")
+	assert.Contains(t, console.String(), `"adapter": "kafak"`)
+}
+
+func TestKeySampling(t *testing.T) {
+	adapter := &recordingAdapter{}
+	l, _ := newObservedLogger(zapcore.DebugLevel, adapter)
+	l.sampler = newKeySampler("request_id", 3)
+
+	for i := 0; i < 7; i++ {
+		l.Infow("routine", "request_id", "a")
+	}
+	l.WithField("request_id", "b").Info("routine")
+	l.WithField("request_id", "b").Info("routine")
+	l.Errorw("failed", "request_id", "a")
+	l.Info("no key")
+
+	// a: 第1、4、7条，b: 第1条，error和不带键的日志总是保留
+	entries := adapter.entries(t, 6)
+	counts := make(map[string]int)
+	for _, e := range entries {
+		counts[fmt.Sprint(e.Message, "/", e.Properties["request_id"])]++
+	}
+	assert.Equal(t, map[string]int{
+		"routine/a":    3,
+		"routine/b":    1,
+		"failed/a":     1,
+		"no key/<nil>": 1,
+	}, counts)
+	assert.Equal(t, uint64(5), l.Stats().Sampled)
+
+	assert.Nil(t, newKeySampler("request_id", 1))
+}
+
+func TestMetricsHandler(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, _ := newObservedLogger(zapcore.DebugLevel, adapter)
+	l.stats.logged[zapcore.InfoLevel-zapcore.DebugLevel].Add(3)
+	l.stats.logged[zapcore.ErrorLevel-zapcore.DebugLevel].Add(1)
+	assert.NoError(t, l.Close())
+
+	rec := httptest.NewRecorder()
+	l.MetricsHandler().ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/metrics", nil))
+
+	assert.Equal(t, http.StatusOK, rec.Code)
+	assert.Contains(t, rec.Header().Get("Content-Type"), "text/plain")
+	body := rec.Body.String()
+	assert.Contains(t, body, "# TYPE logger_entries_total counter\n")
+	assert.Contains(t, body, `logger_entries_total{level="info"} 3`)
+	assert.Contains(t, body, `logger_entries_total{level="error"} 1`)
+	assert.Contains(t, body, `logger_entries_total{level="debug"} 0`)
+	assert.Contains(t, body, "logger_adapter_flushes_total 1\n")
+	assert.Contains(t, body, "logger_adapter_flush_errors_total 0\n")
+	assert.Contains(t, body, "# TYPE logger_pending_entries gauge\n")
+}
+
+type testAddress struct {
+	City string `json:"city"`
+	Zip  string `json:"zip"`
+}
+
+type testPayload struct {
+	ID      int          `json:"id"`
+	Tags    []string     `json:"tags"`
+	Address *testAddress `json:"address"`
+}
+
+// testObject 实现zapcore.ObjectMarshaler
+type testObject struct{ name string }
+
+func (o testObject) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	enc.AddString("name", o.name)
+	return enc.AddObject("inner", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+		enc.AddInt("depth", 2)
+		return nil
+	}))
+}
+
+func TestStructFields(t *testing.T) {
+	dir := t.TempDir()
+	adapter := &recordingAdapter{name: "recording"}
+	RegisterAdapter("recording-structs", func() LogAdapter { return adapter })
+
+	config := NewConfig(
+		WithPath(dir),
+		WithFileOutput(),
+		WithAdapter("recording-structs", nil),
+	)
+	l, err := New(config)
+	assert.NoError(t, err)
+
+	payload := testPayload{ID: 7, Tags: []string{"a", "b"}, Address: &testAddress{City: "Hangzhou", Zip: "310000"}}
+	AsKeyValueLogger(l).Infow("event", "payload", payload, "object", testObject{name: "obj"})
+
+	expectedPayload := map[string]interface{}{
+		"id":      float64(7),
+		"tags":    []interface{}{"a", "b"},
+		"address": map[string]interface{}{"city": "Hangzhou", "zip": "310000"},
+	}
+
+	// 文件输出中为嵌套的JSON对象
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(readLogFile(t, dir))), &record))
+	assert.Equal(t, expectedPayload, record["payload"])
+	assert.Equal(t, map[string]interface{}{"name": "obj", "inner": map[string]interface{}{"depth": float64(2)}}, record["object"])
+
+	// 适配器属性中同样为嵌套的map
+	entry := adapter.entries(t, 1)[0]
+	assert.Equal(t, expectedPayload, entry.Properties["payload"])
+	assert.Equal(t, map[string]interface{}{"name": "obj", "inner": map[string]interface{}{"depth": 2}}, entry.Properties["object"])
+}
+
+// fixedClock 返回固定时间的时钟
+type fixedClock struct{ t time.Time }
+
+func (c fixedClock) Now() time.Time { return c.t }
+
+func TestWithClock(t *testing.T) {
+	dir := t.TempDir()
+	fixed := time.Date(2021, 3, 4, 5, 6, 7, 8000000, time.FixedZone("CST", 8*3600))
+	adapter := &recordingAdapter{name: "recording"}
+	RegisterAdapter("recording-clock", func() LogAdapter { return adapter })
+
+	l, err := NewWithOptions(
+		WithPath(dir),
+		WithFileOutput(),
+		WithClock(fixedClock{fixed}),
+		WithAdapter("recording-clock", nil),
+	)
+	assert.NoError(t, err)
+	l.Info("pinned")
+
+	// 文件按时钟日期归档，时间戳为固定时间
+	data, err := os.ReadFile(filepath.Join(dir, "2021-03", "03-04.log"))
+	assert.NoError(t, err)
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal(data, &record))
+	assert.Equal(t, "2021-03-04T05:06:07.008+0800", record["time"])
+
+	entry := adapter.entries(t, 1)[0]
+	assert.True(t, fixed.Equal(entry.Time))
+}
+
+func TestReadEntries(t *testing.T) {
+	dir := t.TempDir()
+	fixed := time.Date(2021, 3, 4, 5, 6, 7, 8000000, time.UTC)
+	l, err := NewWithOptions(
+		WithPath(dir),
+		WithFileOutput(),
+		WithNodeID("node-1"),
+		WithModule("api"),
+		WithIP("10.0.0.1"),
+		WithClock(fixedClock{fixed}),
+	)
+	assert.NoError(t, err)
+	AsKeyValueLogger(l).Infow("first", "user", "alice", "attempt", 2)
+	l.Error("second")
+
+	data, err := os.ReadFile(filepath.Join(dir, "2021-03", "03-04.log"))
+	assert.NoError(t, err)
+
+	// 中间插入一行损坏的日志
+	lines := strings.SplitAfter(string(data), "\n")
+	content := lines[0] + "{not json\n" + lines[1]
+
+	entries, err := ReadEntries(strings.NewReader(content))
+	assert.ErrorContains(t, err, "line 2:")
+	if assert.Len(t, entries, 2) {
+		first := entries[0]
+		assert.Equal(t, "info", first.Level)
+		assert.Equal(t, "first", first.Message)
+		assert.True(t, fixed.Equal(first.Time))
+		assert.Equal(t, "node-1", first.NodeID)
+		assert.Equal(t, "api", first.Module)
+		assert.Equal(t, "10.0.0.1", first.IP)
+		assert.NotEmpty(t, first.Caller)
+		assert.Equal(t, map[string]interface{}{"user": "alice", "attempt": float64(2)}, first.Properties)
+
+		assert.Equal(t, "error", entries[1].Level)
+		assert.Equal(t, "second", entries[1].Message)
+	}
+}
+
+func TestReplay(t *testing.T) {
+	dir := t.TempDir()
+	line := func(ts, level, msg string) string {
+		return fmt.Sprintf(`{"level":%q,"time":%q,"msg":%q,"module":"api"}`+"\n", level, ts, msg)
+	}
+
+	month := filepath.Join(dir, "2021-03")
+	assert.NoError(t, os.MkdirAll(month, 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(month, "03-04.log"),
+		[]byte(line("2021-03-04T10:00:00.000Z", "INFO", "day1 current")+"garbage\n"), 0644))
+	assert.NoError(t, os.WriteFile(filepath.Join(month, "03-05.log"),
+		[]byte(line("2021-03-05T10:00:00.000Z", "WARN", "day2 warn")+line("2021-03-05T11:00:00.000Z", "DEBUG", "day2 debug")), 0644))
+
+	// 压缩的备份排在当天文件之前
+	var gzData bytes.Buffer
+	gz := gzip.NewWriter(&gzData)
+	_, _ = gz.Write([]byte(line("2021-03-04T09:00:00.000Z", "ERROR", "day1 backup")))
+	assert.NoError(t, gz.Close())
+	assert.NoError(t, os.WriteFile(filepath.Join(month, "03-04-2021-03-04T09-30-00.000.log.gz"), gzData.Bytes(), 0644))
+
+	messages := func(a *recordingAdapter) []string {
+		var result []string
+		for _, e := range a.received {
+			result = append(result, e.Message)
+		}
+		return result
+	}
+
+	t.Run("All", func(t *testing.T) {
+		adapter := &recordingAdapter{name: "replay"}
+		err := Replay(context.Background(), dir, adapter)
+		assert.ErrorContains(t, err, "03-04.log: line 2")
+		assert.Equal(t, []string{"day1 backup", "day1 current", "day2 warn", "day2 debug"}, messages(adapter))
+		assert.Equal(t, "api", adapter.received[0].Module)
+	})
+
+	t.Run("Filtered", func(t *testing.T) {
+		adapter := &recordingAdapter{name: "replay"}
+		_ = ReplayFiltered(context.Background(), dir, ReplayFilter{
+			Since:    time.Date(2021, 3, 4, 9, 30, 0, 0, time.UTC),
+			MinLevel: "info",
+		}, adapter)
+		assert.Equal(t, []string{"day1 current", "day2 warn"}, messages(adapter))
+	})
+
+	t.Run("Canceled", func(t *testing.T) {
+		ctx, cancel := context.WithCancel(context.Background())
+		cancel()
+		adapter := &recordingAdapter{name: "replay"}
+		assert.ErrorIs(t, Replay(ctx, dir, adapter), context.Canceled)
+		assert.Empty(t, adapter.received)
+	})
+}
+
+func TestPanicDeliversToAdapters(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, logs := newObservedLogger(zapcore.DebugLevel, adapter)
+
+	assert.Panics(t, func() {
+		l.Panicf("fatal %s", "state")
+	})
+
+	// panic返回前已同步送达并刷新，不需要等待
+	adapter.mu.Lock()
+	received := append([]LogEntry(nil), adapter.received...)
+	adapter.mu.Unlock()
+	if assert.Len(t, received, 1) {
+		assert.Equal(t, "panic", received[0].Level)
+		assert.Equal(t, "fatal state", received[0].Message)
+	}
+	assert.Equal(t, uint64(1), l.Stats().Flushes)
+	assert.Equal(t, int64(0), l.Stats().Pending)
+	assert.Len(t, logs.All(), 1)
+}
+
+// blockingAdapter 在release关闭前阻塞Process的适配器
+type blockingAdapter struct {
+	recordingAdapter
+	release chan struct{}
+}
+
+func (a *blockingAdapter) Process(ctx context.Context, entry LogEntry) error {
+	<-a.release
+	return a.recordingAdapter.Process(ctx, entry)
+}
+
+// delayAdapter 处理每条日志前等待一段时间的测试适配器
+type delayAdapter struct {
+	recordingAdapter
+	delay time.Duration
+}
+
+func (a *delayAdapter) Process(ctx context.Context, entry LogEntry) error {
+	time.Sleep(a.delay)
+	return a.recordingAdapter.Process(ctx, entry)
+}
+
+func TestCloseDrain(t *testing.T) {
+	// 正常关闭时队列中的日志全部送达
+	slow := &delayAdapter{recordingAdapter: recordingAdapter{name: "slow"}, delay: time.Millisecond}
+	l, _ := newObservedLogger(zapcore.DebugLevel, slow)
+	l.dispatchWorkers = 1
+	l.drainTimeout = time.Second
+	for i := 0; i < 50; i++ {
+		l.Infof("entry %d", i)
+	}
+	assert.NoError(t, l.Close())
+	slow.mu.Lock()
+	assert.Len(t, slow.received, 50)
+	slow.mu.Unlock()
+
+	// 关闭后不再接受新日志
+	l.enqueue(LogEntry{Message: "after close"})
+	assert.Nil(t, l.dispatcher)
+	assert.Equal(t, uint64(1), l.Stats().Dropped)
+
+	// 排空超时后放弃剩余日志，仍然刷新并关闭适配器
+	blocking := &blockingAdapter{recordingAdapter: recordingAdapter{name: "blocking"}, release: make(chan struct{})}
+	l, _ = newObservedLogger(zapcore.DebugLevel, blocking)
+	l.dispatchWorkers = 1
+	l.drainTimeout = 20 * time.Millisecond
+	l.Info("in flight")
+	assert.Eventually(t, func() bool { return len(l.dispatcher.entries) == 0 }, time.Second, time.Millisecond)
+	l.Info("queued")
+	l.Info("queued")
+	start := time.Now()
+	err := l.Close()
+	assert.EqualError(t, err, "close logger drain timed out: 3 entries not delivered")
+	assert.Less(t, time.Since(start), time.Second)
+
+	close(blocking.release)
+	assert.Eventually(t, func() bool { return l.Stats().Pending == 0 }, time.Second, time.Millisecond)
+	assert.Len(t, blocking.entries(t, 1), 1)
+	assert.Equal(t, uint64(2), l.Stats().Dropped)
+}
+
+func TestDispatchBuffer(t *testing.T) {
+	adapter := &blockingAdapter{recordingAdapter: recordingAdapter{name: "blocking"}, release: make(chan struct{})}
+	l, _ := newObservedLogger(zapcore.DebugLevel, adapter)
+	l.dispatchBuffer = 2
+	l.dispatchWorkers = 1
+
+	// 工作协程阻塞在第一条日志上，队列容纳两条，其余被丢弃且不阻塞调用方
+	l.Info("first")
+	assert.Eventually(t, func() bool { return len(l.dispatcher.entries) == 0 }, time.Second, time.Millisecond)
+	for i := 0; i < 5; i++ {
+		l.Info("burst")
+	}
+	assert.Equal(t, uint64(3), l.Stats().Dropped)
+	assert.Equal(t, int64(3), l.Stats().Pending)
+
+	// Close处理完队列中剩余的日志
+	close(adapter.release)
+	assert.NoError(t, l.Close())
+	assert.Len(t, adapter.received, 3)
+	assert.Equal(t, int64(0), l.Stats().Pending)
+}
+
+func TestDefaultOutputType(t *testing.T) {
+	assert.Equal(t, OutputTerminal, defaultOutputType(""))
+	assert.Equal(t, OutputBoth, defaultOutputType("./logs"))
+
+	// 只设置路径时同时输出到文件和终端
+	dir := t.TempDir()
+	var console bytes.Buffer
+	config := NewConfig(WithPath(dir))
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err := New(config)
+	assert.NoError(t, err)
+	l.Info("both outputs")
+
+	assert.Contains(t, console.String(), "both outputs")
+	assert.Contains(t, readLogFile(t, dir), "both outputs")
+}
+
+// brokenAdapter 初始化和刷新都失败的测试适配器
+type brokenAdapter struct {
+	recordingAdapter
+	initErr error
+}
+
+func (a *brokenAdapter) Init(config map[string]interface{}) error { return a.initErr }
+func (a *brokenAdapter) Flush() error                             { return errors.New("flush failed") }
+
+func TestInternalErrorWriter(t *testing.T) {
+	RegisterAdapter("broken-init", func() LogAdapter {
+		return &brokenAdapter{recordingAdapter: recordingAdapter{name: "broken-init"}, initErr: errors.New("no route")}
+	})
+	RegisterAdapter("broken-flush", func() LogAdapter {
+		return &brokenAdapter{recordingAdapter: recordingAdapter{name: "broken-flush"}}
+	})
+
+	var console, internal bytes.Buffer
+	config := NewConfig(
+		WithLenientAdapters(),
+		WithInternalErrorWriter(&internal),
+		WithAdapter("broken-init", nil),
+		WithAdapter("broken-flush", nil),
+	)
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err := New(config)
+	assert.NoError(t, err)
+	l.Info("application log")
+	assert.EqualError(t, l.Close(), "flush adapter broken-flush failed: flush failed")
+
+	// 日志包自身的错误不会混入应用日志
+	assert.Contains(t, internal.String(), "init adapter broken-init failed, skipped: no route")
+	assert.Contains(t, internal.String(), "flush adapter broken-flush failed: flush failed")
+	assert.NotContains(t, console.String(), "broken-init")
+	assert.Contains(t, console.String(), "application log")
+}
+
+func TestCurrentLogFile(t *testing.T) {
+	dir := t.TempDir()
+	l, err := newZapLogger(NewConfig(WithPath(dir), WithFileOutput()))
+	assert.NoError(t, err)
+	assert.Equal(t, filepath.Join(dir, time.Now().Format("2006-01"), time.Now().Format("01-02.log")), l.CurrentLogFile())
+
+	terminal, err := newZapLogger(NewConfig(WithTerminalOutput()))
+	assert.NoError(t, err)
+	assert.Empty(t, terminal.CurrentLogFile())
+}
+
+func TestGoroutineIDAndSequence(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, logs := newObservedLogger(zapcore.DebugLevel, adapter)
+	l.goroutineID = true
+	l.sequence = true
+
+	l.Info("first")
+	AsKeyValueLogger(l.WithField("k", "v")).Infow("second", "user", "alice")
+	done := make(chan struct{})
+	go func() {
+		defer close(done)
+		l.Warn("other goroutine")
+	}()
+	<-done
+
+	all := logs.All()
+	if assert.Len(t, all, 3) {
+		first, second, other := all[0].ContextMap(), all[1].ContextMap(), all[2].ContextMap()
+		assert.Equal(t, uint64(1), first[SequenceField])
+		assert.Equal(t, uint64(2), second[SequenceField])
+		assert.Equal(t, uint64(3), other[SequenceField])
+		assert.Equal(t, currentGoroutineID(), first[GoroutineField])
+		assert.Equal(t, first[GoroutineField], second[GoroutineField])
+		assert.NotEqual(t, first[GoroutineField], other[GoroutineField])
+		assert.Equal(t, "alice", second["user"])
+	}
+
+	bySeq := make(map[uint64]LogEntry)
+	for _, e := range adapter.entries(t, 3) {
+		bySeq[e.Properties[SequenceField].(uint64)] = e
+	}
+	assert.Equal(t, "second", bySeq[2].Message)
+	assert.Equal(t, currentGoroutineID(), bySeq[2].Properties[GoroutineField])
+	assert.Equal(t, "v", bySeq[2].Properties["k"])
+}
+
+func TestAdapterInstances(t *testing.T) {
+	var created []*failingAdapter
+	RegisterAdapter("recording-instances", func() LogAdapter {
+		adapter := &failingAdapter{recordingAdapter{name: "recording-instances"}}
+		created = append(created, adapter)
+		return adapter
+	})
+
+	logger, err := NewWithOptions(
+		WithTerminalOutput(),
+		WithLevel("error"),
+		WithAdapterInstance("recording-instances", "primary", nil),
+		WithAdapterInstance("recording-instances", "dr", nil),
+	)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	defer l.Close()
+	if !assert.Len(t, created, 2) {
+		return
+	}
+
+	l.Info("both")
+	created[0].entries(t, 1)
+	created[1].entries(t, 1)
+	stats := l.Stats()
+	assert.Equal(t, 1, stats.Buffered["primary"])
+	assert.Equal(t, 1, stats.Buffered["dr"])
+
+	l.RemoveAdapter("dr")
+	l.Info("primary only")
+	assert.Len(t, created[0].entries(t, 2), 2)
+	time.Sleep(20 * time.Millisecond)
+	assert.Len(t, created[1].entries(t, 1), 1)
+
+	assert.Same(t, created[0], unwrapAdapter(NewAdapterInstance("primary", created[0])))
+	assert.Same(t, created[0], NewAdapterInstance("recording-instances", created[0]))
+}
+
+func TestBufferedConsole(t *testing.T) {
+	var console bytes.Buffer
+	config := NewConfig(WithTerminalOutput(), WithBufferedConsole(64*1024, time.Hour))
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+
+	l.Info("buffered info")
+	assert.Empty(t, console.String())
+
+	l.Error("urgent error")
+	assert.Contains(t, console.String(), "buffered info")
+	assert.Contains(t, console.String(), "urgent error")
+
+	l.Info("before sync")
+	assert.NotContains(t, console.String(), "before sync")
+	assert.NoError(t, l.Sync())
+	assert.Contains(t, console.String(), "before sync")
+
+	l.WithField("k", "v").Warn("before close")
+	assert.NotContains(t, console.String(), "before close")
+	assert.NoError(t, l.Close())
+	assert.Contains(t, console.String(), "before close")
+}
+
+// syncCountWriter 记录Sync次数的写入器
+type syncCountWriter struct {
+	bytes.Buffer
+	syncs int
+	err   error
+}
+
+func (w *syncCountWriter) Sync() error {
+	w.syncs++
+	return w.err
+}
+
+func TestConsoleSync(t *testing.T) {
+	newConsole := func(out zapcore.WriteSyncer, opts ...Option) *ZapLogger {
+		config := NewConfig(append([]Option{WithTerminalOutput()}, opts...)...)
+		config.consoleOutput = out
+		logger, err := New(config)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		return logger.(*ZapLogger)
+	}
+
+	// 只在error及以上级别Sync
+	out := &syncCountWriter{}
+	l := newConsole(out, WithConsoleSync(false))
+	l.Info("info")
+	l.Warn("warn")
+	assert.Equal(t, 0, out.syncs)
+	l.Error("error")
+	assert.Equal(t, 1, out.syncs)
+	l.WithField("k", "v").Error("child error")
+	assert.Equal(t, 2, out.syncs)
+
+	// 每条日志都Sync，管道不支持Sync返回的错误被忽略
+	out = &syncCountWriter{err: syscall.EINVAL}
+	l = newConsole(out, WithConsoleSync(true))
+	l.Debug("filtered")
+	l.Info("info")
+	l.Warn("warn")
+	assert.Equal(t, 2, out.syncs)
+
+	// 与缓冲输出同时使用时每条日志写入后刷新缓冲区
+	out = &syncCountWriter{}
+	l = newConsole(out, WithBufferedConsole(64*1024, time.Hour), WithConsoleSync(true))
+	l.Info("buffered info")
+	assert.Contains(t, out.String(), "buffered info")
+	assert.NoError(t, l.Close())
+
+	// 非终端的写入器需要Sync，未知的模式返回错误
+	assert.False(t, isTerminal(out))
+	config := NewConfig(WithTerminalOutput(), WithConsoleSync(true))
+	config.ConsoleSync = "sometimes"
+	_, err := New(config)
+	assert.EqualError(t, err, "unknown console sync: sometimes")
+}
+
+func TestFatalExitCode(t *testing.T) {
+	// 子进程中调用Fatal，父进程检查退出码和已刷新的适配器
+	if dir := os.Getenv("LOGGER_FATAL_DIR"); dir != "" {
+		l, err := NewWithOptions(WithTerminalOutput(), WithFatalExitCode(3))
+		if err != nil {
+			os.Exit(2)
+		}
+		l.AddAdapter(&fileFlushAdapter{recordingAdapter: &recordingAdapter{name: "recording"}, path: filepath.Join(dir, "flushed")})
+		l.Info("queued before fatal")
+		AsKeyValueLogger(l).Fatalw("cannot continue", "reason", "test")
+		return
+	}
+
+	dir := t.TempDir()
+	cmd := exec.Command(os.Args[0], "-test.run=^TestFatalExitCode$")
+	cmd.Env = append(os.Environ(), "LOGGER_FATAL_DIR="+dir)
+	output, err := cmd.CombinedOutput()
+
+	var exitErr *exec.ExitError
+	if assert.ErrorAs(t, err, &exitErr) {
+		assert.Equal(t, 3, exitErr.ExitCode())
+	}
+	assert.Contains(t, string(output), "cannot continue")
+
+	flushed, err := os.ReadFile(filepath.Join(dir, "flushed"))
+	assert.NoError(t, err)
+	// 退出前已处理完分发队列并刷新适配器
+	assert.Contains(t, string(flushed), "queued before fatal\n")
+	assert.Contains(t, string(flushed), "cannot continue\n")
+}
+
+// fileFlushAdapter 刷新时把收到的消息写入文件的测试适配器
+type fileFlushAdapter struct {
+	*recordingAdapter
+	path string
+}
+
+func (a *fileFlushAdapter) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	var b strings.Builder
+	for _, entry := range a.received {
+		b.WriteString(entry.Message + "\n")
+	}
+	return os.WriteFile(a.path, []byte(b.String()), 0644)
+}
+
+func TestAudit(t *testing.T) {
+	regular := &recordingAdapter{name: "regular"}
+	audit := &recordingAdapter{name: "audit"}
+	logger, err := NewWithOptions(WithTerminalOutput(), WithLevel("error"), WithKeySampling("user", 100), WithAuditAdapter(audit))
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	l.AddAdapter(regular)
+
+	for i := 0; i < 3; i++ {
+		assert.NoError(t, l.WithField("user", "alice").Audit("login", map[string]interface{}{"attempt": i}))
+	}
+
+	// Audit返回时已处理完成，按调用顺序到达且不受采样影响
+	audit.mu.Lock()
+	received := append([]LogEntry(nil), audit.received...)
+	audit.mu.Unlock()
+	if assert.Len(t, received, 3) {
+		for i, entry := range received {
+			assert.Equal(t, AuditLevel, entry.Level)
+			assert.Equal(t, "login", entry.Message)
+			assert.Equal(t, "alice", entry.Properties["user"])
+			assert.Equal(t, i, entry.Properties["attempt"])
+		}
+	}
+	assert.NoError(t, l.Close())
+	assert.Empty(t, regular.received)
+
+	failing, err := NewWithOptions(WithTerminalOutput(), WithAuditAdapter(&failingAdapter{recordingAdapter{name: "audit"}}))
+	assert.NoError(t, err)
+	assert.EqualError(t, failing.(*ZapLogger).Audit("login", nil), "audit adapter audit process failed: process failed")
+
+	plain, err := NewWithOptions(WithTerminalOutput())
+	assert.NoError(t, err)
+	assert.Error(t, plain.(*ZapLogger).Audit("login", nil))
+}
+
+func TestHybridConsoleFormat(t *testing.T) {
+	var console bytes.Buffer
+	config := NewConfig(WithTerminalOutput(), WithModule("poc"), WithConsoleFormat(ConsoleFormatHybrid))
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err := New(config)
+	assert.NoError(t, err)
+
+	AsKeyValueLogger(l.WithField("user", "alice")).Infow("login ok", "attempt", 2, "note", "two words", "tags", []string{"a", "b"})
+
+	line := console.String()
+	assert.True(t, strings.HasSuffix(line, "\n"))
+	assert.Equal(t, 1, strings.Count(line, "\n"))
+	assert.Contains(t, line, "\x1b[34mINFO\x1b[0m")
+	assert.Contains(t, line, "\tlogin ok attempt=2 module=poc note=\"two words\" tags=[\"a\",\"b\"] user=alice\n")
+
+	_, err = New(NewConfig(WithConsoleFormat("xml")))
+	assert.EqualError(t, err, "unknown console format: xml")
+}
+
+// steppingClock 可以手动调整时间的时钟
+type steppingClock struct {
+	mu sync.Mutex
+	t  time.Time
+}
+
+func (c *steppingClock) Now() time.Time {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	return c.t
+}
+
+func (c *steppingClock) Set(t time.Time) {
+	c.mu.Lock()
+	defer c.mu.Unlock()
+	c.t = t
+}
+
+func TestRotationClockSkew(t *testing.T) {
+	beforeMidnight := time.Date(2024, 3, 31, 23, 59, 59, 0, time.Local)
+	afterMidnight := beforeMidnight.Add(2 * time.Second)
+	day := func(dir string, ts time.Time) string {
+		data, _ := os.ReadFile(filepath.Join(dir, ts.Format("2006-01"), ts.Format("01-02.log")))
+		return string(data)
+	}
+
+	t.Run("ForwardOnly", func(t *testing.T) {
+		dir := t.TempDir()
+		clock := &steppingClock{t: beforeMidnight}
+		w, err := NewRotateWriter(dir, RotationOptions{Clock: clock})
+		assert.NoError(t, err)
+		defer w.Close()
+
+		_, _ = w.Write([]byte("a\n"))
+		clock.Set(afterMidnight)
+		_, _ = w.Write([]byte("b\n"))
+		// 时钟回拨到零点前，继续写入新的一天
+		clock.Set(beforeMidnight)
+		_, _ = w.Write([]byte("c\n"))
+		clock.Set(afterMidnight)
+		_, _ = w.Write([]byte("d\n"))
+
+		assert.Equal(t, "a\n", day(dir, beforeMidnight))
+		assert.Equal(t, "b\nc\nd\n", day(dir, afterMidnight))
+		assert.Equal(t, filepath.Join(dir, "2024-04", "04-01.log"), w.CurrentFile())
+	})
+
+	t.Run("Tolerance", func(t *testing.T) {
+		dir := t.TempDir()
+		clock := &steppingClock{t: afterMidnight}
+		w, err := NewRotateWriter(dir, RotationOptions{Clock: clock, ClockSkewTolerance: time.Minute})
+		assert.NoError(t, err)
+		defer w.Close()
+
+		_, _ = w.Write([]byte("a\n"))
+		clock.Set(beforeMidnight)
+		_, _ = w.Write([]byte("b\n"))
+		// 回拨超过容忍范围视为时钟校正
+		clock.Set(beforeMidnight.Add(-time.Hour))
+		_, _ = w.Write([]byte("c\n"))
+
+		assert.Equal(t, "a\nb\n", day(dir, afterMidnight))
+		assert.Equal(t, "c\n", day(dir, beforeMidnight))
+	})
+}
+
+func TestRotationBackupSameMillisecond(t *testing.T) {
+	dir := t.TempDir()
+	clock := &steppingClock{t: time.Date(2024, 3, 31, 12, 0, 0, 0, time.UTC)}
+	w, err := NewRotateWriter(dir, RotationOptions{Clock: clock, MaxSizeMB: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer w.Close()
+
+	// 时钟不变时连续两次按大小旋转，两个备份都被保留
+	big := bytes.Repeat([]byte("x"), 1024*1024)
+	for _, b := range []byte("abc") {
+		big[0] = b
+		_, err := w.Write(big)
+		assert.NoError(t, err)
+	}
+	backups, err := w.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 2) {
+		first, _ := os.ReadFile(backups[1].path)
+		second, _ := os.ReadFile(backups[0].path)
+		assert.Equal(t, byte('a'), first[0])
+		assert.Equal(t, byte('b'), second[0])
+	}
+}
+
+func TestRotationWriteAfterClose(t *testing.T) {
+	dir := t.TempDir()
+	beforeMidnight := time.Date(2024, 3, 31, 23, 59, 59, 0, time.Local)
+	clock := &steppingClock{t: beforeMidnight}
+	w, err := NewRotateWriter(dir, RotationOptions{Clock: clock})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, _ = w.Write([]byte("a\n"))
+	assert.NoError(t, w.Close())
+
+	// 关闭后跨天写入返回ErrClosed，不会打开新一天的文件
+	clock.Set(beforeMidnight.Add(2 * time.Second))
+	_, err = w.Write([]byte("b\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+	_, err = os.Stat(filepath.Join(dir, "2024-04", "04-01.log"))
+	assert.True(t, os.IsNotExist(err))
+}
+
+func TestRotationBucketDuration(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 3, 31, 23, 44, 0, 0, time.UTC)
+	clock := &steppingClock{t: start}
+	w, err := NewRotateWriter(dir, RotationOptions{Clock: clock, BucketDuration: 15 * time.Minute, MaxSizeMB: 1})
+	if !assert.NoError(t, err) {
+		return
+	}
+	defer w.Close()
+	read := func(day, file string) string {
+		data, _ := os.ReadFile(filepath.Join(dir, day, file))
+		return string(data)
+	}
+
+	// 时间向下取整到桶的起点，桶变化时切换文件，跨天时切换目录
+	_, _ = w.Write([]byte("a\n"))
+	assert.Equal(t, filepath.Join(dir, "2024-03-31", "23-30.log"), w.CurrentFile())
+	clock.Set(start.Add(30 * time.Second))
+	_, _ = w.Write([]byte("b\n"))
+	clock.Set(start.Add(time.Minute))
+	_, _ = w.Write([]byte("c\n"))
+	clock.Set(start.Add(16 * time.Minute))
+	_, _ = w.Write([]byte("d\n"))
+	assert.Equal(t, "a\nb\n", read("2024-03-31", "23-30.log"))
+	assert.Equal(t, "c\n", read("2024-03-31", "23-45.log"))
+	assert.Equal(t, "d\n", read("2024-04-01", "00-00.log"))
+
+	// 按大小旋转的备份沿用时间桶的文件名，同一毫秒内的第二个备份推后一毫秒
+	big := bytes.Repeat([]byte("x"), 1024*1024)
+	_, _ = w.Write(big)
+	_, _ = w.Write([]byte("e\n"))
+	backups, err := w.backups()
+	assert.NoError(t, err)
+	if assert.Len(t, backups, 2) {
+		assert.True(t, strings.HasPrefix(filepath.Base(backups[0].path), "00-00-2024-04-01T00-00-00.001"))
+		assert.True(t, strings.HasPrefix(filepath.Base(backups[1].path), "00-00-2024-04-01T00-00-00.000"))
+	}
+
+	// 时间桶必须是整分钟并能整除一天
+	for _, d := range []time.Duration{-time.Hour, 7 * time.Minute, 90 * time.Second, 25 * time.Hour} {
+		_, err := NewRotateWriter(t.TempDir(), RotationOptions{BucketDuration: d})
+		assert.EqualError(t, err, fmt.Sprintf("invalid bucket duration: %v", d))
+	}
+	for _, d := range []time.Duration{time.Minute, time.Hour, 24 * time.Hour} {
+		assert.NoError(t, validateBucketDuration(d))
+	}
+}
+
+func TestParseLevel(t *testing.T) {
+	tests := map[string]zapcore.Level{
+		"debug":    zapcore.DebugLevel,
+		"INFO":     zapcore.InfoLevel,
+		" Warning": zapcore.WarnLevel,
+		"warn":     zapcore.WarnLevel,
+		"err":      zapcore.ErrorLevel,
+		"ERROR ":   zapcore.ErrorLevel,
+		"dpanic":   zapcore.DPanicLevel,
+		"Critical": zapcore.PanicLevel,
+		"fatal":    zapcore.FatalLevel,
+	}
+	for input, want := range tests {
+		level, err := ParseLevel(input)
+		assert.NoError(t, err, input)
+		assert.Equal(t, want, level, input)
+	}
+
+	_, err := ParseLevel("verbose")
+	assert.EqualError(t, err, "unknown log level: verbose")
+
+	// 配置中的未知级别直接返回错误，不再静默使用info
+	_, err = New(NewConfig(WithTerminalOutput(), WithLevel("verbose")))
+	assert.EqualError(t, err, "unknown log level: verbose")
+	_, err = New(NewConfig(WithTerminalOutput(), WithConsoleLevel("loud")))
+	assert.EqualError(t, err, "unknown log level: loud")
+
+	l, err := New(NewConfig(WithTerminalOutput(), WithLevel("Warning")))
+	assert.NoError(t, err)
+	assert.False(t, l.(*ZapLogger).logger.Core().Enabled(zapcore.InfoLevel))
+	assert.True(t, l.(*ZapLogger).logger.Core().Enabled(zapcore.WarnLevel))
+	assert.NoError(t, l.(*ZapLogger).SetFloorLevel("ERR"))
+}
+
+func TestValidate(t *testing.T) {
+	closed := &recordingAdapter{name: "validate"}
+	RegisterAdapter("recording-validate", func() LogAdapter { return closed })
+
+	before := runtime.NumGoroutine()
+	dir := t.TempDir()
+	assert.NoError(t, Validate(NewConfig(WithPath(dir), WithFileOutput(), WithAdapter("recording-validate", nil))))
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before)
+
+	RegisterAdapter("broken-validate", func() LogAdapter {
+		return &brokenAdapter{recordingAdapter: recordingAdapter{name: "broken-validate"}, initErr: errors.New("connect refused")}
+	})
+	assert.EqualError(t, Validate(NewConfig(WithAdapter("broken-validate", nil))), "init adapter broken-validate failed: connect refused")
+	assert.EqualError(t, Validate(NewConfig(WithLevel("loud"))), "unknown log level: loud")
+
+	file := filepath.Join(t.TempDir(), "file")
+	assert.NoError(t, os.WriteFile(file, nil, 0644))
+	assert.Error(t, Validate(NewConfig(WithPath(file))))
+}
+
+func TestEnvAndVersion(t *testing.T) {
+	var console bytes.Buffer
+	adapter := &recordingAdapter{name: "recording"}
+	config := NewConfig(WithTerminalOutput(), WithEnv("prod"), WithVersion("1.4.2"))
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	logger.AddAdapter(adapter)
+
+	logger.WithField("user", "alice").Info("started")
+	AsKeyValueLogger(logger).Infow("override", "env", "canary")
+
+	assert.Contains(t, console.String(), `"env": "prod", "version": "1.4.2"`)
+	entries := adapter.entries(t, 2)
+	byMessage := map[string]LogEntry{entries[0].Message: entries[0], entries[1].Message: entries[1]}
+	assert.Equal(t, "prod", byMessage["started"].Properties["env"])
+	assert.Equal(t, "1.4.2", byMessage["started"].Properties["version"])
+	assert.Equal(t, "alice", byMessage["started"].Properties["user"])
+	assert.Equal(t, "canary", byMessage["override"].Properties["env"])
+
+	// 未设置时不附加
+	plain, _ := newObservedLogger(zapcore.InfoLevel, adapter)
+	plain.Info("plain")
+	last := adapter.entries(t, 3)[2]
+	assert.NotContains(t, last.Properties, "env")
+	assert.NotContains(t, last.Properties, "version")
+}
+
+func TestBoostLevel(t *testing.T) {
+	var console bytes.Buffer
+	config := NewConfig(WithTerminalOutput(), WithLevel("warn"))
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	assert.NoError(t, l.SetFloorLevel("error"))
+
+	l.Info("hidden before")
+	outer := l.BoostLevel("info")
+	l.Info("shown outer")
+	l.Debug("hidden outer")
+	inner := l.BoostLevel("debug")
+	l.Debug("shown inner")
+	outer()
+	l.WithField("k", "v").Debug("shown after outer")
+	inner()
+	inner()
+	l.Debug("hidden after")
+	l.Warn("hidden by floor")
+	l.Error("shown error")
+
+	out := console.String()
+	for _, msg := range []string{"shown outer", "shown inner", "shown after outer", "shown error"} {
+		assert.Contains(t, out, msg)
+	}
+	for _, msg := range []string{"hidden before", "hidden outer", "hidden after", "hidden by floor"} {
+		assert.NotContains(t, out, msg)
+	}
+
+	// 并发提升全部结束后恢复配置的级别和闸门
+	var wg sync.WaitGroup
+	for i := 0; i < 20; i++ {
+		wg.Add(1)
+		go func(level string) {
+			defer wg.Done()
+			done := l.BoostLevel(level)
+			time.Sleep(time.Millisecond)
+			done()
+		}([]string{"debug", "info"}[i%2])
+	}
+	wg.Wait()
+	assert.False(t, l.logger.Core().Enabled(zapcore.InfoLevel))
+	assert.True(t, l.logger.Core().Enabled(zapcore.WarnLevel))
+	assert.True(t, l.suppressed(zapcore.WarnLevel))
+
+	assert.NotPanics(t, l.BoostLevel("loud"))
+}
+
+// statsAdapter 报告固定发送统计的测试适配器
+type statsAdapter struct {
+	recordingAdapter
+}
+
+func (a *statsAdapter) Stats() AdapterStats {
+	return AdapterStats{Delivered: 42, LastError: "timeout"}
+}
+
+func TestAdapterStats(t *testing.T) {
+	l, _ := newObservedLogger(zapcore.InfoLevel, &statsAdapter{recordingAdapter{name: "shipper"}}, &recordingAdapter{name: "plain"})
+	l.AddAdapter(NewAdapterInstance("shipper-dr", &statsAdapter{recordingAdapter{name: "shipper"}}))
+
+	stats := l.AdapterStats()
+	assert.Len(t, stats, 2)
+	assert.Equal(t, uint64(42), stats["shipper"].Delivered)
+	assert.Equal(t, "timeout", stats["shipper-dr"].LastError)
+}
+
+func TestCallerFormat(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	tests := []struct {
+		name   string
+		option Option
+		want   string
+	}{
+		{"Short", WithLevel("info"), "\t" + filepath.Base(filepath.Dir(file)) + "/logger_test.go:"},
+		{"Full", WithFullCaller(), "\t" + file + ":"},
+		{"Package", WithPackageCaller(), "\tgithub.com/qishenonly/logger/logger_test.go:"},
+	}
+	for _, tt := range tests {
+		t.Run(tt.name, func(t *testing.T) {
+			var console bytes.Buffer
+			config := NewConfig(WithTerminalOutput(), tt.option)
+			config.consoleOutput = zapcore.AddSync(&console)
+			l, err := New(config)
+			assert.NoError(t, err)
+
+			l.Info("where")
+			assert.Contains(t, console.String(), tt.want)
+		})
+	}
+
+	assert.Equal(t, "github.com/org/repo/pkg", functionPackage("github.com/org/repo/pkg.(*T).Method"))
+	assert.Equal(t, "main", functionPackage("main.main"))
+	assert.Equal(t, "", functionPackage(""))
+
+	_, err := New(NewConfig(func(c *Config) { c.CallerFormat = "long" }))
+	assert.EqualError(t, err, "unknown caller format: long")
+}
+
+func TestBinaryPayloads(t *testing.T) {
+	payload := []byte{0xff, 0x00, 'h', 'i'}
+
+	t.Run("Base64", func(t *testing.T) {
+		dir := t.TempDir()
+		adapter := &recordingAdapter{name: "recording"}
+		logger, err := NewWithOptions(WithPath(dir), WithFileOutput())
+		assert.NoError(t, err)
+		logger.AddAdapter(adapter)
+
+		logger.Info("raw ", payload)
+		AsKeyValueLogger(logger.WithField("body", payload)).Infow("request", "sig", payload)
+
+		content := readLogFile(t, dir)
+		assert.Contains(t, content, `"msg":"raw /wBoaQ=="`)
+		assert.Contains(t, content, `"body":"/wBoaQ=="`)
+		assert.Contains(t, content, `"sig":"/wBoaQ=="`)
+
+		entries := adapter.entries(t, 2)
+		byMessage := map[string]LogEntry{entries[0].Message: entries[0], entries[1].Message: entries[1]}
+		assert.Contains(t, byMessage, "raw /wBoaQ==")
+		assert.Equal(t, "/wBoaQ==", byMessage["request"].Properties["body"])
+		assert.Equal(t, "/wBoaQ==", byMessage["request"].Properties["sig"])
+	})
+
+	t.Run("HexTruncated", func(t *testing.T) {
+		var console bytes.Buffer
+		adapter := &recordingAdapter{name: "recording"}
+		config := NewConfig(WithTerminalOutput(), WithBinaryEncoding(BinaryHex), WithMaxBinarySize(2))
+		config.consoleOutput = zapcore.AddSync(&console)
+		logger, err := New(config)
+		assert.NoError(t, err)
+		logger.AddAdapter(adapter)
+
+		AsKeyValueLogger(logger).Infow("blob", "data", payload)
+		assert.Contains(t, console.String(), `"data": "ff00...(truncated, 4 bytes)"`)
+		assert.Equal(t, "ff00...(truncated, 4 bytes)", adapter.entries(t, 1)[0].Properties["data"])
+	})
+
+	_, err := New(NewConfig(WithBinaryEncoding("base32")))
+	assert.EqualError(t, err, "unknown binary encoding: base32")
+}
+
+// hangingAdapter 在release关闭前阻塞Close的适配器
+type hangingAdapter struct {
+	recordingAdapter
+	release chan struct{}
+}
+
+func (a *hangingAdapter) Close() error {
+	<-a.release
+	return nil
+}
+
+func TestCloseWithContext(t *testing.T) {
+	hanging := &hangingAdapter{recordingAdapter: recordingAdapter{name: "hanging"}, release: make(chan struct{})}
+	defer close(hanging.release)
+	healthy := &statsAdapter{recordingAdapter{name: "healthy"}}
+	l, _ := newObservedLogger(zapcore.InfoLevel, hanging, healthy)
+	l.Info("before close")
+
+	ctx, cancel := context.WithTimeout(context.Background(), 50*time.Millisecond)
+	defer cancel()
+	start := time.Now()
+	err := l.CloseWithContext(ctx)
+	assert.EqualError(t, err, "close adapters timed out: hanging")
+	assert.Less(t, time.Since(start), time.Second)
+
+	// 超时前分发队列已处理完
+	assert.Len(t, hanging.entries(t, 1), 1)
+	assert.Len(t, healthy.entries(t, 1), 1)
+	assert.NoError(t, (&emptyLogger{}).CloseWithContext(ctx))
+}
+
+func TestInlineFields(t *testing.T) {
+	dir := t.TempDir()
+	var console bytes.Buffer
+	config := NewConfig(WithBothOutput(), WithPath(dir), WithModule("poc"), WithInlineFields())
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err := New(config)
+	assert.NoError(t, err)
+
+	AsKeyValueLogger(l.WithField("user", "alice")).Infow("login", "note", "two words")
+	l.Info("no extra")
+
+	lines := strings.Split(strings.TrimSuffix(console.String(), "\n"), "\n")
+	if assert.Len(t, lines, 2) {
+		assert.True(t, strings.HasSuffix(lines[0], "\tlogin module=poc note=\"two words\" user=alice"), lines[0])
+		assert.True(t, strings.HasSuffix(lines[1], "\tno extra module=poc"), lines[1])
+		assert.NotContains(t, lines[0], "{")
+	}
+
+	// 文件的JSON输出保持结构化
+	content := readLogFile(t, dir)
+	assert.Contains(t, content, `"msg":"login","module":"poc","user":"alice","note":"two words"`)
+}
+
+// panickingAdapter 每次Process和Close都会panic的适配器
+type panickingAdapter struct {
+	recordingAdapter
+	calls atomic.Int32
+}
+
+func (a *panickingAdapter) Process(ctx context.Context, entry LogEntry) error {
+	a.calls.Add(1)
+	panic("boom")
+}
+
+func (a *panickingAdapter) Close() error {
+	panic("close boom")
+}
+
+func TestAdapterPanic(t *testing.T) {
+	var internal bytes.Buffer
+	logger, err := NewWithOptions(WithTerminalOutput(), WithLevel("error"), WithInternalErrorWriter(&internal), WithAdapterPanicLimit(2))
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	panicky := &panickingAdapter{recordingAdapter: recordingAdapter{name: "panicky"}}
+	healthy := &recordingAdapter{name: "healthy"}
+	l.AddAdapter(panicky)
+	l.AddAdapter(healthy)
+
+	for i := 0; i < 4; i++ {
+		l.Errorw("request failed", "attempt", i)
+	}
+	assert.EqualError(t, l.Close(), "close adapter panicky failed: adapter panicky close panicked: close boom")
+
+	// 其他适配器不受影响，panic的适配器达到上限后不再被调用
+	assert.Len(t, healthy.entries(t, 4), 4)
+	assert.Equal(t, int32(2), panicky.calls.Load())
+	assert.GreaterOrEqual(t, l.Stats().AdapterErrors, uint64(3))
+
+	output := internal.String()
+	assert.Equal(t, 2, strings.Count(output, "adapter panicky process panicked: boom"))
+	assert.Contains(t, output, "adapter panicky disabled after 2 panics")
+	assert.Contains(t, output, "close adapter panicky failed: adapter panicky close panicked: close boom")
+}
+
+func TestHTTPMiddleware(t *testing.T) {
+	l, logs := newObservedLogger(zapcore.InfoLevel)
+	l.requestIDGenerator = func() string { return "req-1" }
+	l.requestIDHeader = "X-Correlation-ID"
+	handler := l.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		FromContext(r.Context()).Info("handled")
+	}))
+
+	rec := httptest.NewRecorder()
+	handler.ServeHTTP(rec, httptest.NewRequest(http.MethodGet, "/", nil))
+	assert.Equal(t, "req-1", rec.Header().Get("X-Correlation-ID"))
+
+	// 请求已带ID时沿用
+	req := httptest.NewRequest(http.MethodGet, "/", nil)
+	req.Header.Set("X-Correlation-ID", "upstream")
+	rec = httptest.NewRecorder()
+	handler.ServeHTTP(rec, req)
+	assert.Equal(t, "upstream", rec.Header().Get("X-Correlation-ID"))
+
+	entries := logs.All()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, "req-1", entries[0].ContextMap()[RequestIDField])
+		assert.Equal(t, "upstream", entries[1].ContextMap()[RequestIDField])
+	}
+
+	assert.Regexp(t, `^[0-9a-f]{8}-[0-9a-f]{4}-4[0-9a-f]{3}-[89ab][0-9a-f]{3}-[0-9a-f]{12}$`, NewRequestID())
+	assert.NotEqual(t, NewRequestID(), NewRequestID())
+}
+
+func TestTee(t *testing.T) {
+	var console, tee bytes.Buffer
+	config := NewConfig(WithTerminalOutput(), WithModule("poc"), WithTee(&tee))
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+
+	l.WithField("user", "alice").Info("first")
+	l.Debug("below level")
+	assert.Contains(t, console.String(), "first")
+	assert.Equal(t, 1, strings.Count(tee.String(), "\n"))
+	assert.Contains(t, tee.String(), `"msg":"first","module":"poc","user":"alice"`)
+
+	// 移除后不再写入，控制台输出不受影响
+	l.RemoveTee()
+	l.Info("second")
+	assert.NotContains(t, tee.String(), "second")
+	assert.Contains(t, console.String(), "second")
+
+	var incident bytes.Buffer
+	l.SetTee(&incident)
+	l.Warn("third")
+	assert.Contains(t, incident.String(), `"level":"WARN"`)
+	assert.Contains(t, incident.String(), `"msg":"third"`)
+}
+
+func TestConsoleAndFileOptions(t *testing.T) {
+	dir := t.TempDir()
+	var stdout, stderr bytes.Buffer
+	config := NewConfig(
+		WithBothOutput(),
+		WithPath(dir),
+		WithLevel("debug"),
+		WithConsoleOptions(ConsoleOptions{
+			Level:       "info",
+			Color:       true,
+			TimeFormat:  "15:04:05",
+			StderrLevel: "warn",
+		}),
+		WithFileOptions(FileOptions{Level: "warn", TimeFormat: "2006/01/02"}),
+	)
+	config.consoleOutput = zapcore.AddSync(&stdout)
+	config.consoleErrOutput = zapcore.AddSync(&stderr)
+	l, err := New(config)
+	assert.NoError(t, err)
+
+	l.Debug("debug line")
+	l.Info("info line")
+	l.Error("error line")
+	assert.NoError(t, l.Close())
+
+	// info输出到标准输出，warn及以上输出到标准错误输出，级别带颜色
+	assert.NotContains(t, stdout.String(), "debug line")
+	assert.Contains(t, stdout.String(), "\x1b[34mINFO\x1b[0m")
+	assert.Contains(t, stdout.String(), "info line")
+	assert.NotContains(t, stdout.String(), "error line")
+	assert.Contains(t, stderr.String(), "error line")
+	assert.NotContains(t, stderr.String(), "info line")
+	assert.Regexp(t, `^\d{2}:\d{2}:\d{2}\t`, stdout.String())
+
+	content := readLogFile(t, dir)
+	assert.NotContains(t, content, "info line")
+	assert.Regexp(t, `"time":"\d{4}/\d{2}/\d{2}".*"msg":"error line"`, content)
+
+	// 零值保持默认行为
+	defaults := NewConfig(WithConsoleFormat(ConsoleFormatHybrid), WithConsoleOptions(ConsoleOptions{}), WithFileOptions(FileOptions{}))
+	assert.Equal(t, "", defaults.ConsoleFormat)
+	assert.Equal(t, DefaultConfig(), defaults)
+
+	_, err = New(NewConfig(WithConsoleOptions(ConsoleOptions{StderrLevel: "loud"})))
+	assert.Error(t, err)
+}
+
+// orderAdapter 将处理顺序记录到共享的列表中
+type orderAdapter struct {
+	recordingAdapter
+	label string
+	mu    *sync.Mutex
+	order *[]string
+}
+
+func (a *orderAdapter) Init(config map[string]interface{}) error {
+	a.label, _ = config["label"].(string)
+	return nil
+}
+
+func (a *orderAdapter) Process(ctx context.Context, entry LogEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	*a.order = append(*a.order, a.label)
+	return nil
+}
+
+func TestAdapterPriority(t *testing.T) {
+	var mu sync.Mutex
+	var order []string
+	RegisterAdapter("priority-order", func() LogAdapter {
+		return &orderAdapter{recordingAdapter: recordingAdapter{name: "priority-order"}, mu: &mu, order: &order}
+	})
+
+	config := NewConfig(WithTerminalOutput(), WithLevel("error"), WithAdapterPriority("priority-order", 10, map[string]interface{}{"label": "transform"}))
+	config.Adapters = append(config.Adapters,
+		AdapterConfig{Name: "priority-order", ID: "ship", Config: map[string]interface{}{"label": "ship"}},
+		AdapterConfig{Name: "priority-order", ID: "redact", Priority: 20, Config: map[string]interface{}{"label": "redact"}},
+		AdapterConfig{Name: "priority-order", ID: "archive", Config: map[string]interface{}{"label": "archive"}},
+	)
+	l, err := New(config)
+	assert.NoError(t, err)
+
+	// 优先级高的先处理，相同优先级保持配置顺序
+	l.Error("boom")
+	assert.NoError(t, l.Close())
+	mu.Lock()
+	defer mu.Unlock()
+	assert.Equal(t, []string{"redact", "transform", "ship", "archive"}, order)
+}
+
+func TestStackDedup(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, _ := newObservedLogger(zapcore.InfoLevel, adapter)
+	start := time.Date(2024, 4, 1, 12, 0, 0, 0, time.UTC)
+	clock := &steppingClock{t: start}
+	l.clock = clock
+	l.stackDedup = newStackDedup(time.Minute)
+
+	for i := 0; i < 4; i++ {
+		if i == 3 {
+			clock.Set(start.Add(time.Minute))
+		}
+		l.Error("storm")
+	}
+	l.Error("other call site")
+
+	entries := adapter.entries(t, 5)
+	ref, _ := entries[0].Properties["stack_ref"].(string)
+	assert.NotEmpty(t, ref)
+	assert.Contains(t, entries[0].Properties["stacktrace"], "TestStackDedup")
+	assert.NotContains(t, entries[0].Properties, "stack_count")
+
+	// 窗口内重复的堆栈只保留引用和次数
+	for i, entry := range entries[1:3] {
+		assert.Equal(t, ref, entry.Properties["stack_ref"])
+		assert.Equal(t, i+2, entry.Properties["stack_count"])
+		assert.NotContains(t, entry.Properties, "stacktrace")
+	}
+
+	// 超过窗口后重新输出完整堆栈，不同调用位置的堆栈互不影响
+	assert.Equal(t, ref, entries[3].Properties["stack_ref"])
+	assert.Contains(t, entries[3].Properties, "stacktrace")
+	assert.NotEqual(t, ref, entries[4].Properties["stack_ref"])
+	assert.Contains(t, entries[4].Properties, "stacktrace")
+
+	assert.Nil(t, newStackDedup(0))
+}
+
+func TestLevelHook(t *testing.T) {
+	var internal bytes.Buffer
+	var mu sync.Mutex
+	var warned []string
+	logger, err := NewWithOptions(
+		WithTerminalOutput(),
+		WithInternalErrorWriter(&internal),
+		WithLevelHook("warn", func(entry LogEntry) {
+			mu.Lock()
+			defer mu.Unlock()
+			warned = append(warned, entry.Level+":"+entry.Message)
+		}),
+		WithLevelHook("error", func(entry LogEntry) { panic("page failed") }),
+	)
+	assert.NoError(t, err)
+
+	// 没有适配器时回调也会被调用
+	logger.Info("ok")
+	logger.Warn("slow")
+	AsKeyValueLogger(logger).Errorw("down", "service", "db")
+	assert.NoError(t, logger.Close())
+
+	mu.Lock()
+	assert.Equal(t, []string{"warn:slow", "error:down"}, warned)
+	mu.Unlock()
+	assert.Equal(t, 1, strings.Count(internal.String(), "level hook for error panicked: page failed"))
+
+	_, err = NewWithOptions(WithLevelHook("loud", func(LogEntry) {}))
+	assert.Error(t, err)
+}
+
+// toggleWriter 可以切换为写入失败的写入器
+type toggleWriter struct {
+	bytes.Buffer
+	fail bool
+}
+
+func (w *toggleWriter) Write(p []byte) (int, error) {
+	if w.fail {
+		return 0, fmt.Errorf("no space left on device")
+	}
+	return w.Buffer.Write(p)
+}
+
+func (w *toggleWriter) Sync() error { return nil }
+
+func TestFileFallback(t *testing.T) {
+	var console, internal bytes.Buffer
+	file := &toggleWriter{}
+	w := newFileFallback(file, zapcore.AddSync(&console), 2, &internal)
+
+	_, err := w.Write([]byte("one\n"))
+	assert.NoError(t, err)
+	file.fail = true
+	_, err = w.Write([]byte("two\n"))
+	assert.EqualError(t, err, "no space left on device")
+
+	// 连续失败达到阈值后写到控制台
+	_, err = w.Write([]byte("three\n"))
+	assert.NoError(t, err)
+	_, err = w.Write([]byte("four\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "three\nfour\n", console.String())
+	assert.Equal(t, 1, strings.Count(internal.String(), "WARNING: file output failed 2 consecutive times"))
+
+	// 文件恢复后回到文件输出
+	file.fail = false
+	_, err = w.Write([]byte("five\n"))
+	assert.NoError(t, err)
+	assert.Equal(t, "one\nfive\n", file.String())
+	assert.Equal(t, "three\nfour\n", console.String())
+	assert.Contains(t, internal.String(), "file output recovered")
+
+	assert.Same(t, file, newFileFallback(file, nil, -1, nil))
+}
+
+func TestRotateWriterReopensAfterFailure(t *testing.T) {
+	dir := t.TempDir()
+	w, err := NewDailyRotateWriter(dir)
+	assert.NoError(t, err)
+
+	// 模拟旋转时打开文件失败后文件为空，下次写入重新打开
+	w.mutex.Lock()
+	assert.NoError(t, w.file.Close())
+	w.file = nil
+	w.mutex.Unlock()
+	_, err = w.Write([]byte("recovered\n"))
+	assert.NoError(t, err)
+	assert.Contains(t, readLogFile(t, dir), "recovered")
+
+	assert.NoError(t, w.Close())
+	_, err = w.Write([]byte("after close\n"))
+	assert.ErrorIs(t, err, os.ErrClosed)
+}
+
+func TestLogAt(t *testing.T) {
+	dir := t.TempDir()
+	adapter := &recordingAdapter{name: "recording"}
+	var internal bytes.Buffer
+	logger, err := NewWithOptions(WithFileOutput(), WithPath(dir), WithLevel("debug"), WithInternalErrorWriter(&internal))
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	l.AddAdapter(adapter)
+
+	imported := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.LogAt(imported, "warning", "imported event", map[string]interface{}{"source": "legacy", "id": 7})
+	l.InfoAt(imported.Add(time.Second), "second", nil)
+	l.LogAt(imported, "loud", "dropped", nil)
+	assert.Equal(t, time.Now().Format("01-02.log"), filepath.Base(l.CurrentLogFile()))
+	assert.NoError(t, l.Close())
+
+	entries := adapter.entries(t, 2)
+	assert.Equal(t, "warn", entries[0].Level)
+	assert.True(t, imported.Equal(entries[0].Time))
+	assert.Equal(t, map[string]interface{}{"source": "legacy", "id": 7}, entries[0].Properties)
+	assert.True(t, imported.Add(time.Second).Equal(entries[1].Time))
+
+	// 文件内容使用指定的时间，文件名仍按当前日期
+	content := readLogFile(t, dir)
+	assert.Contains(t, content, `"level":"WARN","time":"2020-01-02T03:04:05.000Z","caller":"module/logger_test.go:`)
+	assert.Contains(t, content, `"msg":"imported event","module":"default","id":7,"source":"legacy"`)
+	assert.NotContains(t, content, "dropped")
+	assert.Contains(t, internal.String(), "log at failed: unknown log level: loud")
+}
+
+func TestSharedRotateWriter(t *testing.T) {
+	dir := t.TempDir()
+	poc, err := NewWithOptions(WithFileOutput(), WithPath(dir), WithModule("poc"))
+	assert.NoError(t, err)
+	finger, err := NewWithOptions(WithFileOutput(), WithPath(dir+"/."), WithModule("finger"))
+	assert.NoError(t, err)
+
+	// 同一目录共用一个写入器
+	assert.Same(t, poc.(*ZapLogger).rotator, finger.(*ZapLogger).rotator)
+	poc.Info("from poc")
+	finger.Info("from finger")
+
+	// 只有最后一个日志实例关闭时才关闭文件
+	assert.NoError(t, poc.Close())
+	assert.NoError(t, poc.WithField("k", "v").Close())
+	finger.Info("after poc closed")
+	assert.NotEmpty(t, finger.(*ZapLogger).CurrentLogFile())
+	assert.NoError(t, finger.Close())
+	assert.Empty(t, finger.(*ZapLogger).CurrentLogFile())
+
+	content := readLogFile(t, dir)
+	for _, msg := range []string{"from poc", "from finger", "after poc closed"} {
+		assert.Contains(t, content, msg)
+	}
+
+	// 全部释放后重新创建
+	again, err := NewWithOptions(WithFileOutput(), WithPath(dir))
+	assert.NoError(t, err)
+	assert.NotSame(t, poc.(*ZapLogger).rotator, again.(*ZapLogger).rotator)
+	assert.NoError(t, again.Close())
+}
+
+// failingFlushAdapter 刷新时返回指定错误的适配器
+type failingFlushAdapter struct {
+	recordingAdapter
+	err error
+}
+
+func (a *failingFlushAdapter) Flush() error { return a.err }
+
+func TestCloseAndFlushJoinErrors(t *testing.T) {
+	errKafka := errors.New("broker unavailable")
+	errES := errors.New("bulk rejected")
+	logger, err := NewWithOptions(WithTerminalOutput(), WithInternalErrorWriter(io.Discard))
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	l.AddAdapter(&failingFlushAdapter{recordingAdapter: recordingAdapter{name: "kafka"}, err: errKafka})
+	l.AddAdapter(&recordingAdapter{name: "healthy"})
+	l.AddAdapter(&failingFlushAdapter{recordingAdapter: recordingAdapter{name: "elasticsearch"}, err: errES})
+	l.Info("hello")
+
+	// Flush返回所有失败适配器的错误，每个错误标明适配器名称
+	err = l.Flush()
+	assert.ErrorIs(t, err, errKafka)
+	assert.ErrorIs(t, err, errES)
+	assert.EqualError(t, err, "flush adapter kafka failed: broker unavailable\nflush adapter elasticsearch failed: bulk rejected")
+
+	// Close同样按适配器顺序合并错误
+	err = l.Close()
+	assert.ErrorIs(t, err, errKafka)
+	assert.ErrorIs(t, err, errES)
+	assert.EqualError(t, err, "flush adapter kafka failed: broker unavailable\nflush adapter elasticsearch failed: bulk rejected")
+}
+
+// gatedWriter 在release关闭前阻塞每次写入的写入器
+type gatedWriter struct {
+	mu      sync.Mutex
+	buf     bytes.Buffer
+	release chan struct{}
+}
+
+func (w *gatedWriter) Write(p []byte) (int, error) {
+	<-w.release
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.Write(p)
+}
+
+func (w *gatedWriter) Sync() error { return nil }
+
+func (w *gatedWriter) String() string {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+	return w.buf.String()
+}
+
+func TestAsyncCore(t *testing.T) {
+	// 文件输出异步写出，Close时写出剩余日志
+	dir := t.TempDir()
+	l, err := NewWithOptions(WithFileOutput(), WithPath(dir), WithAsyncCore(128))
+	assert.NoError(t, err)
+	for i := 0; i < 100; i++ {
+		AsKeyValueLogger(l).Infow("async entry", "i", i)
+	}
+	assert.NoError(t, l.Close())
+	assert.Equal(t, 100, strings.Count(readLogFile(t, dir), "async entry"))
+
+	// 队列满时默认丢弃新日志，调用方不被阻塞
+	console := &gatedWriter{release: make(chan struct{})}
+	config := NewConfig(WithTerminalOutput(), WithAsyncCore(1))
+	config.consoleOutput = console
+	dropping, err := New(config)
+	assert.NoError(t, err)
+	for i := 0; i < 5; i++ {
+		AsKeyValueLogger(dropping).Infow("burst", "i", i)
+	}
+	assert.GreaterOrEqual(t, dropping.(*ZapLogger).Stats().AsyncDropped, uint64(3))
+	close(console.release)
+	assert.NoError(t, dropping.(*ZapLogger).Sync())
+	written := strings.Count(console.String(), "burst")
+	assert.Equal(t, 5, written+int(dropping.(*ZapLogger).Stats().AsyncDropped))
+	assert.NoError(t, dropping.Close())
+
+	// 阻塞策略不丢失日志，Sync等待队列写出
+	console = &gatedWriter{release: make(chan struct{})}
+	close(console.release)
+	config = NewConfig(WithTerminalOutput(), WithAsyncCore(1), WithAsyncCorePolicy(AsyncPolicyBlock))
+	config.consoleOutput = console
+	blocking, err := New(config)
+	assert.NoError(t, err)
+	for i := 0; i < 50; i++ {
+		AsKeyValueLogger(blocking).Infow("steady", "i", i)
+	}
+	assert.NoError(t, blocking.(*ZapLogger).Sync())
+	assert.Equal(t, 50, strings.Count(console.String(), "steady"))
+	assert.Zero(t, blocking.(*ZapLogger).Stats().AsyncDropped)
+	assert.NoError(t, blocking.Close())
+
+	// 关闭后的写入直接交给底层输出
+	blocking.Info("after close")
+	assert.Contains(t, console.String(), "after close")
+
+	_, err = NewWithOptions(WithTerminalOutput(), WithAsyncCore(8), WithAsyncCorePolicy("wait"))
+	assert.EqualError(t, err, "unknown async policy: wait")
+}
+
+func TestAdapterProperties(t *testing.T) {
+	var console bytes.Buffer
+	audit := &recordingAdapter{name: "audit"}
+	properties := map[string]interface{}{"datacenter": "sh-1", "cluster": "blue"}
+	config := NewConfig(
+		WithTerminalOutput(),
+		WithAdapterProperties(properties),
+		WithAdapterProperties(map[string]interface{}{"region": "cn"}),
+		WithAuditAdapter(audit),
+	)
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	recorder := &recordingAdapter{name: "recorder"}
+	l.AddAdapter(recorder)
+
+	// 修改传入的map不影响已创建的日志
+	properties["cluster"] = "green"
+
+	l.Info("plain")
+	AsKeyValueLogger(l.WithField("cluster", "red")).Infow("scoped", "datacenter", "bj-2")
+	assert.NoError(t, l.Audit("grant", map[string]interface{}{"user": "alice"}))
+
+	entries := recorder.entries(t, 2)
+	assert.Equal(t, map[string]interface{}{"datacenter": "sh-1", "cluster": "blue", "region": "cn"}, entries[0].Properties)
+	// 子日志字段和调用时的字段优先于静态属性
+	assert.Equal(t, "red", entries[1].Properties["cluster"])
+	assert.Equal(t, "bj-2", entries[1].Properties["datacenter"])
+	assert.Equal(t, "cn", entries[1].Properties["region"])
+
+	auditEntries := audit.entries(t, 1)
+	assert.Equal(t, "sh-1", auditEntries[0].Properties["datacenter"])
+	assert.Equal(t, "alice", auditEntries[0].Properties["user"])
+
+	// 静态属性不输出到控制台
+	assert.NoError(t, l.Close())
+	assert.Contains(t, console.String(), "plain")
+	assert.NotContains(t, console.String(), "sh-1")
+	assert.NotContains(t, console.String(), "region")
+}
+
+func TestFromSlog(t *testing.T) {
+	var buf bytes.Buffer
+	handler := slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelDebug, AddSource: true})
+	l := FromSlog(slog.New(handler))
+
+	decode := func() []map[string]interface{} {
+		var records []map[string]interface{}
+		for _, line := range strings.Split(strings.TrimSpace(buf.String()), "\n") {
+			var record map[string]interface{}
+			assert.NoError(t, json.Unmarshal([]byte(line), &record))
+			records = append(records, record)
+		}
+		buf.Reset()
+		return records
+	}
+
+	// 键值对和子日志字段作为slog属性转发，调用位置指向调用方
+	AsKeyValueLogger(l.WithField("module", "poc")).Infow("login", "user", "alice", "attempt", 2)
+	l.WithError(fmt.Errorf("wrap: %w", io.EOF)).Errorf("failed %d times", 3)
+	l.Debug("details")
+	records := decode()
+	assert.Len(t, records, 3)
+	assert.Equal(t, "INFO", records[0]["level"])
+	assert.Equal(t, "login", records[0]["msg"])
+	assert.Equal(t, "poc", records[0]["module"])
+	assert.Equal(t, "alice", records[0]["user"])
+	assert.Equal(t, float64(2), records[0]["attempt"])
+	assert.Contains(t, records[0]["source"].(map[string]interface{})["file"], "logger_test.go")
+	assert.Equal(t, "ERROR", records[1]["level"])
+	assert.Equal(t, "failed 3 times", records[1]["msg"])
+	assert.Equal(t, "wrap: EOF", records[1]["error"])
+	assert.Equal(t, []interface{}{"wrap: EOF", "EOF"}, records[1]["error_chain"])
+	assert.Equal(t, "DEBUG", records[2]["level"])
+
+	// 没有对应slog级别的panic映射为更高的级别
+	assert.PanicsWithValue(t, "boom", func() { AsKeyValueLogger(l).Panicw("boom", "k", "v") })
+	records = decode()
+	assert.Equal(t, "ERROR+4", records[0]["level"])
+	assert.Equal(t, slog.LevelError+8, SlogLevel(zapcore.FatalLevel))
+
+	// LogAt使用指定时间，级别由slog的Handler过滤
+	at := time.Date(2020, 1, 2, 3, 4, 5, 0, time.UTC)
+	l.LogAt(at, "warn", "imported", map[string]interface{}{"id": 7})
+	l.LogAt(at, "loud", "dropped", nil)
+	records = decode()
+	assert.Len(t, records, 1)
+	assert.Equal(t, "2020-01-02T03:04:05Z", records[0]["time"])
+	assert.Equal(t, "WARN", records[0]["level"])
+	assert.Equal(t, float64(7), records[0]["id"])
+
+	quiet := FromSlog(slog.New(slog.NewJSONHandler(&buf, &slog.HandlerOptions{Level: slog.LevelWarn})))
+	quiet.Info("filtered")
+	assert.Empty(t, buf.String())
+
+	// 适配器和审计由本包的输出管道提供，slog支撑时不可用
+	l.AddAdapter(&recordingAdapter{name: "ignored"})
+	assert.EqualError(t, l.Audit("grant", nil), "audit adapter not configured")
+	assert.NoError(t, l.Flush())
+	assert.NoError(t, l.Close())
+}
+
+func TestAccessLogSampling(t *testing.T) {
+	l, logs := newObservedLogger(zapcore.InfoLevel)
+	config := NewConfig(WithSuccessSampleRate(1, 3))
+	l.accessLog = newAccessLog(config.AccessLog, config.SuccessSampleKeep, config.SuccessSampleEvery)
+	handler := l.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		switch r.URL.Path {
+		case "/redirect":
+			http.Redirect(w, r, "/", http.StatusFound)
+		case "/missing":
+			http.NotFound(w, r)
+		case "/broken":
+			w.WriteHeader(http.StatusInternalServerError)
+		default:
+			_, _ = w.Write([]byte("ok"))
+		}
+	}))
+	serve := func(path string, n int) {
+		for i := 0; i < n; i++ {
+			handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, path, nil))
+		}
+	}
+
+	// 2xx和3xx分别每3条输出1条，4xx和5xx全部输出
+	serve("/", 6)
+	serve("/redirect", 3)
+	serve("/missing", 4)
+	serve("/broken", 2)
+
+	count := func(status int) int {
+		return logs.FilterField(zap.Int("status", status)).Len()
+	}
+	assert.Equal(t, 2, count(http.StatusOK))
+	assert.Equal(t, 1, count(http.StatusFound))
+	assert.Equal(t, 4, count(http.StatusNotFound))
+	assert.Equal(t, 2, count(http.StatusInternalServerError))
+
+	entries := logs.FilterMessage("http request").All()
+	assert.Len(t, entries, 9)
+	first := entries[0].ContextMap()
+	assert.Equal(t, "GET", first["method"])
+	assert.Equal(t, "/", first["path"])
+	assert.Equal(t, int64(2), first["bytes"])
+	assert.NotEmpty(t, first[RequestIDField])
+	assert.Equal(t, zapcore.WarnLevel, logs.FilterField(zap.Int("status", http.StatusNotFound)).All()[0].Level)
+	assert.Equal(t, zapcore.ErrorLevel, logs.FilterField(zap.Int("status", http.StatusInternalServerError)).All()[0].Level)
+
+	// 未启用访问日志时不输出
+	assert.Nil(t, newAccessLog(false, 1, 3))
+}
+
+func TestAccessLog(t *testing.T) {
+	r := httptest.NewRequest(http.MethodPost, "/orders?id=1", nil)
+	r.Header.Set("User-Agent", "test-agent")
+	entry := NewAccessLog(r, http.StatusCreated, 12, 1500*time.Microsecond)
+	entry.RequestID = "req-1"
+
+	// JSON序列化使用与日志相同的字段名
+	data, err := json.Marshal(entry)
+	if !assert.NoError(t, err) {
+		return
+	}
+	var decoded map[string]interface{}
+	if !assert.NoError(t, json.Unmarshal(data, &decoded)) {
+		return
+	}
+	assert.Equal(t, map[string]interface{}{
+		"method": "POST", "path": "/orders", "status": float64(201), "bytes": float64(12),
+		"duration_ms": 1.5, "remote_addr": "192.0.2.1:1234", "user_agent": "test-agent", "request_id": "req-1",
+	}, decoded)
+
+	// 按状态码选择级别
+	l, logs := newObservedLogger(zapcore.InfoLevel)
+	entry.LogWith(l)
+	entry.Status = http.StatusNotFound
+	entry.LogWith(l)
+	entry.Status = http.StatusBadGateway
+	entry.LogWith(l)
+	entries := logs.FilterMessage("http request").All()
+	if !assert.Len(t, entries, 3) {
+		return
+	}
+	assert.Equal(t, zapcore.InfoLevel, entries[0].Level)
+	assert.Equal(t, zapcore.WarnLevel, entries[1].Level)
+	assert.Equal(t, zapcore.ErrorLevel, entries[2].Level)
+	fields := entries[0].ContextMap()
+	assert.Equal(t, "req-1", fields[RequestIDField])
+	assert.Equal(t, "test-agent", fields["user_agent"])
+	assert.Equal(t, 1.5, fields["duration_ms"])
+
+	// 只输出指定的字段，空字符串字段省略
+	entry.Fields = []string{AccessLogMethod, AccessLogStatus, AccessLogUserAgent}
+	entry.UserAgent = ""
+	assert.Equal(t, []interface{}{"method", "POST", "status", http.StatusBadGateway}, entry.KeysAndValues())
+
+	// 中间件按配置选择字段
+	l, logs = newObservedLogger(zapcore.InfoLevel)
+	l.accessLog = newAccessLog(true, 0, 0)
+	l.accessLog.fields = []string{AccessLogPath, AccessLogStatus}
+	handler := l.HTTPMiddleware(http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {}))
+	handler.ServeHTTP(httptest.NewRecorder(), httptest.NewRequest(http.MethodGet, "/health", nil))
+	entries = logs.FilterMessage("http request").All()
+	if !assert.Len(t, entries, 1) {
+		return
+	}
+	fields = entries[0].ContextMap()
+	assert.Equal(t, "/health", fields["path"])
+	assert.Equal(t, int64(http.StatusOK), fields["status"])
+	assert.NotContains(t, fields, "method")
+	assert.NotEmpty(t, fields[RequestIDField])
+
+	// 未知的字段名在创建时返回错误
+	_, err = New(NewConfig(WithAccessLogFields("query")))
+	assert.EqualError(t, err, "unknown access log field: query")
+}
+
+// closeRecorder 记录写入内容和关闭次数的写入器，模拟lumberjack.Logger
+type closeRecorder struct {
+	bytes.Buffer
+	closes int
+}
+
+func (w *closeRecorder) Close() error {
+	w.closes++
+	return nil
+}
+
+func TestFileWriter(t *testing.T) {
+	w := &closeRecorder{}
+
+	// 校验配置时不写入也不关闭调用方的写入器
+	assert.NoError(t, Validate(NewConfig(WithFileWriter(w))))
+	assert.Zero(t, w.closes)
+
+	dir := t.TempDir()
+	logger, err := NewWithOptions(WithFileWriter(w), WithPath(dir), WithModule("poc"))
+	assert.NoError(t, err)
+	logger.WithField("user", "alice").Info("to custom sink")
+	assert.Empty(t, logger.(*ZapLogger).CurrentLogFile())
+
+	// 关闭时只关闭一次，子日志共享同一个写入器
+	assert.NoError(t, logger.WithField("k", "v").Close())
+	assert.NoError(t, logger.Close())
+	assert.Equal(t, 1, w.closes)
+	assert.Contains(t, w.String(), `"msg":"to custom sink","module":"poc","user":"alice"`)
+
+	// 不使用默认的按天旋转写入器
+	entries, err := os.ReadDir(dir)
+	assert.NoError(t, err)
+	assert.Empty(t, entries)
+
+	// 只在未设置输出类型时启用文件输出，不覆盖已设置的输出类型
+	assert.Equal(t, OutputFile, NewConfig(WithFileWriter(w)).OutputType)
+	assert.Equal(t, OutputBoth, NewConfig(WithOutputType(OutputBoth), WithFileWriter(w)).OutputType)
+	assert.Equal(t, OutputTerminal, NewConfig(WithTerminalOutput(), WithFileWriter(w)).OutputType)
+	assert.Equal(t, OutputAdapters, NewConfig(WithOutputType(OutputAdapters), WithFileWriter(w)).OutputType)
+
+	// 输出类型不包含文件时写入器不会被写入，创建日志返回错误且不关闭写入器
+	closes := w.closes
+	for _, outputType := range []OutputType{OutputTerminal, OutputAdapters} {
+		_, err := New(NewConfig(WithOutputType(outputType), WithFileWriter(w)))
+		assert.EqualError(t, err, fmt.Sprintf("file writer requires file output, got output type %s", outputType))
+	}
+	assert.Equal(t, closes, w.closes)
+
+	// 直接构造配置未设置输出类型时与WithFileWriter一致
+	assert.Equal(t, OutputFile, configOutputType(Config{FileWriter: w}))
+	assert.Equal(t, OutputBoth, configOutputType(Config{FileWriter: w, Path: dir}))
+}
+
+// callerFunctionTarget 用于校验func字段的调用方
+type callerFunctionTarget struct{}
+
+func (callerFunctionTarget) handle(l Logger) {
+	AsKeyValueLogger(l).Infow("handled", "user", "alice")
+}
+
+func TestCallerFunction(t *testing.T) {
+	var console bytes.Buffer
+	config := NewConfig(WithTerminalOutput(), WithCallerFunction())
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	recorder := &recordingAdapter{name: "recorder"}
+	l.AddAdapter(recorder)
+	// 测试代码与日志包同名，只裁剪日志包自身的方法，使调用方为测试中的类型
+	l.stackTrimPrefixes = []string{"github.com/qishenonly/logger.(*ZapLogger).", "github.com/qishenonly/logger.(*adapterGroup)."}
+
+	callerFunctionTarget{}.handle(l)
+	l.Infow("explicit", FunctionField, "custom")
+
+	const function = "github.com/qishenonly/logger.callerFunctionTarget.handle"
+	entries := recorder.entries(t, 2)
+	assert.Equal(t, function, entries[0].Properties[FunctionField])
+	assert.Equal(t, "alice", entries[0].Properties["user"])
+	assert.Equal(t, "custom", entries[1].Properties[FunctionField])
+	assert.NoError(t, l.Close())
+	assert.Contains(t, console.String(), "\t"+function+"\thandled")
+
+	// 未开启时不附加
+	plain, logs := newObservedLogger(zapcore.InfoLevel, &recordingAdapter{name: "plain"})
+	plain.Info("no func")
+	assert.NotContains(t, logs.All()[0].ContextMap(), FunctionField)
+	assert.Equal(t, "", captureCaller([]string{""}, 0).Function)
+}
+
+func TestSortedFields(t *testing.T) {
+	fixed := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	var tee bytes.Buffer
+	dir := t.TempDir()
+	logger, err := NewWithOptions(WithFileOutput(), WithPath(dir), WithModule("poc"), WithClock(fixedClock{fixed}), WithSortedFields(), WithTee(&tee))
+	assert.NoError(t, err)
+
+	// 字段添加顺序不同时输出相同
+	AsKeyValueLogger(logger.WithField("zone", "b").WithField("user", "alice")).Infow("login", "attempt", 2, `we"ird`, "x\ny")
+	AsKeyValueLogger(logger.WithField("user", "alice").WithField("zone", "b")).Infow("login", `we"ird`, "x\ny", "attempt", 2)
+	assert.NoError(t, logger.Close())
+
+	data, err := os.ReadFile(filepath.Join(dir, "2024-05", "05-06.log"))
+	assert.NoError(t, err)
+	lines := strings.Split(strings.TrimSpace(string(data)), "\n")
+	if assert.Len(t, lines, 2) {
+		// 两次调用只有行号不同
+		caller := regexp.MustCompile(`"caller":"[^"]+"`)
+		assert.Equal(t, caller.ReplaceAllString(lines[0], ""), caller.ReplaceAllString(lines[1], ""))
+		assert.Regexp(t, `^\{"time":"2024-05-06T07:08:09\.000Z","level":"INFO","msg":"login","attempt":2,"caller":"[^"]+","module":"poc","user":"alice","we\\"ird":"x\\ny","zone":"b"\}$`, lines[0])
+
+		// 输出仍是合法的JSON
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+		assert.Equal(t, "x\ny", record[`we"ird`])
+	}
+	assert.Equal(t, 2, strings.Count(tee.String(), `{"time":"2024-05-06T07:08:09.000Z","level":"INFO","msg":"login","attempt":2,`))
+}
+
+func TestOmitEmpty(t *testing.T) {
+	var nilPointer *int
+	var nilMap map[string]int
+	var nilSlice []string
+	var nilError error
+	one := 1
+	cases := []struct {
+		name  string
+		value interface{}
+		empty bool
+	}{
+		{"nil", nil, true},
+		{"empty string", "", true},
+		{"string", "x", false},
+		{"nil pointer", nilPointer, true},
+		{"pointer", &one, false},
+		{"nil map", nilMap, true},
+		{"empty map", map[string]int{}, true},
+		{"map", map[string]int{"a": 1}, false},
+		{"nil slice", nilSlice, true},
+		{"empty slice", []string{}, true},
+		{"slice", []string{"a"}, false},
+		{"empty array", [0]int{}, true},
+		{"array", [1]int{}, false},
+		{"nil error", nilError, true},
+		{"zero time", time.Time{}, true},
+		{"time", time.Now(), false},
+		{"zero int", 0, false},
+		{"zero float", 0.0, false},
+		{"false", false, false},
+		{"zero duration", time.Duration(0), false},
+		{"struct", struct{}{}, false},
+	}
+	for _, c := range cases {
+		assert.Equal(t, c.empty, isEmptyValue(c.value), "value %s", c.name)
+		assert.Equal(t, c.empty, isEmptyField(zap.Any("k", c.value)), "field %s", c.name)
+	}
+	assert.True(t, isEmptyField(zap.Binary("k", nil)))
+	assert.True(t, isEmptyField(zap.ByteString("k", []byte{})))
+	assert.True(t, isEmptyField(zap.Stringer("k", nil)))
+	assert.False(t, isEmptyField(zap.Error(errors.New("x"))))
+
+	var console bytes.Buffer
+	config := NewConfig(WithTerminalOutput(), WithOmitEmpty())
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	recorder := &recordingAdapter{name: "recorder"}
+	l.AddAdapter(recorder)
+
+	AsKeyValueLogger(l.WithField("ip", "").WithField("user", "alice")).Infow("login", "note", "", "tags", []string{}, "since", time.Time{}, "attempt", 0, "ok", false)
+
+	// 输出和适配器属性都只保留非空的字段
+	entries := recorder.entries(t, 1)
+	assert.Equal(t, map[string]interface{}{"user": "alice", "attempt": 0, "ok": false}, entries[0].Properties)
+	assert.NoError(t, l.Close())
+	assert.Contains(t, console.String(), `"user": "alice", "attempt": 0, "ok": false}`)
+	for _, key := range []string{"ip", "note", "tags", "since"} {
+		assert.NotContains(t, console.String(), `"`+key+`"`)
+	}
+}
+
+// verifyingAdapter 按self_test字段确认自检日志的适配器
+type verifyingAdapter struct {
+	recordingAdapter
+}
+
+func (a *verifyingAdapter) VerifySelfTest(ctx context.Context, id string) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	levels := 0
+	for _, entry := range a.received {
+		if entry.Properties[SelfTestField] == id {
+			levels++
+		}
+	}
+	if levels == 0 {
+		return fmt.Errorf("self test %s not received", id)
+	}
+	return nil
+}
+
+func TestSelfTest(t *testing.T) {
+	dir := t.TempDir()
+	logger, err := NewWithOptions(WithFileOutput(), WithPath(dir), WithLevel("debug"), WithInternalErrorWriter(io.Discard))
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	verifier := &verifyingAdapter{recordingAdapter{name: "verifier"}}
+	l.AddAdapter(verifier)
+	l.AddAdapter(&recordingAdapter{name: "plain"})
+
+	// 每个级别输出一条带self_test标记的日志，文件和适配器都确认收到
+	assert.NoError(t, l.SelfTest(context.Background()))
+	var levels []string
+	var id interface{}
+	for _, entry := range verifier.entries(t, 4) {
+		levels = append(levels, entry.Level)
+		id = entry.Properties[SelfTestField]
+	}
+	assert.Equal(t, []string{"debug", "info", "warn", "error"}, levels)
+	assert.Equal(t, 4, strings.Count(readLogFile(t, dir), fmt.Sprintf(`"%s":"%s"`, SelfTestField, id)))
+
+	// 适配器刷新失败和确认失败一起返回
+	l.RemoveAdapter("verifier")
+	l.AddAdapter(&failingFlushAdapter{recordingAdapter: recordingAdapter{name: "kafka"}, err: errors.New("broker unavailable")})
+	l.AddAdapter(&rejectingVerifier{recordingAdapter{name: "loki"}})
+	err = l.SelfTest(context.Background())
+	assert.ErrorContains(t, err, "flush adapter kafka failed: broker unavailable")
+	assert.ErrorContains(t, err, "verify adapter loki failed: rejected")
+	assert.NotContains(t, err.Error(), "log file")
+
+	ctx, cancel := context.WithCancel(context.Background())
+	cancel()
+	assert.ErrorIs(t, l.SelfTest(ctx), context.Canceled)
+	assert.EqualError(t, l.Close(), "flush adapter kafka failed: broker unavailable")
+}
+
+// rejectingVerifier 总是确认失败的适配器
+type rejectingVerifier struct {
+	recordingAdapter
+}
+
+func (a *rejectingVerifier) VerifySelfTest(ctx context.Context, id string) error {
+	return errors.New("rejected")
+}
+
+func TestLevelSampling(t *testing.T) {
+	var console bytes.Buffer
+	fixed := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	config := NewConfig(
+		WithTerminalOutput(),
+		WithLevel("debug"),
+		WithClock(fixedClock{fixed}),
+		WithLevelSampling("debug", 2, 0),
+		WithLevelSampling("info", 1, 3),
+	)
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+
+	// 固定时钟下所有日志处于同一个采样周期
+	for i := 0; i < 10; i++ {
+		logger.Debug("debug sampled")
+		logger.WithField("i", i).Info("info sampled")
+		logger.Warn("warn kept")
+		logger.Error("error kept")
+	}
+	assert.NoError(t, logger.Close())
+
+	out := console.String()
+	assert.Equal(t, 2, strings.Count(out, "debug sampled"))
+	// 第1条输出，之后每3条输出一条：第1、4、7、10条
+	assert.Equal(t, 4, strings.Count(out, "info sampled"))
+	assert.Equal(t, 10, strings.Count(out, "warn kept"))
+	assert.Equal(t, 10, strings.Count(out, "error kept"))
+
+	_, err = NewWithOptions(WithTerminalOutput(), WithLevelSampling("error", 1, 100))
+	assert.EqualError(t, err, "sampling is not allowed for level error")
+	_, err = NewWithOptions(WithTerminalOutput(), WithLevelSampling("verbose", 1, 100))
+	assert.Error(t, err)
+	_, err = NewWithOptions(WithTerminalOutput(), WithLevelSampling("info", -1, 100))
+	assert.Error(t, err)
+}
+
+func TestAddField(t *testing.T) {
+	adapter := &recordingAdapter{}
+	l, logs := newObservedLogger(zapcore.DebugLevel, adapter)
+
+	parent := AddField(ContextWithLogger(context.Background(), l), "tenant", "acme")
+	child := AddField(parent, "user_id", 42)
+	child = AddField(child, "tenant", "globex")
+
+	InfoCtx(parent, "parent")
+	FromContext(child).Info("child")
+
+	// 子context覆盖同名字段，父context中的字段不受影响
+	entries := logs.All()
+	if assert.Len(t, entries, 2) {
+		assert.Equal(t, map[string]interface{}{"tenant": "acme"}, entries[0].ContextMap())
+		assert.Equal(t, map[string]interface{}{"tenant": "globex", "user_id": int64(42)}, entries[1].ContextMap())
+	}
+	received := adapter.entries(t, 2)
+	assert.Equal(t, "acme", received[0].Properties["tenant"])
+	assert.Equal(t, 42, received[1].Properties["user_id"])
+}
+
+// brokerAdapter 刷新前缓存日志的适配器，记录初始化时的broker
+type brokerAdapter struct {
+	mu      sync.Mutex
+	broker  string
+	pending []LogEntry
+	flushed []LogEntry
+	closed  bool
+}
+
+func (a *brokerAdapter) Name() string { return "broker" }
+
+func (a *brokerAdapter) Init(config map[string]interface{}) error {
+	broker, _ := config["broker"].(string)
+	if broker == "" {
+		return errors.New("broker is required")
+	}
+	a.broker = broker
+	return nil
+}
+
+func (a *brokerAdapter) Process(ctx context.Context, entry LogEntry) error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.pending = append(a.pending, entry)
+	return nil
+}
+
+func (a *brokerAdapter) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.flushed = append(a.flushed, a.pending...)
+	a.pending = nil
+	return nil
+}
+
+func (a *brokerAdapter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.closed = true
+	return nil
+}
+
+func TestReplaceAdapter(t *testing.T) {
+	var created []*brokerAdapter
+	RegisterAdapter("broker", func() LogAdapter {
+		a := &brokerAdapter{}
+		created = append(created, a)
+		return a
+	})
+	config := NewConfig(WithTerminalOutput(), WithAdapter("broker", map[string]interface{}{"broker": "old:9092"}))
+	config.consoleOutput = zapcore.AddSync(io.Discard)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+
+	for i := 0; i < 100; i++ {
+		l.Info("before")
+	}
+	assert.NoError(t, l.ReplaceAdapter("broker", AdapterConfig{Name: "broker", Config: map[string]interface{}{"broker": "new:9092"}}))
+	l.Info("after")
+	assert.NoError(t, l.Flush())
+
+	// 替换前分发的日志全部由旧适配器刷新，旧适配器随后关闭
+	if assert.Len(t, created, 2) {
+		old, replacement := created[0], created[1]
+		assert.Len(t, old.flushed, 100)
+		assert.Empty(t, old.pending)
+		assert.True(t, old.closed)
+		assert.Equal(t, "new:9092", replacement.broker)
+		if assert.Len(t, replacement.flushed, 1) {
+			assert.Equal(t, "after", replacement.flushed[0].Message)
+		}
+	}
+
+	// 新适配器初始化失败或找不到旧适配器时保留原有适配器
+	err = l.ReplaceAdapter("broker", AdapterConfig{Name: "broker", Config: map[string]interface{}{}})
+	assert.EqualError(t, err, "init adapter broker failed: broker is required")
+	err = l.ReplaceAdapter("missing", AdapterConfig{Name: "broker", Config: map[string]interface{}{"broker": "x"}})
+	assert.EqualError(t, err, "adapter missing not found")
+	assert.True(t, created[3].closed)
+	err = l.ReplaceAdapter("broker", AdapterConfig{Name: "unregistered"})
+	assert.EqualError(t, err, "unknown adapter: unregistered")
+	assert.Equal(t, "new:9092", unwrapAdapter(l.adapters[0]).(*brokerAdapter).broker)
+	assert.NoError(t, l.Close())
+}
+
+func TestDurationFormat(t *testing.T) {
+	cases := []struct {
+		format string
+		output interface{}
+		prop   interface{}
+	}{
+		{"", 0.25, 0.25},
+		{DurationMillis, float64(250), float64(250)},
+		{DurationNanos, float64(250000000), int64(250000000)},
+		{DurationString, "250ms", "250ms"},
+		{DurationSeconds, 0.25, 0.25},
+	}
+	for _, c := range cases {
+		var tee bytes.Buffer
+		config := NewConfig(WithTerminalOutput(), WithDurationFormat(c.format), WithTee(&tee))
+		config.consoleOutput = zapcore.AddSync(io.Discard)
+		logger, err := New(config)
+		assert.NoError(t, err)
+		l := logger.(*ZapLogger)
+		recorder := &recordingAdapter{name: "recorder"}
+		l.AddAdapter(recorder)
+
+		AsKeyValueLogger(l.WithField("timeout", time.Second)).Infow("request done", "latency", 250*time.Millisecond)
+
+		// 输出和适配器属性使用相同的时长格式
+		props := recorder.entries(t, 1)[0].Properties
+		assert.Equal(t, c.prop, props["latency"], "format %q", c.format)
+		if c.format == DurationString {
+			assert.Equal(t, "1s", props["timeout"])
+		}
+		assert.NoError(t, l.Close())
+		var out map[string]interface{}
+		assert.NoError(t, json.Unmarshal(tee.Bytes(), &out))
+		assert.Equal(t, c.output, out["latency"], "format %q", c.format)
+	}
+
+	_, err := NewWithOptions(WithTerminalOutput(), WithDurationFormat("minutes"))
+	assert.EqualError(t, err, "unknown duration format: minutes")
+}
+
+// countingStringer 记录String被调用的次数
+type countingStringer struct{ calls atomic.Int32 }
+
+func (s *countingStringer) String() string {
+	s.calls.Add(1)
+	return "expensive"
+}
+
+func TestVerbosity(t *testing.T) {
+	adapter := &recordingAdapter{}
+	l, logs := newObservedLogger(zapcore.DebugLevel, adapter)
+	l.SetVerbosity(1)
+
+	expensive := &countingStringer{}
+	l.V(1).Info("v1")
+	l.V(2).Infof("v2 %v", expensive)
+	AsKeyValueLogger(l.V(2)).Infow("v2", "value", expensive)
+	// 其他级别不受详细级别影响
+	l.V(2).Warn("v2 warn")
+	assert.Zero(t, expensive.calls.Load())
+
+	// 运行时调整阈值对已有视图立即生效
+	verbose := l.V(2).WithField("component", "raft")
+	verbose.Info("hidden")
+	l.SetVerbosity(2)
+	verbose.Info("shown")
+	assert.Equal(t, 2, l.Verbosity())
+
+	var messages []string
+	for _, entry := range logs.All() {
+		messages = append(messages, entry.Message)
+	}
+	assert.Equal(t, []string{"v1", "v2 warn", "shown"}, messages)
+	assert.Len(t, adapter.entries(t, 3), 3)
+
+	l2, err := NewWithOptions(WithTerminalOutput(), WithVerbosity(3))
+	assert.NoError(t, err)
+	assert.Equal(t, 3, l2.(*ZapLogger).Verbosity())
+}
+
+func TestExtraRootFields(t *testing.T) {
+	dir := t.TempDir()
+	var console bytes.Buffer
+	config := NewConfig(
+		WithPath(dir),
+		WithBothOutput(),
+		WithExtraRootFields(map[string]interface{}{"@version": 1}),
+		WithExtraRootFields(map[string]interface{}{"meta": map[string]string{"pipeline": "ingest"}}),
+	)
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	recorder := &recordingAdapter{name: "recorder"}
+	l.AddAdapter(recorder)
+
+	l.WithField("user", "alice").Info("login")
+	assert.NotContains(t, recorder.entries(t, 1)[0].Properties, "@version")
+	assert.NoError(t, l.Close())
+
+	// 文件的每行JSON顶层携带固定字段，控制台输出不受影响
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(readLogFile(t, dir)), &record))
+	assert.Equal(t, float64(1), record["@version"])
+	assert.Equal(t, map[string]interface{}{"pipeline": "ingest"}, record["meta"])
+	assert.Equal(t, "alice", record["user"])
+	assert.Equal(t, "login", record["msg"])
+	assert.NotContains(t, console.String(), "@version")
+
+	_, err = NewWithOptions(WithTerminalOutput(), WithExtraRootFields(map[string]interface{}{"level": "x"}))
+	assert.EqualError(t, err, "extra root field level collides with standard key")
+}
+
+func TestLevelRemap(t *testing.T) {
+	rules := map[string]string{
+		"module=dep,level=error,msg=^conn(ection)? (reset|refused)": "warn",
+		"level=debug,msg=important, really":                         "error",
+		"module=other,level=warn":                                   "debug",
+	}
+	var console bytes.Buffer
+	config := NewConfig(WithTerminalOutput(), WithModule("dep"), WithJSONConsole(), WithLevelRemap(rules))
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l := logger.(*ZapLogger)
+	adapter := &recordingAdapter{name: "recording"}
+	l.AddAdapter(adapter)
+
+	l.Error("connection reset")
+	l.Errorf("disk %s", "full")
+	l.Debugw("important, really", "k", "v")
+	l.Debug("noise")
+	l.Warn("kept warn")
+	l.Zap().Error("conn refused")
+	assert.NoError(t, l.Sync())
+
+	// 输出和适配器都使用改写后的级别，debug改写为error后不再被控制台级别过滤
+	levels := map[string]string{}
+	for _, line := range strings.Split(strings.TrimSpace(console.String()), "\n") {
+		var record map[string]interface{}
+		if assert.NoError(t, json.Unmarshal([]byte(line), &record)) {
+			levels[record["msg"].(string)] = record["level"].(string)
+		}
+	}
+	assert.Equal(t, map[string]string{
+		"connection reset":  "WARN",
+		"disk full":         "ERROR",
+		"important, really": "ERROR",
+		"kept warn":         "WARN",
+		"conn refused":      "WARN",
+	}, levels)
+	entries := adapter.entries(t, 6)
+	got := map[string]string{}
+	for _, entry := range entries {
+		got[entry.Message] = entry.Level
+	}
+	assert.Equal(t, map[string]string{
+		"connection reset":  "warn",
+		"disk full":         "error",
+		"important, really": "error",
+		"noise":             "debug",
+		"kept warn":         "warn",
+		"conn refused":      "warn",
+	}, got)
+	for _, entry := range entries {
+		_, hasStack := entry.Properties["stacktrace"]
+		assert.Equal(t, entry.Level == "error", hasStack, entry.Message)
+	}
+	assert.NoError(t, l.Close())
+
+	// 规则按模块筛选，条件多的规则优先
+	remap, err := newLevelRemap(map[string]string{"level=error": "info", "level=error,msg=x": "warn", "module=dep": "debug"}, "app")
+	assert.NoError(t, err)
+	assert.Equal(t, zapcore.WarnLevel, remap.apply(zapcore.ErrorLevel, "x"))
+	assert.Equal(t, zapcore.InfoLevel, remap.apply(zapcore.ErrorLevel, "y"))
+	assert.Equal(t, zapcore.WarnLevel, remap.apply(zapcore.WarnLevel, "x"))
+	assert.Equal(t, zapcore.FatalLevel, remap.apply(zapcore.FatalLevel, "x"))
+	remap, err = newLevelRemap(map[string]string{"module=dep": "debug"}, "app")
+	assert.NoError(t, err)
+	assert.Nil(t, remap)
+
+	for rule, want := range map[string]string{
+		"level=error":         `invalid level remap level=error: cannot remap to fatal`,
+		"level=panic":         `invalid level remap level=panic: cannot remap from panic`,
+		"msg=(":               "invalid level remap msg=(: error parsing regexp: missing closing ): `(`",
+		"node=1":              `invalid level remap node=1: unknown condition node`,
+		"module":              `invalid level remap module: bad condition "module"`,
+		"":                    `invalid level remap : no conditions`,
+		"level=error,level=x": `invalid level remap level=error,level=x: unknown log level: x`,
+	} {
+		target := "warn"
+		if rule == "level=error" {
+			target = "fatal"
+		}
+		_, err := newLevelRemap(map[string]string{rule: target}, "")
+		assert.EqualError(t, err, want, rule)
+	}
+}
+
+func TestPresets(t *testing.T) {
+	// 生产预设：JSON文件和NDJSON控制台，UTC时间，error附加调用栈
+	dir := t.TempDir()
+	var console bytes.Buffer
+	config := Production(dir)
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err := New(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l.Debug("hidden")
+	l.Info("started")
+	l.Error("failed")
+	assert.NoError(t, l.Close())
+
+	lines := strings.Split(strings.TrimSpace(console.String()), "\n")
+	if assert.Len(t, lines, 2) {
+		var info, failed map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &info))
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &failed))
+		assert.Equal(t, "started", info["msg"])
+		assert.True(t, strings.HasSuffix(info["time"].(string), "Z"), info["time"])
+		assert.Contains(t, info["caller"], "logger_test.go:")
+		assert.NotContains(t, info, "stacktrace")
+		assert.Contains(t, failed["stacktrace"], "TestPresets")
+	}
+	assert.Equal(t, strings.TrimSpace(console.String()), strings.TrimSpace(readLogFile(t, dir)))
+
+	// 开发预设：可读的彩色控制台，debug级别
+	console.Reset()
+	config = Development()
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err = New(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	AsKeyValueLogger(l).Debugw("debugging", "k", "v")
+	assert.NoError(t, l.Close())
+	assert.Contains(t, console.String(), "debugging")
+	assert.Contains(t, console.String(), "k=v")
+	assert.Contains(t, console.String(), "\x1b[")
+	assert.Contains(t, console.String(), "logger_test.go:")
+
+	// Init系列在预设之上应用选项并替换默认日志
+	loggerMu.Lock()
+	previous := defaultLogger
+	loggerMu.Unlock()
+	defer func() {
+		loggerMu.Lock()
+		defaultLogger = previous
+		loggerMu.Unlock()
+	}()
+	console.Reset()
+	assert.NoError(t, InitDevelopment(WithLevel("warn"), func(c *Config) { c.consoleOutput = zapcore.AddSync(&console) }))
+	Info("skipped")
+	Warn("shown")
+	assert.NotContains(t, console.String(), "skipped")
+	assert.Contains(t, console.String(), "shown")
+	assert.NoError(t, Default().Close())
+	assert.NoError(t, InitProduction(t.TempDir(), WithTerminalOutput(), func(c *Config) { c.consoleOutput = zapcore.AddSync(io.Discard) }))
+	assert.NoError(t, Default().Close())
+
+	_, err = New(NewConfig(WithTerminalOutput(), WithStacktraceLevel("loud")))
+	assert.EqualError(t, err, "unknown log level: loud")
+}
+
+func TestJSONConsole(t *testing.T) {
+	dir := t.TempDir()
+	var console bytes.Buffer
+	config := NewConfig(WithPath(dir), WithBothOutput(), WithJSONConsole(), WithLevelColors(map[string]string{"info": "green"}))
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err := New(config)
+	assert.NoError(t, err)
+
+	AsKeyValueLogger(l).Infow("order created", "order_id", 42)
+	l.WithField("user", "alice").Warn("slow request")
+	assert.NoError(t, l.Close())
+
+	// 控制台每行一个JSON对象，与文件输出的内容相同，颜色设置不生效
+	lines := strings.Split(strings.TrimSpace(console.String()), "\n")
+	assert.Len(t, lines, 2)
+	fileLines := strings.Split(strings.TrimSpace(readLogFile(t, dir)), "\n")
+	assert.Equal(t, fileLines, lines)
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, "order created", record["msg"])
+	assert.Equal(t, "INFO", record["level"])
+	assert.Equal(t, float64(42), record["order_id"])
+	assert.NotContains(t, console.String(), "\x1b[")
+
+	// 只输出到控制台时不需要日志路径
+	console.Reset()
+	config = NewConfig(WithTerminalOutput(), WithConsoleFormat(ConsoleFormatJSON))
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err = New(config)
+	assert.NoError(t, err)
+	l.Info("ready")
+	assert.NoError(t, l.Close())
+	assert.NoError(t, json.Unmarshal(console.Bytes(), &record))
+	assert.Equal(t, "ready", record["msg"])
+}
+
+func TestFieldLimit(t *testing.T) {
+	var console bytes.Buffer
+	config := NewConfig(WithTerminalOutput(), WithJSONConsole(), WithFieldLimit(3, 8))
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	recorder := &recordingAdapter{name: "recorder"}
+	l.AddAdapter(recorder)
+
+	// module字段由With添加，计入字段数，调用时只能再保留两个字段
+	l.Infow("too many", "a", 1, "b", "0123456789abc", "c", 3, "d", 4)
+	l.Infow("within limit", "a", 1)
+	entries := recorder.entries(t, 2)
+	assert.NoError(t, l.Close())
+
+	lines := strings.Split(strings.TrimSpace(console.String()), "\n")
+	assert.Len(t, lines, 2)
+	var record map[string]interface{}
+	assert.NoError(t, json.Unmarshal([]byte(lines[0]), &record))
+	assert.Equal(t, float64(1), record["a"])
+	assert.Equal(t, "01234567...(truncated, 13 bytes)", record["b"])
+	assert.NotContains(t, record, "c")
+	assert.NotContains(t, record, "d")
+	assert.Equal(t, true, record[FieldsTruncatedField])
+	record = nil
+	assert.NoError(t, json.Unmarshal([]byte(lines[1]), &record))
+	assert.NotContains(t, record, FieldsTruncatedField)
+
+	// 适配器属性按key排序保留前3个，结构化的值按JSON大小截断
+	assert.Equal(t, map[string]interface{}{
+		"a": 1, "b": "01234567...(truncated, 13 bytes)", "c": 3, FieldsTruncatedField: true,
+	}, entries[0].Properties)
+	assert.Equal(t, map[string]interface{}{"a": 1}, entries[1].Properties)
+
+	limit, err := newFieldLimit(0, 8)
+	assert.NoError(t, err)
+	limited := limit.limitProperties(map[string]interface{}{"tags": []string{"alpha", "beta"}})
+	assert.Equal(t, `["alpha"...(truncated, 16 bytes)`, limited["tags"])
+
+	_, err = NewWithOptions(WithTerminalOutput(), WithFieldLimit(-1, 0))
+	assert.EqualError(t, err, "invalid field limit: -1 fields, 0 bytes")
+}
+
+func TestSamplingHook(t *testing.T) {
+	start := time.Date(2024, 5, 6, 7, 8, 9, 0, time.UTC)
+	clock := &steppingClock{t: start}
+	var (
+		mu      sync.Mutex
+		reports []string
+	)
+	var console bytes.Buffer
+	config := NewConfig(
+		WithTerminalOutput(),
+		WithClock(clock),
+		WithLevelSampling("info", 1, 0),
+		WithKeySampling("request_id", 3),
+		WithSamplingHook(func(level string, dropped int) {
+			mu.Lock()
+			defer mu.Unlock()
+			reports = append(reports, fmt.Sprintf("%s=%d", level, dropped))
+		}),
+	)
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	recorder := &recordingAdapter{name: "recorder"}
+	l.AddAdapter(recorder)
+	reported := func() []string {
+		mu.Lock()
+		defer mu.Unlock()
+		return append([]string(nil), reports...)
+	}
+
+	// 同一周期内只累计，不调用回调
+	for i := 0; i < 5; i++ {
+		l.Info("busy")
+	}
+	assert.Empty(t, reported())
+
+	// 周期结束后的丢弃触发汇总，包括本条；新周期的第一条照常输出
+	clock.Set(start.Add(1500 * time.Millisecond))
+	l.Info("busy")
+	l.Info("busy")
+	assert.Equal(t, []string{"info=5"}, reported())
+
+	// 按键采样丢弃的适配器日志同样计入，Close时报告剩余计数
+	for i := 0; i < 3; i++ {
+		l.Warnw("retry", "request_id", "r1")
+	}
+	recorder.entries(t, 3)
+	assert.NoError(t, l.Close())
+	assert.Equal(t, []string{"info=5", "warn=2"}, reported())
+
+	stats := l.Stats()
+	assert.Equal(t, uint64(5), stats.OutputSampled)
+	assert.Equal(t, uint64(2), stats.Sampled)
+	assert.Equal(t, map[string]uint64{"info": 5, "warn": 2}, stats.SampledByLevel)
+	assert.Equal(t, 2, strings.Count(console.String(), "busy"))
+}
+
+func TestLogfmtFieldOrder(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, _ := newObservedLogger(zapcore.InfoLevel, adapter)
+	keys := func(properties []Property) []string {
+		var result []string
+		for _, p := range properties {
+			result = append(result, p.Key)
+		}
+		return result
+	}
+
+	// With添加的字段在前，调用时的键值对按传入顺序在后
+	child := l.WithField("tenant", "t1").WithField("user", "u1")
+	AsKeyValueLogger(child).Infow("order created", "zeta", 1, "alpha", "two words", "mid", true)
+	l.Zap().With(zap.String("z", "1")).Info("raw", zap.Int("b", 2), zap.Int("a", 3))
+	entries := adapter.entries(t, 2)
+	assert.Equal(t, []string{"tenant", "user", "zeta", "alpha", "mid"}, entries[0].Order)
+	assert.Equal(t, []string{"tenant", "user", "zeta", "alpha", "mid"}, keys(entries[0].OrderedProperties()))
+	assert.Equal(t, []string{"z", "b", "a"}, keys(entries[1].OrderedProperties()))
+
+	// 顺序和取值经logfmt往返后保持不变
+	parsed, err := ParseLogfmt(entries[0].Logfmt())
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"time", "level", "msg", "caller", "tenant", "user", "zeta", "alpha", "mid"}, keys(parsed))
+	assert.Equal(t, Property{Key: "msg", Value: "order created"}, parsed[2])
+	assert.Equal(t, Property{Key: "alpha", Value: "two words"}, parsed[7])
+	assert.Equal(t, Property{Key: "mid", Value: "true"}, parsed[8])
+
+	// Order中没有的属性按key排序追加，需要转义的值加引号
+	entry := LogEntry{
+		Level:      "warn",
+		Time:       time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC),
+		Message:    `say "hi"`,
+		Properties: map[string]interface{}{"b": "x=y", "a": []interface{}{1, "z"}, "c": nil, "first": 1.5},
+		Order:      []string{"first", "missing"},
+	}
+	line := entry.Logfmt()
+	assert.Equal(t, `time=2024-01-02T03:04:05Z level=warn msg="say \"hi\"" first=1.5 a="[1,\"z\"]" b="x=y" c=`, line)
+	parsed, err = ParseLogfmt(line)
+	assert.NoError(t, err)
+	assert.Equal(t, []string{"time", "level", "msg", "first", "a", "b", "c"}, keys(parsed))
+	assert.Equal(t, `say "hi"`, parsed[2].Value)
+	assert.Equal(t, `[1,"z"]`, parsed[4].Value)
+	assert.Equal(t, "x=y", parsed[5].Value)
+
+	_, err = ParseLogfmt(`msg="unterminated`)
+	assert.EqualError(t, err, "parse logfmt failed: invalid quoted value for msg")
+}
+
+// zapStringer 测试zap.Stringer字段
+type zapStringer struct{}
+
+func (zapStringer) String() string { return "stringer" }
+
+func TestEntryFromZap(t *testing.T) {
+	now := time.Date(2024, 1, 2, 3, 4, 5, 0, time.UTC)
+	ent := zapcore.Entry{
+		Level:   zapcore.WarnLevel,
+		Time:    now,
+		Message: "converted",
+		Caller:  zapcore.NewEntryCaller(0, "/src/app/handler/user.go", 42, true),
+		Stack:   "goroutine 1 [running]",
+	}
+	fields := []zapcore.Field{
+		zap.String("str", "s"),
+		zap.Int("int", 1),
+		zap.Int8("int8", -8),
+		zap.Uint32("uint32", 32),
+		zap.Uintptr("uintptr", 0x10),
+		zap.Float64("float64", 1.5),
+		zap.Float32("float32", 2.5),
+		zap.Bool("bool", true),
+		zap.Duration("duration", 1500*time.Millisecond),
+		zap.Time("time", now),
+		zap.Error(errors.New("boom")),
+		zap.Error(nil),
+		zap.Skip(),
+		zap.Stringer("stringer", zapStringer{}),
+		zap.Binary("binary", []byte{1, 2}),
+		zap.ByteString("bytestring", []byte("bs")),
+		zap.Complex128("complex", complex(1, 2)),
+		zap.Object("object", zapcore.ObjectMarshalerFunc(func(enc zapcore.ObjectEncoder) error {
+			enc.AddString("name", "alice")
+			enc.AddComplex64("c", complex(3, -4))
+			return nil
+		})),
+		zap.Ints("ints", []int{1, 2}),
+		zap.Any("reflect", struct{ A int }{A: 1}),
+		zap.Namespace("ns"),
+		zap.String("inner", "v"),
+		zap.Int("inner_int", 2),
+	}
+
+	entry := EntryFromZap(ent, fields)
+	assert.Equal(t, "warn", entry.Level)
+	assert.Equal(t, now, entry.Time)
+	assert.Equal(t, "converted", entry.Message)
+	assert.Equal(t, "handler/user.go:42", entry.Caller)
+	assert.Equal(t, map[string]interface{}{
+		"str":        "s",
+		"int":        int64(1),
+		"int8":       int8(-8),
+		"uint32":     uint32(32),
+		"uintptr":    uintptr(0x10),
+		"float64":    1.5,
+		"float32":    float32(2.5),
+		"bool":       true,
+		"duration":   1.5,
+		"time":       now.Format(iso8601Layout),
+		"error":      "boom",
+		"stringer":   "stringer",
+		"binary":     []byte{1, 2},
+		"bytestring": "bs",
+		"complex":    "1+2i",
+		"object":     map[string]interface{}{"name": "alice", "c": "3-4i"},
+		"ints":       []interface{}{float64(1), float64(2)},
+		"reflect":    map[string]interface{}{"A": float64(1)},
+		"ns":         map[string]interface{}{"inner": "v", "inner_int": float64(2)},
+		"stacktrace": "goroutine 1 [running]",
+	}, entry.Properties)
+	assert.Equal(t, []string{
+		"str", "int", "int8", "uint32", "uintptr", "float64", "float32", "bool", "duration", "time", "error",
+		"stringer", "binary", "bytestring", "complex", "object", "ints", "reflect", "ns", "stacktrace",
+	}, entry.Order)
+	_, err := json.Marshal(entry)
+	assert.NoError(t, err)
+
+	// 没有字段和堆栈时不创建属性，未记录调用位置时为空
+	entry = EntryFromZap(zapcore.Entry{Level: zapcore.DebugLevel, Message: "bare"}, nil)
+	assert.Equal(t, LogEntry{Level: "debug", Message: "bare"}, entry)
+
+	// 与钩子配合把原生zap日志转换为LogEntry
+	var hooked []LogEntry
+	core := zapcore.NewCore(zapcore.NewJSONEncoder(zap.NewProductionEncoderConfig()), zapcore.AddSync(io.Discard), zapcore.InfoLevel)
+	zap.New(core, zap.Hooks(func(e zapcore.Entry) error {
+		hooked = append(hooked, EntryFromZap(e, nil))
+		return nil
+	})).Info("hooked")
+	if assert.Len(t, hooked, 1) {
+		assert.Equal(t, "hooked", hooked[0].Message)
+		assert.Equal(t, "info", hooked[0].Level)
+	}
+}
+
+func TestAdaptersOutput(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	RegisterAdapter("recording-adapters-only", func() LogAdapter { return adapter })
+
+	var console bytes.Buffer
+	config := NewConfig(WithAdaptersOutput(), WithAdapter("recording-adapters-only", nil))
+	config.consoleOutput = zapcore.AddSync(&console)
+	l, err := New(config)
+	assert.NoError(t, err)
+
+	AsKeyValueLogger(l).Infow("shipped", "user", "alice")
+	l.Error("failed")
+	entries := adapter.entries(t, 2)
+	assert.Equal(t, "shipped", entries[0].Message)
+	assert.Equal(t, "alice", entries[0].Properties["user"])
+	assert.NoError(t, l.Close())
+	// 只分发到适配器时不输出到控制台
+	assert.Empty(t, console.String())
+
+	_, err = NewWithOptions(WithAdaptersOutput())
+	assert.EqualError(t, err, "output type adapters requires at least one adapter")
+	_, err = NewWithOptions(WithAdaptersOutput(), WithLenientAdapters(), WithAdapter("not-registered", nil))
+	assert.Error(t, err)
+}
+
+func TestSliceAndMapFields(t *testing.T) {
+	nested := map[string]interface{}{
+		"counts": map[string]int{"a": 1, "b": 2},
+		"matrix": [][]int{{1, 2}, {3}},
+		"tags":   []string{"x", "y"},
+	}
+	expected := map[string]interface{}{
+		"counts": map[string]interface{}{"a": float64(1), "b": float64(2)},
+		"matrix": []interface{}{[]interface{}{float64(1), float64(2)}, []interface{}{float64(3)}},
+		"tags":   []interface{}{"x", "y"},
+	}
+	for _, format := range []string{ConsoleFormatText, ConsoleFormatHybrid} {
+		dir := t.TempDir()
+		var console bytes.Buffer
+		config := NewConfig(WithPath(dir), WithBothOutput(), WithConsoleFormat(format))
+		config.consoleOutput = zapcore.AddSync(&console)
+		logger, err := New(config)
+		assert.NoError(t, err)
+		l := logger.(*ZapLogger)
+		recorder := &recordingAdapter{name: "recorder"}
+		l.AddAdapter(recorder)
+
+		AsKeyValueLogger(l.WithField("ids", []int64{7, 8})).Infow("batch", "nested", nested)
+
+		// 适配器属性与文件输出解码后的结构一致
+		props := recorder.entries(t, 1)[0].Properties
+		assert.Equal(t, expected, props["nested"], "format %s", format)
+		assert.Equal(t, []interface{}{float64(7), float64(8)}, props["ids"], "format %s", format)
+		assert.NoError(t, l.Close())
+
+		var record map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(readLogFile(t, dir))), &record))
+		assert.Equal(t, expected, record["nested"], "format %s", format)
+		assert.Equal(t, []interface{}{float64(7), float64(8)}, record["ids"], "format %s", format)
+
+		// 控制台输出为JSON数组和对象，而不是%v格式的字符串
+		assert.Contains(t, console.String(), `{"counts":{"a":1,"b":2},"matrix":[[1,2],[3]],"tags":["x","y"]}`, "format %s", format)
+		assert.NotContains(t, console.String(), "map[", "format %s", format)
+	}
+}
+
+func TestPanicBehavior(t *testing.T) {
+	var console bytes.Buffer
+	config := NewConfig(WithTerminalOutput(), WithPanicBehavior(PanicLogOnly))
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	recorder := &recordingAdapter{name: "recorder"}
+	l.AddAdapter(recorder)
+
+	// 只输出日志，不panic，返回前已分发到适配器
+	assert.NotPanics(t, func() {
+		l.Panic("disk full")
+		l.Panicf("retry %d", 3)
+		l.Panicw("corrupted", "file", "a.db")
+	})
+	recorder.mu.Lock()
+	received := append([]LogEntry(nil), recorder.received...)
+	recorder.mu.Unlock()
+	if assert.Len(t, received, 3) {
+		assert.Equal(t, "panic", received[0].Level)
+		assert.Equal(t, "a.db", received[2].Properties["file"])
+	}
+	assert.NoError(t, l.Close())
+	assert.Equal(t, 3, strings.Count(console.String(), "PANIC"))
+
+	crashConfig := NewConfig(WithTerminalOutput(), WithPanicBehavior(PanicCrash))
+	crashConfig.consoleOutput = zapcore.AddSync(io.Discard)
+	crash, err := New(crashConfig)
+	assert.NoError(t, err)
+	assert.Panics(t, func() { crash.Panic("boom") })
+
+	_, err = NewWithOptions(WithTerminalOutput(), WithPanicBehavior("ignore"))
+	assert.EqualError(t, err, "unknown panic behavior: ignore")
+}
+
+// contextAdapter 记录处理日志时上下文中的值，并阻塞到上下文取消
+type contextAdapter struct {
+	recordingAdapter
+	values  chan interface{}
+	aborted chan error
+}
+
+func (a *contextAdapter) Process(ctx context.Context, entry LogEntry) error {
+	a.values <- ctx.Value(testContextKey{})
+	<-ctx.Done()
+	a.aborted <- ctx.Err()
+	return ctx.Err()
+}
+
+type testContextKey struct{}
+
+func TestBaseContext(t *testing.T) {
+	base, cancel := context.WithCancel(context.WithValue(context.Background(), testContextKey{}, "app"))
+	defer cancel()
+	config := NewConfig(WithTerminalOutput(), WithBaseContext(base))
+	config.consoleOutput = zapcore.AddSync(io.Discard)
+	logger, err := New(config)
+	assert.NoError(t, err)
+	l := logger.(*ZapLogger)
+	adapter := &contextAdapter{recordingAdapter: recordingAdapter{name: "ctx"}, values: make(chan interface{}, 2), aborted: make(chan error, 2)}
+	l.AddAdapter(adapter)
+
+	// 父上下文的值传递给适配器，取消时中止正在进行的发送
+	l.Info("first")
+	assert.Equal(t, "app", <-adapter.values)
+	cancel()
+	assert.Equal(t, context.Canceled, <-adapter.aborted)
+
+	// Close超时返回时取消父上下文，中止仍在进行的发送
+	other := NewConfig(WithTerminalOutput())
+	other.consoleOutput = zapcore.AddSync(io.Discard)
+	logger, err = New(other)
+	assert.NoError(t, err)
+	l = logger.(*ZapLogger)
+	adapter = &contextAdapter{recordingAdapter: recordingAdapter{name: "ctx"}, values: make(chan interface{}, 2), aborted: make(chan error, 2)}
+	l.AddAdapter(adapter)
+	l.Info("second")
+	assert.Nil(t, <-adapter.values)
+	ctx, stop := context.WithTimeout(context.Background(), 20*time.Millisecond)
+	defer stop()
+	assert.EqualError(t, l.CloseWithContext(ctx), "close logger timed out: dispatch queue not drained")
+	assert.Equal(t, context.Canceled, <-adapter.aborted)
+}
+
+func TestModuleOutputs(t *testing.T) {
+	dir := t.TempDir()
+	newModule := func(module string, console *bytes.Buffer) Logger {
+		config := NewConfig(
+			WithPath(dir),
+			WithBothOutput(),
+			WithModule(module),
+			WithModuleOutput("poc", OutputFile),
+			WithModuleOutput("api", OutputTerminal),
+		)
+		config.consoleOutput = zapcore.AddSync(console)
+		l, err := New(config)
+		assert.NoError(t, err)
+		return l
+	}
+
+	var pocConsole, apiConsole, fingerConsole bytes.Buffer
+	loggers := []Logger{newModule("poc", &pocConsole), newModule("api", &apiConsole), newModule("finger", &fingerConsole)}
+	for _, l := range loggers {
+		l.Info("started")
+	}
+	for _, l := range loggers {
+		assert.NoError(t, l.Close())
+	}
+
+	// poc只写文件，api只写终端，未列出的finger使用全局的both
+	file := readLogFile(t, dir)
+	assert.Contains(t, file, `"module":"poc"`)
+	assert.NotContains(t, file, `"module":"api"`)
+	assert.Contains(t, file, `"module":"finger"`)
+	assert.Empty(t, pocConsole.String())
+	assert.Contains(t, apiConsole.String(), "started")
+	assert.Contains(t, fingerConsole.String(), "started")
+
+	_, err := NewWithOptions(WithModule("poc"), WithModuleOutput("poc", "syslog"))
+	assert.EqualError(t, err, "invalid output type syslog for module poc")
+}
+
+// flakyAdapter 每条消息的前failures次处理失败的测试适配器，failures小于0时总是失败
+type flakyAdapter struct {
+	recordingAdapter
+	failures map[string]int
+}
+
+func (a *flakyAdapter) Process(ctx context.Context, entry LogEntry) error {
+	_ = a.recordingAdapter.Process(ctx, entry)
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := a.failures[entry.Message]
+	if n == 0 {
+		return nil
+	}
+	if n > 0 {
+		a.failures[entry.Message] = n - 1
+	}
+	return errors.New("transient failure")
+}
+
+// attempts 返回消息被处理的次数
+func (a *flakyAdapter) attempts(message string) int {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	n := 0
+	for _, entry := range a.received {
+		if entry.Message == message {
+			n++
+		}
+	}
+	return n
+}
+
+func TestDispatchRetry(t *testing.T) {
+	flaky := &flakyAdapter{recordingAdapter: recordingAdapter{name: "flaky"}, failures: map[string]int{"transient": 1, "permanent": -1}}
+	healthy := &recordingAdapter{name: "healthy"}
+	l, _ := newObservedLogger(zapcore.DebugLevel, flaky, healthy)
+	l.dispatchRetries = 2
+	l.dispatchRetryDelay = time.Millisecond
+
+	// 失败的日志只重新交给失败的适配器，重试次数用尽后丢弃；Close等待重试完成
+	l.Info("transient")
+	l.Info("permanent")
+	l.Info("ok")
+	assert.NoError(t, l.Close())
+	assert.Equal(t, 2, flaky.attempts("transient"))
+	assert.Equal(t, 3, flaky.attempts("permanent"))
+	assert.Equal(t, 1, flaky.attempts("ok"))
+	assert.Len(t, healthy.entries(t, 3), 3)
+	stats := l.Stats()
+	assert.Equal(t, uint64(3), stats.Retried)
+	assert.Equal(t, uint64(1), stats.RetryDropped)
+	assert.Equal(t, uint64(4), stats.AdapterErrors)
+	assert.Equal(t, int64(0), stats.Pending)
+
+	// 持续失败时新日志仍然得到处理，移除适配器后不再重试
+	failing := &flakyAdapter{recordingAdapter: recordingAdapter{name: "failing"}, failures: map[string]int{}}
+	healthy = &recordingAdapter{name: "healthy"}
+	l, _ = newObservedLogger(zapcore.DebugLevel, failing, healthy)
+	l.dispatchRetries = 1000
+	l.dispatchRetryDelay = time.Millisecond
+	l.dispatchWorkers = 1
+	for i := 0; i < 20; i++ {
+		failing.failures[fmt.Sprintf("msg %d", i)] = -1
+	}
+	for i := 0; i < 20; i++ {
+		l.Infof("msg %d", i)
+	}
+	assert.Len(t, healthy.entries(t, 20), 20)
+	assert.Eventually(t, func() bool { return l.Stats().Retried > 20 }, time.Second, time.Millisecond)
+	l.RemoveAdapter("failing")
+	assert.NoError(t, l.Close())
+	assert.Equal(t, int64(0), l.Stats().Pending)
+
+	// 未启用重试时失败的日志不再处理
+	failing = &flakyAdapter{recordingAdapter: recordingAdapter{name: "failing"}, failures: map[string]int{"once": 1}}
+	l, _ = newObservedLogger(zapcore.DebugLevel, failing)
+	l.Info("once")
+	assert.NoError(t, l.Close())
+	assert.Equal(t, 1, failing.attempts("once"))
+	assert.Equal(t, uint64(0), l.Stats().Retried)
+}
+
+func TestBackpressure(t *testing.T) {
+	adapter := &blockingAdapter{recordingAdapter: recordingAdapter{name: "blocking"}, release: make(chan struct{})}
+	l, _ := newObservedLogger(zapcore.DebugLevel, adapter)
+	l.dispatchBuffer = 2
+	l.dispatchWorkers = 1
+	assert.Equal(t, 2, l.Stats().QueueCapacity)
+
+	// 工作协程阻塞在第一条日志上，队列被填满后默认立即丢弃
+	l.Info("first")
+	assert.Eventually(t, func() bool { return len(l.dispatcher.entries) == 0 }, time.Second, time.Millisecond)
+	l.Info("queued")
+	l.Info("queued")
+	l.Info("dropped")
+	stats := l.Stats()
+	assert.Equal(t, 2, stats.QueueLength)
+	assert.Equal(t, 2, stats.QueueHighMark)
+	assert.Equal(t, uint64(1), stats.QueueFull)
+	assert.Equal(t, uint64(1), stats.Dropped)
+
+	// 阻塞等待超时后丢弃
+	l.backpressure = BlockWithTimeout(20 * time.Millisecond)
+	start := time.Now()
+	l.Info("timed out")
+	assert.GreaterOrEqual(t, time.Since(start), 20*time.Millisecond)
+	assert.Equal(t, uint64(2), l.Stats().Dropped)
+
+	// 等待期间队列出现空位时不丢弃
+	l.backpressure = BlockWithTimeout(time.Second)
+	done := make(chan struct{})
+	go func() {
+		l.Info("waited")
+		close(done)
+	}()
+	assert.Eventually(t, func() bool { return l.Stats().QueueFull == 3 }, time.Second, time.Millisecond)
+	close(adapter.release)
+	<-done
+	assert.NoError(t, l.Flush())
+	stats = l.Stats()
+	assert.Equal(t, uint64(2), stats.Dropped)
+	assert.Equal(t, 0, stats.QueueLength)
+	assert.Len(t, adapter.entries(t, 4), 4)
+}
+
+func TestErrorGroups(t *testing.T) {
+	dir := t.TempDir()
+	adapter := &recordingAdapter{name: "recording"}
+	RegisterAdapter("recording-error-groups", func() LogAdapter { return adapter })
+	l, err := NewWithOptions(
+		WithPath(dir),
+		WithFileOutput(),
+		WithAdapter("recording-error-groups", nil),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	// 单个错误保持原有的error字段，不附加errors
+	l.WithError(errors.New("single")).Info("one")
+	// 合并的错误逐个记录，带有原因链的错误附加error_chain
+	cause := errors.New("timeout")
+	joined := errors.Join(errors.New("disk full"), fmt.Errorf("upload: %w", cause))
+	l.WithError(joined).Info("joined")
+	// []error中的nil元素记录为null，保持下标
+	l.WithField("batch", []error{errors.New("first"), nil, errors.New("third")}).Info("batch")
+	assert.NoError(t, l.Close())
+
+	wantJoined := []interface{}{
+		map[string]interface{}{"error": "disk full"},
+		map[string]interface{}{"error": "upload: timeout", "error_chain": []interface{}{"upload: timeout", "timeout"}},
+	}
+	wantBatch := []interface{}{
+		map[string]interface{}{"error": "first"},
+		nil,
+		map[string]interface{}{"error": "third"},
+	}
+
+	lines := strings.Split(strings.TrimSpace(readLogFile(t, dir)), "\n")
+	if assert.Len(t, lines, 3) {
+		records := make([]map[string]interface{}, len(lines))
+		for i, line := range lines {
+			assert.NoError(t, json.Unmarshal([]byte(line), &records[i]))
+		}
+		assert.Equal(t, "single", records[0]["error"])
+		assert.NotContains(t, records[0], "errors")
+		assert.Equal(t, "disk full\nupload: timeout", records[1]["error"])
+		assert.Equal(t, wantJoined, records[1]["errors"])
+		assert.Equal(t, wantBatch, records[2]["batch"])
+	}
+
+	entries := adapter.entries(t, 3)
+	assert.NotContains(t, entries[0].Properties, "errors")
+	assert.Equal(t, wantJoined, jsonRoundTrip(t, entries[1].Properties["errors"]))
+	assert.Equal(t, wantBatch, jsonRoundTrip(t, entries[2].Properties["batch"]))
+}
+
+// jsonRoundTrip 将值编码为JSON后解码，用于比较不同来源的嵌套值
+func jsonRoundTrip(t *testing.T, value interface{}) interface{} {
+	t.Helper()
+	data, err := json.Marshal(value)
+	assert.NoError(t, err)
+	var out interface{}
+	assert.NoError(t, json.Unmarshal(data, &out))
+	return out
+}
+
+// nextLineCaller 返回调用该函数的下一行的调用位置后缀，如/logger_test.go:42
+func nextLineCaller() string {
+	_, file, line, _ := runtime.Caller(1)
+	return fmt.Sprintf("/%s:%d", filepath.Base(file), line+1)
+}
+
+// wrappedInfo 模拟在日志方法外再封装一层的用户代码
+func wrappedInfo(l *ZapLogger, msg string) {
+	l.Info(msg)
+}
+
+func TestAdapterCaller(t *testing.T) {
+	recorder := &recordingAdapter{name: "recorder"}
+	l, _ := newObservedLogger(zapcore.InfoLevel, recorder)
+	// 测试代码与日志包同名，只裁剪日志包自身的方法，使调用方为测试函数
+	l.stackTrimPrefixes = []string{"github.com/qishenonly/logger.(*ZapLogger).", "github.com/qishenonly/logger.(*adapterGroup).", "github.com/qishenonly/logger.(*adapterCore).", "go.uber.org/zap"}
+
+	var want []string
+	want = append(want, nextLineCaller())
+	l.Info("info")
+	want = append(want, nextLineCaller())
+	l.Infof("infof %d", 1)
+	want = append(want, nextLineCaller())
+	l.Infow("infow", "k", "v")
+	want = append(want, nextLineCaller())
+	l.WithField("k", "v").Info("with")
+	want = append(want, nextLineCaller())
+	l.Zap().Info("zap")
+
+	entries := recorder.entries(t, len(want))
+	for i, caller := range want {
+		assert.True(t, strings.HasSuffix(entries[i].Caller, caller), "%s: %s, want %s", entries[i].Message, entries[i].Caller, caller)
+	}
+
+	// 额外跳过一层时指向封装函数的调用方
+	recorder.mu.Lock()
+	recorder.received = nil
+	recorder.mu.Unlock()
+	l.adapterCallerSkip = 1
+	want = []string{nextLineCaller()}
+	wrappedInfo(l, "wrapped")
+	entries = recorder.entries(t, 1)
+	assert.True(t, strings.HasSuffix(entries[0].Caller, want[0]), entries[0].Caller)
+	assert.Equal(t, runtime.Frame{}, captureCaller(nil, 1000))
+}
+
+func TestFieldNamespace(t *testing.T) {
+	dir := t.TempDir()
+	adapter := &recordingAdapter{name: "recording"}
+	RegisterAdapter("recording-namespace", func() LogAdapter { return adapter })
+	l, err := NewWithOptions(
+		WithPath(dir),
+		WithFileOutput(),
+		WithNodeID("node-1"),
+		WithModule("api"),
+		WithSequence(),
+		WithFieldNamespace("labels"),
+		WithAdapter("recording-namespace", nil),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+
+	AsKeyValueLogger(l.WithField("user", "alice")).Infow("login", "attempt", 2)
+	l.Info("plain")
+	assert.NoError(t, l.Close())
+
+	lines := strings.Split(strings.TrimSpace(readLogFile(t, dir)), "\n")
+	if assert.Len(t, lines, 2) {
+		var login, plain map[string]interface{}
+		assert.NoError(t, json.Unmarshal([]byte(lines[0]), &login))
+		assert.NoError(t, json.Unmarshal([]byte(lines[1]), &plain))
+		assert.Equal(t, map[string]interface{}{"user": "alice", "attempt": float64(2)}, login["labels"])
+		assert.Equal(t, "node-1", login["nodeId"])
+		assert.Equal(t, "api", login["module"])
+		assert.Equal(t, float64(1), login["seq"])
+		assert.NotContains(t, login, "user")
+		// 没有字段时不输出空对象
+		assert.NotContains(t, plain, "labels")
+	}
+
+	entries := adapter.entries(t, 2)
+	assert.Equal(t, map[string]interface{}{"user": "alice", "attempt": 2}, entries[0].Properties["labels"])
+	assert.Equal(t, uint64(1), entries[0].Properties[SequenceField])
+	assert.Equal(t, "node-1", entries[0].NodeID)
+	assert.NotContains(t, entries[0].Properties, "user")
+	assert.NotContains(t, entries[1].Properties, "labels")
+
+	// 与标准字段同名时返回错误
+	_, err = NewWithOptions(WithTerminalOutput(), WithFieldNamespace("msg"))
+	assert.EqualError(t, err, "field namespace msg collides with standard key")
+}
+
+func TestWithFields(t *testing.T) {
+	adapter := &recordingAdapter{name: "recording"}
+	l, logs := newObservedLogger(zapcore.InfoLevel, adapter)
+
+	child := l.With("requestId", "r1", "userId", 42, "dangling")
+	grandchild := child.WithFields(map[string]interface{}{"userId": 43, "zone": "b"})
+	// 子日志与父日志共享适配器，创建子日志后添加的适配器同样生效
+	late := &recordingAdapter{name: "late"}
+	l.AddAdapter(late)
+
+	child.Info("child")
+	grandchild.Info("grandchild")
+	l.Info("parent")
+
+	entries := adapter.entries(t, 3)
+	assert.Equal(t, map[string]interface{}{"requestId": "r1", "userId": 42}, entries[0].Properties)
+	assert.Equal(t, map[string]interface{}{"requestId": "r1", "userId": 43, "zone": "b"}, entries[1].Properties)
+	assert.Empty(t, entries[2].Properties)
+	assert.Len(t, late.entries(t, 3), 3)
+
+	assert.Equal(t, map[string]interface{}{"requestId": "r1", "userId": int64(42)}, logs.All()[0].ContextMap())
+	assert.Equal(t, int64(43), logs.All()[1].ContextMap()["userId"])
+	assert.Empty(t, logs.All()[2].Context)
+
+	// 空日志和无字段时返回自身
+	empty := &emptyLogger{}
+	assert.Equal(t, Logger(empty), empty.With("k", "v"))
+	assert.Equal(t, Logger(l), l.WithFields(nil))
+}
+
+func TestSetLevel(t *testing.T) {
+	dir := t.TempDir()
+	var console bytes.Buffer
+	config := NewConfig(WithPath(dir), WithBothOutput(), WithConsoleLevel("info"), WithFileLevel("warn"))
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l := logger.(*ZapLogger)
+
+	l.Debug("hidden before")
+	assert.NoError(t, l.SetLevel("debug"))
+	l.WithField("k", "v").Debug("shown debug")
+	// 提升结束后恢复为设置的级别而不是配置的级别
+	l.BoostLevel("debug")()
+	l.Debug("shown after boost")
+
+	// 无法识别的级别返回错误且不修改级别
+	assert.EqualError(t, l.SetLevel("verbose"), "unknown log level: verbose")
+	assert.NoError(t, l.SetLevel("error"))
+	l.Warn("hidden warn")
+	l.Error("shown error")
+	assert.NoError(t, l.Close())
+
+	file := readLogFile(t, dir)
+	for _, out := range []string{console.String(), file} {
+		assert.NotContains(t, out, "hidden before")
+		assert.Contains(t, out, "shown debug")
+		assert.Contains(t, out, "shown after boost")
+		assert.NotContains(t, out, "hidden warn")
+		assert.Contains(t, out, "shown error")
+	}
+
+	// 包级SetLevel转发到默认日志
+	loggerMu.Lock()
+	previous := defaultLogger
+	defaultLogger = &emptyLogger{}
+	loggerMu.Unlock()
+	defer func() {
+		loggerMu.Lock()
+		defaultLogger = previous
+		loggerMu.Unlock()
+	}()
+	assert.NoError(t, SetLevel("debug"))
+	assert.Error(t, SetLevel("verbose"))
+}
+
+func TestRotationPeriodRetention(t *testing.T) {
+	dir := t.TempDir()
+	start := time.Date(2024, 3, 1, 10, 0, 0, 0, time.Local)
+	clock := &steppingClock{t: start}
+
+	// 上次运行遗留的超过保留天数的文件，删除后空的月度目录一并移除
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "2024-01"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2024-01", "01-10.log"), []byte("old\n"), 0644))
+	assert.NoError(t, os.MkdirAll(filepath.Join(dir, "2024-02"), 0755))
+	assert.NoError(t, os.WriteFile(filepath.Join(dir, "2024-02", "02-20.log"), []byte("recent\n"), 0644))
+
+	w, err := NewRotateWriter(dir, RotationOptions{Clock: clock, MaxAgeDays: 30, Compress: true})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, err = w.Write([]byte("first day\n"))
+	assert.NoError(t, err)
+	clock.Set(start.AddDate(0, 0, 1))
+	_, err = w.Write([]byte("second day\n"))
+	assert.NoError(t, err)
+	assert.NoError(t, w.Close())
+
+	_, err = os.Stat(filepath.Join(dir, "2024-01"))
+	assert.True(t, os.IsNotExist(err))
+	readGzip := func(path string) string {
+		f, err := os.Open(path)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		defer f.Close()
+		gz, err := gzip.NewReader(f)
+		if !assert.NoError(t, err) {
+			return ""
+		}
+		data, _ := io.ReadAll(gz)
+		return string(data)
+	}
+	// 已经结束的日期的文件被压缩，当天的文件保持原样
+	assert.Equal(t, "recent\n", readGzip(filepath.Join(dir, "2024-02", "02-20.log.gz")))
+	assert.Equal(t, "first day\n", readGzip(filepath.Join(dir, "2024-03", "03-01.log.gz")))
+	data, err := os.ReadFile(filepath.Join(dir, "2024-03", "03-02.log"))
+	assert.NoError(t, err)
+	assert.Equal(t, "second day\n", string(data))
+
+	// 不设置保留策略时不处理前一天的文件
+	plainDir := t.TempDir()
+	clock.Set(start)
+	w, err = NewRotateWriter(plainDir, RotationOptions{Clock: clock})
+	if !assert.NoError(t, err) {
+		return
+	}
+	_, _ = w.Write([]byte("a\n"))
+	clock.Set(start.AddDate(0, 0, 1))
+	_, _ = w.Write([]byte("b\n"))
+	assert.NoError(t, w.Close())
+	_, err = os.Stat(filepath.Join(plainDir, "2024-03", "03-01.log"))
+	assert.NoError(t, err)
+}
+
+func TestDispatchPoolBounded(t *testing.T) {
+	// 没有适配器时不创建分发队列和工作协程
+	l, _ := newObservedLogger(zapcore.DebugLevel)
+	l.Info("no adapters")
+	assert.Nil(t, l.dispatcher)
+
+	// 大量日志只使用固定数量的工作协程，不随日志条数增长，队列满时丢弃
+	blocking := &blockingAdapter{recordingAdapter: recordingAdapter{name: "blocking"}, release: make(chan struct{})}
+	l, _ = newObservedLogger(zapcore.DebugLevel, blocking)
+	l.dispatchWorkers = 2
+	l.dispatchBuffer = 10
+	before := runtime.NumGoroutine()
+	for i := 0; i < 1000; i++ {
+		l.Infof("entry %d", i)
+	}
+	assert.LessOrEqual(t, runtime.NumGoroutine(), before+2)
+	assert.Greater(t, l.Stats().Dropped, uint64(0))
+
+	close(blocking.release)
+	assert.NoError(t, l.Close())
+	assert.Nil(t, l.dispatcher)
+}
+
+func TestDispatchDefaultWorkers(t *testing.T) {
+	// 未配置工作协程数时固定启动4个，与适配器数量无关
+	for _, count := range []int{1, 10} {
+		adapters := make([]LogAdapter, count)
+		for i := range adapters {
+			adapters[i] = &recordingAdapter{name: fmt.Sprintf("recorder-%d", i)}
+		}
+		l, _ := newObservedLogger(zapcore.DebugLevel, adapters...)
+		before := runtime.NumGoroutine()
+		l.Info("start workers")
+		assert.Equal(t, before+defaultDispatchWorkers, runtime.NumGoroutine(), "adapters: %d", count)
+		assert.NoError(t, l.Close())
+	}
+
+	// 配置后使用指定的数量
+	l, _ := newObservedLogger(zapcore.DebugLevel, &recordingAdapter{name: "recorder"})
+	l.dispatchWorkers = 1
+	before := runtime.NumGoroutine()
+	l.Info("start workers")
+	assert.Equal(t, before+1, runtime.NumGoroutine())
+	assert.NoError(t, l.Close())
+}
+
+func TestAdapterCallerFormat(t *testing.T) {
+	_, file, _, _ := runtime.Caller(0)
+	for format, dir := range map[string]string{
+		CallerShort:   filepath.Base(filepath.Dir(file)),
+		CallerFull:    filepath.Dir(file),
+		CallerPackage: "github.com/qishenonly/logger",
+	} {
+		recorder := &recordingAdapter{name: "recorder"}
+		config := NewConfig(WithTerminalOutput())
+		config.CallerFormat = format
+		config.consoleOutput = zapcore.AddSync(io.Discard)
+		l, err := newZapLogger(config)
+		if !assert.NoError(t, err) {
+			return
+		}
+		l.AddAdapter(recorder)
+		l.stackTrimPrefixes = []string{"github.com/qishenonly/logger.(*ZapLogger).", "github.com/qishenonly/logger.(*adapterGroup)."}
+
+		// Info和Infof的调用位置都指向测试代码，而不是日志包内部
+		want := []string{dir + nextLineCaller()}
+		l.Info("info")
+		want = append(want, dir+nextLineCaller())
+		l.Infof("infof %s", format)
+		entries := recorder.entries(t, 2)
+		for i := range want {
+			assert.Equal(t, want[i], entries[i].Caller, format)
+		}
+		assert.NoError(t, l.Close())
+	}
+}
+
+// ctxRecordingAdapter 记录适配器处理每条日志时收到的context，与批量适配器一样在context已结束时拒绝日志
+type ctxRecordingAdapter struct {
+	recordingAdapter
+	ctxs map[string]context.Context
+}
+
+func (a *ctxRecordingAdapter) Process(ctx context.Context, entry LogEntry) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+	a.mu.Lock()
+	a.ctxs[entry.Message] = ctx
+	a.mu.Unlock()
+	return a.recordingAdapter.Process(ctx, entry)
+}
+
+func TestWithContextFields(t *testing.T) {
+	adapter := &ctxRecordingAdapter{recordingAdapter: recordingAdapter{name: "context"}, ctxs: make(map[string]context.Context)}
+	l, logs := newObservedLogger(zapcore.InfoLevel, adapter)
+	l.contextFields = []string{TraceIDField, SpanIDField, "tenant", "user"}
+
+	ctx, cancel := context.WithCancel(context.Background())
+	ctx = WithTraceID(ctx, "trace-1")
+	ctx = context.WithValue(ctx, ContextKey("tenant"), "acme")
+	ctx = context.WithValue(ctx, ContextKey("user"), "")
+	AsKeyValueLogger(l.WithContext(ctx)).Infow("handled", "k", "v")
+	// 调用方取消context后记录的日志仍然送达，处理上下文保留context中的值
+	cancel()
+	l.WithContext(ctx).Info("after cancel")
+	l.Info("plain")
+
+	// 只附加context中存在且不为空的字段
+	assert.Equal(t, map[string]interface{}{TraceIDField: "trace-1", "tenant": "acme", "k": "v"}, logs.All()[0].ContextMap())
+	entries := adapter.entries(t, 3)
+	assert.Equal(t, map[string]interface{}{TraceIDField: "trace-1", "tenant": "acme", "k": "v"}, entries[0].Properties)
+	assert.Empty(t, entries[2].Properties)
+
+	adapter.mu.Lock()
+	defer adapter.mu.Unlock()
+	assert.Equal(t, "acme", adapter.ctxs["handled"].Value(ContextKey("tenant")))
+	assert.Equal(t, "acme", adapter.ctxs["after cancel"].Value(ContextKey("tenant")))
+	assert.Equal(t, "after cancel", entries[1].Message)
+	assert.Nil(t, adapter.ctxs["plain"].Value(ContextKey("tenant")))
+
+	// 未配置字段时只设置context，nil返回自身
+	plain, _ := newObservedLogger(zapcore.InfoLevel)
+	assert.Equal(t, Logger(plain), plain.WithContext(nil)) //nolint:staticcheck
+	assert.NotSame(t, plain, plain.WithContext(ctx))
+}
+
+func TestAdapterLevel(t *testing.T) {
+	warn := &recordingAdapter{name: "recording-level-warn"}
+	all := &recordingAdapter{name: "recording-level-all"}
+	RegisterAdapter("recording-level-warn", func() LogAdapter { return warn }, ConfigSchema{"topic": ConfigString})
+	RegisterAdapter("recording-level-all", func() LogAdapter { return all })
+
+	var console bytes.Buffer
+	config := NewConfig(
+		WithTerminalOutput(),
+		WithLevel("debug"),
+		WithAdapterLevel("recording-level-warn", "warn", map[string]interface{}{"topic": "logs"}),
+		WithAdapter("recording-level-all", nil),
+	)
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err := New(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l := logger.(*ZapLogger)
+
+	l.Debug("debug")
+	l.Info("info")
+	l.Warn("warn")
+	l.Error("error")
+	assert.NoError(t, l.Flush())
+
+	// 每个适配器按各自的最低级别过滤，控制台仍输出所有级别
+	for _, msg := range []string{"debug", "info", "warn", "error"} {
+		assert.Contains(t, console.String(), msg)
+	}
+	assert.Len(t, all.entries(t, 4), 4)
+	time.Sleep(20 * time.Millisecond)
+	received := warn.entries(t, 2)
+	if assert.Len(t, received, 2) {
+		assert.Equal(t, "warn", received[0].Level)
+		assert.Equal(t, "error", received[1].Level)
+	}
+	assert.Equal(t, "recording-level-warn", l.adapters[0].Name())
+	assert.NoError(t, l.Close())
+
+	// level配置项必须是合法的级别，且不传给适配器的配置校验
+	assert.EqualError(t, Validate(NewConfig(WithAdapterLevel("recording-level-warn", "loud", nil))),
+		"init adapter recording-level-warn failed: invalid adapter level: loud")
+	assert.EqualError(t, Validate(NewConfig(WithAdapter("recording-level-warn", map[string]interface{}{"level": 3}))),
+		"init adapter recording-level-warn failed: adapter level expects string, got int")
+}
+
+// lifecycleAdapter 按顺序记录刷新和关闭调用
+type lifecycleAdapter struct {
+	recordingAdapter
+	calls []string
+}
+
+func (a *lifecycleAdapter) Flush() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls = append(a.calls, fmt.Sprintf("flush:%d", len(a.received)))
+	return nil
+}
+
+func (a *lifecycleAdapter) Close() error {
+	a.mu.Lock()
+	defer a.mu.Unlock()
+	a.calls = append(a.calls, "close")
+	return nil
+}
+
+func TestGlobalClose(t *testing.T) {
+	loggerMu.Lock()
+	previous := defaultLogger
+	loggerMu.Unlock()
+	defer func() {
+		loggerMu.Lock()
+		defaultLogger = previous
+		loggerMu.Unlock()
+	}()
+
+	adapter := &lifecycleAdapter{recordingAdapter: recordingAdapter{name: "lifecycle"}}
+	RegisterAdapter("global-close", func() LogAdapter { return adapter })
+	dir := t.TempDir()
+	if !assert.NoError(t, InitWithOptions(WithPath(dir), WithFileOutput(), WithAdapter("global-close", nil))) {
+		return
+	}
+	closing := Default()
+	Info("last line before exit")
+	assert.NoError(t, Sync())
+
+	// 关闭前分发队列中的日志先交给适配器，之后刷新再关闭
+	assert.NoError(t, Close())
+	adapter.mu.Lock()
+	assert.Equal(t, []string{"flush:1", "close"}, adapter.calls)
+	adapter.mu.Unlock()
+	assert.Contains(t, readLogFile(t, dir), "last line before exit")
+
+	// 关闭后Default重新创建默认日志，重复关闭不报错
+	assert.NoError(t, Close())
+	assert.NoError(t, Sync())
+	assert.NotSame(t, closing, Default())
+	assert.NotPanics(t, func() { Info("after close") })
+	assert.NoError(t, Close())
+
+	// 合并错误中只去掉不支持Sync的错误
+	assert.NoError(t, dropUnsupportedSync(errors.Join(&os.PathError{Op: "sync", Path: "/dev/stdout", Err: syscall.EINVAL})))
+	assert.ErrorIs(t, dropUnsupportedSync(errors.Join(syscall.ENOTTY, os.ErrClosed)), os.ErrClosed)
+}
+
+func TestEncoderFormat(t *testing.T) {
+	// 文件使用纯文本格式和自定义时间格式
+	dir := t.TempDir()
+	logger, err := NewWithOptions(WithPath(dir), WithFileOutput(), WithFileFormat(FileFormatText), WithTimeFormat("2006/01/02 15:04:05"))
+	if !assert.NoError(t, err) {
+		return
+	}
+	AsKeyValueLogger(logger).Infow("plain text", "k", "v")
+	assert.NoError(t, logger.Close())
+	line := strings.TrimSpace(readLogFile(t, dir))
+	parts := strings.Split(line, "\t")
+	if assert.GreaterOrEqual(t, len(parts), 5, line) {
+		_, err := time.ParseInLocation("2006/01/02 15:04:05", parts[0], time.Local)
+		assert.NoError(t, err)
+		assert.Equal(t, "INFO", parts[1])
+		assert.Equal(t, "plain text", parts[3])
+		assert.Contains(t, parts[4], `"k": "v"`)
+	}
+
+	// JSON文件和JSON控制台使用自定义的key
+	dir = t.TempDir()
+	var console bytes.Buffer
+	config := NewConfig(
+		WithPath(dir),
+		WithOutputType(OutputBoth),
+		WithJSONConsole(),
+		WithEncoderKeys(EncoderKeys{Time: "@timestamp", Level: "severity", Message: "message", Caller: "src"}),
+	)
+	config.consoleOutput = zapcore.AddSync(&console)
+	logger, err = New(config)
+	if !assert.NoError(t, err) {
+		return
+	}
+	logger.Info("renamed")
+	assert.NoError(t, logger.Close())
+	for _, out := range []string{readLogFile(t, dir), console.String()} {
+		var record map[string]interface{}
+		if !assert.NoError(t, json.Unmarshal([]byte(strings.TrimSpace(out)), &record), out) {
+			continue
+		}
+		assert.Equal(t, "renamed", record["message"])
+		assert.Equal(t, "INFO", record["severity"])
+		assert.Contains(t, record, "@timestamp")
+		assert.Contains(t, record, "src")
+		for _, key := range []string{"time", "level", "msg", "caller"} {
+			assert.NotContains(t, record, key)
+		}
+	}
+
+	// 未知格式和冲突的key返回错误
+	assert.EqualError(t, Validate(NewConfig(WithPath(t.TempDir()), WithFileFormat("xml"))), "unknown file format: xml")
+	assert.EqualError(t, Validate(NewConfig(WithEncoderKeys(EncoderKeys{Level: "msg"}))), "encoder key msg collides with standard key")
+	assert.EqualError(t, Validate(NewConfig(WithEncoderKeys(EncoderKeys{Time: "nodeId"}))), "encoder key nodeId collides with standard key")
+}
+
+func TestAdapterLookup(t *testing.T) {
+	created := map[string]*recordingAdapter{}
+	RegisterAdapter("recording-lookup", func() LogAdapter {
+		adapter := &recordingAdapter{name: "recording-lookup"}
+		created[fmt.Sprint(len(created))] = adapter
+		return adapter
+	})
+
+	logger, err := NewWithOptions(
+		WithTerminalOutput(),
+		WithLevel("error"),
+		WithAdapter("recording-lookup", nil),
+		WithAdapterInstance("recording-lookup", "leveled", map[string]interface{}{AdapterLevelKey: "warn"}),
+	)
+	if !assert.NoError(t, err) {
+		return
+	}
+	l := logger.(*ZapLogger)
+	defer l.Close()
+
+	// 返回实例ID和级别包装下的原始适配器
+	found, ok := l.Adapter("recording-lookup")
+	assert.True(t, ok)
+	assert.Same(t, created["0"], found)
+	found, ok = l.Adapter("leveled")
+	assert.True(t, ok)
+	assert.Same(t, created["1"], found)
+
+	_, ok = l.Adapter("missing")
+	assert.False(t, ok)
+}
+
+func TestMaxAdapterGoroutines(t *testing.T) {
+	newLimited := func(policy AdapterLimitPolicy) (*ZapLogger, *blockingAdapter) {
+		blocking := &blockingAdapter{recordingAdapter: recordingAdapter{name: "blocking"}, release: make(chan struct{})}
+		config := NewConfig(WithTerminalOutput(), WithDispatchWorkers(2), WithMaxAdapterGoroutines(1), WithAdapterLimitPolicy(policy))
+		config.consoleOutput = zapcore.AddSync(io.Discard)
+		logger, err := New(config)
+		if !assert.NoError(t, err) {
+			t.FailNow()
+		}
+		l := logger.(*ZapLogger)
+		l.AddAdapter(blocking)
+		return l, blocking
+	}
+
+	// 默认上限为256
+	l, err := newZapLogger(NewConfig(WithTerminalOutput()))
+	if assert.NoError(t, err) {
+		assert.Equal(t, defaultMaxAdapterGoroutines, cap(l.adapterSlots))
+		assert.NoError(t, l.Close())
+	}
+
+	// 默认等待名额：两个工作协程只有一个能处理，另一个等待后继续，日志不丢失
+	l, blocking := newLimited("")
+	l.Info("first")
+	l.Info("second")
+	assert.Eventually(t, func() bool { return l.Stats().AdapterLimitHits == 1 }, time.Second, time.Millisecond)
+	close(blocking.release)
+	assert.Len(t, blocking.entries(t, 2), 2)
+	assert.NoError(t, l.Close())
+	assert.Zero(t, l.Stats().Dropped)
+
+	// AdapterLimitDrop：达到上限时放弃并计入Dropped
+	l, blocking = newLimited(AdapterLimitDrop)
+	l.Info("first")
+	l.Info("second")
+	assert.Eventually(t, func() bool { return l.Stats().Dropped == 1 }, time.Second, time.Millisecond)
+	assert.Equal(t, uint64(1), l.Stats().AdapterLimitHits)
+	close(blocking.release)
+	assert.NoError(t, l.Close())
+	assert.Len(t, blocking.entries(t, 1), 1)
+}
+
+func TestGlobalCloseConcurrent(t *testing.T) {
+	loggerMu.Lock()
+	previous := defaultLogger
+	loggerMu.Unlock()
+	defer func() {
+		loggerMu.Lock()
+		defaultLogger = previous
+		loggerMu.Unlock()
+	}()
+
+	// Close与Default并发调用时没有数据竞争，Default总是返回可用的日志
+	var wg sync.WaitGroup
+	for i := 0; i < 4; i++ {
+		wg.Add(2)
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				_ = Close()
+			}
+		}()
+		go func() {
+			defer wg.Done()
+			for j := 0; j < 20; j++ {
+				assert.NotNil(t, Default())
+			}
+		}()
+	}
+	wg.Wait()
+	assert.NoError(t, Close())
+}
+
+func TestGlobalCloseTimeout(t *testing.T) {
+	loggerMu.Lock()
+	previous := defaultLogger
+	loggerMu.Unlock()
+	defer func() {
+		loggerMu.Lock()
+		defaultLogger = previous
+		loggerMu.Unlock()
+	}()
+
+	// 与Close一样清除默认日志，之后的全局调用不会写入已关闭的日志
+	assert.NoError(t, InitWithOptions(WithTerminalOutput()))
+	closed := Default()
+	adapter := &lifecycleAdapter{recordingAdapter: recordingAdapter{name: "lifecycle"}}
+	closed.AddAdapter(adapter)
+	assert.NoError(t, CloseTimeout(time.Second))
+	assert.Contains(t, adapter.calls, "close")
+
+	loggerMu.RLock()
+	assert.Nil(t, defaultLogger)
+	loggerMu.RUnlock()
+	assert.NotSame(t, closed, Default())
+	assert.NoError(t, Close())
+	assert.NoError(t, CloseTimeout(time.Second))
+}
+
+func TestBackpressureConcurrentCallers(t *testing.T) {
+	adapter := &blockingAdapter{recordingAdapter: recordingAdapter{name: "blocking"}, release: make(chan struct{})}
+	l, _ := newObservedLogger(zapcore.DebugLevel, adapter)
+	l.dispatchBuffer = 1
+	l.dispatchWorkers = 1
+	l.backpressure = BlockWithTimeout(100 * time.Millisecond)
+
+	l.Info("first")
+	assert.Eventually(t, func() bool { return len(l.dispatcher.entries) == 0 }, time.Second, time.Millisecond)
+	l.Info("queued")
+
+	// 每个调用方各自等待超时，总耗时不随调用方数量累加
+	start := time.Now()
+	var wg sync.WaitGroup
+	for i := 0; i < 5; i++ {
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			l.Info("timed out")
+		}()
+	}
+	wg.Wait()
+	assert.Less(t, time.Since(start), 400*time.Millisecond)
+	assert.Equal(t, uint64(5), l.Stats().Dropped)
+
+	// 慢适配器处理日志时仍然可以添加和移除适配器
+	added := make(chan struct{})
+	go func() {
+		l.AddAdapter(&recordingAdapter{name: "added"})
+		l.RemoveAdapter("added")
+		close(added)
+	}()
+	select {
+	case <-added:
+	case <-time.After(time.Second):
+		t.Fatal("AddAdapter blocked by a slow adapter")
+	}
+
+	close(adapter.release)
+	assert.NoError(t, l.Close())
+	assert.Len(t, adapter.entries(t, 2), 2)
+}
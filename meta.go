@@ -0,0 +1,66 @@
+package logger
+
+import (
+	"bytes"
+	"runtime"
+	"strconv"
+
+	"go.uber.org/zap"
+)
+
+// 附加元数据的字段名
+const (
+	GoroutineField = "goroutine"
+	SequenceField  = "seq"
+	FunctionField  = "func"
+)
+
+// entryMeta 返回WithGoroutineID/WithSequence为本条日志附加的键值对，都未开启时返回nil
+func (l *ZapLogger) entryMeta() []interface{} {
+	if !l.goroutineID && !l.sequence {
+		return nil
+	}
+
+	meta := make([]interface{}, 0, 4)
+	if l.goroutineID {
+		meta = append(meta, GoroutineField, currentGoroutineID())
+	}
+	if l.sequence {
+		meta = append(meta, SequenceField, l.seq.Add(1))
+	}
+	return meta
+}
+
+// sugarWith 返回附加了元数据字段的SugaredLogger，没有元数据时直接返回自身的SugaredLogger
+func (l *ZapLogger) sugarWith(meta []interface{}) *zap.SugaredLogger {
+	if len(meta) == 0 {
+		return l.sugar
+	}
+	return l.sugar.With(meta...)
+}
+
+// metaProperties 将元数据合并到适配器属性中，调用时传入的属性优先
+func metaProperties(meta []interface{}, properties map[string]interface{}) map[string]interface{} {
+	if len(meta) == 0 {
+		return properties
+	}
+
+	merged := keysAndValuesToProperties(meta, propertyValue)
+	for k, v := range properties {
+		merged[k] = v
+	}
+	return merged
+}
+
+// currentGoroutineID 从runtime.Stack的首行"goroutine 123 [running]:"中解析当前协程ID
+// 每次调用都需要获取一次调用栈，开销约为一次普通日志写入的数倍
+func currentGoroutineID() uint64 {
+	var buf [64]byte
+	b := buf[:runtime.Stack(buf[:], false)]
+	b = bytes.TrimPrefix(b, []byte("goroutine "))
+	if i := bytes.IndexByte(b, ' '); i >= 0 {
+		b = b[:i]
+	}
+	id, _ := strconv.ParseUint(string(b), 10, 64)
+	return id
+}
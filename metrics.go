@@ -0,0 +1,75 @@
+package logger
+
+import (
+	"fmt"
+	"io"
+	"net/http"
+	"sort"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// MetricsHandler 返回以Prometheus文本格式输出默认日志实例运行统计的HTTP处理器，可挂载到/metrics
+// 每次请求时读取默认日志实例，因此可以在Init之前挂载
+func MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveMetrics(w, Stats())
+	})
+}
+
+// MetricsHandler 返回以Prometheus文本格式输出该日志实例运行统计的HTTP处理器
+func (l *ZapLogger) MetricsHandler() http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveMetrics(w, l.Stats())
+	})
+}
+
+// serveMetrics 输出统计
+func serveMetrics(w http.ResponseWriter, stats LoggerStats) {
+	w.Header().Set("Content-Type", "text/plain; version=0.0.4; charset=utf-8")
+	_ = writeMetrics(w, stats)
+}
+
+// writeMetrics 按Prometheus文本格式写出统计，不依赖Prometheus客户端库
+func writeMetrics(w io.Writer, stats LoggerStats) error {
+	var err error
+	printf := func(format string, args ...interface{}) {
+		if err == nil {
+			_, err = fmt.Fprintf(w, format, args...)
+		}
+	}
+	metric := func(name, typ, help string, value interface{}) {
+		printf("# HELP %s %s\n# TYPE %s %s\n%s %v\n", name, help, name, typ, name, value)
+	}
+
+	printf("# HELP logger_entries_total Log entries written by level.\n# TYPE logger_entries_total counter\n")
+	for level := zapcore.DebugLevel; level <= zapcore.FatalLevel; level++ {
+		printf("logger_entries_total{level=%q} %d\n", level.String(), stats.Logged[level.String()])
+	}
+
+	metric("logger_dropped_total", "counter", "Entries dropped before reaching adapters.", stats.Dropped)
+	metric("logger_sampled_total", "counter", "Entries dropped by key sampling before reaching adapters.", stats.Sampled)
+	metric("logger_output_sampled_total", "counter", "Entries dropped by level sampling before reaching console and file outputs.", stats.OutputSampled)
+	metric("logger_async_dropped_total", "counter", "Entries dropped because the async output queue was full.", stats.AsyncDropped)
+	metric("logger_adapter_errors_total", "counter", "Adapter process, flush and close failures.", stats.AdapterErrors)
+	metric("logger_adapter_flushes_total", "counter", "Adapter flushes triggered by the logger.", stats.Flushes)
+	metric("logger_adapter_flush_errors_total", "counter", "Adapter flushes that returned an error.", stats.FlushErrors)
+	metric("logger_pending_entries", "gauge", "Entries dispatched but not yet processed by adapters.", stats.Pending)
+	metric("logger_dispatch_queue_length", "gauge", "Entries currently waiting in the adapter dispatch queue.", stats.QueueLength)
+	metric("logger_dispatch_queue_capacity", "gauge", "Capacity of the adapter dispatch queue.", stats.QueueCapacity)
+	metric("logger_dispatch_queue_high_mark", "gauge", "Largest adapter dispatch queue length observed.", stats.QueueHighMark)
+	metric("logger_dispatch_queue_full_total", "counter", "Dispatches that found the adapter dispatch queue full.", stats.QueueFull)
+	metric("logger_adapter_goroutine_limit_total", "counter", "Adapter deliveries that hit the concurrent adapter goroutine limit.", stats.AdapterLimitHits)
+
+	names := make([]string, 0, len(stats.Buffered))
+	for name := range stats.Buffered {
+		names = append(names, name)
+	}
+	sort.Strings(names)
+	printf("# HELP logger_adapter_buffered_entries Entries buffered in adapters and not yet sent.\n# TYPE logger_adapter_buffered_entries gauge\n")
+	for _, name := range names {
+		printf("logger_adapter_buffered_entries{adapter=%q} %d\n", name, stats.Buffered[name])
+	}
+
+	return err
+}
@@ -0,0 +1,260 @@
+package logger
+
+import (
+	"crypto/rand"
+	"fmt"
+	"net/http"
+	"sync/atomic"
+	"time"
+)
+
+// DefaultRequestIDHeader HTTP中间件默认读取和回写请求ID的头
+const DefaultRequestIDHeader = "X-Request-ID"
+
+// HTTPMiddleware 返回使用默认日志实例的HTTP中间件，每次请求时读取默认日志实例，因此可以在Init之前挂载
+func HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		if l, ok := Default().(*ZapLogger); ok {
+			l.HTTPMiddleware(next).ServeHTTP(w, r)
+			return
+		}
+		serveWithRequestID(w, r, next, Default(), nil, "", nil)
+	})
+}
+
+// HTTPMiddleware 返回为每个请求生成请求ID的HTTP中间件
+// 请求头中已带请求ID时沿用该ID，否则使用WithRequestIDGenerator设置的生成器（默认UUIDv4）生成
+// 请求ID回写到响应头，并以携带request_id字段的子日志存入请求的context，处理器中通过FromContext获取
+// 启用WithAccessLog后请求结束时输出一条访问日志，成功的请求可以通过WithSuccessSampleRate采样
+func (l *ZapLogger) HTTPMiddleware(next http.Handler) http.Handler {
+	return http.HandlerFunc(func(w http.ResponseWriter, r *http.Request) {
+		serveWithRequestID(w, r, next, l, l.requestIDGenerator, l.requestIDHeader, l.accessLog)
+	})
+}
+
+// serveWithRequestID 生成请求ID并将请求范围的子日志存入context后调用next，access不为空时输出访问日志
+func serveWithRequestID(w http.ResponseWriter, r *http.Request, next http.Handler, base Logger, generate func() string, header string, access *accessLog) {
+	if generate == nil {
+		generate = NewRequestID
+	}
+	if header == "" {
+		header = DefaultRequestIDHeader
+	}
+
+	id := r.Header.Get(header)
+	if id == "" {
+		id = generate()
+	}
+	w.Header().Set(header, id)
+
+	requestLogger := base.WithField(RequestIDField, id)
+	ctx := ContextWithLogger(r.Context(), requestLogger)
+	if access == nil {
+		next.ServeHTTP(w, r.WithContext(ctx))
+		return
+	}
+
+	recorder := &statusRecorder{ResponseWriter: w}
+	start := time.Now()
+	next.ServeHTTP(recorder, r.WithContext(ctx))
+	access.log(requestLogger, r, recorder.statusCode(), recorder.bytes, time.Since(start))
+}
+
+// 访问日志的字段名，用于WithAccessLogFields选择输出的字段
+const (
+	AccessLogMethod     = "method"
+	AccessLogPath       = "path"
+	AccessLogStatus     = "status"
+	AccessLogBytes      = "bytes"
+	AccessLogDuration   = "duration_ms"
+	AccessLogRemoteAddr = "remote_addr"
+	AccessLogUserAgent  = "user_agent"
+	AccessLogRequestID  = RequestIDField
+)
+
+// accessLogFields 访问日志默认输出的字段及顺序
+var accessLogFields = []string{
+	AccessLogMethod, AccessLogPath, AccessLogStatus, AccessLogBytes, AccessLogDuration,
+	AccessLogRemoteAddr, AccessLogUserAgent, AccessLogRequestID,
+}
+
+// AccessLog 一次HTTP请求的访问日志，各服务使用相同的字段，也可以直接序列化为JSON发送给适配器
+type AccessLog struct {
+	Method     string  `json:"method"`
+	Path       string  `json:"path"`
+	Status     int     `json:"status"`
+	Bytes      int64   `json:"bytes"`
+	DurationMS float64 `json:"duration_ms"`
+	RemoteAddr string  `json:"remote_addr,omitempty"`
+	UserAgent  string  `json:"user_agent,omitempty"`
+	RequestID  string  `json:"request_id,omitempty"`
+
+	// Fields 输出的字段名（AccessLogMethod等），为空时输出全部字段；字符串字段为空时总是省略
+	Fields []string `json:"-"`
+}
+
+// NewAccessLog 根据请求、响应状态码、写出的字节数和耗时创建访问日志，RequestID需要调用方设置
+func NewAccessLog(r *http.Request, status int, bytes int64, elapsed time.Duration) AccessLog {
+	return AccessLog{
+		Method:     r.Method,
+		Path:       r.URL.Path,
+		Status:     status,
+		Bytes:      bytes,
+		DurationMS: float64(elapsed.Microseconds()) / 1000,
+		RemoteAddr: r.RemoteAddr,
+		UserAgent:  r.UserAgent(),
+	}
+}
+
+// KeysAndValues 按Fields返回访问日志的键值对，可以传给Infow等方法
+func (a AccessLog) KeysAndValues() []interface{} {
+	fields := a.Fields
+	if len(fields) == 0 {
+		fields = accessLogFields
+	}
+	keysAndValues := make([]interface{}, 0, len(fields)*2)
+	add := func(key string, value interface{}) {
+		if s, ok := value.(string); ok && s == "" {
+			return
+		}
+		keysAndValues = append(keysAndValues, key, value)
+	}
+	for _, field := range fields {
+		switch field {
+		case AccessLogMethod:
+			add(field, a.Method)
+		case AccessLogPath:
+			add(field, a.Path)
+		case AccessLogStatus:
+			add(field, a.Status)
+		case AccessLogBytes:
+			add(field, a.Bytes)
+		case AccessLogDuration:
+			add(field, a.DurationMS)
+		case AccessLogRemoteAddr:
+			add(field, a.RemoteAddr)
+		case AccessLogUserAgent:
+			add(field, a.UserAgent)
+		case AccessLogRequestID:
+			add(field, a.RequestID)
+		}
+	}
+	return keysAndValues
+}
+
+// LogWith 以"http request"消息输出访问日志，按状态码选择级别：5xx为error，4xx为warn，其余为info
+func (a AccessLog) LogWith(l Logger) {
+	keysAndValues := a.KeysAndValues()
+	kv := AsKeyValueLogger(l)
+	switch {
+	case a.Status >= 500:
+		kv.Errorw("http request", keysAndValues...)
+	case a.Status >= 400:
+		kv.Warnw("http request", keysAndValues...)
+	default:
+		kv.Infow("http request", keysAndValues...)
+	}
+}
+
+// validateAccessLogFields 检查访问日志的字段名
+func validateAccessLogFields(fields []string) error {
+	for _, field := range fields {
+		known := false
+		for _, f := range accessLogFields {
+			known = known || f == field
+		}
+		if !known {
+			return fmt.Errorf("unknown access log field: %s", field)
+		}
+	}
+	return nil
+}
+
+// accessLog HTTP中间件的访问日志，成功的请求按状态类别分别计数采样，4xx和5xx总是输出
+type accessLog struct {
+	keep     uint64
+	every    uint64
+	counters [2]atomic.Uint64 // 2xx和3xx请求的计数
+	fields   []string         // 输出的字段，为空时输出全部字段
+}
+
+// newAccessLog 创建访问日志，未启用时返回nil；keep和every表示成功的请求每every条输出keep条，every小于等于1时全部输出
+func newAccessLog(enabled bool, keep, every int) *accessLog {
+	if !enabled {
+		return nil
+	}
+	access := &accessLog{}
+	if keep > 0 && every > 1 && keep < every {
+		access.keep = uint64(keep)
+		access.every = uint64(every)
+	}
+	return access
+}
+
+// sample 判断该状态码的请求是否输出访问日志
+func (a *accessLog) sample(status int) bool {
+	if a.every == 0 || status < 200 || status >= 400 {
+		return true
+	}
+	n := a.counters[status/100-2].Add(1) - 1
+	return n%a.every < a.keep
+}
+
+// log 采样后输出访问日志，l已携带request_id字段，访问日志中不再重复
+func (a *accessLog) log(l Logger, r *http.Request, status int, bytes int64, elapsed time.Duration) {
+	if !a.sample(status) {
+		return
+	}
+	entry := NewAccessLog(r, status, bytes, elapsed)
+	entry.Fields = a.fields
+	entry.LogWith(l)
+}
+
+// statusRecorder 记录响应状态码和写出字节数的ResponseWriter
+type statusRecorder struct {
+	http.ResponseWriter
+	status int
+	bytes  int64
+}
+
+// WriteHeader 记录第一次写出的状态码
+func (w *statusRecorder) WriteHeader(status int) {
+	if w.status == 0 {
+		w.status = status
+	}
+	w.ResponseWriter.WriteHeader(status)
+}
+
+// Write 记录写出的字节数，未设置状态码时与net/http一样视为200
+func (w *statusRecorder) Write(p []byte) (int, error) {
+	if w.status == 0 {
+		w.status = http.StatusOK
+	}
+	n, err := w.ResponseWriter.Write(p)
+	w.bytes += int64(n)
+	return n, err
+}
+
+// Unwrap 返回原始的ResponseWriter，供http.ResponseController使用
+func (w *statusRecorder) Unwrap() http.ResponseWriter {
+	return w.ResponseWriter
+}
+
+// statusCode 返回响应状态码，处理器没有写出任何内容时为200
+func (w *statusRecorder) statusCode() int {
+	if w.status == 0 {
+		return http.StatusOK
+	}
+	return w.status
+}
+
+// NewRequestID 生成随机的UUIDv4请求ID，是请求ID生成器的默认实现
+func NewRequestID() string {
+	var b [16]byte
+	if _, err := rand.Read(b[:]); err != nil {
+		return ""
+	}
+	b[6] = b[6]&0x0f | 0x40 // 版本4
+	b[8] = b[8]&0x3f | 0x80 // RFC 4122变体
+	return fmt.Sprintf("%x-%x-%x-%x-%x", b[0:4], b[4:6], b[6:8], b[8:10], b[10:16])
+}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"fmt"
+
+	"go.uber.org/zap"
+	"go.uber.org/zap/zapcore"
+)
+
+// rootFieldKeys 配置了字段命名空间时仍然输出在顶层的字段：节点信息、环境版本和日志包自身附加的元数据
+var rootFieldKeys = map[string]bool{
+	"nodeId":             true,
+	"module":             true,
+	"ip":                 true,
+	"env":                true,
+	"version":            true,
+	GoroutineField:       true,
+	SequenceField:        true,
+	FunctionField:        true,
+	FieldsTruncatedField: true,
+}
+
+// validateFieldNamespace 检查字段命名空间不与标准字段和顶层字段同名
+func validateFieldNamespace(namespace string, encoderConfig zapcore.EncoderConfig) error {
+	if namespace == "" {
+		return nil
+	}
+	for _, key := range []string{
+		encoderConfig.TimeKey, encoderConfig.LevelKey, encoderConfig.NameKey, encoderConfig.CallerKey,
+		encoderConfig.FunctionKey, encoderConfig.MessageKey, encoderConfig.StacktraceKey,
+	} {
+		if key == namespace {
+			return fmt.Errorf("field namespace %s collides with standard key", namespace)
+		}
+	}
+	if rootFieldKeys[namespace] {
+		return fmt.Errorf("field namespace %s collides with standard key", namespace)
+	}
+	return nil
+}
+
+// splitNamespaceFields 将字段分为保留在顶层的字段和放入命名空间的字段
+func splitNamespaceFields(fields []zapcore.Field) (root, nested []zapcore.Field) {
+	for _, f := range fields {
+		if rootFieldKeys[f.Key] {
+			root = append(root, f)
+		} else {
+			nested = append(nested, f)
+		}
+	}
+	return root, nested
+}
+
+// namespacedFields 将一组字段编码为嵌套对象的内容
+type namespacedFields []zapcore.Field
+
+// MarshalLogObject 实现zapcore.ObjectMarshaler接口
+func (fields namespacedFields) MarshalLogObject(enc zapcore.ObjectEncoder) error {
+	for _, f := range fields {
+		f.AddTo(enc)
+	}
+	return nil
+}
+
+// fieldNamespaceCore 将With和调用时传入的字段放入命名空间对象，节点信息等标准字段保留在顶层
+// 放入命名空间的With字段在每次写入时与调用时的字段一起编码，保证同一对象中先是With字段再是调用时的字段
+type fieldNamespaceCore struct {
+	zapcore.Core
+	namespace string
+	fields    []zapcore.Field // With添加的、需要放入命名空间的字段
+}
+
+// With 实现zapcore.Core接口
+func (c *fieldNamespaceCore) With(fields []zapcore.Field) zapcore.Core {
+	root, nested := splitNamespaceFields(fields)
+	return &fieldNamespaceCore{
+		Core:      c.Core.With(root),
+		namespace: c.namespace,
+		fields:    append(c.fields[:len(c.fields):len(c.fields)], nested...),
+	}
+}
+
+// Check 实现zapcore.Core接口
+func (c *fieldNamespaceCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *fieldNamespaceCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	root, nested := splitNamespaceFields(fields)
+	if len(c.fields)+len(nested) > 0 {
+		all := append(c.fields[:len(c.fields):len(c.fields)], nested...)
+		root = append(root, zap.Object(c.namespace, namespacedFields(all)))
+	}
+	return c.Core.Write(ent, root)
+}
+
+// nestProperties 将适配器属性中除标准字段和静态属性以外的项放入命名空间的map，没有配置命名空间时原样返回
+func (g *adapterGroup) nestProperties(properties map[string]interface{}) map[string]interface{} {
+	if g.fieldNamespace == "" || len(properties) == 0 {
+		return properties
+	}
+
+	result := make(map[string]interface{}, len(properties))
+	var nested map[string]interface{}
+	for k, v := range properties {
+		if _, static := g.adapterProperties[k]; static || rootFieldKeys[k] {
+			result[k] = v
+			continue
+		}
+		if nested == nil {
+			nested = make(map[string]interface{}, len(properties))
+		}
+		nested[k] = v
+	}
+	if nested != nil {
+		result[g.fieldNamespace] = nested
+	}
+	return result
+}
@@ -0,0 +1,110 @@
+package logger
+
+import (
+	"reflect"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// isEmptyValue 判断WithOmitEmpty下需要省略的值：
+// nil（包括nil的指针、接口、map、切片、函数、通道）、空字符串、长度为0的切片/map/数组、零值time.Time
+// 数字0和false是有意义的取值，不视为空
+func isEmptyValue(v interface{}) bool {
+	switch x := v.(type) {
+	case nil:
+		return true
+	case string:
+		return x == ""
+	case time.Time:
+		return x.IsZero()
+	}
+
+	rv := reflect.ValueOf(v)
+	switch rv.Kind() {
+	case reflect.Pointer, reflect.Interface, reflect.Func, reflect.Chan:
+		return rv.IsNil()
+	case reflect.Map, reflect.Slice:
+		return rv.IsNil() || rv.Len() == 0
+	case reflect.Array:
+		return rv.Len() == 0
+	}
+	return false
+}
+
+// isEmptyField 判断zap字段的值是否为空，规则与isEmptyValue一致，数字和布尔类型的字段总是保留
+func isEmptyField(field zapcore.Field) bool {
+	switch field.Type {
+	case zapcore.StringType:
+		return field.String == ""
+	case zapcore.ReflectType, zapcore.StringerType, zapcore.ArrayMarshalerType, zapcore.ObjectMarshalerType,
+		zapcore.ByteStringType, zapcore.BinaryType, zapcore.TimeFullType:
+		return isEmptyValue(field.Interface)
+	}
+	return false
+}
+
+// omitEmptyFields 去掉值为空的字段，没有空字段时原样返回
+func omitEmptyFields(fields []zapcore.Field) []zapcore.Field {
+	for i, field := range fields {
+		if !isEmptyField(field) {
+			continue
+		}
+		kept := append([]zapcore.Field(nil), fields[:i]...)
+		for _, f := range fields[i+1:] {
+			if !isEmptyField(f) {
+				kept = append(kept, f)
+			}
+		}
+		return kept
+	}
+	return fields
+}
+
+// zeroTimeProperty 零值time.Time经propertyValue转换后的属性值
+var zeroTimeProperty = time.Time{}.Format(iso8601Layout)
+
+// isEmptyProperty 判断适配器属性是否为空，属性中的时间已转换为字符串，零值时间按转换后的形式识别
+func isEmptyProperty(v interface{}) bool {
+	return isEmptyValue(v) || v == zeroTimeProperty
+}
+
+// omitEmptyProperties 去掉值为空的适配器属性，没有空属性时原样返回
+func omitEmptyProperties(properties map[string]interface{}) map[string]interface{} {
+	for _, v := range properties {
+		if !isEmptyProperty(v) {
+			continue
+		}
+		kept := make(map[string]interface{}, len(properties))
+		for k, v := range properties {
+			if !isEmptyProperty(v) {
+				kept[k] = v
+			}
+		}
+		return kept
+	}
+	return properties
+}
+
+// omitEmptyCore 写入前去掉值为空的字段
+type omitEmptyCore struct {
+	zapcore.Core
+}
+
+// With 实现zapcore.Core接口
+func (c *omitEmptyCore) With(fields []zapcore.Field) zapcore.Core {
+	return &omitEmptyCore{Core: c.Core.With(omitEmptyFields(fields))}
+}
+
+// Check 实现zapcore.Core接口
+func (c *omitEmptyCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *omitEmptyCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	return c.Core.Write(ent, omitEmptyFields(fields))
+}
@@ -1,5 +1,11 @@
 package logger
 
+import (
+	"context"
+	"io"
+	"time"
+)
+
 // Option 定义日志配置选项
 type Option func(*Config)
 
@@ -10,6 +16,20 @@ func WithLevel(level string) Option {
 	}
 }
 
+// WithConsoleLevel 设置控制台输出级别，未设置时使用WithLevel的级别
+func WithConsoleLevel(level string) Option {
+	return func(c *Config) {
+		c.ConsoleLevel = level
+	}
+}
+
+// WithFileLevel 设置文件输出级别，未设置时使用WithLevel的级别
+func WithFileLevel(level string) Option {
+	return func(c *Config) {
+		c.FileLevel = level
+	}
+}
+
 // WithPath 设置日志文件路径
 func WithPath(path string) Option {
 	return func(c *Config) {
@@ -38,6 +58,20 @@ func WithIP(ip string) Option {
 	}
 }
 
+// WithEnv 设置运行环境，每条日志携带env字段
+func WithEnv(env string) Option {
+	return func(c *Config) {
+		c.Env = env
+	}
+}
+
+// WithVersion 设置服务版本，每条日志携带version字段
+func WithVersion(version string) Option {
+	return func(c *Config) {
+		c.Version = version
+	}
+}
+
 // WithOutputType 设置输出类型
 func WithOutputType(outputType OutputType) Option {
 	return func(c *Config) {
@@ -60,6 +94,567 @@ func WithBothOutput() Option {
 	return WithOutputType(OutputBoth)
 }
 
+// WithAdaptersOutput 设置只分发到适配器，不输出到文件和终端，至少需要通过WithAdapter等选项配置一个适配器
+func WithAdaptersOutput() Option {
+	return WithOutputType(OutputAdapters)
+}
+
+// WithModuleOutput 为模块module单独设置输出类型，可以多次调用为不同模块分别设置
+// 创建日志时按Module选择输出类型，未设置的模块使用WithOutputType等选项设置的全局输出类型
+func WithModuleOutput(module string, outputType OutputType) Option {
+	return func(c *Config) {
+		if c.ModuleOutputs == nil {
+			c.ModuleOutputs = make(map[string]OutputType)
+		}
+		c.ModuleOutputs[module] = outputType
+	}
+}
+
+// WithLevelDecorations 设置控制台输出中各级别的前缀符号，如 {"info": "ℹ", "error": "✖"}，不影响文件输出
+func WithLevelDecorations(decorations map[string]string) Option {
+	return func(c *Config) {
+		c.LevelDecorations = decorations
+	}
+}
+
+// WithLevelColors 设置控制台输出中各级别的颜色，支持 black、red、green、yellow、blue、magenta、cyan、white、gray
+func WithLevelColors(colors map[string]string) Option {
+	return func(c *Config) {
+		c.LevelColors = colors
+	}
+}
+
+// WithLineEnding 设置文件输出的行尾，如"\r\n"，控制台输出保持"\n"
+func WithLineEnding(lineEnding string) Option {
+	return func(c *Config) {
+		c.LineEnding = lineEnding
+	}
+}
+
+// WithNoLineEnding 文件输出的每条日志不追加行尾
+func WithNoLineEnding() Option {
+	return WithLineEnding(NoLineEnding)
+}
+
+// WithFileFormat 设置文件输出格式，FileFormatText使文件与控制台一样输出纯文本，控制台格式由WithConsoleFormat设置
+func WithFileFormat(format string) Option {
+	return func(c *Config) {
+		c.FileFormat = format
+	}
+}
+
+// WithTimeFormat 设置控制台和文件的时间格式，为time包的布局字符串，如time.RFC3339Nano
+// 通过ConsoleOptions、FileOptions单独设置的时间格式优先
+func WithTimeFormat(layout string) Option {
+	return func(c *Config) {
+		c.TimeFormat = layout
+	}
+}
+
+// WithEncoderKeys 覆盖输出中时间、级别、消息、调用位置的key，如EncoderKeys{Time: "@timestamp", Level: "severity"}
+func WithEncoderKeys(keys EncoderKeys) Option {
+	return func(c *Config) {
+		c.EncoderKeys = keys
+	}
+}
+
+// WithConsoleOptions 一次设置全部控制台输出配置，覆盖之前单独设置的控制台选项，未设置的字段使用默认值
+func WithConsoleOptions(opts ConsoleOptions) Option {
+	return func(c *Config) {
+		c.ConsoleLevel = opts.Level
+		c.ConsoleFormat = opts.Format
+		c.ConsoleColor = opts.Color
+		c.LevelDecorations = opts.LevelDecorations
+		c.LevelColors = opts.LevelColors
+		c.ConsoleTimeFormat = opts.TimeFormat
+		c.ConsoleStderrLevel = opts.StderrLevel
+		c.InlineFields = opts.InlineFields
+		c.ConsoleBufferSize = opts.BufferSize
+		c.ConsoleFlushInterval = opts.FlushInterval
+		c.ConsoleSync = opts.Sync
+	}
+}
+
+// WithFileOptions 一次设置全部文件输出配置，覆盖之前单独设置的文件选项，未设置的字段使用默认值
+func WithFileOptions(opts FileOptions) Option {
+	return func(c *Config) {
+		c.FileLevel = opts.Level
+		c.FileFormat = opts.Format
+		c.FileTimeFormat = opts.TimeFormat
+		c.LineEnding = opts.LineEnding
+		c.Rotation = opts.Rotation
+	}
+}
+
+// WithRotation 设置文件旋转选项，在按天旋转的基础上按大小旋转、压缩和清理备份
+func WithRotation(rotation RotationOptions) Option {
+	return func(c *Config) {
+		c.Rotation = rotation
+	}
+}
+
+// WithBucketDuration 文件按固定时间桶切分，如15*time.Minute，文件为 <日志路径>/2006-01-02/15-04.log，
+// 供按固定时间窗口消费日志的批处理任务使用；必须是整分钟并能整除一天，time.Hour即按小时切分
+func WithBucketDuration(d time.Duration) Option {
+	return func(c *Config) {
+		c.Rotation.BucketDuration = d
+	}
+}
+
+// WithClockSkewTolerance 设置文件旋转对时钟回拨的容忍范围，默认只向前旋转
+// 回拨在容忍范围内时继续写入当前文件，超过范围视为时钟校正
+func WithClockSkewTolerance(tolerance time.Duration) Option {
+	return func(c *Config) {
+		c.Rotation.ClockSkewTolerance = tolerance
+	}
+}
+
+// WithFileFallbackThreshold 设置文件输出连续失败多少次后回退到控制台，默认3，小于0时不回退
+// 回退时通过内部错误输出给出警告，只输出到文件时日志改为写到控制台，文件恢复写入后自动回到文件输出
+func WithFileFallbackThreshold(threshold int) Option {
+	return func(c *Config) {
+		c.FileFallbackThreshold = threshold
+	}
+}
+
+// WithStackTrimPrefix 添加适配器堆栈顶部需要裁剪的函数前缀，如封装日志的内部包
+func WithStackTrimPrefix(prefixes ...string) Option {
+	return func(c *Config) {
+		c.StackTrimPrefixes = append(c.StackTrimPrefixes, prefixes...)
+	}
+}
+
+// WithAdapterCallerSkip 适配器日志的调用位置在跳过日志包和zap自身的帧之后再跳过skip层调用方，
+// 与控制台和文件输出的调用位置各自独立，供在日志方法外再封装一层的代码使用
+func WithAdapterCallerSkip(skip int) Option {
+	return func(c *Config) {
+		c.AdapterCallerSkip = skip
+	}
+}
+
+// WithFullStacktrace 适配器堆栈保留日志包和zap自身的帧
+func WithFullStacktrace() Option {
+	return func(c *Config) {
+		c.FullStacktrace = true
+	}
+}
+
+// WithStackDedup 在window内对适配器日志的重复堆栈去重，首次出现时输出完整stacktrace和stack_ref，
+// 之后相同的堆栈只输出stack_ref和窗口内的累计次数stack_count，超过window后重新输出完整堆栈
+func WithStackDedup(window time.Duration) Option {
+	return func(c *Config) {
+		c.StackDedupWindow = window
+	}
+}
+
+// WithKeySampling 分发到适配器时按属性key的取值分组采样，每个取值每rate条保留一条
+// 不包含该属性的日志以及error及以上级别的日志总是保留
+func WithKeySampling(key string, rate int) Option {
+	return func(c *Config) {
+		c.SamplingKey = key
+		c.SamplingRate = rate
+	}
+}
+
+// WithExtraRootFields 在文件和旁路输出的每行JSON顶层附加固定字段，用于满足采集端要求的结构，如logstash的"@version": 1
+// 与WithField等日志字段不同，这些字段不输出到控制台，也不发送给适配器；与time、level、msg等标准字段同名时New返回错误
+// 可以多次调用，同名字段以后设置的为准
+func WithExtraRootFields(fields map[string]interface{}) Option {
+	return func(c *Config) {
+		if c.ExtraRootFields == nil {
+			c.ExtraRootFields = make(map[string]interface{}, len(fields))
+		}
+		for k, v := range fields {
+			c.ExtraRootFields[k] = v
+		}
+	}
+}
+
+// WithSamplingHook 设置采样丢弃的回调，级别采样和按键采样丢弃日志时按级别累计，每秒最多汇总调用一次，Close时报告剩余的计数
+// 回调在记录日志的协程中同步调用，应尽快返回，适合累加指标；回调不应通过本日志输出，避免丢弃与输出相互触发
+func WithSamplingHook(hook func(level string, dropped int)) Option {
+	return func(c *Config) {
+		c.SamplingHook = hook
+	}
+}
+
+// WithStacktraceLevel 达到level的日志在控制台和文件输出中附加调用栈(stacktrace字段)，如"error"
+// 适配器日志在error及以上级别总是附加堆栈，不受该选项影响
+func WithStacktraceLevel(level string) Option {
+	return func(c *Config) {
+		c.StacktraceLevel = level
+	}
+}
+
+// WithLevelRemap 改写匹配的日志的级别，用于把依赖库过多的error降为warn等，不需要修改依赖
+// key由逗号分隔的条件组成：module=<模块名>、level=<原始级别>、msg=<消息正则>，msg必须放在最后；value为改写后的级别，
+// 如{"module=kafka-client,level=error,msg=^connection reset": "warn"}。条件多的规则优先，条件数相同时按key排序取第一条；
+// 改写发生在控制台、文件输出判断级别和采样之前，以及发送到适配器之前；SetFloorLevel的闸门、V视图和节流按原始级别判断；
+// panic及以上级别不参与改写
+func WithLevelRemap(rules map[string]string) Option {
+	return func(c *Config) {
+		c.LevelRemap = rules
+	}
+}
+
+// WithFieldNamespace 将With、WithField和Infow等传入的字段放入名为namespace的嵌套对象，如{"labels": {"user": "alice"}}，
+// 避免与ECS等schema中保留的顶层字段冲突；nodeId、module、ip、env、version以及goroutine、seq、func等元数据保留在顶层，
+// 适配器属性同样放入该key下的map，配置的静态属性保留在顶层；与time、level、msg等标准字段同名时New返回错误
+func WithFieldNamespace(namespace string) Option {
+	return func(c *Config) {
+		c.FieldNamespace = namespace
+	}
+}
+
+// WithLevelSampling 控制台和文件输出对level级别的日志采样，每秒内相同消息先输出initial条，之后每thereafter条输出一条
+// 可以多次调用为不同级别分别设置，如debug、info大量采样而warn不采样；未设置的级别不采样，
+// error及以上级别不允许采样，New时返回错误；适配器分发不受影响，可使用WithKeySampling
+func WithLevelSampling(level string, initial, thereafter int) Option {
+	return func(c *Config) {
+		if c.LevelSampling == nil {
+			c.LevelSampling = make(map[string]LevelSampling)
+		}
+		c.LevelSampling[level] = LevelSampling{Initial: initial, Thereafter: thereafter}
+	}
+}
+
+// WithClock 设置日志时间戳使用的时钟，输出核心、适配器和文件旋转都使用该时钟
+func WithClock(clock Clock) Option {
+	return func(c *Config) {
+		c.Clock = clock
+	}
+}
+
+// WithDispatchBuffer 设置适配器分发队列容量，默认1024
+// 队列满时新日志不会阻塞调用方，而是被丢弃并计入Stats().Dropped，较大的队列可以容忍突发流量
+func WithDispatchBuffer(n int) Option {
+	return func(c *Config) {
+		c.DispatchBuffer = n
+	}
+}
+
+// WithBackpressurePolicy 设置适配器分发队列满时的处理策略，DropWhenFull(默认)或BlockWithTimeout(d)
+// 可以结合Stats()中的QueueHighMark、QueueFull和Dropped在"不阻塞应用"和"不丢日志"之间取舍
+func WithBackpressurePolicy(policy BackpressurePolicy) Option {
+	return func(c *Config) {
+		c.BackpressurePolicy = policy
+	}
+}
+
+// WithDispatchRetry 适配器处理日志失败时，等待delay后重新交给该适配器，最多重试retries次，用尽后丢弃并计入Stats().RetryDropped
+// 适合逐条进行网络调用的适配器（如webhook、socket）提高送达率；重试使用与分发队列同样容量的独立队列，
+// 工作协程在新日志和到期的重试之间交替处理，持续失败时不会挤占新日志，delay为0时使用默认的100ms
+func WithDispatchRetry(retries int, delay time.Duration) Option {
+	return func(c *Config) {
+		c.DispatchRetries = retries
+		c.DispatchRetryDelay = delay
+	}
+}
+
+// WithDrainTimeout 设置Close等待分发队列处理完成的最长时间，超时后放弃剩余的日志，仍然刷新并关闭所有适配器，
+// 返回的错误中包含未送达的日志数；为0时一直等待，只受CloseWithContext的上下文限制
+func WithDrainTimeout(d time.Duration) Option {
+	return func(c *Config) {
+		c.DrainTimeout = d
+	}
+}
+
+// WithDispatchWorkers 设置适配器分发工作协程数，默认4个，与适配器数量无关
+// 每个工作协程依次把日志交给所有适配器，多个协程可以并行处理慢适配器，但不再保证顺序
+func WithDispatchWorkers(n int) Option {
+	return func(c *Config) {
+		c.DispatchWorkers = n
+	}
+}
+
+// WithMaxAdapterGoroutines 限制同时把日志交给适配器处理的协程数，默认256
+// 分发工作协程和同步处理panic日志的调用方共用这些名额，达到上限时按WithAdapterLimitPolicy等待或丢弃，次数计入Stats().AdapterLimitHits
+func WithMaxAdapterGoroutines(n int) Option {
+	return func(c *Config) {
+		c.MaxAdapterGoroutines = n
+	}
+}
+
+// WithAdapterLimitPolicy 设置处理适配器日志的协程数达到上限时的处理方式，AdapterLimitBlock(默认)或AdapterLimitDrop
+func WithAdapterLimitPolicy(policy AdapterLimitPolicy) Option {
+	return func(c *Config) {
+		c.AdapterLimitPolicy = policy
+	}
+}
+
+// WithCallerFunction 每条日志在输出和适配器属性中附加func字段，值为调用方的完整函数名，如github.com/org/repo/pkg.(*T).Method
+// 控制台和文件输出复用zap捕获调用位置时解析的帧；适配器日志需要额外获取一次调用栈，有一定开销，因此需要显式开启
+func WithCallerFunction() Option {
+	return func(c *Config) {
+		c.CallerFunction = true
+	}
+}
+
+// WithSortedFields 文件和旁路的JSON输出按固定顺序排列字段：time、level、msg在前，其余字段按key排序
+// 字段顺序不再随With的使用方式变化，便于golden文件测试和人工浏览；每条日志需要额外解析和重排一次，默认不开启
+func WithSortedFields() Option {
+	return func(c *Config) {
+		c.SortedFields = true
+	}
+}
+
+// WithOmitEmpty 控制台、文件输出和适配器属性都省略值为空的字段，避免"ip":""之类的噪音
+// 空值包括nil（含nil指针、map、切片）、空字符串、长度为0的切片/map/数组和零值time.Time；数字0和false是有意义的取值，总是保留
+func WithOmitEmpty() Option {
+	return func(c *Config) {
+		c.OmitEmpty = true
+	}
+}
+
+// WithFieldLimit 限制每条日志的字段数和单个字段值的字节数，防止异常的调用方撑大每行日志或超出ES的字段数上限
+// 控制台、文件输出按添加顺序保留前maxFields个字段，适配器属性按key排序保留；过长的字符串和结构化的值被截断为字符串，
+// 被限制的日志附加fields_truncated: true，参数为0表示对应项不限制
+func WithFieldLimit(maxFields int, maxValueBytes int) Option {
+	return func(c *Config) {
+		c.MaxFields = maxFields
+		c.MaxFieldValueBytes = maxValueBytes
+	}
+}
+
+// WithInternalErrorWriter 设置日志包自身错误的输出，默认标准错误输出
+// 宽松模式下的适配器初始化失败、适配器刷新和关闭失败、文件写入和旋转失败都写入这里，不会混入应用日志
+func WithInternalErrorWriter(w io.Writer) Option {
+	return func(c *Config) {
+		c.InternalErrorWriter = w
+	}
+}
+
+// WithGoroutineID 每条日志在文件输出和适配器属性中附加goroutine字段，便于关联同一协程的日志
+// 协程ID通过解析runtime.Stack获取，每条日志都会额外获取一次调用栈，建议仅在排查并发问题时开启
+func WithGoroutineID() Option {
+	return func(c *Config) {
+		c.GoroutineID = true
+	}
+}
+
+// WithSequence 每条日志在文件输出和适配器属性中附加原子递增的seq字段，子日志与父日志共享序号
+func WithSequence() Option {
+	return func(c *Config) {
+		c.Sequence = true
+	}
+}
+
+// WithLevelHook 添加一个在日志达到level及以上级别时调用的回调，如累加业务指标或发送告警，可以添加多个
+// 回调在适配器处理之后于分发协程中调用，不阻塞写日志的调用方，回调中的panic会被捕获并写入内部错误输出
+func WithLevelHook(level string, fn func(entry LogEntry)) Option {
+	return func(c *Config) {
+		c.LevelHooks = append(c.LevelHooks, LevelHook{Level: level, Fn: fn})
+	}
+}
+
+// WithAdapterProperties 添加合并到每条适配器日志Properties中的静态属性，如数据中心、集群名，可以多次调用
+// 这些属性只发送给适配器（包括审计适配器），不输出到控制台和文件；同名时WithField等添加的字段和调用时的字段优先
+func WithAdapterProperties(properties map[string]interface{}) Option {
+	return func(c *Config) {
+		if c.AdapterProperties == nil {
+			c.AdapterProperties = make(map[string]interface{}, len(properties))
+		}
+		for k, v := range properties {
+			c.AdapterProperties[k] = v
+		}
+	}
+}
+
+// WithTee 将每条日志以JSON额外写一份到w，如内存缓冲区或调试文件，用于临时排查日志丢失等问题
+// 旁路输出是附加的，不影响控制台、文件和适配器，运行时可以通过SetTee替换或RemoveTee移除
+func WithTee(w io.Writer) Option {
+	return func(c *Config) {
+		c.Tee = w
+	}
+}
+
+// WithLenientAdapters 适配器初始化失败时跳过该适配器并输出警告，默认初始化失败直接返回错误
+// 警告属于日志包自身的错误，写入WithInternalErrorWriter设置的输出(默认标准错误输出)，而不是控制台核心：
+// 不受控制台级别和格式影响，只输出到文件或适配器时同样可见，也不会混入结构化的应用日志
+func WithLenientAdapters() Option {
+	return func(c *Config) {
+		c.LenientAdapters = true
+	}
+}
+
+// WithBufferedConsole 控制台输出使用缓冲区，每flushInterval刷新一次，error及以上级别和Sync/Close时立即刷新
+// 适合大量日志通过管道输出的场景，flushInterval为0时使用默认的100ms
+func WithBufferedConsole(size int, flushInterval time.Duration) Option {
+	return func(c *Config) {
+		c.ConsoleBufferSize = size
+		c.ConsoleFlushInterval = flushInterval
+	}
+}
+
+// WithConsoleSync 标准输出不是终端（重定向到文件或管道）时，always为true在每条日志写入后Sync，否则只在error及以上级别写入后Sync，
+// 以吞吐量换取与标准错误输出的顺序和崩溃前最后几行日志不丢失；与WithBufferedConsole同时使用时Sync会刷新缓冲区
+func WithConsoleSync(always bool) Option {
+	return func(c *Config) {
+		if always {
+			c.ConsoleSync = ConsoleSyncAlways
+		} else {
+			c.ConsoleSync = ConsoleSyncError
+		}
+	}
+}
+
+// WithFatalExitCode 设置Fatal退出进程时使用的退出码，默认1
+func WithFatalExitCode(code int) Option {
+	return func(c *Config) {
+		c.FatalExitCode = code
+	}
+}
+
+// WithPanicBehavior 设置Panic、Panicf、Panicw的行为，PanicCrash(默认)输出日志后panic，
+// PanicLogOnly以panic级别输出日志并刷新适配器后返回，避免库代码替调用方决定让整个程序崩溃
+func WithPanicBehavior(behavior string) Option {
+	return func(c *Config) {
+		c.PanicBehavior = behavior
+	}
+}
+
+// WithBaseContext 设置适配器处理日志的上下文的父上下文，如应用关闭时取消的根上下文
+// 每条日志的处理上下文在此基础上附加超时，父上下文的值和取消会传递给适配器；Close返回时取消该上下文，
+// 超时返回时可以中止仍在进行的发送
+func WithBaseContext(ctx context.Context) Option {
+	return func(c *Config) {
+		c.BaseContext = ctx
+	}
+}
+
+// WithContextFields 设置Logger.WithContext从context中提取的字段名，如WithContextFields("trace_id", "span_id")
+// 按名称依次查找logger.ContextKey(name)和字符串name作为key存入的值，trace_id、span_id、request_id还会查找WithTraceID等存入的值；
+// context中没有该值或值为空字符串时不附加该字段；可以多次调用，字段名累加
+func WithContextFields(keys ...string) Option {
+	return func(c *Config) {
+		c.ContextFields = append(c.ContextFields, keys...)
+	}
+}
+
+// WithAuditAdapter 设置审计适配器，Audit写入的日志同步、按顺序交给它处理并刷新，不采样也不丢弃
+// 传入的适配器应已初始化，Close时会被关闭
+func WithAuditAdapter(adapter LogAdapter) Option {
+	return func(c *Config) {
+		c.AuditAdapter = adapter
+	}
+}
+
+// WithAdapterPanicLimit 适配器Process、Flush累计panic达到limit次后停用该适配器，不再向其分发日志
+// 适配器的panic总是被捕获并写入内部错误输出，不会导致应用崩溃
+func WithAdapterPanicLimit(limit int) Option {
+	return func(c *Config) {
+		c.AdapterPanicLimit = limit
+	}
+}
+
+// WithRequestIDGenerator 设置HTTP中间件生成请求ID的函数，如UUIDv7、KSUID或从trace ID派生，默认UUIDv4
+func WithRequestIDGenerator(generate func() string) Option {
+	return func(c *Config) {
+		c.RequestIDGenerator = generate
+	}
+}
+
+// WithAccessLog HTTP中间件在请求结束时输出访问日志，包含method、path、status、bytes和duration_ms字段
+// 5xx以error级别输出，4xx以warn级别输出，其余以info级别输出
+func WithAccessLog() Option {
+	return func(c *Config) {
+		c.AccessLog = true
+	}
+}
+
+// WithSuccessSampleRate 启用访问日志，成功(2xx/3xx)的请求每every条只输出keep条，4xx和5xx总是全部输出
+// 2xx和3xx分别计数，如WithSuccessSampleRate(1, 100)在高QPS服务中减少访问日志量而不隐藏错误
+func WithSuccessSampleRate(keep, every int) Option {
+	return func(c *Config) {
+		c.AccessLog = true
+		c.SuccessSampleKeep = keep
+		c.SuccessSampleEvery = every
+	}
+}
+
+// WithAccessLogFields 启用访问日志并只输出指定的字段，如WithAccessLogFields(AccessLogMethod, AccessLogPath, AccessLogStatus)
+// 未知的字段名在创建日志时返回错误，request_id由请求日志携带，不受此设置影响
+func WithAccessLogFields(fields ...string) Option {
+	return func(c *Config) {
+		c.AccessLog = true
+		c.AccessLogFields = append([]string(nil), fields...)
+	}
+}
+
+// WithRequestIDHeader 设置HTTP中间件读取和回写请求ID的头，默认X-Request-ID
+func WithRequestIDHeader(header string) Option {
+	return func(c *Config) {
+		c.RequestIDHeader = header
+	}
+}
+
+// WithConsoleFormat 设置控制台输出格式，ConsoleFormatHybrid在可读的行后以key=value输出全部字段，文件输出不受影响
+// ConsoleFormatJSON以与文件相同的JSON格式每行输出一个对象，用于容器环境
+func WithConsoleFormat(format string) Option {
+	return func(c *Config) {
+		c.ConsoleFormat = format
+	}
+}
+
+// WithJSONConsole 控制台以与文件输出相同的JSON格式每行输出一条日志(NDJSON)，
+// 配合WithTerminalOutput在容器中不需要日志路径即可输出JSON，等同于WithConsoleFormat(ConsoleFormatJSON)
+func WithJSONConsole() Option {
+	return func(c *Config) {
+		c.ConsoleFormat = ConsoleFormatJSON
+	}
+}
+
+// WithInlineFields 控制台输出将字段以key=value拼接到消息中，而不是作为单独的结构化字段，用于只读取消息列的旧工具
+// 文件的JSON输出和适配器不受影响
+func WithInlineFields() Option {
+	return func(c *Config) {
+		c.InlineFields = true
+	}
+}
+
+// WithFullCaller 调用位置输出完整的文件路径，控制台和文件输出均生效
+func WithFullCaller() Option {
+	return func(c *Config) {
+		c.CallerFormat = CallerFull
+	}
+}
+
+// WithPackageCaller 调用位置输出为包导入路径加文件名，如github.com/org/repo/pkg/handler.go:42
+func WithPackageCaller() Option {
+	return func(c *Config) {
+		c.CallerFormat = CallerPackage
+	}
+}
+
+// WithBinaryEncoding 设置[]byte参数和字段的编码方式，BinaryBase64(默认)或BinaryHex
+func WithBinaryEncoding(encoding string) Option {
+	return func(c *Config) {
+		c.BinaryEncoding = encoding
+	}
+}
+
+// WithDurationFormat 设置time.Duration字段的编码方式，DurationSeconds(默认)、DurationMillis、DurationNanos或DurationString
+// 控制台、文件输出和适配器属性使用相同的格式
+func WithDurationFormat(format string) Option {
+	return func(c *Config) {
+		c.DurationFormat = format
+	}
+}
+
+// WithVerbosity 设置详细级别阈值，V(n).Info等在n小于等于该值时输出，运行时可以通过SetVerbosity调整
+func WithVerbosity(level int) Option {
+	return func(c *Config) {
+		c.Verbosity = level
+	}
+}
+
+// WithMaxBinarySize 设置[]byte最多编码的字节数，超出部分截断并注明原始大小，默认4096
+func WithMaxBinarySize(size int) Option {
+	return func(c *Config) {
+		c.MaxBinarySize = size
+	}
+}
+
 // WithAdapter 添加一个日志适配器
 func WithAdapter(name string, config map[string]interface{}) Option {
 	return func(c *Config) {
@@ -70,6 +665,40 @@ func WithAdapter(name string, config map[string]interface{}) Option {
 	}
 }
 
+// WithAdapterInstance 添加一个带实例ID的日志适配器，同一适配器可以用不同ID添加多个实例
+func WithAdapterInstance(name, id string, config map[string]interface{}) Option {
+	return func(c *Config) {
+		c.Adapters = append(c.Adapters, AdapterConfig{
+			Name:   name,
+			ID:     id,
+			Config: config,
+		})
+	}
+}
+
+// WithAdapterPriority 添加一个带优先级的日志适配器，优先级高的适配器先处理同一条日志
+// 每条日志总是按优先级依次交给各适配器，但多个分发协程并行时不同日志之间不保证顺序，需要严格顺序时使用单个分发协程
+func WithAdapterPriority(name string, priority int, config map[string]interface{}) Option {
+	return func(c *Config) {
+		c.Adapters = append(c.Adapters, AdapterConfig{
+			Name:     name,
+			Priority: priority,
+			Config:   config,
+		})
+	}
+}
+
+// WithAdapterLevel 添加一个只接收不低于level的日志的适配器，如只把warn及以上的日志发送到Elasticsearch
+// 等同于在适配器配置中设置level配置项，不影响控制台和文件的输出级别
+func WithAdapterLevel(name, level string, config map[string]interface{}) Option {
+	leveled := make(map[string]interface{}, len(config)+1)
+	for k, v := range config {
+		leveled[k] = v
+	}
+	leveled[AdapterLevelKey] = level
+	return WithAdapter(name, leveled)
+}
+
 // WithElasticsearchAdapter 添加Elasticsearch适配器
 func WithElasticsearchAdapter(config map[string]interface{}) Option {
 	return WithAdapter("elasticsearch", config)
@@ -81,10 +710,54 @@ func WithKafkaAdapter(config map[string]interface{}) Option {
 }
 
 // WithPrometheusAdapter 添加Prometheus适配器
+// 适配器不在adapters包中，而是单独的adapters/prometheus包(类型为prometheus.Adapter)，避免导入adapters就引入Prometheus客户端库；
+// 需要额外导入该包注册适配器，只导入adapters时适配器未注册，创建日志时输出unknown adapter警告并跳过
+//
+//	import _ "github.com/qishenonly/logger/adapters/prometheus"
 func WithPrometheusAdapter(config map[string]interface{}) Option {
 	return WithAdapter("prometheus", config)
 }
 
+// WithAzureMonitorAdapter 添加Azure Monitor适配器
+func WithAzureMonitorAdapter(config map[string]interface{}) Option {
+	return WithAdapter("azuremonitor", config)
+}
+
+// WithGCPAdapter 添加GCP Cloud Logging适配器
+func WithGCPAdapter(config map[string]interface{}) Option {
+	return WithAdapter("gcp", config)
+}
+
+// WithFluentAdapter 添加Fluentd/Fluent Bit Forward协议适配器
+func WithFluentAdapter(config map[string]interface{}) Option {
+	return WithAdapter("fluent", config)
+}
+
+// WithClickHouseAdapter 添加ClickHouse适配器
+func WithClickHouseAdapter(config map[string]interface{}) Option {
+	return WithAdapter("clickhouse", config)
+}
+
+// WithOTLPAdapter 添加OpenTelemetry OTLP日志导出适配器
+func WithOTLPAdapter(config map[string]interface{}) Option {
+	return WithAdapter("otlp", config)
+}
+
+// WithEventLogAdapter 添加Windows事件日志适配器，非Windows平台初始化失败
+func WithEventLogAdapter(config map[string]interface{}) Option {
+	return WithAdapter("eventlog", config)
+}
+
+// WithRingBufferAdapter 添加环形缓冲适配器，遇到触发级别的日志时输出最近的日志
+func WithRingBufferAdapter(config map[string]interface{}) Option {
+	return WithAdapter("ringbuffer", config)
+}
+
+// WithMemoryAdapter 添加内存适配器，在测试中通过ZapLogger.Adapter("memory")取得*adapters.MemoryAdapter断言输出的日志
+func WithMemoryAdapter(config map[string]interface{}) Option {
+	return WithAdapter("memory", config)
+}
+
 // DefaultConfig 返回默认配置
 func DefaultConfig() Config {
 	return Config{
@@ -93,7 +766,7 @@ func DefaultConfig() Config {
 		NodeID:     "",
 		Module:     "default",
 		IP:         "",
-		OutputType: GetDefaultOutputType(),
+		OutputType: "", // 由Init/New根据Path选择
 		Adapters:   []AdapterConfig{},
 	}
 }
@@ -106,3 +779,32 @@ func NewConfig(opts ...Option) Config {
 	}
 	return cfg
 }
+
+// WithFileWriter 使用w作为文件输出，替代默认按天旋转的DailyRotateWriter，仍使用本包的编码器、字段和适配器
+// 用于接入已有的旋转库，如&lumberjack.Logger{Filename: "app.log", MaxSize: 100}，本包不依赖这些库；
+// 未设置输出类型时启用文件输出，已设置的输出类型保持不变；Path可以为空，日志实例Close时关闭w
+// 输出类型为OutputTerminal或OutputAdapters时w不会被写入，New返回错误
+func WithFileWriter(w io.WriteCloser) Option {
+	return func(c *Config) {
+		c.FileWriter = w
+		if !IsValidOutputType(c.OutputType) {
+			c.OutputType = OutputFile
+		}
+	}
+}
+
+// WithAsyncCore 控制台和文件输出由单独的协程写出，日志调用只需编码并复制到容量为bufferSize条的队列后立即返回
+// 与适配器分发不同，它作用于输出核心自身的写入；队列满时默认丢弃新日志并计入Stats().AsyncDropped，
+// 可通过WithAsyncCorePolicy改为阻塞。Sync等待队列写出，Close时写出剩余日志；已启用控制台缓冲时控制台不再异步
+func WithAsyncCore(bufferSize int) Option {
+	return func(c *Config) {
+		c.AsyncCoreBuffer = bufferSize
+	}
+}
+
+// WithAsyncCorePolicy 设置异步输出队列满时的策略：AsyncPolicyDrop(默认)或AsyncPolicyBlock
+func WithAsyncCorePolicy(policy string) Option {
+	return func(c *Config) {
+		c.AsyncCorePolicy = policy
+	}
+}
@@ -1,5 +1,18 @@
 package logger
 
+import (
+	"bytes"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"sync"
+	"syscall"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
 // OutputType 定义日志输出类型
 type OutputType string
 
@@ -10,14 +23,257 @@ const (
 	OutputTerminal OutputType = "terminal"
 	// OutputBoth 同时输出到文件和终端
 	OutputBoth OutputType = "both"
+	// OutputAdapters 只分发到适配器，不输出到文件和终端，至少需要配置一个适配器
+	OutputAdapters OutputType = "adapters"
 )
 
 // IsValidOutputType 检查输出类型是否有效
 func IsValidOutputType(output OutputType) bool {
-	return output == OutputFile || output == OutputTerminal || output == OutputBoth
+	return output == OutputFile || output == OutputTerminal || output == OutputBoth || output == OutputAdapters
 }
 
 // GetDefaultOutputType 获取默认输出类型
 func GetDefaultOutputType() OutputType {
 	return OutputTerminal // 默认输出到终端
 }
+
+// defaultOutputType 未指定输出类型时根据日志路径选择：设置了路径时同时输出到文件和终端，否则只输出到终端
+func defaultOutputType(path string) OutputType {
+	if path != "" {
+		return OutputBoth
+	}
+	return GetDefaultOutputType()
+}
+
+// configOutputType 未指定输出类型时按配置选择：设置了FileWriter时至少输出到文件，其余与defaultOutputType相同
+func configOutputType(config Config) OutputType {
+	outputType := defaultOutputType(config.Path)
+	if config.FileWriter != nil && outputType == OutputTerminal {
+		return OutputFile
+	}
+	return outputType
+}
+
+// NoLineEnding 作为行尾配置时，文件输出的每条日志不再追加换行
+const NoLineEnding = "none"
+
+// trimNewlineWriter 去掉每条日志末尾换行的写入器，zap每次Write恰好写入一条完整日志
+type trimNewlineWriter struct {
+	zapcore.WriteSyncer
+}
+
+// Write 实现io.Writer接口
+func (w trimNewlineWriter) Write(p []byte) (n int, err error) {
+	trimmed := bytes.TrimSuffix(p, []byte(zapcore.DefaultLineEnding))
+	if _, err := w.WriteSyncer.Write(trimmed); err != nil {
+		return 0, err
+	}
+	return len(p), nil
+}
+
+// defaultConsoleFlushInterval 控制台缓冲区默认刷新间隔，保证交互使用时输出及时可见
+const defaultConsoleFlushInterval = 100 * time.Millisecond
+
+// newConsoleBuffer 创建定时刷新的控制台缓冲输出
+func newConsoleBuffer(ws zapcore.WriteSyncer, size int, flushInterval time.Duration, clock Clock) *zapcore.BufferedWriteSyncer {
+	if flushInterval <= 0 {
+		flushInterval = defaultConsoleFlushInterval
+	}
+	buffer := &zapcore.BufferedWriteSyncer{
+		WS:            noSyncWriter{ws},
+		Size:          size,
+		FlushInterval: flushInterval,
+	}
+	if clock != nil {
+		buffer.Clock = zapClock{clock}
+	}
+	return buffer
+}
+
+// noSyncWriter 忽略Sync的写入器，标准输出是终端或管道时Sync会返回错误，缓冲区刷新只需要写出内容
+type noSyncWriter struct {
+	zapcore.WriteSyncer
+}
+
+// Sync 实现zapcore.WriteSyncer接口
+func (noSyncWriter) Sync() error {
+	return nil
+}
+
+// flushOnErrorCore 写入达到level的日志后立即刷新输出，避免错误日志滞留在缓冲区
+type flushOnErrorCore struct {
+	zapcore.Core
+	out   zapcore.WriteSyncer
+	level zapcore.Level
+}
+
+// flushOnError 为写入缓冲输出的核心添加错误级别立即刷新，out为空时原样返回
+func flushOnError(core zapcore.Core, out *zapcore.BufferedWriteSyncer) zapcore.Core {
+	if out == nil {
+		return core
+	}
+	return &flushOnErrorCore{Core: core, out: out, level: zapcore.ErrorLevel}
+}
+
+// syncOnWrite 写入达到level的日志后立即Sync输出，用于WithConsoleSync
+func syncOnWrite(core zapcore.Core, out zapcore.WriteSyncer, level zapcore.Level) zapcore.Core {
+	return &flushOnErrorCore{Core: core, out: out, level: level}
+}
+
+// parseConsoleSync 解析ConsoleSync配置，返回需要Sync的最低级别，为空时ok为false
+func parseConsoleSync(mode string) (level zapcore.Level, ok bool, err error) {
+	switch mode {
+	case "":
+		return 0, false, nil
+	case ConsoleSyncAlways:
+		return zapcore.DebugLevel, true, nil
+	case ConsoleSyncError:
+		return zapcore.ErrorLevel, true, nil
+	default:
+		return 0, false, fmt.Errorf("unknown console sync: %s", mode)
+	}
+}
+
+// isTerminal 判断输出是否为终端，只有*os.File可能是终端
+func isTerminal(ws zapcore.WriteSyncer) bool {
+	f, ok := ws.(*os.File)
+	if !ok {
+		return false
+	}
+	info, err := f.Stat()
+	return err == nil && info.Mode()&os.ModeCharDevice != 0
+}
+
+// pipeSyncWriter 忽略管道等不支持Sync的输出返回的错误，重定向到文件时仍然落盘
+type pipeSyncWriter struct {
+	zapcore.WriteSyncer
+}
+
+// Sync 实现zapcore.WriteSyncer接口
+func (w pipeSyncWriter) Sync() error {
+	err := w.WriteSyncer.Sync()
+	if unsupportedSync(err) {
+		return nil
+	}
+	return err
+}
+
+// unsupportedSync 判断错误是否为终端、管道等不支持Sync的输出返回的错误
+func unsupportedSync(err error) bool {
+	return errors.Is(err, syscall.EINVAL) || errors.Is(err, syscall.ENOTSUP) || errors.Is(err, syscall.ENOTTY)
+}
+
+// dropUnsupportedSync 去掉合并错误中不支持Sync返回的错误，保留文件等输出真正的同步失败
+func dropUnsupportedSync(err error) error {
+	if group, ok := err.(interface{ Unwrap() []error }); ok {
+		var errs []error
+		for _, e := range group.Unwrap() {
+			if e = dropUnsupportedSync(e); e != nil {
+				errs = append(errs, e)
+			}
+		}
+		return errors.Join(errs...)
+	}
+	if unsupportedSync(err) {
+		return nil
+	}
+	return err
+}
+
+// With 实现zapcore.Core接口
+func (c *flushOnErrorCore) With(fields []zapcore.Field) zapcore.Core {
+	return &flushOnErrorCore{Core: c.Core.With(fields), out: c.out, level: c.level}
+}
+
+// Check 实现zapcore.Core接口
+func (c *flushOnErrorCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if c.Enabled(ent.Level) {
+		return ce.AddCore(ent, c)
+	}
+	return ce
+}
+
+// Write 实现zapcore.Core接口
+func (c *flushOnErrorCore) Write(ent zapcore.Entry, fields []zapcore.Field) error {
+	if err := c.Core.Write(ent, fields); err != nil {
+		return err
+	}
+	if ent.Level >= c.level {
+		return c.out.Sync()
+	}
+	return nil
+}
+
+// defaultFileFallbackThreshold 文件输出连续失败多少次后回退到控制台
+const defaultFileFallbackThreshold = 3
+
+// fileFallbackWriter 文件输出连续失败达到阈值时把日志写到控制台并输出醒目的警告，文件恢复后自动回到文件输出
+// 已经同时输出到控制台时console为空，只输出警告，避免同一条日志在控制台出现两次
+type fileFallbackWriter struct {
+	file        zapcore.WriteSyncer
+	console     zapcore.WriteSyncer
+	threshold   int
+	errorOutput io.Writer
+
+	mu       sync.Mutex
+	failures int
+	active   bool
+}
+
+// newFileFallback 创建文件回退写入器，threshold为0时使用默认值，小于0时不回退
+func newFileFallback(file, console zapcore.WriteSyncer, threshold int, errorOutput io.Writer) zapcore.WriteSyncer {
+	if threshold < 0 {
+		return file
+	}
+	if threshold == 0 {
+		threshold = defaultFileFallbackThreshold
+	}
+	return &fileFallbackWriter{
+		file:        file,
+		console:     console,
+		threshold:   threshold,
+		errorOutput: errorOutput,
+	}
+}
+
+// Write 实现io.Writer接口
+func (w *fileFallbackWriter) Write(p []byte) (int, error) {
+	w.mu.Lock()
+	defer w.mu.Unlock()
+
+	n, err := w.file.Write(p)
+	if err == nil {
+		if w.active {
+			w.active = false
+			writeInternalError(w.errorOutput, "file output recovered, console fallback disabled")
+		}
+		w.failures = 0
+		return n, nil
+	}
+
+	w.failures++
+	if w.failures < w.threshold {
+		return n, err
+	}
+	if !w.active {
+		w.active = true
+		writeInternalError(w.errorOutput, "WARNING: file output failed %d consecutive times, logs go to console until the file recovers: %v", w.failures, err)
+	}
+	if w.console == nil {
+		return n, err
+	}
+	return w.console.Write(p)
+}
+
+// Sync 实现zapcore.WriteSyncer接口
+func (w *fileFallbackWriter) Sync() error {
+	return w.file.Sync()
+}
+
+// nopWriteCloser Close不做任何操作的写入器
+type nopWriteCloser struct {
+	io.Writer
+}
+
+// Close 实现io.Closer接口
+func (nopWriteCloser) Close() error { return nil }
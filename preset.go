@@ -0,0 +1,47 @@
+package logger
+
+// Production 生产环境的预设配置，返回的Config可以继续修改
+// info级别，path下按天旋转的JSON文件，控制台每行一个JSON对象(NDJSON)，附加短格式的调用位置，
+// error及以上级别附加调用栈，时间戳和文件按天切换使用UTC
+func Production(path string) Config {
+	config := DefaultConfig()
+	config.Level = "info"
+	config.Path = path
+	config.OutputType = OutputBoth
+	config.ConsoleFormat = ConsoleFormatJSON
+	config.CallerFormat = CallerShort
+	config.StacktraceLevel = "error"
+	config.Clock = UTCClock
+	config.Rotation.Clock = UTCClock
+	return config
+}
+
+// Development 开发环境的预设配置，返回的Config可以继续修改
+// debug级别，只输出到控制台，可读的hybrid格式，级别带颜色，附加短格式的调用位置
+func Development() Config {
+	config := DefaultConfig()
+	config.Level = "debug"
+	config.OutputType = OutputTerminal
+	config.ConsoleFormat = ConsoleFormatHybrid
+	config.ConsoleColor = true
+	config.CallerFormat = CallerShort
+	return config
+}
+
+// InitProduction 使用Production预设初始化默认日志，opts在预设之上继续修改配置
+func InitProduction(path string, opts ...Option) error {
+	config := Production(path)
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return Init(config)
+}
+
+// InitDevelopment 使用Development预设初始化默认日志，opts在预设之上继续修改配置
+func InitDevelopment(opts ...Option) error {
+	config := Development()
+	for _, opt := range opts {
+		opt(&config)
+	}
+	return Init(config)
+}
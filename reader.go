@@ -0,0 +1,100 @@
+package logger
+
+import (
+	"bufio"
+	"bytes"
+	"encoding/json"
+	"errors"
+	"fmt"
+	"io"
+	"strings"
+	"time"
+)
+
+// ReadEntries 解析文件输出的JSON日志行，返回日志条目
+// 无法解析的行会被跳过，所有解析错误合并后与已解析的条目一起返回；读取失败时立即返回
+func ReadEntries(r io.Reader) ([]LogEntry, error) {
+	var entries []LogEntry
+	parseErrs, err := scanEntries(r, func(entry LogEntry) error {
+		entries = append(entries, entry)
+		return nil
+	})
+	if err != nil {
+		return entries, err
+	}
+	return entries, errors.Join(parseErrs...)
+}
+
+// scanEntries 逐行解析日志并交给fn处理，返回跳过的行的解析错误
+// 读取失败或fn返回错误时立即停止
+func scanEntries(r io.Reader, fn func(LogEntry) error) ([]error, error) {
+	var parseErrs []error
+
+	reader := bufio.NewReader(r)
+	for lineNo := 1; ; lineNo++ {
+		line, err := reader.ReadBytes('\n')
+		if len(bytes.TrimSpace(line)) > 0 {
+			entry, perr := ParseEntry(line)
+			if perr != nil {
+				parseErrs = append(parseErrs, fmt.Errorf("line %d: %v", lineNo, perr))
+			} else if ferr := fn(entry); ferr != nil {
+				return parseErrs, ferr
+			}
+		}
+
+		if err == io.EOF {
+			return parseErrs, nil
+		}
+		if err != nil {
+			return parseErrs, fmt.Errorf("read log entries failed: %v", err)
+		}
+	}
+}
+
+// ParseEntry 解析一行JSON日志，识别level、time、msg、caller、nodeId、module、ip，其余字段放入Properties
+// 级别统一转换为小写，与适配器收到的级别一致
+func ParseEntry(line []byte) (LogEntry, error) {
+	var record map[string]interface{}
+	if err := json.Unmarshal(bytes.TrimSpace(line), &record); err != nil {
+		return LogEntry{}, fmt.Errorf("parse log entry failed: %v", err)
+	}
+
+	var entry LogEntry
+	if level, ok := record["level"].(string); ok {
+		entry.Level = strings.ToLower(level)
+		delete(record, "level")
+	}
+	if ts, ok := record["time"].(string); ok {
+		t, err := time.Parse(iso8601Layout, ts)
+		if err != nil {
+			return LogEntry{}, fmt.Errorf("parse log time failed: %v", err)
+		}
+		entry.Time = t
+		delete(record, "time")
+	}
+	if msg, ok := record["msg"].(string); ok {
+		entry.Message = msg
+		delete(record, "msg")
+	}
+	if caller, ok := record["caller"].(string); ok {
+		entry.Caller = caller
+		delete(record, "caller")
+	}
+	if nodeID, ok := record["nodeId"].(string); ok {
+		entry.NodeID = nodeID
+		delete(record, "nodeId")
+	}
+	if module, ok := record["module"].(string); ok {
+		entry.Module = module
+		delete(record, "module")
+	}
+	if ip, ok := record["ip"].(string); ok {
+		entry.IP = ip
+		delete(record, "ip")
+	}
+
+	if len(record) > 0 {
+		entry.Properties = record
+	}
+	return entry, nil
+}
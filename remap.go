@@ -0,0 +1,161 @@
+package logger
+
+import (
+	"fmt"
+	"regexp"
+	"sort"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// levelRemapRule 一条级别重映射规则，条件为空表示不限制
+type levelRemapRule struct {
+	key   string
+	from  *zapcore.Level
+	msg   *regexp.Regexp
+	to    zapcore.Level
+	conds int // 条件数，条件多的规则优先
+}
+
+// levelRemap 按模块、原始级别和消息正则改写日志级别，为空表示不改写
+type levelRemap struct {
+	rules []levelRemapRule
+}
+
+// newLevelRemap 解析WithLevelRemap的规则，只保留适用于module的规则，没有适用的规则时返回nil
+// key由逗号分隔的条件组成：module=<模块>、level=<原始级别>、msg=<消息正则>，msg必须放在最后，其后的内容都属于正则；
+// value为改写后的级别；panic及以上级别影响进程的控制流程，不允许改写
+func newLevelRemap(rules map[string]string, module string) (*levelRemap, error) {
+	r := &levelRemap{}
+	for key, target := range rules {
+		rule := levelRemapRule{key: key}
+		to, err := ParseLevel(target)
+		if err != nil {
+			return nil, fmt.Errorf("invalid level remap %s: %v", key, err)
+		}
+		if to > zapcore.ErrorLevel {
+			return nil, fmt.Errorf("invalid level remap %s: cannot remap to %s", key, to)
+		}
+		rule.to = to
+
+		applies := true
+		rest := strings.TrimSpace(key)
+		for rest != "" {
+			if pattern, ok := strings.CutPrefix(rest, "msg="); ok {
+				if rule.msg, err = regexp.Compile(pattern); err != nil {
+					return nil, fmt.Errorf("invalid level remap %s: %v", key, err)
+				}
+				rule.conds++
+				break
+			}
+			cond, next, _ := strings.Cut(rest, ",")
+			rest = strings.TrimSpace(next)
+			name, value, ok := strings.Cut(strings.TrimSpace(cond), "=")
+			if !ok {
+				return nil, fmt.Errorf("invalid level remap %s: bad condition %q", key, cond)
+			}
+			switch name {
+			case "module":
+				applies = applies && value == module
+			case "level":
+				from, err := ParseLevel(value)
+				if err != nil {
+					return nil, fmt.Errorf("invalid level remap %s: %v", key, err)
+				}
+				if from > zapcore.ErrorLevel {
+					return nil, fmt.Errorf("invalid level remap %s: cannot remap from %s", key, from)
+				}
+				rule.from = &from
+			default:
+				return nil, fmt.Errorf("invalid level remap %s: unknown condition %s", key, name)
+			}
+			rule.conds++
+		}
+		if rule.conds == 0 {
+			return nil, fmt.Errorf("invalid level remap %s: no conditions", key)
+		}
+		if applies {
+			r.rules = append(r.rules, rule)
+		}
+	}
+	if len(r.rules) == 0 {
+		return nil, nil
+	}
+
+	// 条件多的规则优先，条件数相同时按key排序，保证多条规则匹配时结果确定
+	sort.Slice(r.rules, func(i, j int) bool {
+		if r.rules[i].conds != r.rules[j].conds {
+			return r.rules[i].conds > r.rules[j].conds
+		}
+		return r.rules[i].key < r.rules[j].key
+	})
+	return r, nil
+}
+
+// apply 返回改写后的级别，没有匹配的规则时返回原级别
+func (r *levelRemap) apply(level zapcore.Level, msg string) zapcore.Level {
+	if r == nil || level > zapcore.ErrorLevel {
+		return level
+	}
+	for _, rule := range r.rules {
+		if rule.from != nil && *rule.from != level {
+			continue
+		}
+		if rule.msg != nil && !rule.msg.MatchString(msg) {
+			continue
+		}
+		return rule.to
+	}
+	return level
+}
+
+// applyName 改写级别名称，供适配器日志条目使用，无法识别的级别原样返回
+func (r *levelRemap) applyName(level string, msg string) string {
+	if r == nil {
+		return level
+	}
+	lvl, err := zapcore.ParseLevel(level)
+	if err != nil {
+		return level
+	}
+	return r.apply(lvl, msg).String()
+}
+
+// enabled 判断level的日志改写后是否可能被core接受，用于在未知消息时提前判断
+func (r *levelRemap) enabled(core zapcore.LevelEnabler, level zapcore.Level) bool {
+	if core.Enabled(level) {
+		return true
+	}
+	if level > zapcore.ErrorLevel {
+		return false
+	}
+	for _, rule := range r.rules {
+		if (rule.from == nil || *rule.from == level) && core.Enabled(rule.to) {
+			return true
+		}
+	}
+	return false
+}
+
+// levelRemapCore 在输出核心判断级别之前改写日志级别，级别采样和各输出的级别过滤都使用改写后的级别
+type levelRemapCore struct {
+	zapcore.Core
+	remap *levelRemap
+}
+
+// Enabled 实现zapcore.Core接口，改写后可能被接受的级别也返回true
+func (c *levelRemapCore) Enabled(level zapcore.Level) bool {
+	return c.remap.enabled(c.Core, level)
+}
+
+// With 实现zapcore.Core接口
+func (c *levelRemapCore) With(fields []zapcore.Field) zapcore.Core {
+	return &levelRemapCore{Core: c.Core.With(fields), remap: c.remap}
+}
+
+// Check 实现zapcore.Core接口
+func (c *levelRemapCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	ent.Level = c.remap.apply(ent.Level, ent.Message)
+	return c.Core.Check(ent, ce)
+}
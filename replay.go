@@ -0,0 +1,143 @@
+package logger
+
+import (
+	"compress/gzip"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+	"path/filepath"
+	"sort"
+	"strings"
+	"time"
+)
+
+// ReplayFilter 重放日志时的过滤条件，零值表示不过滤
+type ReplayFilter struct {
+	Since    time.Time // 只重放不早于该时间的日志
+	Until    time.Time // 只重放早于该时间的日志
+	MinLevel string    // 只重放不低于该级别的日志，如warn
+}
+
+// Replay 将日志目录中的所有日志重新发送到给定的适配器，如在Elasticsearch恢复后补录日志
+func Replay(ctx context.Context, dir string, adapters ...LogAdapter) error {
+	return ReplayFiltered(ctx, dir, ReplayFilter{}, adapters...)
+}
+
+// ReplayFiltered 按过滤条件将日志目录中的日志重新发送到给定的适配器
+// 按YYYY-MM/MM-DD.log的目录结构依次读取，包括按大小旋转产生的备份(支持.gz)，
+// 无法解析的行会被跳过并在最后合并返回；ctx取消或适配器处理失败时立即停止，结束时刷新所有适配器
+func ReplayFiltered(ctx context.Context, dir string, filter ReplayFilter, adapters ...LogAdapter) error {
+	var minLevel int8
+	if filter.MinLevel != "" {
+		level, err := ParseLevel(filter.MinLevel)
+		if err != nil {
+			return fmt.Errorf("invalid replay level: %s", filter.MinLevel)
+		}
+		minLevel = int8(level)
+	}
+
+	files, err := replayFiles(dir)
+	if err != nil {
+		return err
+	}
+
+	var parseErrs []error
+	process := func(entry LogEntry) error {
+		if err := ctx.Err(); err != nil {
+			return err
+		}
+		if !filter.Since.IsZero() && entry.Time.Before(filter.Since) {
+			return nil
+		}
+		if !filter.Until.IsZero() && !entry.Time.Before(filter.Until) {
+			return nil
+		}
+		if filter.MinLevel != "" {
+			if level, err := ParseLevel(entry.Level); err == nil && int8(level) < minLevel {
+				return nil
+			}
+		}
+
+		for _, adapter := range adapters {
+			if err := adapter.Process(ctx, entry); err != nil {
+				return fmt.Errorf("replay to adapter %s failed: %v", adapter.Name(), err)
+			}
+		}
+		return nil
+	}
+
+	for _, file := range files {
+		errs, err := replayFile(file, process)
+		for _, e := range errs {
+			parseErrs = append(parseErrs, fmt.Errorf("%s: %v", file, e))
+		}
+		if err != nil {
+			flushAdapters(adapters)
+			return err
+		}
+	}
+
+	if err := flushAdapters(adapters); err != nil {
+		return err
+	}
+	return errors.Join(parseErrs...)
+}
+
+// replayFiles 返回日志目录中按时间排序的日志文件
+// 同一天的备份文件名(MM-DD-时间.log)排在当前文件(MM-DD.log)之前
+func replayFiles(dir string) ([]string, error) {
+	var files []string
+	err := filepath.Walk(dir, func(path string, info os.FileInfo, err error) error {
+		if err != nil {
+			return err
+		}
+		if info.IsDir() {
+			return nil
+		}
+		name := strings.TrimSuffix(info.Name(), compressSuffix)
+		if filepath.Ext(name) == ".log" {
+			files = append(files, path)
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list log files failed: %v", err)
+	}
+
+	sort.Strings(files)
+	return files, nil
+}
+
+// replayFile 读取一个日志文件，.gz文件会先解压
+func replayFile(path string, fn func(LogEntry) error) ([]error, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, fmt.Errorf("open log file failed: %v", err)
+	}
+	defer file.Close()
+
+	var r io.Reader = file
+	if strings.HasSuffix(path, compressSuffix) {
+		gz, err := gzip.NewReader(file)
+		if err != nil {
+			return nil, fmt.Errorf("open compressed log file failed: %v", err)
+		}
+		defer gz.Close()
+		r = gz
+	}
+
+	return scanEntries(r, fn)
+}
+
+// flushAdapters 刷新所有适配器，返回第一个错误
+func flushAdapters(adapters []LogAdapter) error {
+	var firstErr error
+	for _, adapter := range adapters {
+		if err := adapter.Flush(); err != nil && firstErr == nil {
+			firstErr = fmt.Errorf("flush adapter %s failed: %v", adapter.Name(), err)
+		}
+	}
+	return firstErr
+}
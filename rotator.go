@@ -1,33 +1,122 @@
 package logger
 
 import (
+	"compress/gzip"
+	"errors"
 	"fmt"
+	"io"
 	"os"
 	"path/filepath"
+	"sort"
+	"strings"
 	"sync"
+	"syscall"
 	"time"
 
 	"go.uber.org/zap/zapcore"
 )
 
+// backupTimeFormat 备份文件名中的时间格式
+const backupTimeFormat = "2006-01-02T15-04-05.000"
+
+// compressSuffix 压缩备份的文件后缀
+const compressSuffix = ".gz"
+
+// fileCheckInterval 检查日志文件是否仍然存在的最小间隔
+var fileCheckInterval = time.Second
+
+// RotationOptions 日志文件旋转选项，在按天旋转的基础上按大小旋转并管理备份
+// 按大小旋转时当前文件被重命名为备份，例如 2006-01/01-02-2006-01-02T15-04-05.000.log，
+// 压缩后追加.gz后缀；按天切换时前一天的文件保持原样
+type RotationOptions struct {
+	// MaxSizeMB 单个日志文件的最大大小(MB)，为0时只按天旋转
+	MaxSizeMB int
+	// MaxAgeDays 备份的最长保留天数，为0时不按时间清理
+	MaxAgeDays int
+	// MaxBackups 最多保留的备份数，为0时不按数量清理
+	MaxBackups int
+	// Compress 是否使用gzip压缩备份
+	Compress bool
+	// LocalTime 备份文件名是否使用本地时间，默认使用UTC
+	LocalTime bool
+	// Clock 判断日期和生成备份文件名使用的时钟，为空时使用系统时间
+	Clock Clock
+	// ClockSkewTolerance 时钟回拨的容忍范围，为0时只向前旋转，从不重新打开更早日期的文件；
+	// 大于0时回拨超过该范围视为时钟校正，按新的时间选择日志文件
+	ClockSkewTolerance time.Duration
+	// BucketDuration 按固定时间桶切分文件，如15*time.Minute，时间向下取整到桶的起点，
+	// 文件为 <日志路径>/2006-01-02/15-04.log，桶变化时切换文件；必须是整分钟并能整除一天，为0时按天切分并按月归档
+	BucketDuration time.Duration
+}
+
+// validateBucketDuration 检查时间桶是整分钟并能整除一天，0表示不使用时间桶
+func validateBucketDuration(d time.Duration) error {
+	if d == 0 {
+		return nil
+	}
+	if d < 0 || d%time.Minute != 0 || (24*time.Hour)%d != 0 {
+		return fmt.Errorf("invalid bucket duration: %v", d)
+	}
+	return nil
+}
+
+// bucketStart 返回t所在时间桶的起点，从当天零点开始计算
+func bucketStart(t time.Time, d time.Duration) time.Time {
+	midnight := time.Date(t.Year(), t.Month(), t.Day(), 0, 0, 0, 0, t.Location())
+	offset := t.Sub(midnight)
+	return midnight.Add(offset - offset%d)
+}
+
 // DailyRotateWriter 按天旋转的日志写入器，并按月归档
 type DailyRotateWriter struct {
 	logPath     string
 	file        *os.File
-	currentDate string
+	currentDate string // 当前文件对应的日期，使用时间桶时为桶的起点
+	size        int64
+	options     RotationOptions
+	lastCheck   time.Time
+	latest      time.Time // 判断日期时已经到达的最大时间
 	mutex       sync.Mutex
+	closed      bool
+
+	errorOutput io.Writer
+
+	millCh   chan struct{}
+	millOnce sync.Once
+	millWg   sync.WaitGroup
 }
 
 // NewDailyRotateWriter 创建一个按天旋转、按月归档的日志写入器
 func NewDailyRotateWriter(logPath string) (*DailyRotateWriter, error) {
+	return NewRotateWriter(logPath, RotationOptions{})
+}
+
+// NewRotateWriter 创建一个按天和按大小旋转、按月归档的日志写入器
+// 重启后继续追加到当天已有的日志文件并沿用其大小，上次运行遗留的备份会按保留策略压缩和清理
+func NewRotateWriter(logPath string, options RotationOptions) (*DailyRotateWriter, error) {
+	return newRotateWriter(logPath, options, nil)
+}
+
+// newRotateWriter 创建日志写入器，备份压缩和清理的错误写入errorOutput，为空时使用标准错误输出
+func newRotateWriter(logPath string, options RotationOptions, errorOutput io.Writer) (*DailyRotateWriter, error) {
+	if err := validateBucketDuration(options.BucketDuration); err != nil {
+		return nil, err
+	}
 	writer := &DailyRotateWriter{
-		logPath: logPath,
+		logPath:     logPath,
+		options:     options,
+		errorOutput: errorOutput,
 	}
 
 	if err := writer.rotateFile(); err != nil {
 		return nil, err
 	}
 
+	// 处理上次运行遗留的备份（如压缩前进程退出）
+	writer.mutex.Lock()
+	writer.startMill()
+	writer.mutex.Unlock()
+
 	return writer, nil
 }
 
@@ -36,14 +125,100 @@ func (w *DailyRotateWriter) Write(p []byte) (n int, err error) {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
 
-	today := time.Now().Format("2006-01-02")
-	if today != w.currentDate {
+	// 关闭后不再切换或重新打开文件，避免打开的文件无人关闭
+	if w.closed {
+		return 0, os.ErrClosed
+	}
+
+	if w.periodKey(w.rotationNow()) != w.currentDate {
+		if err := w.rotateFile(); err != nil {
+			return 0, err
+		}
+		// 前一个时间段的文件已关闭，按保留策略压缩和清理
+		w.startMill()
+	}
+
+	// 上次打开文件失败（如磁盘已满、权限变更）时重试，恢复后继续写入文件
+	if w.file == nil {
+		if err := w.rotateFile(); err != nil {
+			return 0, err
+		}
+	}
+
+	// 日志目录或文件被外部删除时重新创建
+	if w.fileMissing() {
 		if err := w.rotateFile(); err != nil {
 			return 0, err
 		}
 	}
 
-	return w.file.Write(p)
+	// 超过大小限制时先备份当前文件
+	if max := w.maxSize(); max > 0 && w.size > 0 && w.size+int64(len(p)) > max {
+		if err := w.backupFile(); err != nil {
+			return 0, err
+		}
+	}
+
+	n, err = w.file.Write(p)
+	if err != nil && n == 0 && isMissingFileError(err) {
+		// 文件已失效（如NFS上被删除），重新打开后重试一次
+		if rerr := w.rotateFile(); rerr != nil {
+			return 0, fmt.Errorf("%v; reopen log file failed: %v", err, rerr)
+		}
+		n, err = w.file.Write(p)
+	}
+	w.size += int64(n)
+	return n, err
+}
+
+// rotationNow 返回判断日期使用的时间（调用前需要获取锁）
+// 时钟回拨（如跨零点时NTP校正）时保持已经到达的最大时间，避免在两天的文件之间来回切换
+func (w *DailyRotateWriter) rotationNow() time.Time {
+	now := clockNow(w.options.Clock)
+	if now.Before(w.latest) {
+		tolerance := w.options.ClockSkewTolerance
+		if tolerance <= 0 || w.latest.Sub(now) <= tolerance {
+			return w.latest
+		}
+	}
+	w.latest = now
+	return now
+}
+
+// periodKey 返回时间所在的日期，使用时间桶时返回桶的起点，取值变化时切换文件
+func (w *DailyRotateWriter) periodKey(now time.Time) string {
+	if w.options.BucketDuration > 0 {
+		return bucketStart(now, w.options.BucketDuration).Format("2006-01-02T15:04")
+	}
+	return now.Format("2006-01-02")
+}
+
+// fileMissing 按fileCheckInterval间隔检查当前文件是否已被删除（调用前需要获取锁）
+func (w *DailyRotateWriter) fileMissing() bool {
+	now := time.Now()
+	if now.Sub(w.lastCheck) < fileCheckInterval {
+		return false
+	}
+	w.lastCheck = now
+
+	_, err := os.Stat(w.file.Name())
+	return os.IsNotExist(err)
+}
+
+// isMissingFileError 判断写入错误是否由文件或目录不存在导致
+func isMissingFileError(err error) bool {
+	return errors.Is(err, os.ErrNotExist) || errors.Is(err, syscall.ESTALE)
+}
+
+// CurrentFile 返回当前正在写入的日志文件路径，已关闭时返回空字符串
+func (w *DailyRotateWriter) CurrentFile() string {
+	w.mutex.Lock()
+	defer w.mutex.Unlock()
+
+	if w.file == nil {
+		return ""
+	}
+	return w.file.Name()
 }
 
 // Sync 实现zapcore.WriteSyncer接口
@@ -57,10 +232,17 @@ func (w *DailyRotateWriter) Sync() error {
 	return nil
 }
 
-// Close 关闭文件
+// Close 关闭文件，并等待正在进行的备份压缩和清理完成
 func (w *DailyRotateWriter) Close() error {
 	w.mutex.Lock()
 	defer w.mutex.Unlock()
+	w.closed = true
+
+	if w.millCh != nil {
+		close(w.millCh)
+		w.millCh = nil
+		w.millWg.Wait()
+	}
 
 	if w.file != nil {
 		err := w.file.Close()
@@ -82,12 +264,17 @@ func (w *DailyRotateWriter) rotateFile() error {
 	}
 
 	// 更新当前日期
-	now := time.Now()
-	w.currentDate = now.Format("2006-01-02")
+	now := w.rotationNow()
+	w.currentDate = w.periodKey(now)
 
-	// 按月归档的目录结构: logs/2006-01/02.log
+	// 按月归档的目录结构: logs/2006-01/02.log，使用时间桶时为: logs/2006-01-02/15-04.log
 	monthDir := now.Format("2006-01")
 	dayFile := now.Format("01-02.log")
+	if w.options.BucketDuration > 0 {
+		start := bucketStart(now, w.options.BucketDuration)
+		monthDir = start.Format("2006-01-02")
+		dayFile = start.Format("15-04.log")
+	}
 
 	// 月度目录路径
 	monthDirPath := filepath.Join(w.logPath, monthDir)
@@ -104,11 +291,376 @@ func (w *DailyRotateWriter) rotateFile() error {
 		return fmt.Errorf("open log file failed: %v", err)
 	}
 
+	info, err := file.Stat()
+	if err != nil {
+		_ = file.Close()
+		return fmt.Errorf("stat log file failed: %v", err)
+	}
+
 	w.file = file
+	w.size = info.Size()
 	return nil
 }
 
+// maxSize 返回单个文件的最大字节数
+func (w *DailyRotateWriter) maxSize() int64 {
+	return int64(w.options.MaxSizeMB) * 1024 * 1024
+}
+
+// backupFile 将当前文件重命名为带时间戳的备份并重新打开（调用前需要获取锁）
+func (w *DailyRotateWriter) backupFile() error {
+	name := w.file.Name()
+	if err := w.file.Close(); err != nil {
+		return err
+	}
+	w.file = nil
+
+	now := clockNow(w.options.Clock)
+	if !w.options.LocalTime {
+		now = now.UTC()
+	}
+	backup := backupName(name, now)
+	if err := os.Rename(name, backup); err != nil {
+		return fmt.Errorf("rename log file failed: %v", err)
+	}
+
+	if err := w.rotateFile(); err != nil {
+		return err
+	}
+
+	w.startMill()
+	return nil
+}
+
+// backupName 返回name在now时刻的备份文件名
+// 同一毫秒内多次旋转时名称已被占用（包括已压缩的备份），依次推后一毫秒，避免重命名覆盖之前的备份
+func backupName(name string, now time.Time) string {
+	ext := filepath.Ext(name)
+	prefix := strings.TrimSuffix(name, ext)
+	for {
+		backup := fmt.Sprintf("%s-%s%s", prefix, now.Format(backupTimeFormat), ext)
+		if _, err := os.Lstat(backup); os.IsNotExist(err) {
+			if _, err := os.Lstat(backup + compressSuffix); os.IsNotExist(err) {
+				return backup
+			}
+		}
+		now = now.Add(time.Millisecond)
+	}
+}
+
+// startMill 通知后台协程压缩和清理备份
+func (w *DailyRotateWriter) startMill() {
+	if !w.options.Compress && w.options.MaxAgeDays == 0 && w.options.MaxBackups == 0 {
+		return
+	}
+
+	w.millOnce.Do(func() {
+		w.millCh = make(chan struct{}, 1)
+		w.millWg.Add(1)
+		go w.millRun(w.millCh)
+	})
+
+	if w.millCh == nil {
+		return
+	}
+	select {
+	case w.millCh <- struct{}{}:
+	default:
+	}
+}
+
+// millRun 后台处理备份，直到millCh关闭
+func (w *DailyRotateWriter) millRun(millCh chan struct{}) {
+	defer w.millWg.Done()
+	for range millCh {
+		if err := w.millRunOnce(); err != nil {
+			writeInternalError(w.errorOutput, "process log backups failed: %v", err)
+		}
+	}
+}
+
+// logBackup 一个备份文件
+type logBackup struct {
+	path      string
+	timestamp time.Time
+}
+
+// millRunOnce 按保留策略删除过期备份，并压缩剩余的未压缩备份
+func (w *DailyRotateWriter) millRunOnce() error {
+	backups, err := w.backups()
+	if err != nil {
+		return err
+	}
+
+	var remove []logBackup
+	if w.options.MaxBackups > 0 && len(backups) > w.options.MaxBackups {
+		remove = append(remove, backups[w.options.MaxBackups:]...)
+		backups = backups[:w.options.MaxBackups]
+	}
+	if w.options.MaxAgeDays > 0 {
+		cutoff := clockNow(w.options.Clock).Add(-time.Duration(w.options.MaxAgeDays) * 24 * time.Hour)
+		var kept []logBackup
+		for _, b := range backups {
+			if b.timestamp.Before(cutoff) {
+				remove = append(remove, b)
+			} else {
+				kept = append(kept, b)
+			}
+		}
+		backups = kept
+	}
+
+	var firstErr error
+	for _, b := range remove {
+		if err := os.Remove(b.path); err != nil && !os.IsNotExist(err) && firstErr == nil {
+			firstErr = fmt.Errorf("remove log backup failed: %v", err)
+		}
+	}
+
+	if w.options.Compress {
+		for _, b := range backups {
+			if strings.HasSuffix(b.path, compressSuffix) {
+				continue
+			}
+			if err := compressLogFile(b.path); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+
+	if err := w.millPeriodFiles(); err != nil && firstErr == nil {
+		firstErr = err
+	}
+	return firstErr
+}
+
+// periodFile 一个已经结束的时间段（某一天或某个时间桶）的日志文件
+type periodFile struct {
+	path string
+	end  time.Time // 时间段的结束时间
+}
+
+// millPeriodFiles 删除结束时间超过MaxAgeDays的时间段文件并移除因此变空的目录，开启Compress时压缩其余已结束的时间段文件
+// 当前时间段的文件正在写入，不做处理
+func (w *DailyRotateWriter) millPeriodFiles() error {
+	if !w.options.Compress && w.options.MaxAgeDays == 0 {
+		return nil
+	}
+	now := clockNow(w.options.Clock)
+	files, err := w.periodFiles(now)
+	if err != nil {
+		return err
+	}
+
+	var firstErr error
+	for _, f := range files {
+		if w.options.MaxAgeDays > 0 && f.end.Before(now.Add(-time.Duration(w.options.MaxAgeDays)*24*time.Hour)) {
+			if err := os.Remove(f.path); err != nil && !os.IsNotExist(err) && firstErr == nil {
+				firstErr = fmt.Errorf("remove log file failed: %v", err)
+			}
+			// 目录中还有其他文件时删除失败，忽略错误
+			_ = os.Remove(filepath.Dir(f.path))
+			continue
+		}
+		if w.options.Compress && !strings.HasSuffix(f.path, compressSuffix) {
+			if err := compressLogFile(f.path); err != nil && firstErr == nil {
+				firstErr = err
+			}
+		}
+	}
+	return firstErr
+}
+
+// periodFiles 返回now所在时间段之前的时间段文件，按天时为 2006-01/01-02.log[.gz]，使用时间桶时为 2006-01-02/15-04.log[.gz]
+// 按大小旋转产生的备份文件名更长，不包括在内
+func (w *DailyRotateWriter) periodFiles(now time.Time) ([]periodFile, error) {
+	current := time.Date(now.Year(), now.Month(), now.Day(), 0, 0, 0, 0, now.Location())
+	if w.options.BucketDuration > 0 {
+		current = bucketStart(now, w.options.BucketDuration)
+	}
+
+	var files []periodFile
+	err := filepath.Walk(w.logPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		start, ok := w.parsePeriodFile(filepath.Base(filepath.Dir(path)), info.Name(), now.Location())
+		if !ok || !start.Before(current) {
+			return nil
+		}
+		end := start.AddDate(0, 0, 1)
+		if w.options.BucketDuration > 0 {
+			end = start.Add(w.options.BucketDuration)
+		}
+		files = append(files, periodFile{path: path, end: end})
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list log files failed: %v", err)
+	}
+	return files, nil
+}
+
+// parsePeriodFile 从目录名和文件名中解析时间段的起点，不是时间段文件时返回false
+func (w *DailyRotateWriter) parsePeriodFile(dir, name string, loc *time.Location) (time.Time, bool) {
+	name = strings.TrimSuffix(strings.TrimSuffix(name, compressSuffix), ".log")
+	if len(name) != len("01-02") {
+		return time.Time{}, false
+	}
+	if w.options.BucketDuration > 0 {
+		start, err := time.ParseInLocation("2006-01-02 15-04", dir+" "+name, loc)
+		return start, err == nil
+	}
+	start, err := time.ParseInLocation("2006-01 01-02", dir+" "+name, loc)
+	if err != nil || start.Format("2006-01") != dir {
+		return time.Time{}, false
+	}
+	return start, true
+}
+
+// backups 返回所有按大小旋转产生的备份，按时间从新到旧排序
+func (w *DailyRotateWriter) backups() ([]logBackup, error) {
+	var backups []logBackup
+	err := filepath.Walk(w.logPath, func(path string, info os.FileInfo, err error) error {
+		if err != nil || info.IsDir() {
+			return nil
+		}
+		if ts, ok := parseBackupTime(info.Name(), w.options.LocalTime); ok {
+			backups = append(backups, logBackup{path: path, timestamp: ts})
+		}
+		return nil
+	})
+	if err != nil {
+		return nil, fmt.Errorf("list log backups failed: %v", err)
+	}
+
+	sort.Slice(backups, func(i, j int) bool {
+		return backups[i].timestamp.After(backups[j].timestamp)
+	})
+	return backups, nil
+}
+
+// parseBackupTime 从备份文件名 MM-DD-<时间>.log[.gz] 中解析时间，使用时间桶时前缀为 HH-MM-，长度相同
+func parseBackupTime(name string, localTime bool) (time.Time, bool) {
+	name = strings.TrimSuffix(name, compressSuffix)
+	ext := filepath.Ext(name)
+	name = strings.TrimSuffix(name, ext)
+
+	// 前缀为按天的文件名 MM-DD-或时间桶的文件名 HH-MM-
+	const prefixLen = len("01-02-")
+	if len(name) != prefixLen+len(backupTimeFormat) {
+		return time.Time{}, false
+	}
+
+	loc := time.UTC
+	if localTime {
+		loc = time.Local
+	}
+	ts, err := time.ParseInLocation(backupTimeFormat, name[prefixLen:], loc)
+	if err != nil {
+		return time.Time{}, false
+	}
+	return ts, true
+}
+
+// compressLogFile 将文件压缩为.gz并删除原文件
+func compressLogFile(path string) error {
+	src, err := os.Open(path)
+	if err != nil {
+		return fmt.Errorf("open log backup failed: %v", err)
+	}
+	defer src.Close()
+
+	dst, err := os.OpenFile(path+compressSuffix, os.O_CREATE|os.O_TRUNC|os.O_WRONLY, 0644)
+	if err != nil {
+		return fmt.Errorf("create compressed log backup failed: %v", err)
+	}
+
+	gz := gzip.NewWriter(dst)
+	if _, err := io.Copy(gz, src); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + compressSuffix)
+		return fmt.Errorf("compress log backup failed: %v", err)
+	}
+	if err := gz.Close(); err != nil {
+		_ = dst.Close()
+		_ = os.Remove(path + compressSuffix)
+		return fmt.Errorf("compress log backup failed: %v", err)
+	}
+	if err := dst.Close(); err != nil {
+		return fmt.Errorf("compress log backup failed: %v", err)
+	}
+
+	_ = src.Close()
+	return os.Remove(path)
+}
+
 // AsWriteSyncer 将DailyRotateWriter转换为zapcore.WriteSyncer
 func (w *DailyRotateWriter) AsWriteSyncer() zapcore.WriteSyncer {
 	return zapcore.AddSync(w)
 }
+
+// sharedRotateWriters 按日志目录共享的写入器，多个日志实例写入同一目录时共用一个文件句柄和旋转状态
+var sharedRotateWriters = struct {
+	mu      sync.Mutex
+	writers map[string]*sharedRotateWriter
+}{writers: make(map[string]*sharedRotateWriter)}
+
+// sharedRotateWriter 带引用计数的共享写入器
+type sharedRotateWriter struct {
+	writer *DailyRotateWriter
+	refs   int
+}
+
+// rotateWriterKey 返回日志目录的规范路径，相对路径和符号链接指向同一目录时得到相同的key
+func rotateWriterKey(logPath string) string {
+	path, err := filepath.Abs(logPath)
+	if err != nil {
+		return filepath.Clean(logPath)
+	}
+	if resolved, err := filepath.EvalSymlinks(path); err == nil {
+		return resolved
+	}
+	return path
+}
+
+// acquireRotateWriter 获取日志目录的共享写入器，目录尚未打开时创建，使用完后需要调用releaseRotateWriter
+// 同一目录的写入器只创建一次，旋转选项以第一个创建者为准
+func acquireRotateWriter(logPath string, options RotationOptions, errorOutput io.Writer) (*DailyRotateWriter, error) {
+	sharedRotateWriters.mu.Lock()
+	defer sharedRotateWriters.mu.Unlock()
+
+	key := rotateWriterKey(logPath)
+	if shared, ok := sharedRotateWriters.writers[key]; ok {
+		shared.refs++
+		return shared.writer, nil
+	}
+
+	writer, err := newRotateWriter(logPath, options, errorOutput)
+	if err != nil {
+		return nil, err
+	}
+	// 创建后目录已存在，按解析后的路径登记
+	key = rotateWriterKey(logPath)
+	sharedRotateWriters.writers[key] = &sharedRotateWriter{writer: writer, refs: 1}
+	return writer, nil
+}
+
+// releaseRotateWriter 释放共享写入器，最后一个使用者释放时关闭文件
+func releaseRotateWriter(writer *DailyRotateWriter) error {
+	sharedRotateWriters.mu.Lock()
+	defer sharedRotateWriters.mu.Unlock()
+
+	for key, shared := range sharedRotateWriters.writers {
+		if shared.writer != writer {
+			continue
+		}
+		shared.refs--
+		if shared.refs > 0 {
+			return nil
+		}
+		delete(sharedRotateWriters.writers, key)
+		break
+	}
+	return writer.Close()
+}
@@ -0,0 +1,122 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+)
+
+// adapterPanicError 适配器方法panic时返回的错误
+type adapterPanicError struct {
+	adapter string
+	op      string
+	value   interface{}
+}
+
+func (e *adapterPanicError) Error() string {
+	return fmt.Sprintf("adapter %s %s panicked: %v", e.adapter, e.op, e.value)
+}
+
+// safeCall 调用适配器方法并捕获其中的panic，避免单个适配器的错误导致应用崩溃
+// panic被转换为adapterPanicError返回，并计入该适配器的panic次数
+func (g *adapterGroup) safeCall(adapter LogAdapter, op string, fn func() error) (err error) {
+	defer func() {
+		if r := recover(); r != nil {
+			err = &adapterPanicError{adapter: adapter.Name(), op: op, value: r}
+			g.recordPanic(adapter)
+		}
+	}()
+	return fn()
+}
+
+// recordPanic 记录适配器的panic次数，达到上限时输出停用提示
+func (g *adapterGroup) recordPanic(adapter LogAdapter) {
+	g.panicMu.Lock()
+	defer g.panicMu.Unlock()
+
+	if g.panicCounts == nil {
+		g.panicCounts = make(map[string]int)
+	}
+	g.panicCounts[adapter.Name()]++
+	if g.panicLimit > 0 && g.panicCounts[adapter.Name()] == g.panicLimit {
+		g.internalError("adapter %s disabled after %d panics", adapter.Name(), g.panicLimit)
+	}
+}
+
+// adapterDisabled 判断适配器是否因panic次数达到上限而被停用，未设置上限时总是返回false
+func (g *adapterGroup) adapterDisabled(adapter LogAdapter) bool {
+	if g.panicLimit <= 0 {
+		return false
+	}
+	g.panicMu.Lock()
+	defer g.panicMu.Unlock()
+	return g.panicCounts[adapter.Name()] >= g.panicLimit
+}
+
+// processAdapter 在超时上下文中将日志交给适配器处理，上下文派生自WithBaseContext设置的父上下文，
+// 已停用的适配器和低于适配器最低级别的日志直接跳过；同时处理的协程数受WithMaxAdapterGoroutines限制
+// 返回适配器处理失败的错误，供分发重试使用；panic不是暂时性的失败，写入内部错误输出后返回nil
+func (g *adapterGroup) processAdapter(adapter LogAdapter, entry LogEntry) error {
+	if !adapterAccepts(adapter, entry.Level) || g.adapterDisabled(adapter) {
+		return nil
+	}
+	if !g.acquireAdapterSlot() {
+		return nil
+	}
+	defer g.releaseAdapterSlot()
+
+	parent := g.baseCtx
+	if parent == nil {
+		parent = context.Background()
+	}
+	var (
+		ctx    context.Context
+		cancel context.CancelFunc
+	)
+	if entry.ctx != nil {
+		// 保留记录日志时context中的值，但不随调用方取消：请求结束后异步分发的日志仍需送达，
+		// 只受基础上下文和处理超时限制
+		ctx, cancel = context.WithTimeout(context.WithoutCancel(entry.ctx), adapterProcessTimeout)
+		stop := context.AfterFunc(parent, cancel)
+		defer stop()
+	} else {
+		ctx, cancel = context.WithTimeout(parent, adapterProcessTimeout)
+	}
+	defer cancel()
+	if err := g.safeCall(adapter, "process", func() error { return adapter.Process(ctx, entry) }); err != nil {
+		g.stats.adapterErrors.Add(1)
+		// 普通的处理错误由适配器自行上报，panic则需要写入内部错误输出
+		if _, ok := err.(*adapterPanicError); ok {
+			g.internalError("%v", err)
+			return nil
+		}
+		return err
+	}
+	return nil
+}
+
+// flushAdapter 刷新单个适配器并记录失败，返回标明适配器名称的错误，已停用的适配器直接跳过
+func (g *adapterGroup) flushAdapter(adapter LogAdapter) error {
+	if g.adapterDisabled(adapter) {
+		return nil
+	}
+	g.stats.flushes.Add(1)
+	if err := g.safeCall(adapter, "flush", adapter.Flush); err != nil {
+		g.stats.flushErrors.Add(1)
+		g.stats.adapterErrors.Add(1)
+		err = fmt.Errorf("flush adapter %s failed: %w", adapter.Name(), err)
+		g.internalError("%v", err)
+		return err
+	}
+	return nil
+}
+
+// closeAdapter 关闭单个适配器并记录失败，返回标明适配器名称的错误，停用的适配器也会被关闭以释放资源
+func (g *adapterGroup) closeAdapter(adapter LogAdapter) error {
+	if err := g.safeCall(adapter, "close", adapter.Close); err != nil {
+		g.stats.adapterErrors.Add(1)
+		err = fmt.Errorf("close adapter %s failed: %w", adapter.Name(), err)
+		g.internalError("%v", err)
+		return err
+	}
+	return nil
+}
@@ -0,0 +1,215 @@
+package logger
+
+import (
+	"fmt"
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// maxSampledKeys 按键采样时最多跟踪的不同取值数，超过后清空计数重新开始
+const maxSampledKeys = 10000
+
+// keySampler 按属性值分组采样，每个取值各自计数，每rate条保留第一条
+// 不包含该属性的日志以及error及以上级别的日志不参与采样
+type keySampler struct {
+	key      string
+	rate     uint64
+	mu       sync.Mutex
+	counters map[string]uint64
+}
+
+// newKeySampler 创建按键采样器，rate小于等于1时不采样
+func newKeySampler(key string, rate int) *keySampler {
+	if key == "" || rate <= 1 {
+		return nil
+	}
+	return &keySampler{
+		key:      key,
+		rate:     uint64(rate),
+		counters: make(map[string]uint64),
+	}
+}
+
+// allow 判断日志是否保留
+func (s *keySampler) allow(properties map[string]interface{}) bool {
+	value, ok := properties[s.key]
+	if !ok {
+		return true
+	}
+	group := fmt.Sprint(value)
+
+	s.mu.Lock()
+	defer s.mu.Unlock()
+
+	n, seen := s.counters[group]
+	if !seen && len(s.counters) >= maxSampledKeys {
+		// 计数表有界，取值过多时整体重置
+		s.counters = make(map[string]uint64)
+	}
+	s.counters[group] = n + 1
+	return n%s.rate == 0
+}
+
+// levelSamplingTick 按级别采样的计数周期
+const levelSamplingTick = time.Second
+
+// LevelSampling 某个级别的采样设置，含义与zap的采样器一致：
+// 每秒内相同级别和消息的日志先输出前Initial条，之后每Thereafter条输出一条，Thereafter为0时丢弃其余日志
+type LevelSampling struct {
+	Initial    int
+	Thereafter int
+}
+
+// levelSampledCore 按级别选择采样核心，未配置的级别直接交给原核心，不参与采样
+type levelSampledCore struct {
+	zapcore.Core
+	sampled map[zapcore.Level]zapcore.Core
+}
+
+// newLevelSampledCore 为配置了采样的级别创建采样核心，未配置任何级别时返回原核心
+// error及以上级别不允许采样，保证每条错误都会输出，被丢弃的日志计入counter
+func newLevelSampledCore(core zapcore.Core, config map[string]LevelSampling, counter *samplingCounter) (zapcore.Core, error) {
+	if len(config) == 0 {
+		return core, nil
+	}
+
+	hook := zapcore.SamplerHook(func(ent zapcore.Entry, dec zapcore.SamplingDecision) {
+		if dec&zapcore.LogDropped != 0 {
+			counter.record(ent.Level, true)
+		}
+	})
+	sampled := make(map[zapcore.Level]zapcore.Core, len(config))
+	for name, sampling := range config {
+		level, err := ParseLevel(name)
+		if err != nil {
+			return nil, fmt.Errorf("invalid sampling level: %v", err)
+		}
+		if level >= zapcore.ErrorLevel {
+			return nil, fmt.Errorf("sampling is not allowed for level %s", level)
+		}
+		if sampling.Initial < 0 || sampling.Thereafter < 0 {
+			return nil, fmt.Errorf("invalid sampling for level %s: initial and thereafter must not be negative", level)
+		}
+		sampled[level] = zapcore.NewSamplerWithOptions(core, levelSamplingTick, sampling.Initial, sampling.Thereafter, hook)
+	}
+	return &levelSampledCore{Core: core, sampled: sampled}, nil
+}
+
+// With 实现zapcore.Core接口，各级别的采样核心共享计数
+func (c *levelSampledCore) With(fields []zapcore.Field) zapcore.Core {
+	sampled := make(map[zapcore.Level]zapcore.Core, len(c.sampled))
+	for level, core := range c.sampled {
+		sampled[level] = core.With(fields)
+	}
+	return &levelSampledCore{Core: c.Core.With(fields), sampled: sampled}
+}
+
+// Check 实现zapcore.Core接口，配置了采样的级别由采样核心决定是否输出
+func (c *levelSampledCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if core, ok := c.sampled[ent.Level]; ok {
+		return core.Check(ent, ce)
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// samplingReportInterval WithSamplingHook汇总丢弃数的周期
+const samplingReportInterval = time.Second
+
+// samplingCounter 按级别统计被采样丢弃的日志，包括控制台和文件输出的级别采样和适配器的按键采样
+// 设置了回调时按周期汇总各级别的丢弃数交给回调，周期在丢弃时检查，Close时报告剩余的计数；为空时不统计
+type samplingCounter struct {
+	byLevel [zapcore.FatalLevel - zapcore.DebugLevel + 1]atomic.Uint64
+	output  atomic.Uint64 // 控制台和文件输出按级别采样丢弃的日志数
+
+	hook    func(level string, dropped int)
+	clock   Clock
+	mu      sync.Mutex
+	start   time.Time                                        // 当前汇总周期的开始时间
+	pending [zapcore.FatalLevel - zapcore.DebugLevel + 1]int // 当前周期尚未报告的丢弃数
+}
+
+// newSamplingCounter 创建采样丢弃统计，hook为空时只计数
+func newSamplingCounter(hook func(level string, dropped int), clock Clock) *samplingCounter {
+	return &samplingCounter{hook: hook, clock: clock}
+}
+
+// record 记录一条被采样丢弃的日志，output表示由控制台和文件输出的级别采样丢弃
+func (c *samplingCounter) record(level zapcore.Level, output bool) {
+	if c == nil || level < zapcore.DebugLevel || level > zapcore.FatalLevel {
+		return
+	}
+	c.byLevel[level-zapcore.DebugLevel].Add(1)
+	if output {
+		c.output.Add(1)
+	}
+	if c.hook == nil {
+		return
+	}
+
+	now := clockNow(c.clock)
+	c.mu.Lock()
+	if c.start.IsZero() {
+		c.start = now
+	}
+	c.pending[level-zapcore.DebugLevel]++
+	if now.Sub(c.start) < samplingReportInterval {
+		c.mu.Unlock()
+		return
+	}
+	pending := c.take(now)
+	c.mu.Unlock()
+	c.report(pending)
+}
+
+// take 取出并清空当前周期的计数，开始新的周期，调用前需要获取锁
+func (c *samplingCounter) take(now time.Time) [zapcore.FatalLevel - zapcore.DebugLevel + 1]int {
+	pending := c.pending
+	c.pending = [zapcore.FatalLevel - zapcore.DebugLevel + 1]int{}
+	c.start = now
+	return pending
+}
+
+// report 按级别调用回调，在锁外调用，回调中输出的日志再被采样时不会死锁
+func (c *samplingCounter) report(pending [zapcore.FatalLevel - zapcore.DebugLevel + 1]int) {
+	for i, dropped := range pending {
+		if dropped > 0 {
+			c.hook((zapcore.DebugLevel + zapcore.Level(i)).String(), dropped)
+		}
+	}
+}
+
+// flush 立即报告当前周期尚未报告的计数
+func (c *samplingCounter) flush() {
+	if c == nil || c.hook == nil {
+		return
+	}
+	c.mu.Lock()
+	pending := c.take(time.Time{})
+	c.mu.Unlock()
+	c.report(pending)
+}
+
+// outputSampled 返回控制台和文件输出按级别采样丢弃的日志数
+func (c *samplingCounter) outputSampled() uint64 {
+	if c == nil {
+		return 0
+	}
+	return c.output.Load()
+}
+
+// levels 返回各级别累计被采样丢弃的日志数，只包含有丢弃的级别
+func (c *samplingCounter) levels() map[string]uint64 {
+	levels := make(map[string]uint64)
+	if c == nil {
+		return levels
+	}
+	for i := range c.byLevel {
+		if n := c.byLevel[i].Load(); n > 0 {
+			levels[(zapcore.DebugLevel + zapcore.Level(i)).String()] = n
+		}
+	}
+	return levels
+}
@@ -0,0 +1,139 @@
+package logger
+
+import (
+	"fmt"
+	"sort"
+	"strings"
+)
+
+// ConfigType 适配器配置项的类型
+type ConfigType string
+
+const (
+	// ConfigString 字符串
+	ConfigString ConfigType = "string"
+	// ConfigNumber 数值，接受任意整数和浮点数类型
+	ConfigNumber ConfigType = "number"
+	// ConfigBool 布尔值
+	ConfigBool ConfigType = "bool"
+	// ConfigStringList 字符串列表，接受[]string或元素均为字符串的[]interface{}
+	ConfigStringList ConfigType = "[]string"
+	// ConfigMap 键为字符串的map
+	ConfigMap ConfigType = "map"
+	// ConfigAny 任意类型
+	ConfigAny ConfigType = "any"
+)
+
+// ConfigSchema 适配器声明的配置项及其类型
+type ConfigSchema map[string]ConfigType
+
+// ConfigValidator 能够校验自身配置的适配器
+type ConfigValidator interface {
+	// ValidateConfig 校验配置，报告未知或类型错误的配置项
+	ValidateConfig(config map[string]interface{}) error
+}
+
+// Merge 合并多个配置声明，返回新的ConfigSchema
+func (s ConfigSchema) Merge(others ...ConfigSchema) ConfigSchema {
+	merged := make(ConfigSchema, len(s))
+	for k, v := range s {
+		merged[k] = v
+	}
+	for _, other := range others {
+		for k, v := range other {
+			merged[k] = v
+		}
+	}
+	return merged
+}
+
+// Validate 校验配置，所有未知和类型错误的配置项会在一个错误中报告
+func (s ConfigSchema) Validate(config map[string]interface{}) error {
+	keys := make([]string, 0, len(config))
+	for k := range config {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+
+	var problems []string
+	for _, key := range keys {
+		expected, ok := s[key]
+		if !ok {
+			problems = append(problems, fmt.Sprintf("unknown key %q", key))
+			continue
+		}
+		if !matchConfigType(expected, config[key]) {
+			problems = append(problems, fmt.Sprintf("key %q expects %s, got %T", key, expected, config[key]))
+		}
+	}
+
+	if len(problems) > 0 {
+		return fmt.Errorf("invalid adapter config: %s", strings.Join(problems, "; "))
+	}
+	return nil
+}
+
+// matchConfigType 判断值是否符合配置项类型
+func matchConfigType(expected ConfigType, value interface{}) bool {
+	switch expected {
+	case ConfigString:
+		_, ok := value.(string)
+		return ok
+	case ConfigNumber:
+		_, ok := ToFloat64(value)
+		return ok
+	case ConfigBool:
+		_, ok := value.(bool)
+		return ok
+	case ConfigStringList:
+		switch list := value.(type) {
+		case []string:
+			return true
+		case []interface{}:
+			for _, item := range list {
+				if _, ok := item.(string); !ok {
+					return false
+				}
+			}
+			return true
+		}
+		return false
+	case ConfigMap:
+		_, ok := value.(map[string]interface{})
+		return ok
+	default:
+		return true
+	}
+}
+
+// ToFloat64 将任意整数或浮点数转换为float64
+func ToFloat64(value interface{}) (float64, bool) {
+	switch v := value.(type) {
+	case float64:
+		return v, true
+	case float32:
+		return float64(v), true
+	case int:
+		return float64(v), true
+	case int8:
+		return float64(v), true
+	case int16:
+		return float64(v), true
+	case int32:
+		return float64(v), true
+	case int64:
+		return float64(v), true
+	case uint:
+		return float64(v), true
+	case uint8:
+		return float64(v), true
+	case uint16:
+		return float64(v), true
+	case uint32:
+		return float64(v), true
+	case uint64:
+		return float64(v), true
+	default:
+		return 0, false
+	}
+}
@@ -0,0 +1,119 @@
+package logger
+
+import (
+	"bytes"
+	"context"
+	"errors"
+	"fmt"
+	"io"
+	"os"
+)
+
+// SelfTestField 自检日志携带的字段名，值为本次自检的ID，下游可以据此过滤自检日志
+const SelfTestField = "self_test"
+
+// selfTestTail 自检时从日志文件末尾读取的最大字节数
+const selfTestTail = 64 << 10
+
+// SelfTestAdapter 能够确认自检日志已被接收的适配器
+type SelfTestAdapter interface {
+	// VerifySelfTest 确认携带SelfTestField=id属性的日志已被接收，SelfTest在刷新适配器后调用
+	VerifySelfTest(ctx context.Context, id string) error
+}
+
+// SelfTest 端到端检查日志管道，用于部署后的就绪检查
+// 以debug、info、warn、error级别各输出一条携带self_test字段的日志（不会触发panic和退出），然后：
+// 确认文件输出的当前日志文件中已写入error级别的自检日志，刷新所有适配器，
+// 并由实现了SelfTestAdapter的适配器确认已接收，所有失败通过errors.Join合并返回
+func (l *ZapLogger) SelfTest(ctx context.Context) error {
+	if err := ctx.Err(); err != nil {
+		return err
+	}
+
+	id := NewRequestID()
+	tagged := l.WithField(SelfTestField, id)
+	tagged.Debug("logger self test")
+	tagged.Info("logger self test")
+	tagged.Warn("logger self test")
+	tagged.Error("logger self test")
+
+	var errs []error
+	if err := l.verifySelfTestFile(id); err != nil {
+		errs = append(errs, err)
+	}
+	if err := l.Flush(); err != nil {
+		errs = append(errs, err)
+	}
+	if err := ctx.Err(); err != nil {
+		return errors.Join(append(errs, err)...)
+	}
+
+	l.adapterMu.RLock()
+	adapters := append([]LogAdapter(nil), l.adapters...)
+	l.adapterMu.RUnlock()
+	for _, adapter := range adapters {
+		verifier, ok := unwrapAdapter(adapter).(SelfTestAdapter)
+		if !ok {
+			continue
+		}
+		if err := verifier.VerifySelfTest(ctx, id); err != nil {
+			errs = append(errs, fmt.Errorf("verify adapter %s failed: %w", adapter.Name(), err))
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// verifySelfTestFile 确认自检日志已写入当前日志文件，没有按天旋转的文件输出时跳过
+func (l *ZapLogger) verifySelfTestFile(id string) error {
+	if l.rotator == nil {
+		return nil
+	}
+
+	// 异步输出只需等待队列写出，写入是否成功由读取文件确认
+	for _, w := range l.asyncWriters {
+		_ = w.Sync()
+	}
+	if err := l.rotator.Sync(); err != nil {
+		return fmt.Errorf("sync log file failed: %w", err)
+	}
+
+	path := l.rotator.CurrentFile()
+	if path == "" {
+		return fmt.Errorf("log file not open")
+	}
+	tail, err := readTail(path, selfTestTail)
+	if err != nil {
+		return fmt.Errorf("read log file failed: %w", err)
+	}
+	if !bytes.Contains(tail, []byte(id)) {
+		return fmt.Errorf("self test line not found in log file %s", path)
+	}
+	return nil
+}
+
+// readTail 读取文件末尾最多n个字节
+func readTail(path string, n int64) ([]byte, error) {
+	file, err := os.Open(path)
+	if err != nil {
+		return nil, err
+	}
+	defer file.Close()
+
+	info, err := file.Stat()
+	if err != nil {
+		return nil, err
+	}
+	offset := info.Size() - n
+	if offset < 0 {
+		offset = 0
+	}
+	return io.ReadAll(io.NewSectionReader(file, offset, info.Size()-offset))
+}
+
+// SelfTest 检查默认日志实例的日志管道，默认日志不是ZapLogger时返回错误
+func SelfTest(ctx context.Context) error {
+	if l, ok := Default().(*ZapLogger); ok {
+		return l.SelfTest(ctx)
+	}
+	return fmt.Errorf("default logger is not initialized")
+}
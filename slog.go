@@ -0,0 +1,296 @@
+package logger
+
+import (
+	"context"
+	"fmt"
+	"log/slog"
+	"os"
+	"runtime"
+	"sort"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// slog没有的级别映射到比LevelError更高的级别，与zap级别的间隔保持一致
+const (
+	SlogLevelDPanic = slog.LevelError + 2
+	SlogLevelPanic  = slog.LevelError + 4
+	SlogLevelFatal  = slog.LevelError + 8
+)
+
+// SlogLevel 将日志级别映射为slog级别，debug、info、warn、error对应slog的同名级别，
+// dpanic、panic、fatal映射为SlogLevelDPanic、SlogLevelPanic、SlogLevelFatal
+func SlogLevel(level zapcore.Level) slog.Level {
+	switch level {
+	case zapcore.DebugLevel:
+		return slog.LevelDebug
+	case zapcore.InfoLevel:
+		return slog.LevelInfo
+	case zapcore.WarnLevel:
+		return slog.LevelWarn
+	case zapcore.ErrorLevel:
+		return slog.LevelError
+	case zapcore.DPanicLevel:
+		return SlogLevelDPanic
+	case zapcore.PanicLevel:
+		return SlogLevelPanic
+	default:
+		return SlogLevelFatal
+	}
+}
+
+// slogLogger 由slog.Logger支撑的Logger实现，日志交给slog的Handler处理
+type slogLogger struct {
+	logger   *slog.Logger
+	throttle *logThrottle    // 节流设置，为空表示不节流
+	ctx      context.Context // WithContext设置的context，传给slog.Handler，为空时使用context.Background()
+}
+
+// context 返回传给slog.Handler的context
+func (l *slogLogger) context() context.Context {
+	if l.ctx != nil {
+		return l.ctx
+	}
+	return context.Background()
+}
+
+// FromSlog 将slog.Logger包装为Logger，便于迁移期间让使用本包API的代码输出到slog
+// 级别按SlogLevel映射，Infow等方法的键值对和WithField的字段作为slog属性转发，调用位置指向实际调用方；
+// 适配器、文件旋转、审计、BoostLevel由本包的输出管道提供，slog支撑时不可用：
+// AddAdapter和RemoveAdapter不生效，Audit返回错误，BoostLevel不改变级别，Flush和Close不做任何操作
+func FromSlog(l *slog.Logger) Logger {
+	if l == nil {
+		l = slog.Default()
+	}
+	return &slogLogger{logger: l}
+}
+
+// log 按级别写入一条日志，调用位置跳过本函数和包装方法
+func (l *slogLogger) log(level zapcore.Level, msg string, args ...any) {
+	ctx := l.context()
+	if !l.logger.Enabled(ctx, SlogLevel(level)) {
+		return
+	}
+	if l.throttle != nil && !l.throttle.allow() {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(3, pcs[:])
+	record := slog.NewRecord(time.Now(), SlogLevel(level), msg, pcs[0])
+	record.Add(args...)
+	_ = l.logger.Handler().Handle(ctx, record)
+}
+
+func (l *slogLogger) Fatal(args ...any) {
+	l.log(zapcore.FatalLevel, fmt.Sprint(args...))
+	os.Exit(1)
+}
+
+func (l *slogLogger) Fatalf(format string, args ...any) {
+	l.log(zapcore.FatalLevel, fmt.Sprintf(format, args...))
+	os.Exit(1)
+}
+
+func (l *slogLogger) Fatalw(msg string, keysAndValues ...any) {
+	l.log(zapcore.FatalLevel, msg, keysAndValues...)
+	os.Exit(1)
+}
+
+func (l *slogLogger) Panic(args ...any) {
+	msg := fmt.Sprint(args...)
+	l.log(zapcore.PanicLevel, msg)
+	panic(msg)
+}
+
+func (l *slogLogger) Panicf(format string, args ...any) {
+	msg := fmt.Sprintf(format, args...)
+	l.log(zapcore.PanicLevel, msg)
+	panic(msg)
+}
+
+func (l *slogLogger) Panicw(msg string, keysAndValues ...any) {
+	l.log(zapcore.PanicLevel, msg, keysAndValues...)
+	panic(msg)
+}
+
+func (l *slogLogger) Error(args ...any) { l.log(zapcore.ErrorLevel, fmt.Sprint(args...)) }
+
+func (l *slogLogger) Errorf(format string, args ...any) {
+	l.log(zapcore.ErrorLevel, fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Errorw(msg string, keysAndValues ...any) {
+	l.log(zapcore.ErrorLevel, msg, keysAndValues...)
+}
+
+func (l *slogLogger) Warn(args ...any) { l.log(zapcore.WarnLevel, fmt.Sprint(args...)) }
+
+func (l *slogLogger) Warnf(format string, args ...any) {
+	l.log(zapcore.WarnLevel, fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Warnw(msg string, keysAndValues ...any) {
+	l.log(zapcore.WarnLevel, msg, keysAndValues...)
+}
+
+func (l *slogLogger) Info(args ...any) { l.log(zapcore.InfoLevel, fmt.Sprint(args...)) }
+
+func (l *slogLogger) Infof(format string, args ...any) {
+	l.log(zapcore.InfoLevel, fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Infow(msg string, keysAndValues ...any) {
+	l.log(zapcore.InfoLevel, msg, keysAndValues...)
+}
+
+func (l *slogLogger) Debug(args ...any) { l.log(zapcore.DebugLevel, fmt.Sprint(args...)) }
+
+func (l *slogLogger) Debugf(format string, args ...any) {
+	l.log(zapcore.DebugLevel, fmt.Sprintf(format, args...))
+}
+
+func (l *slogLogger) Debugw(msg string, keysAndValues ...any) {
+	l.log(zapcore.DebugLevel, msg, keysAndValues...)
+}
+
+// WithField 返回携带一个slog属性的子日志
+func (l *slogLogger) WithField(key string, value interface{}) Logger {
+	child := *l
+	child.logger = l.logger.With(key, value)
+	return &child
+}
+
+// WithFields 返回携带多个属性的子日志，属性按key排序添加
+func (l *slogLogger) WithFields(fields map[string]interface{}) Logger {
+	if len(fields) == 0 {
+		return l
+	}
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	args := make([]any, 0, len(fields)*2)
+	for _, k := range keys {
+		args = append(args, k, fields[k])
+	}
+	return l.With(args...)
+}
+
+// With 返回以键值对携带属性的子日志，键值对的处理与slog.Logger.With一致
+func (l *slogLogger) With(keysAndValues ...any) Logger {
+	if len(keysAndValues) == 0 {
+		return l
+	}
+	child := *l
+	child.logger = l.logger.With(keysAndValues...)
+	return &child
+}
+
+// WithContext 返回携带ctx的子日志，ctx传给slog.Handler（如从中提取trace ID的Handler），ctx为nil时返回自身
+func (l *slogLogger) WithContext(ctx context.Context) Logger {
+	if ctx == nil {
+		return l
+	}
+	child := *l
+	child.ctx = ctx
+	return &child
+}
+
+// WithError 返回在error属性中携带错误信息的子日志，与ZapLogger一样附带error_chain
+func (l *slogLogger) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+	args := []any{"error", err.Error()}
+	if chain := errorChain(err); len(chain) > 1 {
+		args = append(args, "error_chain", chain)
+	}
+	child := *l
+	child.logger = l.logger.With(args...)
+	return &child
+}
+
+// NoAdapters slog支撑时没有适配器，返回自身
+func (l *slogLogger) NoAdapters() Logger { return l }
+
+// InfoOnce 同一个key在进程生命周期内只输出第一次，与ZapLogger共享已输出的键
+func (l *slogLogger) InfoOnce(key string, msg string) {
+	if !l.logger.Enabled(l.context(), slog.LevelInfo) || !firstOccurrence(key) {
+		return
+	}
+	l.log(zapcore.InfoLevel, msg)
+}
+
+// WarnOnce 同一个key在进程生命周期内只输出第一次，与ZapLogger共享已输出的键
+func (l *slogLogger) WarnOnce(key string, msg string) {
+	if !l.logger.Enabled(l.context(), slog.LevelWarn) || !firstOccurrence(key) {
+		return
+	}
+	l.log(zapcore.WarnLevel, msg)
+}
+
+// WithThrottle 返回按key节流的子日志，节流状态与ZapLogger按key共享
+func (l *slogLogger) WithThrottle(key string, interval time.Duration) Logger {
+	child := *l
+	child.throttle = &logThrottle{key: key, interval: interval}
+	return &child
+}
+
+// SetLevel slog的级别由Handler决定，无法修改，级别有效时同样返回错误
+func (l *slogLogger) SetLevel(level string) error {
+	if _, err := ParseLevel(level); err != nil {
+		return err
+	}
+	return fmt.Errorf("set level not supported by slog logger")
+}
+
+// BoostLevel slog的级别由Handler决定，无法临时调整，返回空的恢复函数
+func (l *slogLogger) BoostLevel(level string) func() { return func() {} }
+
+// LogAt 以指定的时间写入一条日志，未知级别被忽略
+func (l *slogLogger) LogAt(t time.Time, level string, msg string, fields map[string]interface{}) {
+	lvl, err := ParseLevel(level)
+	if err != nil {
+		return
+	}
+	ctx := l.context()
+	if !l.logger.Enabled(ctx, SlogLevel(lvl)) {
+		return
+	}
+
+	var pcs [1]uintptr
+	runtime.Callers(2, pcs[:])
+	record := slog.NewRecord(t, SlogLevel(lvl), msg, pcs[0])
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	for _, k := range keys {
+		record.AddAttrs(slog.Any(k, fields[k]))
+	}
+	_ = l.logger.Handler().Handle(ctx, record)
+}
+
+// Audit slog支撑时没有审计适配器，总是返回错误
+func (l *slogLogger) Audit(msg string, fields map[string]interface{}) error {
+	return fmt.Errorf("audit adapter not configured")
+}
+
+// Flush slog支撑时没有适配器需要刷新
+func (l *slogLogger) Flush() error { return nil }
+
+// Close slog.Logger没有需要释放的资源
+func (l *slogLogger) Close() error { return nil }
+
+// CloseWithContext slog.Logger没有需要释放的资源
+func (l *slogLogger) CloseWithContext(ctx context.Context) error { return nil }
+
+// AddAdapter slog支撑时不支持适配器，调用不生效
+func (l *slogLogger) AddAdapter(adapter LogAdapter) {}
+
+// RemoveAdapter slog支撑时不支持适配器，调用不生效
+func (l *slogLogger) RemoveAdapter(name string) {}
@@ -0,0 +1,123 @@
+package logger
+
+import (
+	"bytes"
+	"encoding/json"
+	"fmt"
+	"sort"
+
+	"go.uber.org/zap/buffer"
+	"go.uber.org/zap/zapcore"
+)
+
+// sortedBufferPool 排序后的JSON输出使用的缓冲区池
+var sortedBufferPool = buffer.NewPool()
+
+// sortedJSONEncoder 以固定顺序输出JSON字段的编码器：时间、级别、消息在前，其余字段按key排序
+// 由内部的JSON编码器完成编码后重新排列字段，字段值保持原样，同名字段只保留最后一个
+type sortedJSONEncoder struct {
+	zapcore.Encoder
+	leading    []string // 固定在前面的标准字段
+	lineEnding string
+}
+
+// newSortedJSONEncoder 包装JSON编码器，使输出字段顺序确定
+func newSortedJSONEncoder(enc zapcore.Encoder, config zapcore.EncoderConfig) zapcore.Encoder {
+	lineEnding := config.LineEnding
+	if lineEnding == "" {
+		lineEnding = zapcore.DefaultLineEnding
+	}
+	var leading []string
+	for _, key := range []string{config.TimeKey, config.LevelKey, config.MessageKey} {
+		if key != "" && key != zapcore.OmitKey {
+			leading = append(leading, key)
+		}
+	}
+	return &sortedJSONEncoder{Encoder: enc, leading: leading, lineEnding: lineEnding}
+}
+
+// Clone 实现zapcore.Encoder接口
+func (e *sortedJSONEncoder) Clone() zapcore.Encoder {
+	return &sortedJSONEncoder{Encoder: e.Encoder.Clone(), leading: e.leading, lineEnding: e.lineEnding}
+}
+
+// EncodeEntry 实现zapcore.Encoder接口，编码后按固定顺序重新排列字段
+func (e *sortedJSONEncoder) EncodeEntry(ent zapcore.Entry, fields []zapcore.Field) (*buffer.Buffer, error) {
+	encoded, err := e.Encoder.EncodeEntry(ent, fields)
+	if err != nil {
+		return nil, err
+	}
+	defer encoded.Free()
+
+	line := bytes.TrimSuffix(encoded.Bytes(), []byte(e.lineEnding))
+	var object map[string]json.RawMessage
+	if err := json.Unmarshal(line, &object); err != nil {
+		return nil, fmt.Errorf("sort json fields failed: %v", err)
+	}
+
+	keys := make([]string, 0, len(object))
+	for k := range object {
+		if !e.isLeading(k) {
+			keys = append(keys, k)
+		}
+	}
+	sort.Strings(keys)
+
+	buf := sortedBufferPool.Get()
+	buf.AppendByte('{')
+	first := true
+	appendField := func(key string) {
+		value, ok := object[key]
+		if !ok {
+			return
+		}
+		if !first {
+			buf.AppendByte(',')
+		}
+		first = false
+		appendJSONString(buf, key)
+		buf.AppendByte(':')
+		_, _ = buf.Write(value)
+	}
+	for _, key := range e.leading {
+		appendField(key)
+	}
+	for _, key := range keys {
+		appendField(key)
+	}
+	buf.AppendByte('}')
+	buf.AppendString(e.lineEnding)
+	return buf, nil
+}
+
+// isLeading 判断key是否为固定在前面的标准字段
+func (e *sortedJSONEncoder) isLeading(key string) bool {
+	for _, k := range e.leading {
+		if k == key {
+			return true
+		}
+	}
+	return false
+}
+
+// appendJSONString 以JSON字符串写出s，转义引号、反斜杠和控制字符
+// s来自json.Unmarshal解码后的key，已是合法的UTF-8
+func appendJSONString(buf *buffer.Buffer, s string) {
+	const hex = "0123456789abcdef"
+	buf.AppendByte('"')
+	for i := 0; i < len(s); i++ {
+		c := s[i]
+		switch {
+		case c == '"' || c == '\\':
+			buf.AppendByte('\\')
+			buf.AppendByte(c)
+		case c < 0x20:
+			buf.AppendString(`\u00`)
+			buf.AppendByte(hex[c>>4])
+			buf.AppendByte(hex[c&0xf])
+		default:
+			buf.AppendByte(c)
+		}
+	}
+	buf.AppendByte('"')
+}
@@ -0,0 +1,171 @@
+package logger
+
+import (
+	"hash/fnv"
+	"path/filepath"
+	"runtime"
+	"strconv"
+	"strings"
+	"sync"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// defaultStackTrimPrefixes 默认从堆栈顶部裁剪的函数前缀：日志包自身和zap
+var defaultStackTrimPrefixes = []string{
+	"github.com/qishenonly/logger.",
+	"go.uber.org/zap.",
+	"go.uber.org/zap/",
+}
+
+// captureStack 捕获调用方的堆栈，格式与zap的stacktrace字段一致
+// 堆栈顶部连续匹配trimPrefixes的帧会被去掉，使第一帧为用户代码；full为true时保留完整堆栈
+func captureStack(trimPrefixes []string, full bool) string {
+	pcs := make([]uintptr, 64)
+	// 跳过runtime.Callers和captureStack本身
+	n := runtime.Callers(2, pcs)
+	frames := runtime.CallersFrames(pcs[:n])
+
+	var sb strings.Builder
+	trimming := !full
+	for {
+		frame, more := frames.Next()
+		if trimming && hasAnyPrefix(frame.Function, trimPrefixes) {
+			if !more {
+				break
+			}
+			continue
+		}
+		trimming = false
+
+		if sb.Len() > 0 {
+			sb.WriteByte('\n')
+		}
+		sb.WriteString(frame.Function)
+		sb.WriteString("\n\t")
+		sb.WriteString(frame.File)
+		sb.WriteByte(':')
+		sb.WriteString(strconv.Itoa(frame.Line))
+
+		if !more {
+			break
+		}
+	}
+	return sb.String()
+}
+
+// captureCaller 返回调用方的栈帧，跳过匹配trimPrefixes的帧后再跳过skip帧，调用栈不够深时返回零值
+// 栈帧的Function为完整函数名，如github.com/org/repo/pkg.(*T).Method
+func captureCaller(trimPrefixes []string, skip int) runtime.Frame {
+	var pcs [32]uintptr
+	// 跳过runtime.Callers和captureCaller本身
+	n := runtime.Callers(2, pcs[:])
+	frames := runtime.CallersFrames(pcs[:n])
+	trimming := true
+	for {
+		frame, more := frames.Next()
+		if trimming && hasAnyPrefix(frame.Function, trimPrefixes) {
+			if !more {
+				return runtime.Frame{}
+			}
+			continue
+		}
+		trimming = false
+		if skip <= 0 {
+			return frame
+		}
+		skip--
+		if !more {
+			return runtime.Frame{}
+		}
+	}
+}
+
+// frameCaller 按调用位置格式将栈帧格式化为file:line，与控制台和文件输出的caller字段一致，格式为空时使用short
+func frameCaller(frame runtime.Frame, format string) string {
+	if frame.File == "" {
+		return ""
+	}
+	caller := zapcore.NewEntryCaller(frame.PC, frame.File, frame.Line, true)
+	switch format {
+	case CallerFull:
+		return caller.FullPath()
+	case CallerPackage:
+		if pkg := functionPackage(frame.Function); pkg != "" {
+			return pkg + "/" + filepath.Base(frame.File) + ":" + strconv.Itoa(frame.Line)
+		}
+	}
+	return caller.TrimmedPath()
+}
+
+// hasAnyPrefix 判断s是否以任一前缀开头
+func hasAnyPrefix(s string, prefixes []string) bool {
+	for _, prefix := range prefixes {
+		if strings.HasPrefix(s, prefix) {
+			return true
+		}
+	}
+	return false
+}
+
+// maxDedupStacks 堆栈去重最多跟踪的不同堆栈数，超过后清空重新开始
+const maxDedupStacks = 10000
+
+// stackDedup 在时间窗口内将重复的堆栈替换为引用，只有首次出现时输出完整堆栈
+type stackDedup struct {
+	window time.Duration
+	mu     sync.Mutex
+	seen   map[string]*dedupStack
+}
+
+// dedupStack 一个堆栈在当前窗口内的首次出现时间和次数
+type dedupStack struct {
+	first time.Time
+	count int
+}
+
+// newStackDedup 创建堆栈去重器，window小于等于0时不去重
+func newStackDedup(window time.Duration) *stackDedup {
+	if window <= 0 {
+		return nil
+	}
+	return &stackDedup{
+		window: window,
+		seen:   make(map[string]*dedupStack),
+	}
+}
+
+// apply 将堆栈写入properties：窗口内首次出现时写入stacktrace和stack_ref，
+// 重复出现时只写入stack_ref和窗口内的累计次数stack_count
+func (d *stackDedup) apply(properties map[string]interface{}, stack string, now time.Time) {
+	if d == nil {
+		properties["stacktrace"] = stack
+		return
+	}
+
+	h := fnv.New64a()
+	_, _ = h.Write([]byte(stack))
+	ref := strconv.FormatUint(h.Sum64(), 16)
+
+	d.mu.Lock()
+	s, ok := d.seen[ref]
+	if !ok || now.Sub(s.first) >= d.window {
+		if !ok && len(d.seen) >= maxDedupStacks {
+			// 跟踪表有界，不同堆栈过多时整体重置
+			d.seen = make(map[string]*dedupStack)
+		}
+		s = &dedupStack{first: now}
+		d.seen[ref] = s
+	}
+	s.count++
+	count := s.count
+	d.mu.Unlock()
+
+	properties["stack_ref"] = ref
+	if count == 1 {
+		properties["stacktrace"] = stack
+		return
+	}
+	properties["stack_count"] = count
+}
@@ -0,0 +1,159 @@
+package logger
+
+import (
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// LoggerStats 日志处理管道的运行统计
+type LoggerStats struct {
+	Logged        map[string]uint64 // 各级别已输出的日志数
+	Dropped       uint64            // 分发到适配器时被丢弃的日志数
+	Sampled       uint64            // 分发到适配器时被按键采样丢弃的日志数
+	AsyncDropped  uint64            // 异步输出队列满时被丢弃的控制台和文件日志数
+	AdapterErrors uint64            // 适配器处理、刷新和关闭失败的次数
+	Flushes       uint64            // 日志刷新适配器的次数
+	FlushErrors   uint64            // 日志刷新适配器失败的次数
+	Pending       int64             // 已分发但适配器尚未处理完成的日志数
+	QueueLength   int               // 分发队列当前的日志数
+	QueueCapacity int               // 分发队列的容量
+	QueueHighMark int               // 分发队列出现过的最大长度
+	QueueFull     uint64            // 分发时队列已满的次数，包括等待后成功入队的情况
+	Buffered      map[string]int    // 各适配器缓冲区中尚未发送的日志数
+
+	AdapterLimitHits uint64 // 处理适配器日志的协程数达到WithMaxAdapterGoroutines上限的次数，包括等待后继续处理的情况
+
+	OutputSampled  uint64            // 控制台和文件输出按级别采样丢弃的日志数
+	SampledByLevel map[string]uint64 // 各级别被采样丢弃的日志数，包括级别采样和按键采样
+
+	Retried      uint64 // 适配器处理失败后重试的次数
+	RetryDropped uint64 // 重试次数用尽或重试队列已满而被丢弃的日志数
+}
+
+// BufferedAdapter 能够报告缓冲区中日志数量的适配器
+type BufferedAdapter interface {
+	// Buffered 返回缓冲区中尚未发送的日志数
+	Buffered() int
+}
+
+// AdapterStats 适配器自身的发送统计
+type AdapterStats struct {
+	Delivered     uint64    // 已成功发送的日志数
+	Failed        uint64    // 发送失败的日志数
+	LastFlush     time.Time // 最近一次成功发送的时间
+	LastError     string    // 最近一次发送失败的错误信息
+	LastErrorTime time.Time // 最近一次发送失败的时间
+}
+
+// StatsAdapter 能够报告自身发送统计的适配器
+type StatsAdapter interface {
+	// Stats 返回适配器的发送统计
+	Stats() AdapterStats
+}
+
+// pipelineStats 父日志和子日志共享的统计计数器
+type pipelineStats struct {
+	logged        [zapcore.FatalLevel - zapcore.DebugLevel + 1]atomic.Uint64
+	dropped       atomic.Uint64
+	sampled       atomic.Uint64
+	adapterErrors atomic.Uint64
+	flushes       atomic.Uint64
+	flushErrors   atomic.Uint64
+	pending       atomic.Int64
+	queueHighMark atomic.Int64
+	queueFull     atomic.Uint64
+	retried       atomic.Uint64
+	retryDropped  atomic.Uint64
+
+	adapterLimitHits atomic.Uint64
+}
+
+// observeQueue 记录入队后的队列长度，更新最大长度
+func (s *pipelineStats) observeQueue(length int) {
+	n := int64(length)
+	for {
+		mark := s.queueHighMark.Load()
+		if n <= mark || s.queueHighMark.CompareAndSwap(mark, n) {
+			return
+		}
+	}
+}
+
+// countEntry 作为zap的Hook统计每条写入的日志
+func (s *pipelineStats) countEntry(entry zapcore.Entry) error {
+	if entry.Level >= zapcore.DebugLevel && entry.Level <= zapcore.FatalLevel {
+		s.logged[entry.Level-zapcore.DebugLevel].Add(1)
+	}
+	return nil
+}
+
+// Stats 返回日志处理管道的运行统计，子日志与父日志共享同一份统计
+func (l *ZapLogger) Stats() LoggerStats {
+	stats := LoggerStats{
+		Logged:        make(map[string]uint64),
+		Dropped:       l.stats.dropped.Load(),
+		Sampled:       l.stats.sampled.Load(),
+		AdapterErrors: l.stats.adapterErrors.Load(),
+		Flushes:       l.stats.flushes.Load(),
+		FlushErrors:   l.stats.flushErrors.Load(),
+		Pending:       l.stats.pending.Load(),
+		QueueHighMark: int(l.stats.queueHighMark.Load()),
+		QueueFull:     l.stats.queueFull.Load(),
+		Buffered:      make(map[string]int),
+
+		OutputSampled:  l.sampling.outputSampled(),
+		SampledByLevel: l.sampling.levels(),
+
+		Retried:      l.stats.retried.Load(),
+		RetryDropped: l.stats.retryDropped.Load(),
+
+		AdapterLimitHits: l.stats.adapterLimitHits.Load(),
+	}
+	for _, w := range l.asyncWriters {
+		stats.AsyncDropped += w.Dropped()
+	}
+	stats.QueueLength, stats.QueueCapacity = l.queueLength()
+
+	for i := range l.stats.logged {
+		if n := l.stats.logged[i].Load(); n > 0 {
+			stats.Logged[(zapcore.DebugLevel + zapcore.Level(i)).String()] = n
+		}
+	}
+
+	l.adapterMu.RLock()
+	defer l.adapterMu.RUnlock()
+	for _, adapter := range l.adapters {
+		if buffered, ok := unwrapAdapter(adapter).(BufferedAdapter); ok {
+			stats.Buffered[adapter.Name()] += buffered.Buffered()
+		}
+	}
+
+	return stats
+}
+
+// AdapterStats 返回实现了StatsAdapter的各适配器的发送统计，按适配器名称(实例ID)索引
+func (l *ZapLogger) AdapterStats() map[string]AdapterStats {
+	l.adapterMu.RLock()
+	defer l.adapterMu.RUnlock()
+
+	stats := make(map[string]AdapterStats)
+	for _, adapter := range l.adapters {
+		if reporter, ok := unwrapAdapter(adapter).(StatsAdapter); ok {
+			stats[adapter.Name()] = reporter.Stats()
+		}
+	}
+	return stats
+}
+
+// Stats 返回默认日志实例的运行统计
+func Stats() LoggerStats {
+	if l, ok := Default().(*ZapLogger); ok {
+		return l.Stats()
+	}
+	return LoggerStats{
+		Logged:   make(map[string]uint64),
+		Buffered: make(map[string]int),
+	}
+}
@@ -0,0 +1,76 @@
+package logger
+
+import (
+	"io"
+	"sync"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// teeWriter 可在运行时替换或移除的旁路输出目标
+type teeWriter struct {
+	mu sync.Mutex
+	w  io.Writer
+}
+
+// set 设置旁路输出目标，nil表示移除
+func (t *teeWriter) set(w io.Writer) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	t.w = w
+}
+
+// active 返回是否设置了旁路输出目标
+func (t *teeWriter) active() bool {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	return t.w != nil
+}
+
+// Write 写入旁路输出目标，未设置时丢弃
+func (t *teeWriter) Write(p []byte) (int, error) {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if t.w == nil {
+		return len(p), nil
+	}
+	return t.w.Write(p)
+}
+
+// Sync 刷新旁路输出目标
+func (t *teeWriter) Sync() error {
+	t.mu.Lock()
+	defer t.mu.Unlock()
+	if s, ok := t.w.(zapcore.WriteSyncer); ok {
+		return s.Sync()
+	}
+	return nil
+}
+
+// teeCore 未设置旁路输出目标时不处理日志，避免编码开销
+type teeCore struct {
+	zapcore.Core
+	tee *teeWriter
+}
+
+func (c *teeCore) With(fields []zapcore.Field) zapcore.Core {
+	return &teeCore{Core: c.Core.With(fields), tee: c.tee}
+}
+
+func (c *teeCore) Check(ent zapcore.Entry, ce *zapcore.CheckedEntry) *zapcore.CheckedEntry {
+	if !c.tee.active() {
+		return ce
+	}
+	return c.Core.Check(ent, ce)
+}
+
+// SetTee 将输出核心产生的每一行JSON日志额外写一份到w，用于排查日志丢失等问题，传入nil移除
+// 旁路输出在适配器和文件旋转之前，与控制台和文件输出相互独立，可以在运行时随时切换
+func (l *ZapLogger) SetTee(w io.Writer) {
+	l.tee.set(w)
+}
+
+// RemoveTee 移除SetTee或WithTee设置的旁路输出
+func (l *ZapLogger) RemoveTee() {
+	l.tee.set(nil)
+}
@@ -0,0 +1,43 @@
+package logger
+
+import (
+	"strings"
+	"testing"
+)
+
+// testingWriter 将日志输出转发到testing.TB
+type testingWriter struct {
+	tb testing.TB
+}
+
+// Write 实现io.Writer接口
+func (w testingWriter) Write(p []byte) (n int, err error) {
+	w.tb.Helper()
+	w.tb.Log(strings.TrimSuffix(string(p), "\n"))
+	return len(p), nil
+}
+
+// Sync 实现zapcore.WriteSyncer接口
+func (w testingWriter) Sync() error {
+	return nil
+}
+
+// NewTestLogger 创建一个将控制台输出写入testing.TB的日志实例
+// 日志会与测试输出交错显示，测试通过时默认不显示，测试结束时自动关闭适配器
+func NewTestLogger(tb testing.TB, opts ...Option) Logger {
+	tb.Helper()
+
+	config := NewConfig(append([]Option{WithLevel("debug")}, opts...)...)
+	config.consoleOutput = testingWriter{tb: tb}
+
+	logger, err := New(config)
+	if err != nil {
+		tb.Fatalf("create test logger failed: %v", err)
+		return nil
+	}
+
+	tb.Cleanup(func() {
+		_ = logger.Close()
+	})
+	return logger
+}
@@ -0,0 +1,74 @@
+package logger
+
+import (
+	"sync"
+	"sync/atomic"
+	"time"
+
+	"go.uber.org/zap"
+)
+
+var (
+	// onceKeys 记录InfoOnce/WarnOnce已输出过的键，进程生命周期内有效
+	onceKeys sync.Map
+	// throttleKeys 记录各节流键最近一次输出的时间(UnixNano)
+	throttleKeys sync.Map
+)
+
+// firstOccurrence 判断键是否第一次出现
+func firstOccurrence(key string) bool {
+	_, loaded := onceKeys.LoadOrStore(key, struct{}{})
+	return !loaded
+}
+
+// logThrottle 按键节流，同一键在interval内最多输出一次
+type logThrottle struct {
+	key      string
+	interval time.Duration
+}
+
+// allow 判断当前是否允许输出，允许时记录本次输出时间
+func (t *logThrottle) allow() bool {
+	now := time.Now().UnixNano()
+	v, _ := throttleKeys.LoadOrStore(t.key, new(atomic.Int64))
+	last := v.(*atomic.Int64)
+	for {
+		prev := last.Load()
+		if prev != 0 && now-prev < int64(t.interval) {
+			return false
+		}
+		if last.CompareAndSwap(prev, now) {
+			return true
+		}
+	}
+}
+
+// InfoOnce 同一个key在进程生命周期内只输出第一次
+func (l *ZapLogger) InfoOnce(key string, msg string) {
+	if l.suppressed(zap.InfoLevel) || !firstOccurrence(key) {
+		return
+	}
+	if l.hasAdapters() {
+		l.sendToAdapters("info", msg, nil)
+	}
+	l.sugar.Info(msg)
+}
+
+// WarnOnce 同一个key在进程生命周期内只输出第一次
+func (l *ZapLogger) WarnOnce(key string, msg string) {
+	if l.suppressed(zap.WarnLevel) || !firstOccurrence(key) {
+		return
+	}
+	if l.hasAdapters() {
+		l.sendToAdapters("warn", msg, nil)
+	}
+	l.sugar.Warn(msg)
+}
+
+// WithThrottle 返回按key节流的子日志，同一key在interval内最多输出一次
+// 节流状态按key在进程内共享，不同子日志使用相同key会共同受限
+func (l *ZapLogger) WithThrottle(key string, interval time.Duration) Logger {
+	child := *l
+	child.throttle = &logThrottle{key: key, interval: interval}
+	return &child
+}
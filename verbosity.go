@@ -0,0 +1,40 @@
+package logger
+
+// V 返回详细级别为level的日志视图，类似glog/klog的V(n)：
+// 视图的Info/Infof/Infow只在当前详细级别阈值（WithVerbosity或SetVerbosity设置）不小于level时输出，
+// 否则在格式化和分发前直接丢弃；其他级别的方法不受影响，阈值在调用时读取，运行时调整对已有视图立即生效
+func (l *ZapLogger) V(level int) Logger {
+	child := *l
+	child.verbosityLevel = level
+	return &child
+}
+
+// SetVerbosity 设置详细级别阈值，V(n)视图在n小于等于阈值时输出，父日志和子日志共享同一阈值
+func (l *ZapLogger) SetVerbosity(level int) {
+	l.verbosity.Store(int32(level))
+}
+
+// Verbosity 返回当前的详细级别阈值
+func (l *ZapLogger) Verbosity() int {
+	return int(l.verbosity.Load())
+}
+
+// verboseSuppressed 判断V视图的info级别日志是否因详细级别不足被丢弃
+func (l *ZapLogger) verboseSuppressed() bool {
+	return l.verbosityLevel > 0 && int32(l.verbosityLevel) > l.verbosity.Load()
+}
+
+// V 返回默认日志实例的详细级别视图，默认日志不是ZapLogger时返回默认日志本身
+func V(level int) Logger {
+	if l, ok := Default().(*ZapLogger); ok {
+		return l.V(level)
+	}
+	return Default()
+}
+
+// SetVerbosity 设置默认日志实例的详细级别阈值，默认日志不是ZapLogger时不生效
+func SetVerbosity(level int) {
+	if l, ok := Default().(*ZapLogger); ok {
+		l.SetVerbosity(level)
+	}
+}
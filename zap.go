@@ -2,105 +2,450 @@ package logger
 
 import (
 	"context"
+	"encoding/json"
+	"errors"
 	"fmt"
+	"io"
 	"os"
+	"reflect"
+	"sort"
+	"strings"
 	"sync"
+	"sync/atomic"
 	"time"
 
 	"go.uber.org/zap"
 	"go.uber.org/zap/zapcore"
 )
 
+// iso8601Layout 与zapcore.ISO8601TimeEncoder一致的时间格式
+const iso8601Layout = "2006-01-02T15:04:05.000Z0700"
+
 // ZapLogger 实现Logger接口的zap日志处理器
 type ZapLogger struct {
-	logger    *zap.Logger
-	sugar     *zap.SugaredLogger
+	logger *zap.Logger
+	sugar  *zap.SugaredLogger
+	*adapterGroup
+	nodeID string
+	module string
+	ip     string
+	fields map[string]interface{} // 子日志携带的字段，会合并到适配器的Properties中
+
+	fieldOrder []string // fields中的key按With添加的顺序排列，适配器日志的LogEntry.Order以此开头
+
+	throttle     *logThrottle // WithThrottle设置的节流，为空表示不节流
+	skipAdapters bool         // NoAdapters返回的视图只写入输出核心，不分发到适配器
+
+	ctx context.Context // WithContext设置的context，适配器处理这些日志的上下文由它派生，为空表示未设置
+
+	verbosityLevel int // V返回的视图的详细级别，超过详细级别阈值时丢弃info级别日志
+
+	rotator       *DailyRotateWriter           // 文件输出的写入器，没有文件输出时为空
+	consoleBuffer *zapcore.BufferedWriteSyncer // 控制台缓冲输出，未启用缓冲时为空
+	asyncWriters  []*asyncWriter               // 异步写出的控制台和文件输出，未启用异步时为空
+	fileWriter    io.Closer                    // 调用方通过FileWriter提供的文件写入器，Close时关闭
+}
+
+// adapterGroup 父日志和子日志共享的适配器集合及统计
+type adapterGroup struct {
 	adapters  []LogAdapter
 	adapterMu sync.RWMutex
-	nodeID    string
-	module    string
-	ip        string
+	stats     pipelineStats
+	floor     atomic.Int32 // 最低级别闸门，存储相对DebugLevel的偏移，零值表示不限制
+
+	stackTrimPrefixes []string    // 适配器堆栈顶部裁剪的函数前缀
+	fullStacktrace    bool        // 保留完整堆栈，不做裁剪
+	stackDedup        *stackDedup // 重复堆栈去重，为空表示不去重
+
+	sampler *keySampler // 按属性值分组采样，为空表示不采样
+	clock   Clock       // 适配器日志条目使用的时钟，为空时使用系统时间
+
+	sampling *samplingCounter // 按级别统计被采样丢弃的日志并汇总给WithSamplingHook的回调
+
+	dispatchMu      sync.Mutex
+	dispatcher      *dispatcher // 首次分发时创建，Close时关闭
+	dispatchBuffer  int         // 分发队列容量，为0时使用默认值
+	dispatchWorkers int         // 分发工作协程数，为0时使用默认的4个
+	dispatchClosed  bool        // Close后不再接受新日志
+
+	dispatchRetries    int           // 适配器处理失败后的最大重试次数，为0时不重试
+	dispatchRetryDelay time.Duration // 每次重试前的等待时间，为0时使用默认值
+	drainTimeout       time.Duration // Close等待分发队列处理完成的最长时间，为0时只受Close的上下文限制
+
+	backpressure     BackpressurePolicy         // 分发队列满时的处理策略
+	adapterSlots     chan struct{}              // 同时处理适配器日志的协程数信号量，容量即上限
+	adapterLimit     AdapterLimitPolicy         // 达到协程数上限时等待还是丢弃
+	activeDispatcher atomic.Pointer[dispatcher] // 与dispatcher相同，供不获取dispatchMu的统计读取
+
+	baseCtx    context.Context    // 适配器处理日志的上下文的父上下文，为空时使用context.Background()
+	cancelBase context.CancelFunc // 取消baseCtx，Close时调用以中止仍在进行的适配器发送
+
+	errorOutput zapcore.WriteSyncer // 日志包自身错误的输出，为空时使用标准错误输出
+
+	goroutineID    bool          // 每条日志附加goroutine字段
+	sequence       bool          // 每条日志附加递增的seq字段
+	callerFunction bool          // 每条适配器日志附加调用方函数名func字段
+	omitEmpty      bool          // 适配器属性去掉值为空的项
+	seq            atomic.Uint64 // 父日志和子日志共享的序号
+
+	adapterCallerSkip int    // 适配器调用位置在裁剪的帧之后额外跳过的帧数
+	callerFormat      string // 适配器调用位置的格式，与输出核心的caller字段一致
+
+	audit   LogAdapter // 审计适配器，与普通适配器的分发隔离，为空表示未配置
+	auditMu sync.Mutex // 保证审计日志按调用顺序串行处理
+
+	levelMu    sync.Mutex
+	coreLevels []*coreLevel    // 各输出核心的级别
+	baseFloor  int32           // SetFloorLevel设置的闸门，提升级别结束后恢复
+	boosts     []zapcore.Level // 正在生效的BoostLevel
+
+	verbosity atomic.Int32 // 详细级别阈值，V(n)视图在n不超过阈值时输出
+
+	binary *binaryEncoder // []byte参数和字段的编码方式
+
+	fieldLimit *fieldLimit // 适配器属性的数量和大小限制，为空表示不限制
+
+	levelRemap *levelRemap // 适配器日志的级别重映射，与输出核心使用相同的规则，为空表示不改写
+
+	fieldNamespace string // 日志字段放入的嵌套对象名，为空表示字段输出在顶层
+
+	contextFields []string // WithContext从context中提取的字段名
+
+	panicMu     sync.Mutex
+	panicCounts map[string]int // 各适配器panic的次数
+	panicLimit  int            // 适配器panic达到该次数后停用，为0表示不停用
+
+	requestIDGenerator func() string // HTTP中间件的请求ID生成器，为空时使用UUIDv4
+	requestIDHeader    string        // HTTP中间件的请求ID头，为空时使用DefaultRequestIDHeader
+	accessLog          *accessLog    // HTTP中间件的访问日志，为空表示不输出
+
+	tee *teeWriter // 旁路输出，可在运行时设置和移除
+
+	levelHooks []levelHook // 日志达到指定级别时调用的回调
+
+	adapterProperties map[string]interface{} // 合并到每条适配器日志属性中的静态属性，不输出到控制台和文件
+
+	durationProperty func(time.Duration) interface{} // time.Duration属性的转换函数，与输出的时长格式一致，为空时转换为秒数
+
+	releaseOnce sync.Once // 保证父日志和子日志多次Close只释放一次文件写入器
 }
 
 // NewZapLogger 创建一个新的zap日志处理器
 func NewZapLogger(logLevel string, logPath string, nodeID string, module string, ip string, outputType OutputType, adapterConfigs []AdapterConfig) (Logger, error) {
-	// 解析日志级别
-	level := zap.InfoLevel
-	switch logLevel {
-	case "debug":
-		level = zap.DebugLevel
-	case "info":
-		level = zap.InfoLevel
-	case "warn":
-		level = zap.WarnLevel
-	case "error":
-		level = zap.ErrorLevel
-	case "panic":
-		level = zap.PanicLevel
-	default:
-		level = zap.InfoLevel
+	logger, err := newZapLogger(Config{
+		Level:      logLevel,
+		Path:       logPath,
+		NodeID:     nodeID,
+		Module:     module,
+		IP:         ip,
+		OutputType: outputType,
+		Adapters:   adapterConfigs,
+	})
+	if err != nil {
+		return nil, err
+	}
+	return logger, nil
+}
+
+// newZapLogger 根据完整配置创建zap日志处理器
+func newZapLogger(config Config) (*ZapLogger, error) {
+	logPath := config.Path
+	nodeID := config.NodeID
+	module := config.Module
+	ip := config.IP
+	outputType := config.OutputType
+
+	// 按模块覆盖输出类型，未列出的模块使用全局输出类型
+	if moduleOutput, ok := config.ModuleOutputs[module]; ok {
+		if !IsValidOutputType(moduleOutput) {
+			return nil, fmt.Errorf("invalid output type %s for module %s", moduleOutput, module)
+		}
+		outputType = moduleOutput
+	}
+
+	// FileWriter只用于文件输出，不输出到文件时写入器不会被写入却仍会在Close时关闭
+	if config.FileWriter != nil && (outputType == OutputTerminal || outputType == OutputAdapters) {
+		return nil, fmt.Errorf("file writer requires file output, got output type %s", outputType)
+	}
+
+	// 解析日志级别，未设置时使用info
+	level, err := parseConfigLevel(config.Level, zap.InfoLevel)
+	if err != nil {
+		return nil, err
+	}
+
+	// 控制台和文件可以使用各自的级别，未设置时使用全局级别
+	consoleLevel, err := parseConfigLevel(config.ConsoleLevel, level)
+	if err != nil {
+		return nil, err
+	}
+	fileLevel, err := parseConfigLevel(config.FileLevel, level)
+	if err != nil {
+		return nil, err
 	}
 
 	// 创建核心编码器
-	encoderConfig := zapcore.EncoderConfig{
-		TimeKey:        "time",
-		LevelKey:       "level",
-		NameKey:        "logger",
-		CallerKey:      "caller",
-		MessageKey:     "msg",
-		StacktraceKey:  "stacktrace",
-		LineEnding:     zapcore.DefaultLineEnding,
-		EncodeLevel:    zapcore.CapitalLevelEncoder,
-		EncodeTime:     zapcore.ISO8601TimeEncoder,
-		EncodeDuration: zapcore.SecondsDurationEncoder,
-		EncodeCaller:   zapcore.ShortCallerEncoder,
+	encoderConfig := newEncoderConfig()
+	if err := config.EncoderKeys.apply(&encoderConfig); err != nil {
+		return nil, err
+	}
+	if config.TimeFormat != "" {
+		encoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(config.TimeFormat)
+	}
+	callerEncoder, err := newCallerEncoder(config.CallerFormat)
+	if err != nil {
+		return nil, err
+	}
+	encoderConfig.EncodeCaller = callerEncoder
+	if err := validateFieldNamespace(config.FieldNamespace, encoderConfig); err != nil {
+		return nil, err
+	}
+	durationEncoder, durationProperty, err := newDurationEncoder(config.DurationFormat)
+	if err != nil {
+		return nil, err
+	}
+	encoderConfig.EncodeDuration = durationEncoder
+	// 函数名来自zap捕获调用位置时已解析的帧，不需要额外获取调用栈
+	if config.CallerFunction {
+		encoderConfig.FunctionKey = FunctionField
 	}
 
-	// 创建多核心日志写入
+	// 控制台编码器可以使用级别装饰和颜色，文件的JSON编码器保持原样
+	consoleEncoderConfig := encoderConfig
+	if levelEncoder := newLevelDecorationEncoder(config.LevelDecorations, config.LevelColors); levelEncoder != nil {
+		consoleEncoderConfig.EncodeLevel = levelEncoder
+	} else if config.ConsoleColor || config.ConsoleFormat == ConsoleFormatHybrid {
+		consoleEncoderConfig.EncodeLevel = zapcore.CapitalColorLevelEncoder
+	}
+	if config.ConsoleTimeFormat != "" {
+		consoleEncoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(config.ConsoleTimeFormat)
+	}
+	var consoleEncoder zapcore.Encoder
+	if config.ConsoleFormat == ConsoleFormatJSON {
+		// JSON控制台与文件输出使用相同的字段，不使用颜色、级别装饰和控制台时间格式
+		consoleEncoder, err = newJSONEncoder(encoderConfig, config.ExtraRootFields, config.SortedFields)
+	} else {
+		consoleEncoder, err = newConsoleEncoder(config.ConsoleFormat, consoleEncoderConfig, config.InlineFields)
+	}
+	if err != nil {
+		return nil, err
+	}
+
+	// 控制台输出目标，默认为标准输出
+	var consoleOutput zapcore.WriteSyncer = zapcore.AddSync(os.Stdout)
+	if config.consoleOutput != nil {
+		consoleOutput = config.consoleOutput
+	}
+
+	// 标准输出不是终端时按ConsoleSync在写入后Sync，管道不支持Sync返回的错误被忽略
+	syncLevel, consoleSync, err := parseConsoleSync(config.ConsoleSync)
+	if err != nil {
+		return nil, err
+	}
+	if consoleSync && isTerminal(consoleOutput) {
+		consoleSync = false
+	}
+	if consoleSync {
+		consoleOutput = pipeSyncWriter{consoleOutput}
+	}
+
+	// 达到stderr级别的控制台日志输出到标准错误输出，未设置时全部输出到控制台输出目标
+	var stderrLevel *zapcore.Level
+	if config.ConsoleStderrLevel != "" {
+		lvl, err := ParseLevel(config.ConsoleStderrLevel)
+		if err != nil {
+			return nil, err
+		}
+		stderrLevel = &lvl
+	}
+	var consoleErrOutput zapcore.WriteSyncer = zapcore.AddSync(os.Stderr)
+	if config.consoleErrOutput != nil {
+		consoleErrOutput = config.consoleErrOutput
+	}
+
+	// 控制台缓冲输出，error及以上级别由flushOnErrorCore立即刷新
+	var consoleBuffer *zapcore.BufferedWriteSyncer
+	if config.ConsoleBufferSize > 0 {
+		consoleBuffer = newConsoleBuffer(consoleOutput, config.ConsoleBufferSize, config.ConsoleFlushInterval, config.Clock)
+		consoleOutput = consoleBuffer
+	}
+
+	// 日志包自身错误的输出，默认标准错误输出
+	errorOutput := newErrorOutput(config.InternalErrorWriter)
+
+	// 创建失败时释放已获取的资源
+	created := false
+
+	// 异步输出，控制台和文件分别由单独的协程写出，创建失败时停止已启动的协程
+	var asyncWriters []*asyncWriter
+	newAsync := func(ws zapcore.WriteSyncer, name string) (zapcore.WriteSyncer, error) {
+		w, err := newAsyncWriter(ws, config.AsyncCoreBuffer, config.AsyncCorePolicy, func(err error) {
+			fmt.Fprintf(errorOutput, "%s async write failed: %v\n", name, err)
+		})
+		if err != nil {
+			return nil, err
+		}
+		asyncWriters = append(asyncWriters, w)
+		return w, nil
+	}
+	defer func() {
+		if !created {
+			for _, w := range asyncWriters {
+				w.Stop()
+			}
+		}
+	}()
+	if config.AsyncCoreBuffer > 0 && consoleBuffer == nil {
+		if consoleOutput, err = newAsync(consoleOutput, "console"); err != nil {
+			return nil, err
+		}
+	}
+
+	// 创建多核心日志写入，记录各核心的级别以便临时调整
 	cores := []zapcore.Core{}
+	var levels []*coreLevel
+
+	// 标准输出核心，启用ConsoleSync时写入后Sync，否则缓冲输出在error级别刷新
+	stdoutCore := func(enabler zapcore.LevelEnabler) zapcore.Core {
+		core := zapcore.NewCore(consoleEncoder, consoleOutput, enabler)
+		if consoleSync {
+			return syncOnWrite(core, consoleOutput, syncLevel)
+		}
+		return flushOnError(core, consoleBuffer)
+	}
+
+	// 控制台输出核心，设置了stderr级别时按级别拆分为两个核心，共享同一个级别
+	addConsoleCores := func() {
+		enabler := newCoreLevel(&levels, consoleLevel)
+		if stderrLevel == nil {
+			cores = append(cores, stdoutCore(enabler))
+			return
+		}
+		cores = append(cores,
+			stdoutCore(splitLevel{enabler, *stderrLevel, false}),
+			zapcore.NewCore(consoleEncoder, consoleErrOutput, splitLevel{enabler, *stderrLevel, true}),
+		)
+	}
 
 	// 根据输出类型选择输出目标
 	if outputType == OutputTerminal || outputType == OutputBoth {
-		// 控制台输出
-		consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
-		consoleCore := zapcore.NewCore(
-			consoleEncoder,
-			zapcore.AddSync(os.Stdout),
-			zap.NewAtomicLevelAt(level),
-		)
-		cores = append(cores, consoleCore)
+		addConsoleCores()
 	}
 
-	// 文件输出（按天）
-	if (outputType == OutputFile || outputType == OutputBoth) && logPath != "" {
+	// 文件输出（按天），创建失败时释放已获取的写入器；设置了FileWriter时使用调用方提供的写入器
+	var rotator *DailyRotateWriter
+	var fileOutput zapcore.WriteSyncer
+	fileEnabled := outputType == OutputFile || outputType == OutputBoth
+	if fileEnabled && config.FileWriter != nil {
+		fileOutput = zapcore.AddSync(config.FileWriter)
+	} else if fileEnabled && logPath != "" {
 		// 使用日志旋转器
-		rotator, err := NewDailyRotateWriter(logPath)
+		rotation := config.Rotation
+		if rotation.Clock == nil {
+			rotation.Clock = config.Clock
+		}
+		// 写入同一目录的日志实例共享写入器，避免重复打开文件和并发旋转
+		rotator, err = acquireRotateWriter(logPath, rotation, errorOutput)
 		if err != nil {
 			return nil, fmt.Errorf("create log rotator failed: %v", err)
 		}
+		defer func() {
+			if !created {
+				_ = releaseRotateWriter(rotator)
+			}
+		}()
+		fileOutput = rotator.AsWriteSyncer()
+	}
+	if fileOutput != nil {
+		// 文件输出可以使用自定义格式、行尾和时间格式
+		fileEncoderConfig := encoderConfig
+		if config.FileTimeFormat != "" {
+			fileEncoderConfig.EncodeTime = zapcore.TimeEncoderOfLayout(config.FileTimeFormat)
+		}
+		switch config.LineEnding {
+		case "":
+		case NoLineEnding:
+			fileOutput = trimNewlineWriter{fileOutput}
+		default:
+			fileEncoderConfig.LineEnding = config.LineEnding
+		}
+
+		// 文件连续写入失败时回退到控制台，只输出到文件时由回退写入器接管控制台输出
+		var fallbackConsole zapcore.WriteSyncer
+		if outputType == OutputFile {
+			fallbackConsole = consoleOutput
+		}
+		fileOutput = newFileFallback(fileOutput, fallbackConsole, config.FileFallbackThreshold, errorOutput)
+		if config.AsyncCoreBuffer > 0 {
+			if fileOutput, err = newAsync(fileOutput, "file"); err != nil {
+				return nil, err
+			}
+		}
 
-		fileEncoder := zapcore.NewJSONEncoder(encoderConfig)
+		fileEncoder, err := newFileEncoder(config.FileFormat, fileEncoderConfig, config.ExtraRootFields, config.SortedFields)
+		if err != nil {
+			return nil, err
+		}
 		fileCore := zapcore.NewCore(
 			fileEncoder,
-			rotator.AsWriteSyncer(),
-			zap.NewAtomicLevelAt(level),
+			fileOutput,
+			newCoreLevel(&levels, fileLevel),
 		)
 		cores = append(cores, fileCore)
 	}
 
-	// 如果没有任何有效的输出核心，至少添加一个控制台输出
-	if len(cores) == 0 {
-		consoleEncoder := zapcore.NewConsoleEncoder(encoderConfig)
-		consoleCore := zapcore.NewCore(
-			consoleEncoder,
-			zapcore.AddSync(os.Stdout),
-			zap.NewAtomicLevelAt(level),
-		)
-		cores = append(cores, consoleCore)
+	// 如果没有任何有效的输出核心，至少添加一个控制台输出；只输出到适配器时不添加
+	if len(cores) == 0 && outputType != OutputAdapters {
+		addConsoleCores()
 	}
 
-	// 合并所有核心
-	core := zapcore.NewTee(cores...)
+	// 旁路输出核心，设置了目标时以JSON写出与其他输出相同的日志
+	tee := &teeWriter{w: config.Tee}
+	teeEncoder, err := newJSONEncoder(encoderConfig, config.ExtraRootFields, config.SortedFields)
+	if err != nil {
+		return nil, err
+	}
+	cores = append(cores, &teeCore{
+		Core: zapcore.NewCore(teeEncoder, tee, newCoreLevel(&levels, level)),
+		tee:  tee,
+	})
+
+	// 合并所有核心，二进制字段统一编码为字符串，开启WithOmitEmpty时去掉空字段，再按WithFieldLimit限制字段，
+	// 配置了字段命名空间时，处理后的字段最后放入命名空间对象
+	binary, err := newBinaryEncoder(config.BinaryEncoding, config.MaxBinarySize)
+	if err != nil {
+		return nil, err
+	}
+	limit, err := newFieldLimit(config.MaxFields, config.MaxFieldValueBytes)
+	if err != nil {
+		return nil, err
+	}
+	for i, c := range cores {
+		if config.FieldNamespace != "" {
+			c = &fieldNamespaceCore{Core: c, namespace: config.FieldNamespace}
+		}
+		cores[i] = &binaryCore{Core: c, enc: binary}
+		if limit != nil {
+			cores[i] = &fieldLimitCore{Core: cores[i], limit: limit}
+		}
+		if config.OmitEmpty {
+			cores[i] = &omitEmptyCore{Core: cores[i]}
+		}
+	}
+	sampling := newSamplingCounter(config.SamplingHook, config.Clock)
+	core, err := newLevelSampledCore(zapcore.NewTee(cores...), config.LevelSampling, sampling)
+	if err != nil {
+		return nil, err
+	}
+
+	// 级别重映射在采样和各输出的级别过滤之前改写级别
+	remap, err := newLevelRemap(config.LevelRemap, module)
+	if err != nil {
+		return nil, err
+	}
+	if remap != nil {
+		core = &levelRemapCore{Core: core, remap: remap}
+	}
 
 	// 添加公共字段
 	fields := []zap.Field{}
@@ -114,153 +459,1117 @@ func NewZapLogger(logLevel string, logPath string, nodeID string, module string,
 		fields = append(fields, zap.String("ip", ip))
 	}
 
+	// env和version同时作为适配器日志的属性，调用时传入的同名字段优先
+	var baseFields map[string]interface{}
+	for _, field := range [][2]string{{"env", config.Env}, {"version", config.Version}} {
+		if field[1] == "" {
+			continue
+		}
+		fields = append(fields, zap.String(field[0], field[1]))
+		if baseFields == nil {
+			baseFields = make(map[string]interface{}, 2)
+		}
+		baseFields[field[0]] = field[1]
+	}
+
+	levelHooks, err := newLevelHooks(config.LevelHooks)
+	if err != nil {
+		return nil, err
+	}
+
+	// 复制静态属性，避免调用方修改配置中的map影响运行中的日志
+	var adapterProperties map[string]interface{}
+	if len(config.AdapterProperties) > 0 {
+		adapterProperties = make(map[string]interface{}, len(config.AdapterProperties))
+		for k, v := range config.AdapterProperties {
+			adapterProperties[k] = v
+		}
+	}
+
+	if err := validateAccessLogFields(config.AccessLogFields); err != nil {
+		return nil, err
+	}
+	access := newAccessLog(config.AccessLog, config.SuccessSampleKeep, config.SuccessSampleEvery)
+	if access != nil {
+		access.fields = config.AccessLogFields
+	}
+
+	group := &adapterGroup{
+		stackTrimPrefixes: append(append([]string(nil), defaultStackTrimPrefixes...), config.StackTrimPrefixes...),
+		fullStacktrace:    config.FullStacktrace,
+		stackDedup:        newStackDedup(config.StackDedupWindow),
+		sampler:           newKeySampler(config.SamplingKey, config.SamplingRate),
+		sampling:          sampling,
+		clock:             config.Clock,
+		dispatchBuffer:    config.DispatchBuffer,
+		dispatchWorkers:   config.DispatchWorkers,
+		backpressure:      config.BackpressurePolicy,
+		adapterSlots:      newAdapterSlots(config.MaxAdapterGoroutines),
+		adapterLimit:      config.AdapterLimitPolicy,
+		errorOutput:       errorOutput,
+		goroutineID:       config.GoroutineID,
+		sequence:          config.Sequence,
+		callerFunction:    config.CallerFunction,
+		omitEmpty:         config.OmitEmpty,
+		audit:             config.AuditAdapter,
+		coreLevels:        levels,
+		binary:            binary,
+
+		adapterCallerSkip: config.AdapterCallerSkip,
+		callerFormat:      config.CallerFormat,
+		fieldLimit:        limit,
+		levelRemap:        remap,
+		fieldNamespace:    config.FieldNamespace,
+		contextFields:     append([]string(nil), config.ContextFields...),
+		panicLimit:        config.AdapterPanicLimit,
+
+		dispatchRetries:    config.DispatchRetries,
+		dispatchRetryDelay: config.DispatchRetryDelay,
+		drainTimeout:       config.DrainTimeout,
+
+		requestIDGenerator: config.RequestIDGenerator,
+		requestIDHeader:    config.RequestIDHeader,
+		accessLog:          access,
+		tee:                tee,
+		levelHooks:         levelHooks,
+		adapterProperties:  adapterProperties,
+		durationProperty:   durationProperty,
+	}
+	group.verbosity.Store(int32(config.Verbosity))
+	baseCtx := config.BaseContext
+	if baseCtx == nil {
+		baseCtx = context.Background()
+	}
+	group.baseCtx, group.cancelBase = context.WithCancel(baseCtx)
+	defer func() {
+		if !created {
+			group.cancelDispatch()
+		}
+	}()
+
 	// 创建logger
-	logger := zap.New(core, zap.AddCaller(), zap.AddCallerSkip(1), zap.Fields(fields...))
+	options := []zap.Option{zap.AddCaller(), zap.AddCallerSkip(1), zap.Fields(fields...), zap.Hooks(group.stats.countEntry), zap.ErrorOutput(errorOutput)}
+	if config.Clock != nil {
+		options = append(options, zap.WithClock(zapClock{config.Clock}))
+	}
+	if config.StacktraceLevel != "" {
+		lvl, err := ParseLevel(config.StacktraceLevel)
+		if err != nil {
+			return nil, err
+		}
+		options = append(options, zap.AddStacktrace(lvl))
+	}
+	options = append(options, zap.WithFatalHook(&fatalHook{group: group, code: config.FatalExitCode}))
+	panicHook, err := newPanicHook(config.PanicBehavior, group)
+	if err != nil {
+		return nil, err
+	}
+	if panicHook != nil {
+		options = append(options, zap.WithPanicHook(panicHook))
+	}
+	logger := zap.New(core, options...)
 
 	// 初始化适配器
+	// 按优先级从高到低初始化和处理适配器，相同优先级保持配置顺序
+	adapterConfigs := append([]AdapterConfig(nil), config.Adapters...)
+	sort.SliceStable(adapterConfigs, func(i, j int) bool {
+		return adapterConfigs[i].Priority > adapterConfigs[j].Priority
+	})
 	adapters := make([]LogAdapter, 0, len(adapterConfigs))
-	if adapterConfigs != nil {
-		for _, cfg := range adapterConfigs {
-			adapter, exists := GetAdapter(cfg.Name)
-			if !exists {
-				continue
-			}
+	for _, cfg := range adapterConfigs {
+		adapter, exists := GetAdapter(cfg.Name)
+		if !exists {
+			// 未注册的适配器名称多半是拼写错误或未导入适配器包，输出警告便于排查
+			logger.Warn("unknown adapter, skipped", zap.String("adapter", cfg.Name))
+			continue
+		}
 
-			if err := adapter.Init(cfg.Config); err != nil {
-				return nil, fmt.Errorf("init adapter %s failed: %v", cfg.Name, err)
+		instance, err := initAdapter(adapter, cfg)
+		if err != nil {
+			// 宽松模式下跳过初始化失败的适配器，警告写入内部错误输出，控制台未启用时同样可见
+			if config.LenientAdapters {
+				group.internalError("init adapter %s failed, skipped: %v", cfg.Name, err)
+				continue
 			}
-
-			adapters = append(adapters, adapter)
+			return nil, fmt.Errorf("init adapter %s failed: %v", cfg.Name, err)
 		}
+
+		adapters = append(adapters, instance)
 	}
+	if outputType == OutputAdapters && len(adapters) == 0 {
+		return nil, fmt.Errorf("output type %s requires at least one adapter", OutputAdapters)
+	}
+	group.adapters = adapters
 
+	created = true
 	return &ZapLogger{
-		logger:   logger,
-		sugar:    logger.Sugar(),
-		adapters: adapters,
-		nodeID:   nodeID,
-		module:   module,
-		ip:       ip,
+		logger:        logger,
+		sugar:         logger.Sugar(),
+		adapterGroup:  group,
+		nodeID:        nodeID,
+		module:        module,
+		ip:            ip,
+		fields:        baseFields,
+		rotator:       rotator,
+		consoleBuffer: consoleBuffer,
+		asyncWriters:  asyncWriters,
+		fileWriter:    config.FileWriter,
 	}, nil
 }
 
+// initAdapter 校验配置后初始化适配器，返回按实例ID和level配置项包装后的适配器
+func initAdapter(adapter LogAdapter, cfg AdapterConfig) (LogAdapter, error) {
+	config, minLevel, err := splitAdapterLevel(cfg.Config)
+	if err != nil {
+		return nil, err
+	}
+	if err := ValidateAdapterConfig(cfg.Name, config); err != nil {
+		return nil, err
+	}
+	if err := adapter.Init(config); err != nil {
+		return nil, err
+	}
+
+	instance := NewAdapterInstance(cfg.ID, adapter)
+	if minLevel != nil {
+		instance = withAdapterLevel(instance, *minLevel)
+	}
+	return instance, nil
+}
+
+// ParseLevel 解析日志级别字符串，忽略大小写和首尾空白
+// 除debug、info、warn、error、dpanic、panic、fatal外还接受常见别名：warning、err、critical(panic)
+func ParseLevel(level string) (zapcore.Level, error) {
+	switch strings.ToLower(strings.TrimSpace(level)) {
+	case "debug":
+		return zap.DebugLevel, nil
+	case "info":
+		return zap.InfoLevel, nil
+	case "warn", "warning":
+		return zap.WarnLevel, nil
+	case "error", "err":
+		return zap.ErrorLevel, nil
+	case "dpanic":
+		return zap.DPanicLevel, nil
+	case "panic", "critical":
+		return zap.PanicLevel, nil
+	case "fatal":
+		return zap.FatalLevel, nil
+	default:
+		return zap.InfoLevel, fmt.Errorf("unknown log level: %s", level)
+	}
+}
+
+// parseConfigLevel 解析配置中的级别，为空时返回fallback
+func parseConfigLevel(level string, fallback zapcore.Level) (zapcore.Level, error) {
+	if level == "" {
+		return fallback, nil
+	}
+	return ParseLevel(level)
+}
+
+// newEncoderConfig 创建默认的编码器配置
+// 时间字段使用ISO8601格式，time.Duration字段默认编码为秒数
+func newEncoderConfig() zapcore.EncoderConfig {
+	return zapcore.EncoderConfig{
+		TimeKey:        "time",
+		LevelKey:       "level",
+		NameKey:        "logger",
+		CallerKey:      "caller",
+		MessageKey:     "msg",
+		StacktraceKey:  "stacktrace",
+		LineEnding:     zapcore.DefaultLineEnding,
+		EncodeLevel:    zapcore.CapitalLevelEncoder,
+		EncodeTime:     zapcore.ISO8601TimeEncoder,
+		EncodeDuration: zapcore.SecondsDurationEncoder,
+		EncodeCaller:   zapcore.ShortCallerEncoder,
+	}
+}
+
 // sendToAdapters 将日志发送到所有适配器
 func (l *ZapLogger) sendToAdapters(level string, message string, properties map[string]interface{}) {
+	l.sendToAdaptersAt(time.Time{}, level, message, properties, nil)
+}
+
+// sendKeysAndValues 将Infow等方法的键值对发送到适配器，LogEntry.Order保留键值对的顺序
+func (l *ZapLogger) sendKeysAndValues(level string, message string, meta []interface{}, keysAndValues []any) {
+	properties := metaProperties(meta, keysAndValuesToProperties(keysAndValues, l.propertyValue))
+	l.sendToAdaptersAt(time.Time{}, level, message, properties, keysAndValuesOrder(keysAndValues))
+}
+
+// sendToAdaptersAt 将指定时间的日志发送到所有适配器，t为零值时使用当前时间
+// order为properties中调用时传入的key的顺序，排在子日志字段之后，为空表示无序
+func (l *ZapLogger) sendToAdaptersAt(t time.Time, level string, message string, properties map[string]interface{}, order []string) {
+	if l.skipAdapters {
+		return
+	}
+	level = l.levelRemap.applyName(level, message)
+
 	l.adapterMu.RLock()
 	defer l.adapterMu.RUnlock()
 
-	if len(l.adapters) == 0 {
+	if len(l.adapters) == 0 && len(l.levelHooks) == 0 {
+		return
+	}
+
+	// 合并静态属性和子日志携带的字段，优先级依次为调用时传入的字段、子日志字段、静态属性
+	if len(l.fields) > 0 || len(l.adapterProperties) > 0 {
+		merged := make(map[string]interface{}, len(l.adapterProperties)+len(l.fields)+len(properties))
+		for k, v := range l.adapterProperties {
+			merged[k] = v
+		}
+		for k, v := range l.fields {
+			merged[k] = v
+		}
+		for k, v := range properties {
+			merged[k] = v
+		}
+		properties = merged
+	}
+
+	// 二进制数据编码为字符串，避免不可读的字节破坏下游的JSON解析
+	properties = l.binary.properties(properties)
+	if l.omitEmpty {
+		properties = omitEmptyProperties(properties)
+	}
+	properties = l.fieldLimit.limitProperties(properties)
+
+	lvl, levelErr := zapcore.ParseLevel(level)
+
+	// 按属性值采样，error及以上级别总是保留
+	if l.sampler != nil && (levelErr != nil || lvl < zap.ErrorLevel) && !l.sampler.allow(properties) {
+		l.stats.sampled.Add(1)
+		if levelErr == nil {
+			l.sampling.record(lvl, false)
+		}
 		return
 	}
 
+	// error及以上级别附加调用方堆栈，调用时已传入的stacktrace优先
+	if levelErr == nil && lvl >= zap.ErrorLevel {
+		if _, exists := properties["stacktrace"]; !exists {
+			withStack := make(map[string]interface{}, len(properties)+2)
+			for k, v := range properties {
+				withStack[k] = v
+			}
+			l.stackDedup.apply(withStack, captureStack(l.stackTrimPrefixes, l.fullStacktrace), clockNow(l.clock))
+			properties = withStack
+		}
+	}
+
+	// 配置了字段命名空间时，字段放入命名空间的map，顶层只保留标准字段
+	properties = l.nestProperties(properties)
+
+	// 取得用户代码的调用位置，与输出核心的调用位置各自计算，不受调用经过Info、Infof、Infow或子日志的影响
+	frame := captureCaller(l.stackTrimPrefixes, l.adapterCallerSkip)
+
+	// 附加调用方函数名，调用时已传入的func优先
+	if l.callerFunction {
+		if _, exists := properties[FunctionField]; !exists {
+			withFunc := make(map[string]interface{}, len(properties)+1)
+			for k, v := range properties {
+				withFunc[k] = v
+			}
+			withFunc[FunctionField] = frame.Function
+			properties = withFunc
+		}
+	}
+
 	// 创建日志条目
+	if t.IsZero() {
+		t = clockNow(l.clock)
+	}
 	entry := LogEntry{
 		Level:      level,
-		Time:       time.Now(),
+		Time:       t,
 		Message:    message,
+		Caller:     frameCaller(frame, l.callerFormat),
 		NodeID:     l.nodeID,
 		Module:     l.module,
 		IP:         l.ip,
 		Properties: properties,
+		Order:      mergeOrder(l.fieldOrder, order),
+		ctx:        l.ctx,
+	}
+	if l.fieldNamespace != "" {
+		// 字段已放入命名空间的map，顶层不再有调用时的顺序
+		entry.Order = nil
 	}
 
-	// 异步发送到适配器
-	for _, adapter := range l.adapters {
-		go func(a LogAdapter, e LogEntry) {
-			ctx, cancel := context.WithTimeout(context.Background(), 5*time.Second)
-			defer cancel()
-			_ = a.Process(ctx, e)
-		}(adapter, entry)
+	// panic及以上级别同步处理并刷新适配器，保证进程崩溃前日志已送达
+	if levelErr == nil && lvl >= zap.DPanicLevel {
+		for _, adapter := range l.adapters {
+			l.processAdapter(adapter, entry)
+			l.flushAdapter(adapter)
+		}
+		l.runLevelHooks(entry)
+		return
 	}
+
+	// 放入分发队列，由工作协程异步发送到适配器
+	l.enqueue(entry)
 }
 
-// Close 关闭日志记录器及其适配器
+// Close 关闭日志记录器及其适配器，等待所有适配器刷新和关闭完成
 func (l *ZapLogger) Close() error {
-	l.adapterMu.Lock()
-	defer l.adapterMu.Unlock()
+	return l.CloseWithContext(context.Background())
+}
 
-	for _, adapter := range l.adapters {
-		_ = adapter.Flush()
-		_ = adapter.Close()
+// CloseWithContext 关闭日志记录器及其适配器，最多等待到ctx结束
+// 分发队列的处理和各适配器的刷新、关闭在独立协程中并行进行，ctx结束时立即返回，
+// 错误中列出未能按时完成的适配器，它们会在后台继续完成；各适配器刷新和关闭的错误通过errors.Join合并返回
+// 开始关闭后不再接受新日志；设置了WithDrainTimeout时排空分发队列超时后放弃剩余日志，仍然刷新并关闭适配器，错误中包含未送达的日志数
+func (l *ZapLogger) CloseWithContext(ctx context.Context) error {
+	// 返回时取消适配器的父上下文，超时返回时中止仍在进行的适配器发送
+	defer l.cancelDispatch()
+
+	// 不再接受新日志，先处理完分发队列中剩余的日志
+	l.dispatchMu.Lock()
+	l.dispatchClosed = true
+	d := l.dispatcher
+	l.dispatchMu.Unlock()
+	drained := make(chan struct{})
+	go func() {
+		l.stopDispatcher()
+		close(drained)
+	}()
+	var drainTimeout <-chan time.Time
+	if l.drainTimeout > 0 {
+		timer := time.NewTimer(l.drainTimeout)
+		defer timer.Stop()
+		drainTimeout = timer.C
+	}
+	var drainErr error
+	select {
+	case <-drained:
+	case <-drainTimeout:
+		// 放弃队列中剩余的日志并中止正在进行的发送，继续刷新和关闭适配器
+		drainErr = fmt.Errorf("close logger drain timed out: %d entries not delivered", l.stats.pending.Load())
+		if d != nil {
+			d.abort.Store(true)
+		}
+		l.cancelDispatch()
+	case <-ctx.Done():
+		return fmt.Errorf("close logger timed out: dispatch queue not drained")
 	}
 
-	l.adapters = nil
-	return nil
+	// 报告最后一个周期尚未汇总的采样丢弃数
+	l.sampling.flush()
+
+	l.adapterMu.RLock()
+	adapters := append([]LogAdapter(nil), l.adapters...)
+	l.adapterMu.RUnlock()
+
+	// 每个适配器(包括审计适配器)在独立协程中刷新并关闭
+	var (
+		mu      sync.Mutex
+		pending = make(map[string]int)
+		wg      sync.WaitGroup
+		errs    = make([]error, len(adapters)+1) // 按适配器顺序保存错误，最后一个位置留给审计适配器
+	)
+	run := func(i int, name string, fn func() error) {
+		pending[name]++
+		wg.Add(1)
+		go func() {
+			defer wg.Done()
+			err := fn()
+			mu.Lock()
+			errs[i] = err
+			pending[name]--
+			mu.Unlock()
+		}()
+	}
+
+	mu.Lock()
+	for i, adapter := range adapters {
+		adapter := adapter
+		run(i, adapter.Name(), func() error {
+			return errors.Join(l.flushAdapter(adapter), l.closeAdapter(adapter))
+		})
+	}
+	if l.audit != nil {
+		run(len(adapters), l.audit.Name(), l.closeAudit)
+	}
+	mu.Unlock()
+
+	closed := make(chan struct{})
+	go func() {
+		wg.Wait()
+		close(closed)
+	}()
+
+	var closeErr error
+	select {
+	case <-closed:
+		closeErr = errors.Join(errs...)
+	case <-ctx.Done():
+		mu.Lock()
+		names := make([]string, 0, len(pending))
+		for name, n := range pending {
+			if n > 0 {
+				names = append(names, name)
+			}
+		}
+		// 已经完成的适配器的错误与超时错误一起返回
+		completed := errors.Join(errs...)
+		mu.Unlock()
+		sort.Strings(names)
+		closeErr = errors.Join(fmt.Errorf("close adapters timed out: %s", strings.Join(names, ", ")), completed)
+	}
+	closeErr = errors.Join(drainErr, closeErr)
+
+	// 适配器仍在处理时无法获取写锁，由后台协程在其完成后移除
+	if l.adapterMu.TryLock() {
+		l.adapters = nil
+		l.adapterMu.Unlock()
+	} else {
+		go func() {
+			l.adapterMu.Lock()
+			l.adapters = nil
+			l.adapterMu.Unlock()
+		}()
+	}
+
+	// 写出异步输出队列中剩余的日志，之后的写入直接交给底层输出
+	for _, w := range l.asyncWriters {
+		w.Stop()
+	}
+
+	// 停止控制台缓冲的定时刷新并写出剩余内容
+	if l.consoleBuffer != nil {
+		if err := l.consoleBuffer.Stop(); err != nil {
+			l.internalError("flush console buffer failed: %v", err)
+		}
+	}
+
+	// 释放共享的文件写入器，最后一个使用该目录的日志实例关闭文件
+	if l.rotator != nil {
+		l.releaseOnce.Do(func() {
+			if err := releaseRotateWriter(l.rotator); err != nil {
+				l.internalError("close log file failed: %v", err)
+			}
+		})
+	}
+	// 关闭调用方提供的文件写入器
+	if l.fileWriter != nil {
+		l.releaseOnce.Do(func() {
+			if err := l.fileWriter.Close(); err != nil {
+				l.internalError("close log file failed: %v", err)
+			}
+		})
+	}
+	return closeErr
 }
 
-// AddAdapter 添加一个适配器
+// cancelDispatch 取消适配器处理日志的父上下文
+func (g *adapterGroup) cancelDispatch() {
+	if g.cancelBase != nil {
+		g.cancelBase()
+	}
+}
+
+// Sync 刷新输出核心中缓冲的日志，包括控制台缓冲区
+func (l *ZapLogger) Sync() error {
+	return l.logger.Sync()
+}
+
+// Flush 等待分发队列中的日志处理完成后刷新所有适配器，返回各适配器刷新失败的合并错误
+func (l *ZapLogger) Flush() error {
+	l.stopDispatcher()
+	l.adapterMu.RLock()
+	defer l.adapterMu.RUnlock()
+	return l.flushAdapters()
+}
+
+// flushAdapters 刷新所有适配器，返回各适配器刷新失败的合并错误，调用方需持有adapterMu
+func (g *adapterGroup) flushAdapters() error {
+	var errs []error
+	for _, adapter := range g.adapters {
+		if err := g.flushAdapter(adapter); err != nil {
+			errs = append(errs, err)
+		}
+	}
+	return errors.Join(errs...)
+}
+
+// AddAdapter 添加一个适配器，排在已有适配器之后，不参与优先级排序
 func (l *ZapLogger) AddAdapter(adapter LogAdapter) {
 	l.adapterMu.Lock()
 	defer l.adapterMu.Unlock()
 	l.adapters = append(l.adapters, adapter)
 }
 
-// RemoveAdapter 移除一个适配器
+// Adapter 按名称返回已添加的适配器，配置了实例ID的适配器按ID查找
+// 返回实例ID和level配置项包装下的原始适配器，便于断言为具体的适配器类型
+func (l *ZapLogger) Adapter(name string) (LogAdapter, bool) {
+	l.adapterMu.RLock()
+	defer l.adapterMu.RUnlock()
+	for _, adapter := range l.adapters {
+		if adapter.Name() == name {
+			return unwrapAdapter(adapter), true
+		}
+	}
+	return nil, false
+}
+
+// RemoveAdapter 按名称移除一个适配器，配置了实例ID的适配器按ID移除
+// 不等待慢适配器，移除前已经开始分发的日志仍可能交给被移除的适配器
 func (l *ZapLogger) RemoveAdapter(name string) {
 	l.adapterMu.Lock()
 	defer l.adapterMu.Unlock()
 
 	for i, adapter := range l.adapters {
 		if adapter.Name() == name {
-			l.adapters = append(l.adapters[:i], l.adapters[i+1:]...)
+			// 分发协程可能正在遍历旧的列表，创建新的列表而不是原地修改
+			adapters := make([]LogAdapter, 0, len(l.adapters)-1)
+			adapters = append(adapters, l.adapters[:i]...)
+			l.adapters = append(adapters, l.adapters[i+1:]...)
 			return
 		}
 	}
 }
 
+// ReplaceAdapter 用newCfg创建的新适配器替换名为name的适配器，配置了实例ID的适配器按ID查找，
+// 用于运行时修改单个适配器的配置（如切换Kafka集群）而不丢失日志：
+// 先初始化新适配器，初始化失败时保留旧适配器；替换前分发队列中的日志仍交给旧适配器处理，
+// 替换后的日志只交给新适配器，旧适配器刷新缓存的日志后关闭，刷新或关闭失败时返回错误，替换仍然生效
+func (l *ZapLogger) ReplaceAdapter(name string, newCfg AdapterConfig) error {
+	adapter, exists := GetAdapter(newCfg.Name)
+	if !exists {
+		return fmt.Errorf("unknown adapter: %s", newCfg.Name)
+	}
+	replacement, err := initAdapter(adapter, newCfg)
+	if err != nil {
+		return fmt.Errorf("init adapter %s failed: %v", newCfg.Name, err)
+	}
+
+	// 先把队列中已有的日志交给旧适配器，再替换为新的列表
+	l.stopDispatcher()
+	l.adapterMu.Lock()
+	var old LogAdapter
+	for i, a := range l.adapters {
+		if a.Name() == name {
+			old = a
+			adapters := append([]LogAdapter(nil), l.adapters...)
+			adapters[i] = replacement
+			l.adapters = adapters
+			break
+		}
+	}
+	l.adapterMu.Unlock()
+
+	if old == nil {
+		_ = l.closeAdapter(replacement)
+		return fmt.Errorf("adapter %s not found", name)
+	}
+	// 分发协程遍历的是替换前取得的列表，等待这期间开始处理的日志完成后旧适配器不会再收到日志
+	l.stopDispatcher()
+	return errors.Join(l.flushAdapter(old), l.closeAdapter(old))
+}
+
+// ReplaceGlobals 将zap的全局日志(zap.L()/zap.S())替换为Zap()返回的logger，返回恢复原全局日志的函数
+// 第三方代码通过zap.L()/zap.S()写入的日志同样会输出并分发到适配器
+func (l *ZapLogger) ReplaceGlobals() func() {
+	return zap.ReplaceGlobals(l.Zap())
+}
+
+// NoAdapters 返回只写入输出核心、不分发到适配器的日志视图
+func (l *ZapLogger) NoAdapters() Logger {
+	child := *l
+	child.skipAdapters = true
+	return &child
+}
+
+// WithField 返回携带一个字段的子日志
+func (l *ZapLogger) WithField(key string, value interface{}) Logger {
+	return l.with(map[string]interface{}{key: value})
+}
+
+// WithFields 返回携带多个字段的子日志，链式调用时同名字段以后添加的为准
+func (l *ZapLogger) WithFields(fields map[string]interface{}) Logger {
+	return l.with(fields)
+}
+
+// With 返回以键值对携带字段的子日志，非字符串的key按fmt.Sprint转换，缺少值的最后一个key被忽略
+func (l *ZapLogger) With(keysAndValues ...any) Logger {
+	return l.with(keysAndValuesToProperties(keysAndValues, func(v interface{}) interface{} { return v }))
+}
+
+// WithError 返回在error字段中携带错误信息的子日志，err为nil时返回自身
+// 错误链中的各层错误信息会记录在error_chain字段中
+func (l *ZapLogger) WithError(err error) Logger {
+	if err == nil {
+		return l
+	}
+
+	fields := map[string]interface{}{"error": err.Error()}
+	if chain := errorChain(err); len(chain) > 1 {
+		fields["error_chain"] = chain
+	}
+	// 合并的错误（如errors.Join）在errors字段中逐个记录，便于按单个错误检索
+	if errs, ok := errorGroup(err); ok {
+		fields["errors"] = errs
+	}
+	return l.with(fields)
+}
+
+// with 创建携带额外字段的子日志，子日志与父日志共享输出和适配器
+func (l *ZapLogger) with(fields map[string]interface{}) *ZapLogger {
+	if len(fields) == 0 {
+		return l
+	}
+
+	merged := make(map[string]interface{}, len(l.fields)+len(fields))
+	for k, v := range l.fields {
+		merged[k] = v
+	}
+	for k, v := range fields {
+		merged[k] = l.propertyValue(v)
+	}
+
+	// 按键排序保证输出字段顺序稳定
+	keys := make([]string, 0, len(fields))
+	for k := range fields {
+		keys = append(keys, k)
+	}
+	sort.Strings(keys)
+	zapFields := make([]zap.Field, 0, len(keys))
+	order := append(make([]string, 0, len(l.fieldOrder)+len(keys)), l.fieldOrder...)
+	for _, k := range keys {
+		zapFields = append(zapFields, anyField(k, fields[k]))
+		if _, exists := l.fields[k]; !exists {
+			order = append(order, k)
+		}
+	}
+
+	child := *l
+	child.logger = l.logger.With(zapFields...)
+	child.sugar = child.logger.Sugar()
+	child.fields = merged
+	child.fieldOrder = order
+	return &child
+}
+
+// errorChain 展开错误链，返回每一层的错误信息
+func errorChain(err error) []string {
+	var chain []string
+	queue := []error{err}
+	for len(queue) > 0 {
+		e := queue[0]
+		queue = queue[1:]
+		if e == nil {
+			continue
+		}
+		chain = append(chain, e.Error())
+
+		switch u := e.(type) {
+		case interface{ Unwrap() error }:
+			queue = append(queue, u.Unwrap())
+		case interface{ Unwrap() []error }:
+			queue = append(queue, u.Unwrap()...)
+		}
+	}
+	return chain
+}
+
+// SetFloorLevel 设置最低级别闸门，低于该级别的Debug/Info/Warn/Error日志在格式化和分发前直接丢弃
+// 闸门独立于各输出核心的级别，子日志共享同一闸门，传入空字符串恢复正常
+func (l *ZapLogger) SetFloorLevel(level string) error {
+	var floor int32
+	if level != "" {
+		lvl, err := ParseLevel(level)
+		if err != nil {
+			return err
+		}
+		floor = int32(lvl - zapcore.DebugLevel)
+	}
+
+	l.levelMu.Lock()
+	defer l.levelMu.Unlock()
+	l.baseFloor = floor
+	l.applyLevels()
+	return nil
+}
+
+// suppressed 判断日志是否需要直接丢弃：V视图的详细级别不足，级别低于最低级别闸门，或被节流
+func (l *ZapLogger) suppressed(level zapcore.Level) bool {
+	if level == zapcore.InfoLevel && l.verboseSuppressed() {
+		return true
+	}
+	if int32(level-zapcore.DebugLevel) < l.floor.Load() {
+		return true
+	}
+	return l.throttle != nil && !l.throttle.allow()
+}
+
+// hasAdapters 是否存在已挂载的适配器或级别回调，两者都没有时无需构造适配器日志条目
+func (l *ZapLogger) hasAdapters() bool {
+	if l.skipAdapters {
+		return false
+	}
+
+	l.adapterMu.RLock()
+	defer l.adapterMu.RUnlock()
+	return len(l.adapters) > 0 || len(l.levelHooks) > 0
+}
+
+// keysAndValuesToProperties 将键值对参数转换为适配器使用的属性
+// convert转换每个属性值，通常为propertyValue
+func keysAndValuesToProperties(keysAndValues []any, convert func(interface{}) interface{}) map[string]interface{} {
+	if len(keysAndValues) == 0 {
+		return nil
+	}
+
+	properties := make(map[string]interface{}, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		properties[key] = convert(keysAndValues[i+1])
+	}
+	return properties
+}
+
+// keysAndValuesOrder 返回键值对中key的顺序，与keysAndValuesToProperties的key一致
+func keysAndValuesOrder(keysAndValues []any) []string {
+	if len(keysAndValues) < 2 {
+		return nil
+	}
+	order := make([]string, 0, len(keysAndValues)/2)
+	for i := 0; i+1 < len(keysAndValues); i += 2 {
+		key, ok := keysAndValues[i].(string)
+		if !ok {
+			key = fmt.Sprint(keysAndValues[i])
+		}
+		order = append(order, key)
+	}
+	return order
+}
+
+// mergeOrder 合并子日志字段和调用时传入的key的顺序，重复的key保留第一次出现的位置
+func mergeOrder(fieldOrder, order []string) []string {
+	if len(order) == 0 {
+		return fieldOrder
+	}
+	if len(fieldOrder) == 0 {
+		return order
+	}
+	merged := make([]string, 0, len(fieldOrder)+len(order))
+	seen := make(map[string]bool, len(fieldOrder)+len(order))
+	for _, keys := range [][]string{fieldOrder, order} {
+		for _, k := range keys {
+			if !seen[k] {
+				seen[k] = true
+				merged = append(merged, k)
+			}
+		}
+	}
+	return merged
+}
+
+// propertyValue 将字段值转换为适配器属性值，与输出编码保持一致
+// time.Duration转换为秒数（日志配置了时长格式时使用adapterGroup.propertyValue），time.Time转换为ISO8601字符串，
+// 结构体、map、切片以及实现zapcore.ObjectMarshaler/ArrayMarshaler或json.Marshaler的值转换为嵌套的map和切片，
+// 错误组（[]error或errors.Join的结果）转换为错误对象的切片
+func propertyValue(value interface{}) interface{} {
+	if errs, ok := errorGroup(value); ok {
+		return propertyValue(errorArray(errs))
+	}
+	switch v := value.(type) {
+	case nil:
+		return nil
+	case time.Duration:
+		return v.Seconds()
+	case time.Time:
+		return v.Format(iso8601Layout)
+	case zapcore.ObjectMarshaler:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := v.MarshalLogObject(enc); err != nil {
+			return value
+		}
+		return enc.Fields
+	case zapcore.ArrayMarshaler:
+		enc := zapcore.NewMapObjectEncoder()
+		if err := enc.AddArray("value", v); err != nil {
+			return value
+		}
+		return enc.Fields["value"]
+	case json.Marshaler:
+		return jsonValue(value)
+	case error, fmt.Stringer, []byte:
+		return value
+	}
+
+	rv := reflect.ValueOf(value)
+	for rv.Kind() == reflect.Ptr {
+		if rv.IsNil() {
+			return value
+		}
+		rv = rv.Elem()
+	}
+	switch rv.Kind() {
+	case reflect.Struct, reflect.Map, reflect.Slice, reflect.Array:
+		return jsonValue(value)
+	default:
+		return value
+	}
+}
+
+// jsonValue 通过JSON编码将值转换为map、切片和基础类型，编码失败时返回原值
+func jsonValue(value interface{}) interface{} {
+	data, err := json.Marshal(value)
+	if err != nil {
+		return value
+	}
+	var generic interface{}
+	if err := json.Unmarshal(data, &generic); err != nil {
+		return value
+	}
+	return generic
+}
+
 // 实现Logger接口方法
+// 低于最低级别闸门或被节流时最先返回，没有适配器时不会构造消息和日志条目，级别未开启时直接返回
 
 func (l *ZapLogger) Panic(args ...any) {
-	l.sendToAdapters("panic", fmt.Sprint(args...), nil)
-	l.sugar.Panic(args...)
+	args = l.binary.args(args)
+	meta := l.entryMeta()
+	if l.hasAdapters() {
+		l.sendToAdapters("panic", fmt.Sprint(args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Panic(args...)
 }
 
 func (l *ZapLogger) Panicf(format string, args ...any) {
-	msg := fmt.Sprintf(format, args...)
-	l.sendToAdapters("panic", msg, nil)
-	l.sugar.Panicf(format, args...)
+	meta := l.entryMeta()
+	if l.hasAdapters() {
+		l.sendToAdapters("panic", fmt.Sprintf(format, args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Panicf(format, args...)
+}
+
+func (l *ZapLogger) Panicw(msg string, keysAndValues ...any) {
+	meta := l.entryMeta()
+	if l.hasAdapters() {
+		l.sendKeysAndValues("panic", msg, meta, keysAndValues)
+	}
+	l.sugarWith(meta).Panicw(msg, keysAndValues...)
+}
+
+func (l *ZapLogger) Fatal(args ...any) {
+	args = l.binary.args(args)
+	meta := l.entryMeta()
+	if l.hasAdapters() {
+		l.sendToAdapters("fatal", fmt.Sprint(args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Fatal(args...)
+}
+
+func (l *ZapLogger) Fatalf(format string, args ...any) {
+	meta := l.entryMeta()
+	if l.hasAdapters() {
+		l.sendToAdapters("fatal", fmt.Sprintf(format, args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Fatalf(format, args...)
+}
+
+func (l *ZapLogger) Fatalw(msg string, keysAndValues ...any) {
+	meta := l.entryMeta()
+	if l.hasAdapters() {
+		l.sendKeysAndValues("fatal", msg, meta, keysAndValues)
+	}
+	l.sugarWith(meta).Fatalw(msg, keysAndValues...)
 }
 
 func (l *ZapLogger) Error(args ...any) {
-	l.sendToAdapters("error", fmt.Sprint(args...), nil)
-	l.sugar.Error(args...)
+	if l.suppressed(zap.ErrorLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.ErrorLevel) {
+		return
+	}
+	args = l.binary.args(args)
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendToAdapters("error", fmt.Sprint(args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Error(args...)
 }
 
 func (l *ZapLogger) Errorf(format string, args ...any) {
-	msg := fmt.Sprintf(format, args...)
-	l.sendToAdapters("error", msg, nil)
-	l.sugar.Errorf(format, args...)
+	if l.suppressed(zap.ErrorLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.ErrorLevel) {
+		return
+	}
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendToAdapters("error", fmt.Sprintf(format, args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Errorf(format, args...)
+}
+
+func (l *ZapLogger) Errorw(msg string, keysAndValues ...any) {
+	if l.suppressed(zap.ErrorLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.ErrorLevel) {
+		return
+	}
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendKeysAndValues("error", msg, meta, keysAndValues)
+	}
+	l.sugarWith(meta).Errorw(msg, keysAndValues...)
 }
 
 func (l *ZapLogger) Warn(args ...any) {
-	l.sendToAdapters("warn", fmt.Sprint(args...), nil)
-	l.sugar.Warn(args...)
+	if l.suppressed(zap.WarnLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.WarnLevel) {
+		return
+	}
+	args = l.binary.args(args)
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendToAdapters("warn", fmt.Sprint(args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Warn(args...)
 }
 
 func (l *ZapLogger) Warnf(format string, args ...any) {
-	msg := fmt.Sprintf(format, args...)
-	l.sendToAdapters("warn", msg, nil)
-	l.sugar.Warnf(format, args...)
+	if l.suppressed(zap.WarnLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.WarnLevel) {
+		return
+	}
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendToAdapters("warn", fmt.Sprintf(format, args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Warnf(format, args...)
+}
+
+func (l *ZapLogger) Warnw(msg string, keysAndValues ...any) {
+	if l.suppressed(zap.WarnLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.WarnLevel) {
+		return
+	}
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendKeysAndValues("warn", msg, meta, keysAndValues)
+	}
+	l.sugarWith(meta).Warnw(msg, keysAndValues...)
 }
 
 func (l *ZapLogger) Info(args ...any) {
-	l.sendToAdapters("info", fmt.Sprint(args...), nil)
-	l.sugar.Info(args...)
+	if l.suppressed(zap.InfoLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.InfoLevel) {
+		return
+	}
+	args = l.binary.args(args)
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendToAdapters("info", fmt.Sprint(args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Info(args...)
 }
 
 func (l *ZapLogger) Infof(format string, args ...any) {
-	msg := fmt.Sprintf(format, args...)
-	l.sendToAdapters("info", msg, nil)
-	l.sugar.Infof(format, args...)
+	if l.suppressed(zap.InfoLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.InfoLevel) {
+		return
+	}
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendToAdapters("info", fmt.Sprintf(format, args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Infof(format, args...)
+}
+
+func (l *ZapLogger) Infow(msg string, keysAndValues ...any) {
+	if l.suppressed(zap.InfoLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.InfoLevel) {
+		return
+	}
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendKeysAndValues("info", msg, meta, keysAndValues)
+	}
+	l.sugarWith(meta).Infow(msg, keysAndValues...)
 }
 
 func (l *ZapLogger) Debug(args ...any) {
-	l.sendToAdapters("debug", fmt.Sprint(args...), nil)
-	l.sugar.Debug(args...)
+	if l.suppressed(zap.DebugLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.DebugLevel) {
+		return
+	}
+	args = l.binary.args(args)
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendToAdapters("debug", fmt.Sprint(args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Debug(args...)
 }
 
 func (l *ZapLogger) Debugf(format string, args ...any) {
-	msg := fmt.Sprintf(format, args...)
-	l.sendToAdapters("debug", msg, nil)
-	l.sugar.Debugf(format, args...)
+	if l.suppressed(zap.DebugLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.DebugLevel) {
+		return
+	}
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendToAdapters("debug", fmt.Sprintf(format, args...), metaProperties(meta, nil))
+	}
+	l.sugarWith(meta).Debugf(format, args...)
+}
+
+func (l *ZapLogger) Debugw(msg string, keysAndValues ...any) {
+	if l.suppressed(zap.DebugLevel) {
+		return
+	}
+	dispatch := l.hasAdapters()
+	if !dispatch && !l.logger.Core().Enabled(zap.DebugLevel) {
+		return
+	}
+	meta := l.entryMeta()
+	if dispatch {
+		l.sendKeysAndValues("debug", msg, meta, keysAndValues)
+	}
+	l.sugarWith(meta).Debugw(msg, keysAndValues...)
+}
+
+// CurrentLogFile 返回文件输出当前正在写入的日志文件路径，没有文件输出时返回空字符串
+func (l *ZapLogger) CurrentLogFile() string {
+	if l.rotator == nil {
+		return ""
+	}
+	return l.rotator.CurrentFile()
 }
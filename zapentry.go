@@ -0,0 +1,93 @@
+package logger
+
+import (
+	"sort"
+	"strconv"
+	"strings"
+
+	"go.uber.org/zap/zapcore"
+)
+
+// EntryFromZap 将zap的日志条目和字段转换为LogEntry，供自定义核心、钩子等从zap接入适配器时使用
+// 级别为小写名称，调用位置为短路径格式，条目带有堆栈时放入stacktrace属性；
+// 字段按类型解析：ObjectMarshaler/ArrayMarshaler转换为嵌套的map和切片，Namespace之后的字段放入该命名空间，
+// error展开为字符串（带有原因链时附加<key>Causes等，与zap的编码一致），时长、时间和结构化的值与适配器属性的转换规则相同；
+// Order按字段出现的顺序记录顶层的key
+func EntryFromZap(e zapcore.Entry, fields []zapcore.Field) LogEntry {
+	entry := LogEntry{
+		Level:   e.Level.String(),
+		Time:    e.Time,
+		Message: e.Message,
+	}
+	if e.Caller.Defined {
+		entry.Caller = e.Caller.TrimmedPath()
+	}
+	if len(fields) == 0 && e.Stack == "" {
+		return entry
+	}
+
+	enc := zapcore.NewMapObjectEncoder()
+	order := make([]string, 0, len(fields))
+	seen := make(map[string]bool, len(fields))
+	for _, f := range fields {
+		before := len(enc.Fields)
+		f.AddTo(enc)
+		if len(enc.Fields) == before {
+			continue
+		}
+		// 大多数字段只添加自身的key，error等添加多个key时按名称补充
+		if _, ok := enc.Fields[f.Key]; ok && !seen[f.Key] && len(enc.Fields) == before+1 {
+			seen[f.Key] = true
+			order = append(order, f.Key)
+			continue
+		}
+		var added []string
+		for k := range enc.Fields {
+			if !seen[k] {
+				added = append(added, k)
+			}
+		}
+		sort.Strings(added)
+		for _, k := range added {
+			seen[k] = true
+			order = append(order, k)
+		}
+	}
+	if e.Stack != "" {
+		if _, exists := enc.Fields["stacktrace"]; !exists {
+			enc.Fields["stacktrace"] = e.Stack
+			order = append(order, "stacktrace")
+		}
+	}
+
+	entry.Properties = make(map[string]interface{}, len(enc.Fields))
+	for k, v := range enc.Fields {
+		entry.Properties[k] = zapFieldValue(propertyValue(v))
+	}
+	entry.Order = order
+	return entry
+}
+
+// zapFieldValue 将MapObjectEncoder中无法JSON编码的复数转换为与zap JSON编码相同的字符串，递归处理嵌套的map和切片
+func zapFieldValue(value interface{}) interface{} {
+	switch v := value.(type) {
+	case complex128:
+		return formatComplex(v, 64)
+	case complex64:
+		return formatComplex(complex128(v), 32)
+	case map[string]interface{}:
+		for k, item := range v {
+			v[k] = zapFieldValue(item)
+		}
+	case []interface{}:
+		for i, item := range v {
+			v[i] = zapFieldValue(item)
+		}
+	}
+	return value
+}
+
+// formatComplex 按zap的格式输出复数，如"1+2i"
+func formatComplex(c complex128, bitSize int) string {
+	return strings.Trim(strconv.FormatComplex(c, 'g', -1, bitSize*2), "()")
+}